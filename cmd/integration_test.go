@@ -127,7 +127,7 @@ func waitForEndpoint(t *testing.T, client httpDoer, target string, timeout time.
 	require.Failf(t, "server readiness", "server did not respond successfully within %v", timeout)
 }
 
-func writeIntegrationConfig(t *testing.T, dir string, port int) string {
+func writeIntegrationConfig(t *testing.T, dir string, port, adminPort int) string {
 	t.Helper()
 	if err := os.MkdirAll(dir, 0o750); err != nil {
 		require.NoError(t, err, "failed to ensure rules folder")
@@ -138,6 +138,12 @@ func writeIntegrationConfig(t *testing.T, dir string, port int) string {
 				"address": "127.0.0.1",
 				"port":    port,
 			},
+			"admin": map[string]any{
+				"listen": map[string]any{
+					"address": "127.0.0.1",
+					"port":    adminPort,
+				},
+			},
 			"logging": map[string]any{
 				"format":            "text",
 				"level":             "warn",
@@ -241,7 +247,8 @@ func TestIntegrationServerStartup(t *testing.T) {
 
 	temp := t.TempDir()
 	port := allocatePort(t)
-	configPath := writeIntegrationConfig(t, temp, port)
+	adminPort := allocatePort(t)
+	configPath := writeIntegrationConfig(t, temp, port, adminPort)
 
 	loader := config.NewLoader("PASSCTRL", configPath)
 	cfg, err := loader.Load(context.Background())
@@ -331,8 +338,20 @@ func TestIntegrationServerStartup(t *testing.T) {
 			Status(http.StatusBadRequest)
 	})
 
+	admin := httpexpect.WithConfig(httpexpect.Config{
+		BaseURL:  integrationURL(adminPort, ""),
+		Reporter: httpexpect.NewRequireReporter(t),
+		Client:   client,
+	})
+
+	t.Run("public listener hides operations routes", func(t *testing.T) {
+		expect.GET("/healthz").Expect().Status(http.StatusNotFound)
+		expect.GET("/deny/explain").Expect().Status(http.StatusNotFound)
+		expect.GET("/metrics").Expect().Status(http.StatusNotFound)
+	})
+
 	t.Run("aggregate health reports ok status", func(t *testing.T) {
-		result := expect.GET("/healthz").Expect()
+		result := admin.GET("/healthz").Expect()
 		result.Status(http.StatusOK)
 		result.Header("Content-Type").Contains("application/json")
 		result.JSON().Object().
@@ -340,7 +359,7 @@ func TestIntegrationServerStartup(t *testing.T) {
 	})
 
 	t.Run("scoped explain surfaces endpoint metadata", func(t *testing.T) {
-		result := expect.GET("/deny/explain").Expect()
+		result := admin.GET("/deny/explain").Expect()
 		result.Status(http.StatusOK)
 		payload := result.JSON().Object()
 		payload.Value("endpoint").String().IsEqual("deny")
@@ -10,11 +10,16 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/credentials"
+	"github.com/l0p7/passctrl/internal/databases"
+	"github.com/l0p7/passctrl/internal/datasets"
+	"github.com/l0p7/passctrl/internal/health"
 	"github.com/l0p7/passctrl/internal/logging"
 	"github.com/l0p7/passctrl/internal/metrics"
 	"github.com/l0p7/passctrl/internal/runtime"
@@ -27,6 +32,7 @@ import (
 type configLoader interface {
 	Load(context.Context) (config.Config, error)
 	WatchRules(context.Context, config.Config, func(config.RuleBundle), func(error)) (ruleWatcher, error)
+	WatchConfig(context.Context, func(config.Config), func(error)) (ruleWatcher, error)
 }
 
 type ruleWatcher interface {
@@ -41,12 +47,15 @@ var (
 	newConfigLoader = func(envPrefix, configFile string) configLoader {
 		return &loaderAdapter{inner: config.NewLoader(envPrefix, configFile)}
 	}
-	newAppLogger       = logging.New
+	newAppLogger       = logging.NewWithLevel
 	newPromRegistry    = func() *prometheus.Registry { return prometheus.NewRegistry() }
 	newMetricsRecorder = func(reg *prometheus.Registry) metrics.Recorder { return metrics.NewRecorder(reg) }
 	newHTTPServer      = func(cfg config.Config, logger *slog.Logger, handler http.Handler) (runnableServer, error) {
 		return server.New(cfg, logger, handler)
 	}
+	newAdminServer = func(cfg config.Config, logger *slog.Logger, handler http.Handler) (runnableServer, error) {
+		return server.NewAdmin(cfg, logger, handler)
+	}
 	buildCache = buildDecisionCache
 )
 
@@ -62,6 +71,10 @@ func (l *loaderAdapter) WatchRules(ctx context.Context, cfg config.Config, onCha
 	return l.inner.WatchRules(ctx, cfg, onChange, onError)
 }
 
+func (l *loaderAdapter) WatchConfig(ctx context.Context, onChange func(config.Config), onError func(error)) (ruleWatcher, error) {
+	return l.inner.WatchConfig(ctx, onChange, onError)
+}
+
 func main() {
 	var (
 		configFile = flag.String("config", "", "path to server configuration file")
@@ -84,31 +97,52 @@ func run(ctx context.Context, envPrefix, configPath string) error {
 		return fmt.Errorf("load configuration: %w", err)
 	}
 
-	logger, err := newAppLogger(cfg.Server.Logging)
+	logger, logLevel, err := newAppLogger(cfg.Server.Logging)
 	if err != nil {
 		return fmt.Errorf("configure logger: %w", err)
 	}
 
 	cacheLogger := logger.With(slog.String("agent", "cache_factory"))
 	decisionCache := buildCache(cacheLogger, cfg.Server.Cache)
-	cacheTTL := time.Duration(cfg.Server.Cache.TTLSeconds) * time.Second
+	templateSandbox, err := newTemplateSandbox(cfg.Server.Templates.TemplatesFolder)
+	if err != nil {
+		// Templates without file access still render; only file reads fail.
+		logger.Warn("template sandbox setup failed", slog.Any("error", err))
+	}
 
-	var templateSandbox *templates.Sandbox
-	if folder := strings.TrimSpace(cfg.Server.Templates.TemplatesFolder); folder != "" {
-		sandbox, err := templates.NewSandbox(folder)
-		if err != nil {
-			logger.Warn("template sandbox setup failed", slog.String("templates_folder", folder), slog.Any("error", err))
-		} else {
-			templateSandbox = sandbox
+	credentialStores, err := buildCredentialStores(cfg.Server, templateSandbox)
+	if err != nil {
+		return fmt.Errorf("load credential stores: %w", err)
+	}
+
+	datasetRegistry, err := buildDatasets(cfg.Server, templateSandbox)
+	if err != nil {
+		return fmt.Errorf("load datasets: %w", err)
+	}
+	for _, status := range datasetRegistry.Statuses() {
+		if status.Error != "" {
+			logger.Error("dataset load failed", slog.String("dataset", status.Name), slog.String("error", status.Error))
 		}
 	}
 
+	databaseRegistry, err := buildDatabases(cfg)
+	if err != nil {
+		return fmt.Errorf("open databases: %w", err)
+	}
+	defer func() {
+		if err := databaseRegistry.Close(); err != nil {
+			logger.Error("database shutdown failed", slog.Any("error", err))
+		}
+	}()
+
+	readiness := newReadiness(logger, cfg.Server.Health)
+
 	promRegistry := newPromRegistry()
 	metricsRecorder := newMetricsRecorder(promRegistry)
 
 	pipe := runtime.NewPipeline(logger, runtime.PipelineOptions{
 		Cache:              decisionCache,
-		CacheTTL:           cacheTTL,
+		CacheTTL:           cacheTTL(cfg.Server.Cache),
 		CacheEpoch:         cfg.Server.Cache.Epoch,
 		CacheKeySalt:       cfg.Server.Cache.KeySalt,
 		Endpoints:          cfg.Endpoints,
@@ -120,6 +154,10 @@ func run(ctx context.Context, envPrefix, configPath string) error {
 		Metrics:            metricsRecorder,
 		LoadedEnvironment:  cfg.LoadedEnvironment,
 		LoadedSecrets:      cfg.LoadedSecrets,
+		CredentialStores:   credentialStores,
+		Datasets:           datasetRegistry,
+		Databases:          databaseRegistry,
+		RulesReload:        cfg.Server.Rules.Reload,
 	})
 	defer func() {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -129,37 +167,111 @@ func run(ctx context.Context, envPrefix, configPath string) error {
 		}
 	}()
 
-	var watcher ruleWatcher
-	if cfg.Server.Rules.RulesFile != "" || cfg.Server.Rules.RulesFolder != "" {
-		w, err := loader.WatchRules(ctx, cfg, func(bundle config.RuleBundle) {
-			pipe.Reload(ctx, bundle)
+	credentialWatcher, err := credentialStores.Watch(ctx, func(name string) {
+		logger.Info("credential store reloaded", slog.String("store", name))
+		pipe.InvalidateCache(ctx, "credential_store_reload")
+	}, func(err error) {
+		if err != nil {
+			logger.Error("credential store watcher error", slog.Any("error", err))
+		}
+	})
+	if err != nil {
+		logger.Error("credential store watcher setup failed", slog.Any("error", err))
+	}
+	defer credentialWatcher.Stop()
+
+	datasetWatcher, err := datasetRegistry.Watch(ctx, func(name string) {
+		logger.Info("dataset reloaded", slog.String("dataset", name))
+		pipe.InvalidateCache(ctx, "dataset_reload")
+	}, func(err error) {
+		if err != nil {
+			logger.Error("dataset watcher error", slog.Any("error", err))
+		}
+	})
+	if err != nil {
+		logger.Error("dataset watcher setup failed", slog.Any("error", err))
+	}
+	defer datasetWatcher.Stop()
+
+	reloader := newConfigReloader(loader, logger, logLevel, pipe, readiness, cfg, decisionCache, templateSandbox)
+	reloader.watchRules(ctx, cfg)
+	defer reloader.Stop()
+
+	if configPath != "" {
+		configWatcher, err := loader.WatchConfig(ctx, func(next config.Config) {
+			reloader.apply(ctx, next, "file")
 		}, func(err error) {
 			if err != nil {
-				logger.Error("rules watcher error", slog.Any("error", err))
+				reloader.reject("file", err)
 			}
 		})
 		if err != nil {
-			logger.Error("rules watcher setup failed", slog.Any("error", err))
+			logger.Error("config watcher setup failed", slog.Any("error", err))
 		} else {
-			watcher = w
+			defer configWatcher.Stop()
 		}
 	}
-	if watcher != nil {
-		defer watcher.Stop()
-	}
 
-	handler := server.NewPipelineHandler(pipe)
-	mux := http.NewServeMux()
-	mux.Handle("/metrics", metricsRecorder.Handler())
-	mux.Handle("/", handler)
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+	defer signal.Stop(hangups)
+	hangupCtx, stopHangups := context.WithCancel(ctx)
+	defer stopHangups()
+	go func() {
+		for {
+			select {
+			case <-hangupCtx.Done():
+				return
+			case <-hangups:
+				reloader.reload(ctx, "sighup")
+			}
+		}
+	}()
+
+	readiness.SetChecks("cache", cacheHealthChecks(cfg.Server.Cache, decisionCache))
+	readiness.SetChecks("backends", backendHealthChecks(cfg.Rules))
+	probeCtx, stopProbes := context.WithCancel(ctx)
+	defer stopProbes()
+	readiness.Start(probeCtx)
 
-	srv, err := newHTTPServer(cfg, logger, mux)
+	publicHandler := server.NewAuthHandler(pipe)
+	if cfg.Server.Admin.CombinedListener {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metricsRecorder.Handler())
+		mux.Handle("/livez", health.LiveHandler())
+		mux.Handle("/readyz", readiness.ReadyHandler())
+		mux.Handle("/", server.NewPipelineHandler(pipe))
+		publicHandler = mux
+	}
+
+	srv, err := newHTTPServer(cfg, logger, publicHandler)
 	if err != nil {
 		logger.Error("unable to construct server", slog.Any("error", err))
 		return err
 	}
+	servers := []runnableServer{srv}
+
+	if cfg.Server.Admin.Enabled() {
+		adminMux := server.NewAdminMux(pipe, metricsRecorder.Handler(), cfg.Server.Admin.Auth)
+		adminMux.Handle("/livez", health.LiveHandler())
+		adminMux.Handle("/readyz", readiness.ReadyHandler())
+		adminMux.Handle("GET /rules/history", http.HandlerFunc(pipe.ServeReloadHistory))
+		adminMux.HandleMutating("POST /rules", http.HandlerFunc(reloader.servePushRules))
+		adminMux.HandleMutating("POST /rules/reload", http.HandlerFunc(reloader.serveReloadRules))
+		if strings.TrimSpace(cfg.Server.Admin.Auth.BearerToken) == "" && strings.TrimSpace(cfg.Server.Admin.Listen.TLS.ClientCAFile) == "" {
+			logger.Warn("admin listener has no bearer token or client certificate verification; rule push and reload routes answer 403")
+		}
+		adminSrv, err := newAdminServer(cfg, logger, adminMux)
+		if err != nil {
+			logger.Error("unable to construct admin server", slog.Any("error", err))
+			return err
+		}
+		servers = append(servers, adminSrv)
+	} else if !cfg.Server.Admin.CombinedListener {
+		logger.Warn("admin listener disabled; metrics, health, and explain routes are not served")
+	}
 
-	if err := srv.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+	if err := runServers(ctx, servers); err != nil && !errors.Is(err, context.Canceled) {
 		logger.Error("server terminated unexpectedly", slog.Any("error", err))
 		fmt.Fprintln(os.Stderr, err)
 		return err
@@ -169,8 +281,181 @@ func run(ctx context.Context, envPrefix, configPath string) error {
 	return nil
 }
 
+// newReadiness builds the probe scheduler; durations were validated with the
+// configuration, so parse failures fall back to the package defaults.
+func newReadiness(logger *slog.Logger, cfg config.HealthConfig) *health.Scheduler {
+	interval, _ := time.ParseDuration(strings.TrimSpace(cfg.Interval))
+	timeout, _ := time.ParseDuration(strings.TrimSpace(cfg.Timeout))
+	return health.NewScheduler(logger, interval, timeout)
+}
+
+// cacheHealthChecks probes remote cache backends. A Redis cache that fell back
+// to memory at startup keeps probing the configured address, so readiness
+// stays down while the shared cache is missing and recovers once it answers.
+func cacheHealthChecks(cfg config.ServerCacheConfig, decisionCache cache.DecisionCache) []health.Check {
+	if pinger, ok := decisionCache.(cache.Pinger); ok {
+		return []health.Check{{Name: "cache", Critical: true, Run: pinger.Ping}}
+	}
+	if strings.EqualFold(strings.TrimSpace(cfg.Backend), "redis") {
+		redisCfg := redisConfig(cfg)
+		return []health.Check{{Name: "cache", Critical: true, Run: func(ctx context.Context) error {
+			if err := cache.PingRedis(ctx, redisCfg); err != nil {
+				return fmt.Errorf("redis unavailable; serving from memory fallback: %w", err)
+			}
+			return nil
+		}}}
+	}
+	return nil
+}
+
+// backendHealthChecks registers one probe per distinct backend healthProbe URL.
+func backendHealthChecks(rules map[string]config.RuleConfig) []health.Check {
+	// Probe deadlines come from the scheduler's per-check context.
+	client := &http.Client{}
+	seen := make(map[string]int)
+	var checks []health.Check
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		probe := rules[name].BackendAPI.HealthProbe
+		target := strings.TrimSpace(probe.URL)
+		if target == "" {
+			continue
+		}
+		if idx, ok := seen[target]; ok {
+			// Shared backends are probed once; any rule marking it critical wins.
+			if probe.Critical && !checks[idx].Critical {
+				checks[idx] = health.HTTPCheck(checks[idx].Name, target, true, client)
+			}
+			continue
+		}
+		seen[target] = len(checks)
+		checks = append(checks, health.HTTPCheck("backend:"+name, target, probe.Critical, client))
+	}
+	return checks
+}
+
+// runServers runs every listener until the context ends or one of them stops;
+// the first listener to stop shuts the others down and its error is returned.
+func runServers(ctx context.Context, servers []runnableServer) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errCh := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func() {
+			errCh <- srv.Run(runCtx)
+			cancel()
+		}()
+	}
+
+	first := <-errCh
+	for range len(servers) - 1 {
+		<-errCh
+	}
+	if first == nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return first
+}
+
+// buildCredentialStores resolves credential and API key files inside the
+// template sandbox and loads every configured store.
+func buildCredentialStores(cfg config.ServerConfig, sandbox *templates.Sandbox) (*credentials.Registry, error) {
+	resolve := func(kind, name, path string) (string, error) {
+		if sandbox == nil {
+			return "", fmt.Errorf("%s %q: files require server.templates.templatesFolder", kind, name)
+		}
+		resolved, err := sandbox.Resolve(path)
+		if err != nil {
+			return "", fmt.Errorf("%s %q: %w", kind, name, err)
+		}
+		return resolved, nil
+	}
+
+	spec := credentials.RegistrySpec{
+		Passwords: make(map[string]credentials.PasswordStoreSpec, len(cfg.CredentialStores)),
+		APIKeys:   make(map[string]credentials.APIKeyStoreSpec, len(cfg.APIKeyStores)),
+	}
+	for name, store := range cfg.CredentialStores {
+		passwords := credentials.PasswordStoreSpec{Users: store.Users}
+		if file := strings.TrimSpace(store.HtpasswdFile); file != "" {
+			resolved, err := resolve("credential store", name, file)
+			if err != nil {
+				return nil, err
+			}
+			passwords.File = resolved
+		}
+		spec.Passwords[name] = passwords
+	}
+	for name, store := range cfg.APIKeyStores {
+		resolved, err := resolve("api key store", name, strings.TrimSpace(store.File))
+		if err != nil {
+			return nil, err
+		}
+		spec.APIKeys[name] = credentials.APIKeyStoreSpec{File: resolved}
+	}
+	return credentials.NewRegistry(spec)
+}
+
+// buildDatasets resolves dataset files inside the template sandbox and loads
+// every configured table. Only path errors abort startup; tables that fail to
+// parse start empty and are reported by the health endpoint.
+func buildDatasets(cfg config.ServerConfig, sandbox *templates.Sandbox) (*datasets.Registry, error) {
+	specs := make(map[string]datasets.Spec, len(cfg.Datasets))
+	for name, dataset := range cfg.Datasets {
+		if sandbox == nil {
+			return nil, fmt.Errorf("dataset %q: files require server.templates.templatesFolder", name)
+		}
+		resolved, err := sandbox.Resolve(strings.TrimSpace(dataset.File))
+		if err != nil {
+			return nil, fmt.Errorf("dataset %q: %w", name, err)
+		}
+		specs[name] = datasets.Spec{File: resolved, Key: dataset.Key}
+	}
+	return datasets.NewRegistry(specs), nil
+}
+
+// buildDatabases opens a pool for every configured database, reading DSNs
+// from the loaded secrets when dsnSecret is set.
+func buildDatabases(cfg config.Config) (*databases.Registry, error) {
+	specs := make(map[string]databases.Spec, len(cfg.Server.Databases))
+	for name, database := range cfg.Server.Databases {
+		dsn := strings.TrimSpace(database.DSN)
+		if secret := strings.TrimSpace(database.DSNSecret); secret != "" {
+			loaded, ok := cfg.LoadedSecrets[secret]
+			if !ok {
+				return nil, fmt.Errorf("database %q: secret %q not loaded", name, secret)
+			}
+			dsn = strings.TrimSpace(loaded)
+		}
+		specs[name] = databases.Spec{Driver: database.Driver, DSN: dsn, MaxOpenConns: database.MaxOpenConns}
+	}
+	return databases.Open(specs)
+}
+
+func cacheTTL(cfg config.ServerCacheConfig) time.Duration {
+	return time.Duration(cfg.TTLSeconds) * time.Second
+}
+
+func redisConfig(cfg config.ServerCacheConfig) cache.RedisConfig {
+	return cache.RedisConfig{
+		Address:  cfg.Redis.Address,
+		Username: cfg.Redis.Username,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+		TLS: cache.RedisTLSConfig{
+			Enabled: cfg.Redis.TLS.Enabled,
+			CAFile:  cfg.Redis.TLS.CAFile,
+		},
+	}
+}
+
 func buildDecisionCache(logger *slog.Logger, cfg config.ServerCacheConfig) cache.DecisionCache {
-	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	ttl := cacheTTL(cfg)
 	backend := strings.TrimSpace(strings.ToLower(cfg.Backend))
 	switch backend {
 	case "", "memory":
@@ -179,16 +464,7 @@ func buildDecisionCache(logger *slog.Logger, cfg config.ServerCacheConfig) cache
 		}
 		return cache.NewMemory(ttl)
 	case "redis":
-		redisCache, err := cache.NewRedis(cache.RedisConfig{
-			Address:  cfg.Redis.Address,
-			Username: cfg.Redis.Username,
-			Password: cfg.Redis.Password,
-			DB:       cfg.Redis.DB,
-			TLS: cache.RedisTLSConfig{
-				Enabled: cfg.Redis.TLS.Enabled,
-				CAFile:  cfg.Redis.TLS.CAFile,
-			},
-		})
+		redisCache, err := cache.NewRedis(redisConfig(cfg))
 		if err != nil {
 			if logger != nil {
 				logger.Error("redis cache initialization failed", slog.Any("error", err))
@@ -6,12 +6,15 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
 	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/health"
 	"github.com/l0p7/passctrl/internal/runtime/cache"
 	"github.com/stretchr/testify/require"
 )
@@ -132,10 +135,123 @@ func TestRunServerRunError(t *testing.T) {
 	overrideHTTPServer(t, func(config.Config, *slog.Logger, http.Handler) (runnableServer, error) {
 		return &stubServer{err: errors.New("run failed")}, nil
 	})
+	admin := &blockingServer{}
+	overrideAdminServer(t, func(config.Config, *slog.Logger, http.Handler) (runnableServer, error) {
+		return admin, nil
+	})
 
 	err := run(context.Background(), "PASSCTRL", "")
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "run failed")
+	require.True(t, admin.stopped, "admin listener should stop when the public listener fails")
+}
+
+func TestRunSplitsPublicAndAdminRoutes(t *testing.T) {
+	tests := []struct {
+		name          string
+		combined      bool
+		adminPort     int
+		publicMetrics int
+		wantAdmin     bool
+	}{
+		{name: "separate admin listener", adminPort: 9090, publicMetrics: http.StatusNotFound, wantAdmin: true},
+		{name: "combined compatibility layout", combined: true, adminPort: 9090, publicMetrics: http.StatusOK, wantAdmin: true},
+		{name: "admin disabled", publicMetrics: http.StatusNotFound},
+		{name: "combined without admin listener", combined: true, publicMetrics: http.StatusOK},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Server.Rules.RulesFolder = ""
+			cfg.Server.Rules.RulesFile = ""
+			cfg.Server.Templates.TemplatesFolder = ""
+			cfg.Server.Admin.Listen.Port = tc.adminPort
+			cfg.Server.Admin.CombinedListener = tc.combined
+
+			overrideConfigLoader(t, func(_, _ string) configLoader {
+				return &fakeLoader{cfg: cfg}
+			})
+			var public, admin http.Handler
+			overrideHTTPServer(t, func(_ config.Config, _ *slog.Logger, handler http.Handler) (runnableServer, error) {
+				public = handler
+				return &stubServer{}, nil
+			})
+			overrideAdminServer(t, func(_ config.Config, _ *slog.Logger, handler http.Handler) (runnableServer, error) {
+				admin = handler
+				return &blockingServer{}, nil
+			})
+
+			require.NoError(t, run(context.Background(), "PASSCTRL", ""))
+			require.Equal(t, tc.publicMetrics, serveStatus(public, "/metrics"))
+			require.Equal(t, tc.publicMetrics, serveStatus(public, "/livez"), "probe routes follow the metrics layout")
+			if !tc.wantAdmin {
+				require.Nil(t, admin)
+				return
+			}
+			require.Equal(t, http.StatusOK, serveStatus(admin, "/metrics"))
+			require.Equal(t, http.StatusOK, serveStatus(admin, "/livez"))
+			require.Equal(t, http.StatusOK, serveStatus(admin, "/rules/history"))
+			push := httptest.NewRecorder()
+			admin.ServeHTTP(push, httptest.NewRequest(http.MethodPost, "/rules", strings.NewReader("rules: {}")))
+			require.Equal(t, http.StatusForbidden, push.Code, "rule push needs admin authentication")
+			require.Equal(t, http.StatusNotFound, serveStatus(admin, "/auth"))
+		})
+	}
+}
+
+func TestBackendHealthChecks(t *testing.T) {
+	rules := map[string]config.RuleConfig{
+		"a-lookup": {BackendAPI: config.RuleBackendConfig{HealthProbe: config.RuleHealthProbeConfig{URL: "https://users.internal/healthz"}}},
+		"b-lookup": {BackendAPI: config.RuleBackendConfig{HealthProbe: config.RuleHealthProbeConfig{URL: "https://users.internal/healthz", Critical: true}}},
+		"c-orders": {BackendAPI: config.RuleBackendConfig{HealthProbe: config.RuleHealthProbeConfig{URL: "https://orders.internal/healthz"}}},
+		"no-probe": {BackendAPI: config.RuleBackendConfig{URL: "https://orders.internal/check"}},
+	}
+
+	checks := backendHealthChecks(rules)
+	require.Len(t, checks, 2, "shared probe URLs are polled once")
+	require.Equal(t, "backend:a-lookup", checks[0].Name)
+	require.True(t, checks[0].Critical, "a critical rule promotes the shared probe")
+	require.Equal(t, "backend:c-orders", checks[1].Name)
+	require.False(t, checks[1].Critical)
+}
+
+func TestCacheHealthChecks(t *testing.T) {
+	require.Empty(t, cacheHealthChecks(config.ServerCacheConfig{Backend: "memory"}, cache.NewMemory(time.Second)))
+
+	redisServer, err := miniredis.Run()
+	if err != nil {
+		if strings.Contains(err.Error(), "operation not permitted") {
+			t.Skip("miniredis unavailable in sandbox")
+		}
+		require.NoError(t, err)
+	}
+	t.Cleanup(redisServer.Close)
+	cfg := config.ServerCacheConfig{
+		Backend: "redis",
+		Redis:   config.ServerRedisCacheConfig{Address: redisServer.Addr()},
+	}
+	redisServer.Close()
+
+	fallback := cacheHealthChecks(cfg, cache.NewMemory(time.Second))
+	require.Len(t, fallback, 1)
+	require.True(t, fallback[0].Critical, "a missing shared cache must gate readiness")
+
+	readiness := health.NewScheduler(newTestLogger(), time.Minute, time.Second)
+	readiness.SetChecks("cache", fallback)
+	readiness.RunOnce(context.Background())
+	require.Equal(t, http.StatusServiceUnavailable, serveStatus(readiness.ReadyHandler(), "/readyz"))
+	require.ErrorContains(t, fallback[0].Run(context.Background()), "memory fallback")
+
+	require.NoError(t, redisServer.Restart())
+	readiness.RunOnce(context.Background())
+	require.Equal(t, http.StatusOK, serveStatus(readiness.ReadyHandler(), "/readyz"), "readiness recovers once redis answers")
+}
+
+func serveStatus(handler http.Handler, path string) int {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code
 }
 
 func overrideConfigLoader(t *testing.T, fn func(string, string) configLoader) {
@@ -150,6 +266,12 @@ func overrideHTTPServer(t *testing.T, fn func(config.Config, *slog.Logger, http.
 	t.Cleanup(func() { newHTTPServer = original })
 }
 
+func overrideAdminServer(t *testing.T, fn func(config.Config, *slog.Logger, http.Handler) (runnableServer, error)) {
+	original := newAdminServer
+	newAdminServer = fn
+	t.Cleanup(func() { newAdminServer = original })
+}
+
 type fakeLoader struct {
 	cfg       config.Config
 	loadErr   error
@@ -157,6 +279,7 @@ type fakeLoader struct {
 	watcher   ruleWatcher
 	stopped   *bool
 	watchSeen bool
+	onChange  func(config.RuleBundle)
 }
 
 func (f *fakeLoader) Load(context.Context) (config.Config, error) {
@@ -166,8 +289,9 @@ func (f *fakeLoader) Load(context.Context) (config.Config, error) {
 	return f.cfg, nil
 }
 
-func (f *fakeLoader) WatchRules(context.Context, config.Config, func(config.RuleBundle), func(error)) (ruleWatcher, error) {
+func (f *fakeLoader) WatchRules(_ context.Context, _ config.Config, onChange func(config.RuleBundle), _ func(error)) (ruleWatcher, error) {
 	f.watchSeen = true
+	f.onChange = onChange
 	if f.watchErr != nil {
 		return nil, f.watchErr
 	}
@@ -177,6 +301,10 @@ func (f *fakeLoader) WatchRules(context.Context, config.Config, func(config.Rule
 	return &noOpWatcher{stopped: f.stopped}, nil
 }
 
+func (f *fakeLoader) WatchConfig(context.Context, func(config.Config), func(error)) (ruleWatcher, error) {
+	return &noOpWatcher{}, nil
+}
+
 type noOpWatcher struct {
 	stopped *bool
 }
@@ -194,3 +322,33 @@ type stubServer struct {
 func (s *stubServer) Run(context.Context) error {
 	return s.err
 }
+
+// blockingServer runs until its context is cancelled.
+type blockingServer struct {
+	stopped bool
+}
+
+func (s *blockingServer) Run(ctx context.Context) error {
+	<-ctx.Done()
+	s.stopped = true
+	return ctx.Err()
+}
+
+func TestBuildDatabases(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.Databases = map[string]config.DatabaseConfig{
+		"authz": {Driver: "sqlite", DSNSecret: "authz_dsn"},
+	}
+	cfg.LoadedSecrets = map[string]string{"authz_dsn": "file:" + filepath.Join(t.TempDir(), "authz.db") + "\n"}
+
+	registry, err := buildDatabases(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, registry.Close()) })
+	db, ok := registry.DB("authz")
+	require.True(t, ok)
+	require.NoError(t, db.PingContext(context.Background()))
+
+	cfg.LoadedSecrets = nil
+	_, err = buildDatabases(cfg)
+	require.EqualError(t, err, `database "authz": secret "authz_dsn" not loaded`)
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/health"
+	"github.com/l0p7/passctrl/internal/logging"
+	"github.com/l0p7/passctrl/internal/metrics"
+	"github.com/l0p7/passctrl/internal/runtime"
+	"github.com/l0p7/passctrl/internal/runtime/cache"
+	"github.com/l0p7/passctrl/internal/templates"
+)
+
+// configReloader applies configuration snapshots delivered by the config file
+// watcher or SIGHUP. Reloads are serialized; settings that can change live are
+// swapped into the running pipeline and the rest are reported until the
+// process restarts.
+type configReloader struct {
+	loader    configLoader
+	logger    *slog.Logger
+	level     *slog.LevelVar
+	pipe      *runtime.Pipeline
+	readiness *health.Scheduler
+
+	mu      sync.Mutex
+	boot    config.Config
+	current config.Config
+	cache   cache.DecisionCache
+	sandbox *templates.Sandbox
+	rules   ruleWatcher
+}
+
+func newConfigReloader(loader configLoader, logger *slog.Logger, level *slog.LevelVar, pipe *runtime.Pipeline, readiness *health.Scheduler, cfg config.Config, decisionCache cache.DecisionCache, sandbox *templates.Sandbox) *configReloader {
+	return &configReloader{
+		loader:    loader,
+		logger:    logger.With(slog.String("agent", "config_reload")),
+		level:     level,
+		pipe:      pipe,
+		readiness: readiness,
+		boot:      cfg,
+		current:   cfg,
+		cache:     decisionCache,
+		sandbox:   sandbox,
+	}
+}
+
+// reload re-reads the configuration sources; failures keep the current
+// settings in place.
+func (r *configReloader) reload(ctx context.Context, trigger string) {
+	next, err := r.loader.Load(ctx)
+	if err != nil {
+		r.reject(trigger, err)
+		return
+	}
+	r.apply(ctx, next, trigger)
+}
+
+func (r *configReloader) reject(trigger string, err error) {
+	r.logger.Error("configuration reload rejected; keeping current settings", slog.String("trigger", trigger), slog.Any("error", err))
+}
+
+// apply swaps the live settings of an already validated snapshot.
+func (r *configReloader) apply(ctx context.Context, next config.Config, trigger string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	level, err := logging.ParseLevel(next.Server.Logging.Level)
+	if err != nil {
+		r.reject(trigger, err)
+		return
+	}
+
+	// A templates folder that changed must load; one that failed before and
+	// still fails keeps the sandbox the server started with.
+	sandbox, err := newTemplateSandbox(next.Server.Templates.TemplatesFolder)
+	if err != nil {
+		if next.Server.Templates.TemplatesFolder != r.current.Server.Templates.TemplatesFolder {
+			r.reject(trigger, err)
+			return
+		}
+		r.logger.Warn("template sandbox setup failed; keeping the current sandbox", slog.String("trigger", trigger), slog.Any("error", err))
+		sandbox = r.sandbox
+	}
+
+	logger := r.logger.With(slog.String("trigger", trigger))
+	if fields := config.RestartRequired(r.boot, next); len(fields) > 0 {
+		logger.Warn("configuration changes require a restart to take effect", slog.Any("fields", fields))
+	}
+
+	if r.level != nil {
+		r.level.Set(level)
+	}
+
+	decisionCache := r.cache
+	if cacheNeedsRebuild(r.current.Server.Cache, next.Server.Cache, r.cache) {
+		decisionCache = buildCache(r.logger.With(slog.String("agent", "cache_factory")), next.Server.Cache)
+		r.readiness.SetChecks("cache", cacheHealthChecks(next.Server.Cache, decisionCache))
+	}
+
+	r.pipe.ApplySettings(ctx, runtime.Settings{
+		Cache:             decisionCache,
+		CacheTTL:          cacheTTL(next.Server.Cache),
+		CacheEpoch:        next.Server.Cache.Epoch,
+		CacheKeySalt:      next.Server.Cache.KeySalt,
+		TemplateSandbox:   sandbox,
+		CorrelationHeader: next.Server.Logging.CorrelationHeader,
+		LoadedEnvironment: next.LoadedEnvironment,
+		LoadedSecrets:     next.LoadedSecrets,
+		RulesReload:       next.Server.Rules.Reload,
+	})
+	r.cache = decisionCache
+	r.sandbox = sandbox
+
+	if rulesChanged(r.current, next) {
+		if !r.watchRules(ctx, next) {
+			r.reloadRules(ctx, config.RuleBundle{
+				Endpoints: next.Endpoints,
+				Rules:     next.Rules,
+				Sources:   next.RuleSources,
+				Skipped:   next.SkippedDefinitions,
+			})
+		}
+	}
+
+	r.current = next
+	logger.Info("configuration reloaded")
+}
+
+// watchRules replaces the rules watcher so it follows the source paths and
+// inline definitions of cfg. The new watcher reloads the pipeline with its
+// initial bundle. It reports false when cfg declares no rules source.
+func (r *configReloader) watchRules(ctx context.Context, cfg config.Config) bool {
+	r.stopRules()
+	if cfg.Server.Rules.RulesFile == "" && cfg.Server.Rules.RulesFolder == "" && !cfg.Server.Rules.Remote.Enabled() {
+		return false
+	}
+	w, err := r.loader.WatchRules(ctx, cfg, func(bundle config.RuleBundle) {
+		r.reloadRules(ctx, bundle)
+	}, func(err error) {
+		if err == nil {
+			return
+		}
+		var loadErr *config.BundleLoadError
+		if errors.As(err, &loadErr) {
+			r.pipe.RecordReloadFailure(err)
+		}
+		r.logger.Error("rules watcher error", slog.Any("error", err))
+	})
+	if err != nil {
+		r.logger.Error("rules watcher setup failed", slog.Any("error", err))
+		return true
+	}
+	r.rules = w
+	return true
+}
+
+// reloadRules hands the bundle to the pipeline and moves the backend health
+// probes to its rules only when the pipeline applied it, so /readyz keeps
+// probing the backends that are still serving after a rollback.
+func (r *configReloader) reloadRules(ctx context.Context, bundle config.RuleBundle) {
+	if record := r.pipe.Reload(ctx, bundle); record.Status == metrics.RulesReloadApplied {
+		r.readiness.SetChecks("backends", backendHealthChecks(bundle.Rules))
+	}
+}
+
+func (r *configReloader) stopRules() {
+	if r.rules != nil {
+		r.rules.Stop()
+		r.rules = nil
+	}
+}
+
+// Stop releases the rules watcher.
+func (r *configReloader) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopRules()
+}
+
+// cacheNeedsRebuild reports whether the decision cache must be reconnected:
+// either its settings changed or a Redis cache is still running on the memory
+// fallback chosen at startup.
+func cacheNeedsRebuild(current, next config.ServerCacheConfig, active cache.DecisionCache) bool {
+	if !reflect.DeepEqual(current, next) {
+		return true
+	}
+	if !strings.EqualFold(strings.TrimSpace(next.Backend), "redis") {
+		return false
+	}
+	_, connected := active.(cache.Pinger)
+	return !connected
+}
+
+func rulesChanged(current, next config.Config) bool {
+	return !reflect.DeepEqual(current.Server.Rules, next.Server.Rules) ||
+		!reflect.DeepEqual(current.InlineEndpoints, next.InlineEndpoints) ||
+		!reflect.DeepEqual(current.InlineRules, next.InlineRules)
+}
+
+// newTemplateSandbox confines template and credential file access to folder.
+// An empty folder leaves templates without file access.
+func newTemplateSandbox(folder string) (*templates.Sandbox, error) {
+	folder = strings.TrimSpace(folder)
+	if folder == "" {
+		return nil, nil
+	}
+	sandbox, err := templates.NewSandbox(folder)
+	if err != nil {
+		return nil, fmt.Errorf("template sandbox %q: %w", folder, err)
+	}
+	return sandbox, nil
+}
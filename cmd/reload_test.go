@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/runtime"
+	"github.com/l0p7/passctrl/internal/server"
+	"github.com/stretchr/testify/require"
+)
+
+func reloadTestConfig(mode string) config.Config {
+	cfg := config.DefaultConfig()
+	cfg.Server.Rules.RulesFolder = ""
+	cfg.Server.Templates.TemplatesFolder = ""
+	cfg.LoadedEnvironment = map[string]string{"MODE": mode}
+	cfg.Endpoints = map[string]config.EndpointConfig{
+		"solo": {
+			Authentication: config.EndpointAuthenticationConfig{
+				Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+			},
+			Rules: []config.EndpointRuleReference{{Name: "mode"}},
+		},
+	}
+	cfg.Rules = map[string]config.RuleConfig{
+		"mode": {Conditions: config.RuleConditionConfig{
+			Pass: []string{`variables.environment.MODE == "open"`},
+			Fail: []string{`variables.environment.MODE != "open"`},
+		}},
+	}
+	cfg.InlineEndpoints = cfg.Endpoints
+	cfg.InlineRules = cfg.Rules
+	return cfg
+}
+
+func newTestReloader(t *testing.T, cfg config.Config) (*configReloader, *slog.LevelVar, *bytes.Buffer, http.Handler) {
+	t.Helper()
+	var logs bytes.Buffer
+	level := new(slog.LevelVar)
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: level}))
+	decisionCache := buildCache(nil, cfg.Server.Cache)
+	pipe := runtime.NewPipeline(newTestLogger(), runtime.PipelineOptions{
+		Cache:             decisionCache,
+		Endpoints:         cfg.Endpoints,
+		Rules:             cfg.Rules,
+		LoadedEnvironment: cfg.LoadedEnvironment,
+	})
+	t.Cleanup(func() { _ = pipe.Close(context.Background()) })
+	readiness := newReadiness(newTestLogger(), cfg.Server.Health)
+	reloader := newConfigReloader(&fakeLoader{cfg: cfg}, logger, level, pipe, readiness, cfg, decisionCache, nil)
+	return reloader, level, &logs, server.NewAuthHandler(pipe)
+}
+
+func authStatus(handler http.Handler) int {
+	req := httptest.NewRequest(http.MethodGet, "/solo/auth", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestConfigReloaderAppliesLiveSettings(t *testing.T) {
+	ctx := context.Background()
+	reloader, level, logs, handler := newTestReloader(t, reloadTestConfig("closed"))
+	require.Equal(t, http.StatusForbidden, authStatus(handler))
+
+	next := reloadTestConfig("open")
+	next.Server.Logging.Level = "debug"
+	next.Server.Cache.TTLSeconds = 120
+	next.Server.Listen.Port = 9000
+	previousCache := reloader.cache
+	reloader.apply(ctx, next, "sighup")
+
+	require.Equal(t, slog.LevelDebug, level.Level())
+	require.NotSame(t, previousCache, reloader.cache, "changed cache settings reconnect the backend")
+	require.Equal(t, http.StatusOK, authStatus(handler), "reloaded environment must reach rule evaluation")
+	require.Contains(t, logs.String(), "configuration changes require a restart")
+	require.Contains(t, logs.String(), "server.listen")
+}
+
+func TestConfigReloaderRejectsInvalidSnapshot(t *testing.T) {
+	reloader, level, logs, handler := newTestReloader(t, reloadTestConfig("open"))
+
+	next := reloadTestConfig("closed")
+	next.Server.Logging.Level = "verbose"
+	reloader.apply(context.Background(), next, "file")
+
+	require.Equal(t, slog.LevelInfo, level.Level())
+	require.Equal(t, http.StatusOK, authStatus(handler), "rejected snapshots must not touch the pipeline")
+	require.Contains(t, logs.String(), "configuration reload rejected")
+}
+
+func TestConfigReloaderRejectsUnusableTemplatesFolder(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	reloader, _, logs, handler := newTestReloader(t, reloadTestConfig("open"))
+	next := reloadTestConfig("closed")
+	next.Server.Templates.TemplatesFolder = missing
+	reloader.apply(context.Background(), next, "file")
+
+	require.Equal(t, http.StatusOK, authStatus(handler), "a templates folder that cannot load must reject the snapshot")
+	require.Contains(t, logs.String(), "configuration reload rejected")
+	require.Empty(t, reloader.current.Server.Templates.TemplatesFolder)
+
+	// A folder that was already unusable at startup keeps the startup sandbox
+	// and does not block unrelated changes.
+	cfg := reloadTestConfig("open")
+	cfg.Server.Templates.TemplatesFolder = missing
+	reloader, _, logs, handler = newTestReloader(t, cfg)
+	next = reloadTestConfig("closed")
+	next.Server.Templates.TemplatesFolder = missing
+	reloader.apply(context.Background(), next, "file")
+
+	require.Equal(t, http.StatusForbidden, authStatus(handler))
+	require.Contains(t, logs.String(), "keeping the current sandbox")
+}
+
+func TestConfigReloaderReloadsInlineRules(t *testing.T) {
+	reloader, _, _, handler := newTestReloader(t, reloadTestConfig("open"))
+
+	next := reloadTestConfig("open")
+	next.Rules = map[string]config.RuleConfig{
+		"mode": {Conditions: config.RuleConditionConfig{Fail: []string{"true"}}},
+	}
+	next.InlineRules = next.Rules
+	reloader.reload(context.Background(), "sighup")
+	require.Equal(t, http.StatusOK, authStatus(handler), "unchanged snapshot keeps the current rules")
+
+	reloader.loader = &fakeLoader{cfg: next}
+	reloader.reload(context.Background(), "sighup")
+	require.Equal(t, http.StatusForbidden, authStatus(handler))
+}
+
+func TestConfigReloaderMovesHealthProbesOnlyWithAppliedBundles(t *testing.T) {
+	probed := func(cfg config.Config) config.Config {
+		cfg.Endpoints = map[string]config.EndpointConfig{
+			"probed": {
+				Authentication: config.EndpointAuthenticationConfig{
+					Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+				},
+				Rules: []config.EndpointRuleReference{{Name: "upstream"}},
+			},
+		}
+		cfg.Rules = map[string]config.RuleConfig{
+			"upstream": {
+				BackendAPI: config.RuleBackendConfig{HealthProbe: config.RuleHealthProbeConfig{URL: "http://upstream.test/healthz"}},
+				Conditions: config.RuleConditionConfig{Pass: []string{"true"}},
+			},
+		}
+		cfg.InlineEndpoints = cfg.Endpoints
+		cfg.InlineRules = cfg.Rules
+		return cfg
+	}
+	checkNames := func(r *configReloader) []string {
+		var names []string
+		for _, check := range r.readiness.Report().Checks {
+			names = append(names, check.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name      string
+		rulesFile string
+		reload    func(t *testing.T, r *configReloader, next config.Config)
+	}{
+		{
+			name: "inline rules",
+			reload: func(_ *testing.T, r *configReloader, next config.Config) {
+				r.apply(context.Background(), next, "sighup")
+			},
+		},
+		{
+			name:      "rules watcher",
+			rulesFile: "rules.yaml",
+			reload: func(t *testing.T, r *configReloader, next config.Config) {
+				loader, ok := r.loader.(*fakeLoader)
+				require.True(t, ok)
+				require.NotNil(t, loader.onChange)
+				loader.onChange(config.RuleBundle{Endpoints: next.Endpoints, Rules: next.Rules})
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := reloadTestConfig("open")
+			cfg.Server.Rules.RulesFile = tc.rulesFile
+			reloader, _, _, handler := newTestReloader(t, cfg)
+			require.Equal(t, tc.rulesFile != "", reloader.watchRules(context.Background(), cfg))
+
+			// Breaking the serving "solo" endpoint is rejected, so the current
+			// snapshot keeps serving and readiness must not probe the
+			// rejected bundle's backend.
+			rejected := probed(cfg)
+			rejected.Endpoints["solo"] = cfg.Endpoints["solo"]
+			rejected.Rules["mode"] = config.RuleConfig{Conditions: config.RuleConditionConfig{Pass: []string{"not valid CEL ("}}}
+			tc.reload(t, reloader, rejected)
+			require.Equal(t, http.StatusOK, authStatus(handler))
+			require.Empty(t, checkNames(reloader), "rejected bundles must not move health probes")
+
+			applied := probed(cfg)
+			applied.Endpoints["solo"] = cfg.Endpoints["solo"]
+			applied.Rules["mode"] = cfg.Rules["mode"]
+			tc.reload(t, reloader, applied)
+			require.Equal(t, []string{"backend:upstream"}, checkNames(reloader))
+		})
+	}
+}
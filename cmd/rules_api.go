@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/metrics"
+	"github.com/l0p7/passctrl/internal/runtime"
+)
+
+// maxRuleBundleBytes caps the size of a rule bundle pushed through the admin API.
+const maxRuleBundleBytes = 8 << 20
+
+// rulesAPIResponse reports the outcome of a pushed or reloaded bundle together
+// with the definitions that were quarantined while loading it.
+type rulesAPIResponse struct {
+	runtime.ReloadRecord
+	SkippedDefinitions []config.DefinitionSkip `json:"skippedDefinitions,omitempty"`
+}
+
+// servePushRules accepts a full rule bundle in the request body and applies it
+// in place of the configured rules source. The bundle stays active until the
+// next change on disk, reload, or push.
+func (r *configReloader) servePushRules(w http.ResponseWriter, req *http.Request) {
+	dryRun, err := dryRunRequested(req)
+	if err != nil {
+		writeRulesAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	format, err := ruleBundleFormat(req)
+	if err != nil {
+		writeRulesAPIError(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, req.Body, maxRuleBundleBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeRulesAPIError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("rule bundle exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		writeRulesAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bundle, err := config.ParseRuleBundle(req.Context(), r.current, body, format)
+	r.respondWithBundle(req.Context(), w, "admin_push", bundle, err, dryRun)
+}
+
+// serveReloadRules rebuilds the bundle from the configured rules source right
+// away instead of waiting for the file watcher.
+func (r *configReloader) serveReloadRules(w http.ResponseWriter, req *http.Request) {
+	dryRun, err := dryRunRequested(req)
+	if err != nil {
+		writeRulesAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bundle, err := config.LoadRuleBundle(req.Context(), r.current)
+	r.respondWithBundle(req.Context(), w, "admin_reload", bundle, err, dryRun)
+}
+
+// respondWithBundle applies or previews a loaded bundle and writes the reload
+// record. Callers hold r.mu.
+func (r *configReloader) respondWithBundle(ctx context.Context, w http.ResponseWriter, trigger string, bundle config.RuleBundle, loadErr error, dryRun bool) {
+	logger := r.logger.With(slog.String("trigger", trigger))
+	if loadErr != nil {
+		if !dryRun {
+			r.pipe.RecordReloadFailure(loadErr)
+		}
+		logger.Warn("rule bundle could not be loaded", slog.Any("error", loadErr))
+		writeRulesAPIError(w, http.StatusUnprocessableEntity, loadErr)
+		return
+	}
+
+	var record runtime.ReloadRecord
+	if dryRun {
+		record = r.pipe.PreviewReload(bundle)
+	} else {
+		record = r.pipe.Reload(ctx, bundle)
+		if record.Status == metrics.RulesReloadApplied {
+			r.readiness.SetChecks("backends", backendHealthChecks(bundle.Rules))
+		}
+		logger.Info("rule bundle submitted through admin API", slog.String("status", string(record.Status)))
+	}
+
+	status := http.StatusOK
+	if record.Status == metrics.RulesReloadRejected {
+		status = http.StatusConflict
+	}
+	writeRulesAPIJSON(w, status, rulesAPIResponse{ReloadRecord: record, SkippedDefinitions: bundle.Skipped})
+}
+
+func dryRunRequested(req *http.Request) (bool, error) {
+	raw := strings.TrimSpace(req.URL.Query().Get("dryRun"))
+	if raw == "" {
+		return false, nil
+	}
+	dryRun, err := strconv.ParseBool(raw)
+	if err != nil {
+		return false, fmt.Errorf("invalid dryRun value %q", raw)
+	}
+	return dryRun, nil
+}
+
+// ruleBundleFormat picks the parser from the format query parameter or the
+// Content-Type header. Requests without either are read as YAML, which also
+// accepts JSON documents.
+func ruleBundleFormat(req *http.Request) (string, error) {
+	if format := strings.ToLower(strings.TrimSpace(req.URL.Query().Get("format"))); format != "" {
+		return format, nil
+	}
+	contentType := strings.TrimSpace(req.Header.Get("Content-Type"))
+	if contentType == "" {
+		return "yaml", nil
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", fmt.Errorf("invalid content type %q", contentType)
+	}
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return "yaml", nil
+	case "application/json":
+		return "json", nil
+	case "application/toml":
+		return "toml", nil
+	default:
+		return "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+}
+
+func writeRulesAPIError(w http.ResponseWriter, status int, err error) {
+	writeRulesAPIJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeRulesAPIJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
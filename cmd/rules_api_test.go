@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/l0p7/passctrl/internal/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+const pushedBundleYAML = `endpoints:
+  pushed:
+    authentication:
+      allow:
+        authorization: [bearer]
+    rules:
+      - name: allow
+rules:
+  allow:
+    conditions:
+      pass: ["true"]
+`
+
+func callRulesAPI(t *testing.T, handler http.HandlerFunc, target, contentType, body string) (int, rulesAPIResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	var resp rulesAPIResponse
+	if rec.Code == http.StatusOK || rec.Code == http.StatusConflict {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	}
+	return rec.Code, resp
+}
+
+func TestRulesAPIPushAppliesBundle(t *testing.T) {
+	reloader, _, _, handler := newTestReloader(t, reloadTestConfig("open"))
+
+	code, resp := callRulesAPI(t, reloader.servePushRules, "/rules?dryRun=true", "application/yaml", pushedBundleYAML)
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, resp.DryRun)
+	require.Equal(t, []string{"pushed"}, resp.Diff.AddedEndpoints)
+	require.False(t, reloader.pipe.EndpointExists("pushed"), "dry runs must not apply the bundle")
+
+	code, resp = callRulesAPI(t, reloader.servePushRules, "/rules", "application/yaml", pushedBundleYAML)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, metrics.RulesReloadApplied, resp.Status)
+	require.Equal(t, []string{"admin-api", "inline-config"}, resp.Sources)
+	require.True(t, reloader.pipe.EndpointExists("pushed"))
+	require.Equal(t, http.StatusOK, authStatus(handler), "inline endpoints keep serving alongside the pushed bundle")
+	require.Len(t, reloader.pipe.ReloadHistory(), 1)
+}
+
+func TestRulesAPIPushRejectsBreakingBundle(t *testing.T) {
+	reloader, _, _, handler := newTestReloader(t, reloadTestConfig("open"))
+
+	body := `{"rules": {"mode": {"conditions": {"pass": ["true"]}}}}`
+	code, resp := callRulesAPI(t, reloader.servePushRules, "/rules", "application/json", body)
+	require.Equal(t, http.StatusConflict, code)
+	require.Equal(t, metrics.RulesReloadRejected, resp.Status)
+	require.NotEmpty(t, resp.SkippedDefinitions, "the duplicate rule is reported as skipped")
+	require.Equal(t, http.StatusOK, authStatus(handler))
+}
+
+func TestRulesAPIPushRejectsUnreadableBodies(t *testing.T) {
+	reloader, _, _, _ := newTestReloader(t, reloadTestConfig("open"))
+
+	code, _ := callRulesAPI(t, reloader.servePushRules, "/rules", "text/plain", "rules: {}")
+	require.Equal(t, http.StatusUnsupportedMediaType, code)
+
+	code, _ = callRulesAPI(t, reloader.servePushRules, "/rules?format=json", "", `{"rules":`)
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+
+	code, _ = callRulesAPI(t, reloader.servePushRules, "/rules?dryRun=maybe", "", pushedBundleYAML)
+	require.Equal(t, http.StatusBadRequest, code)
+
+	history := reloader.pipe.ReloadHistory()
+	require.Len(t, history, 1, "only the malformed bundle reaches the reload history")
+	require.Equal(t, metrics.RulesReloadFailed, history[0].Status)
+}
+
+func TestRulesAPIReloadReadsRulesSource(t *testing.T) {
+	cfg := reloadTestConfig("open")
+	cfg.Server.Rules.RulesFile = filepath.Join(t.TempDir(), "rules.yaml")
+	require.NoError(t, os.WriteFile(cfg.Server.Rules.RulesFile, []byte("rules: {}\n"), 0o600))
+	reloader, _, _, _ := newTestReloader(t, cfg)
+
+	require.NoError(t, os.WriteFile(cfg.Server.Rules.RulesFile, []byte(pushedBundleYAML), 0o600))
+	code, resp := callRulesAPI(t, reloader.serveReloadRules, "/rules/reload", "", "")
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, []string{"pushed"}, resp.Diff.AddedEndpoints)
+	require.True(t, reloader.pipe.EndpointExists("pushed"))
+
+	require.NoError(t, os.Remove(cfg.Server.Rules.RulesFile))
+	code, _ = callRulesAPI(t, reloader.serveReloadRules, "/rules/reload", "", "")
+	require.Equal(t, http.StatusUnprocessableEntity, code)
+	require.True(t, reloader.pipe.EndpointExists("pushed"), "a failed reload keeps the active bundle")
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/expr"
+)
+
+// walkRuleReferences visits every entry of a rule chain depth first, passing
+// the field path (for example `rules[1].anyOf[0]`) alongside the entry.
+func walkRuleReferences(refs []EndpointRuleReference, prefix string, fn func(path string, ref EndpointRuleReference)) {
+	for idx, ref := range refs {
+		path := fmt.Sprintf("%s[%d]", prefix, idx)
+		fn(path, ref)
+		walkRuleReferences(ref.AnyOf, path+".anyOf", fn)
+		walkRuleReferences(ref.AllOf, path+".allOf", fn)
+		walkRuleReferences(ref.Parallel, path+".parallel", fn)
+	}
+}
+
+// validateRuleChain checks that every entry is either a rule or a group, that
+// onPass names a known action, that when guards compile, and that entries of
+// a parallel group do not read each other's exported variables.
+func validateRuleChain(refs []EndpointRuleReference, rules map[string]RuleConfig, env *expr.Environment) error {
+	var firstErr error
+	walkRuleReferences(refs, "rules", func(path string, ref EndpointRuleReference) {
+		if firstErr != nil {
+			return
+		}
+		if firstErr = validateRuleChainEntry(path, ref, env); firstErr == nil && len(ref.Parallel) > 0 {
+			firstErr = validateParallelGroup(path+".parallel", ref.Parallel, rules)
+		}
+	})
+	return firstErr
+}
+
+func validateRuleChainEntry(path string, ref EndpointRuleReference, env *expr.Environment) error {
+	named := strings.TrimSpace(ref.Name) != ""
+	groups := 0
+	for _, group := range [][]EndpointRuleReference{ref.AnyOf, ref.AllOf, ref.Parallel} {
+		if len(group) > 0 {
+			groups++
+		}
+	}
+	switch {
+	case groups > 1:
+		return &fieldError{path: path, err: fmt.Errorf("anyOf, allOf, and parallel are mutually exclusive")}
+	case named && ref.IsGroup():
+		return &fieldError{path: path, err: fmt.Errorf("name cannot be combined with a group")}
+	case !named && !ref.IsGroup():
+		return &fieldError{path: path, err: fmt.Errorf("name, anyOf, allOf, or parallel required")}
+	case ref.IsGroup() && len(ref.With) > 0:
+		return &fieldError{path: path + ".with", err: fmt.Errorf("groups do not take parameters")}
+	}
+	switch strings.ToLower(strings.TrimSpace(ref.OnPass)) {
+	case "", OnPassContinue, OnPassStop:
+	default:
+		return &fieldError{path: path + ".onPass", err: fmt.Errorf("unsupported action %q", ref.OnPass)}
+	}
+	if when := strings.TrimSpace(ref.When); when != "" && env != nil {
+		if _, err := env.Compile(when); err != nil {
+			return &fieldError{path: path + ".when", err: err}
+		}
+	}
+	return nil
+}
+
+// validateParallelGroup rejects groups whose entries depend on one another;
+// running them concurrently would hide the exports they read.
+func validateParallelGroup(path string, entries []EndpointRuleReference, rules map[string]RuleConfig) error {
+	names := make([][]string, len(entries))
+	for idx, entry := range entries {
+		walkRuleReferences([]EndpointRuleReference{entry}, "", func(_ string, ref EndpointRuleReference) {
+			if name := strings.TrimSpace(ref.Name); name != "" {
+				names[idx] = append(names[idx], name)
+			}
+		})
+	}
+	for idx := range entries {
+		for _, name := range names[idx] {
+			rule, ok := rules[name]
+			if !ok {
+				continue
+			}
+			deps := AnalyzeRuleDependencies(rule)
+			for other := range entries {
+				if other == idx {
+					continue
+				}
+				for _, sibling := range names[other] {
+					if sibling != name && deps.DependsOn(sibling) {
+						return &fieldError{path: fmt.Sprintf("%s[%d]", path, idx), err: fmt.Errorf("rule %q reads variables exported by %q in the same parallel group", name, sibling)}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func validateRuleExecution(cfg EndpointRuleExecutionConfig) error {
+	switch strings.ToLower(strings.TrimSpace(cfg.Parallel)) {
+	case "", RuleParallelOff, RuleParallelAuto:
+	default:
+		return &fieldError{path: "ruleExecution.parallel", err: fmt.Errorf("unsupported mode %q", cfg.Parallel)}
+	}
+	if cfg.MaxWorkers < 0 {
+		return &fieldError{path: "ruleExecution.maxWorkers", err: fmt.Errorf("must not be negative")}
+	}
+	return nil
+}
+
+// validateEndpointChains quarantines endpoints whose rule chain is malformed.
+func (a *ruleAggregator) validateEndpointChains(env *expr.Environment) {
+	for name, cfg := range a.endpoints {
+		err := validateRuleExecution(cfg.RuleExecution)
+		if err == nil {
+			err = validateRuleChain(cfg.Rules, a.rules, env)
+		}
+		if err != nil {
+			a.recordEndpointSkip(name, fmt.Sprintf("invalid rule chain: %v", err), a.endpointSources[name])
+			delete(a.endpointSources, name)
+			delete(a.endpoints, name)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRuleBundleValidatesRuleChains(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"rules.yaml": `
+rules:
+  admin-token: {}
+  session: {}
+endpoints:
+  gateway:
+    rules:
+      - anyOf:
+          - name: admin-token
+            onPass: stop
+          - allOf:
+              - name: session
+              - name: session
+                when: request.method == "GET"
+  nested-missing:
+    rules:
+      - anyOf:
+          - name: admin-token
+          - name: ghost
+  both-groups:
+    rules:
+      - anyOf: [{name: session}]
+        allOf: [{name: session}]
+  bad-action:
+    rules:
+      - name: session
+        onPass: halt
+  bad-guard:
+    rules:
+      - allOf:
+          - name: session
+            when: request.method ==
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Endpoints, "gateway")
+	gateway := bundle.Endpoints["gateway"].Rules
+	require.Equal(t, OnPassStop, gateway[0].AnyOf[0].OnPass)
+	require.Len(t, gateway[0].AnyOf[1].AllOf, 2)
+
+	reasons := make(map[string]string)
+	for _, skip := range bundle.Skipped {
+		reasons[skip.Name] = skip.Reason
+	}
+	require.Len(t, reasons, 4)
+	require.Equal(t, "missing rule dependencies: ghost", reasons["nested-missing"])
+	require.Contains(t, reasons["both-groups"], "rules[0]: anyOf, allOf, and parallel are mutually exclusive")
+	require.Contains(t, reasons["bad-action"], `rules[0].onPass: unsupported action "halt"`)
+	require.Contains(t, reasons["bad-guard"], "invalid rule chain: rules[0].allOf[0].when:")
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RuleDependencies lists the rules whose exported variables a rule reads.
+type RuleDependencies struct {
+	// Rules names the rules referenced as variables.rule.<name> in CEL or
+	// .variables.rule.<name> in templates, sorted.
+	Rules []string
+	// Dynamic is set when the rule reads exported variables in a way that
+	// cannot be attributed to specific rules (indexing the whole map, reading
+	// the accumulated response variables or chain history, or rendering a
+	// body file), so it must see every earlier rule's result.
+	Dynamic bool
+}
+
+// DependsOn reports whether the rule must run after the named rule.
+func (d RuleDependencies) DependsOn(name string) bool {
+	if d.Dynamic {
+		return true
+	}
+	idx := sort.SearchStrings(d.Rules, name)
+	return idx < len(d.Rules) && d.Rules[idx] == name
+}
+
+var (
+	ruleVariablePattern = regexp.MustCompile(`\b(?:variables|vars)\s*\.\s*rule\b(\s*\.\s*([A-Za-z_][A-Za-z0-9_]*)|\s*\[\s*["']([^"']+)["']\s*\])?`)
+	// dynamicStatePattern matches template roots that expose other rules'
+	// results wholesale.
+	dynamicStatePattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_])\$?\.(?:state|chain|response)\b|\bvariables\s*\[`)
+)
+
+// AnalyzeRuleDependencies scans every expression and template in a rule for
+// references to other rules' exported variables.
+func AnalyzeRuleDependencies(rule RuleConfig) RuleDependencies {
+	var deps RuleDependencies
+	if strings.TrimSpace(rule.BackendAPI.BodyFile) != "" {
+		deps.Dynamic = true
+	}
+	seen := make(map[string]struct{})
+	visitRuleStrings(reflect.ValueOf(rule), func(text string) {
+		if dynamicStatePattern.MatchString(text) {
+			deps.Dynamic = true
+		}
+		for _, match := range ruleVariablePattern.FindAllStringSubmatch(text, -1) {
+			name := match[2]
+			if name == "" {
+				name = match[3]
+			}
+			if name == "" {
+				deps.Dynamic = true
+				continue
+			}
+			seen[name] = struct{}{}
+		}
+	})
+	for name := range seen {
+		deps.Rules = append(deps.Rules, name)
+	}
+	sort.Strings(deps.Rules)
+	return deps
+}
+
+// visitRuleStrings walks a rule definition and calls fn with every string it
+// holds, so newly added expression fields are covered without listing them.
+func visitRuleStrings(v reflect.Value, fn func(string)) {
+	switch v.Kind() {
+	case reflect.String:
+		if text := v.String(); text != "" {
+			fn(text)
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			visitRuleStrings(v.Elem(), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				visitRuleStrings(v.Field(i), fn)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			visitRuleStrings(v.Index(i), fn)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			visitRuleStrings(iter.Value(), fn)
+		}
+	}
+}
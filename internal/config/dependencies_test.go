@@ -0,0 +1,99 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeRuleDependencies(t *testing.T) {
+	cases := map[string]struct {
+		rule    RuleConfig
+		rules   []string
+		dynamic bool
+	}{
+		"independent": {
+			rule: RuleConfig{Conditions: RuleConditionConfig{Pass: []string{`backend.status == 200`}}},
+		},
+		"cel and template references": {
+			rule: RuleConfig{
+				Conditions: RuleConditionConfig{Pass: []string{`variables.rule.session.user != ""`}},
+				BackendAPI: RuleBackendConfig{Headers: map[string]*string{"X-Tenant": strPtr(`{{ .vars.rule.tenant.id }}`)}},
+				Responses: RuleResponsesConfig{Pass: RuleResponseConfig{Variables: map[string]string{
+					"group": `variables.rule["group-lookup"].name`,
+				}}},
+			},
+			rules: []string{"group-lookup", "session", "tenant"},
+		},
+		"whole map": {
+			rule:    RuleConfig{Conditions: RuleConditionConfig{Pass: []string{`size(vars.rule) > 0`}}},
+			dynamic: true,
+		},
+		"body file": {
+			rule:    RuleConfig{BackendAPI: RuleBackendConfig{BodyFile: "body.tmpl"}},
+			dynamic: true,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			deps := AnalyzeRuleDependencies(tc.rule)
+			require.Equal(t, tc.rules, deps.Rules)
+			require.Equal(t, tc.dynamic, deps.Dynamic)
+			require.Equal(t, tc.dynamic, deps.DependsOn("unrelated"))
+		})
+	}
+}
+
+func TestBuildRuleBundleRejectsDependentParallelEntries(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"rules.yaml": `
+rules:
+  session:
+    responses:
+      pass:
+        variables:
+          user: backend.body.user
+  profile:
+    conditions:
+      pass:
+        - variables.rule.session.user != ""
+  quota: {}
+endpoints:
+  independent:
+    ruleExecution:
+      parallel: auto
+      maxWorkers: 2
+    rules:
+      - parallel:
+          - name: session
+          - name: quota
+      - name: profile
+  dependent:
+    rules:
+      - parallel:
+          - name: session
+          - allOf:
+              - name: quota
+              - name: profile
+  bad-mode:
+    ruleExecution:
+      parallel: always
+    rules:
+      - name: quota
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Endpoints, "independent")
+	require.Equal(t, EndpointRuleExecutionConfig{Parallel: RuleParallelAuto, MaxWorkers: 2}, bundle.Endpoints["independent"].RuleExecution)
+
+	reasons := make(map[string]string)
+	for _, skip := range bundle.Skipped {
+		reasons[skip.Name] = skip.Reason
+	}
+	require.Len(t, reasons, 2)
+	require.Contains(t, reasons["dependent"], `rules[0].parallel[1]: rule "profile" reads variables exported by "session" in the same parallel group`)
+	require.Contains(t, reasons["bad-mode"], `ruleExecution.parallel: unsupported mode "always"`)
+}
@@ -18,6 +18,7 @@ func TestLoadExampleConfigs(t *testing.T) {
 	examples := []struct {
 		name     string
 		path     string
+		env      map[string]string
 		validate func(t *testing.T, cfg Config)
 	}{
 		{
@@ -37,6 +38,7 @@ func TestLoadExampleConfigs(t *testing.T) {
 		{
 			name: "backend-token-introspection",
 			path: "examples/configs/backend-token-introspection.yaml",
+			env:  map[string]string{"INTROSPECTION_CLIENT_SECRET": "example-secret"},
 			validate: func(t *testing.T, cfg Config) {
 				require.Contains(t, cfg.Endpoints, "introspection")
 				ep := cfg.Endpoints["introspection"]
@@ -45,6 +47,12 @@ func TestLoadExampleConfigs(t *testing.T) {
 				require.Equal(t, []string{"bearer"}, ep.Authentication.Allow.Authorization)
 				require.Equal(t, "bearer", ep.Authentication.Challenge.Type)
 				require.Equal(t, "Identity", ep.Authentication.Challenge.Realm)
+
+				require.Contains(t, cfg.Rules, "introspect-bearer-token")
+				backend := cfg.Rules["introspect-bearer-token"].BackendAPI
+				require.Equal(t, "introspection", backend.Type)
+				require.Equal(t, []string{"orders-api"}, backend.Introspection.Audience)
+				require.Equal(t, "example-secret", cfg.LoadedEnvironment["INTROSPECTION_CLIENT_SECRET"])
 			},
 		},
 		{
@@ -76,6 +84,9 @@ func TestLoadExampleConfigs(t *testing.T) {
 
 			// Disable rules folder to use inline rules from the config file
 			t.Setenv("PASSCTRL_SERVER__RULES__RULESFOLDER", "")
+			for key, value := range tc.env {
+				t.Setenv(key, value)
+			}
 
 			loader := NewLoader("PASSCTRL", configPath)
 			cfg, err := loader.Load(context.Background())
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/knadh/koanf/v2"
+)
+
+// fragmentRefKey marks a node that is replaced by a named fragment. Sibling
+// keys next to the reference override the fragment's own keys.
+const fragmentRefKey = "$ref"
+
+// rawRuleDocument is a rules source before fragment references are resolved
+// and definitions are decoded.
+type rawRuleDocument struct {
+	source           string
+	endpoints        map[string]any
+	rules            map[string]any
+	fragments        map[string]any
+	endpointDefaults map[string]any
+}
+
+// fieldOrigin names the fragment a resolved field came from. An empty
+// fragment marks fields written in the definition itself.
+type fieldOrigin struct {
+	fragment string
+	source   string
+}
+
+// fieldOrigins maps field paths (for example `backendApi.headers` or
+// `auth[0].match[1]`) to the fragment that supplied them.
+type fieldOrigins map[string]fieldOrigin
+
+// describe reports the fragment that supplied path, using the deepest
+// recorded ancestor, or an empty string when the field was written inline.
+func (o fieldOrigins) describe(path string) string {
+	best := ""
+	found := false
+	for prefix := range o {
+		matches := prefix == "" || prefix == path ||
+			strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[")
+		if !matches {
+			continue
+		}
+		if !found || len(prefix) > len(best) {
+			best, found = prefix, true
+		}
+	}
+	if !found || o[best].fragment == "" {
+		return ""
+	}
+	origin := o[best]
+	return fmt.Sprintf("fragment %q in %s", origin.fragment, origin.source)
+}
+
+// fieldError ties a validation failure to the definition field that caused it
+// so the aggregator can report which fragment supplied the field.
+type fieldError struct {
+	path string
+	err  error
+}
+
+func (e *fieldError) Error() string { return e.path + ": " + e.err.Error() }
+
+func (e *fieldError) Unwrap() error { return e.err }
+
+// withProvenance appends the fragment that supplied the failing field to err.
+func withProvenance(err error, origins fieldOrigins) string {
+	var fieldErr *fieldError
+	if errors.As(err, &fieldErr) {
+		if origin := origins.describe(fieldErr.path); origin != "" {
+			return fmt.Sprintf("%v (from %s)", err, origin)
+		}
+	}
+	return err.Error()
+}
+
+type fragmentDefinition struct {
+	value   any
+	sources []string
+}
+
+// fragmentSet holds the fragments declared by every rules source. Names are
+// global across files; a name declared twice cannot be referenced.
+type fragmentSet map[string]*fragmentDefinition
+
+func (f fragmentSet) add(name string, value any, source string) {
+	if existing, ok := f[name]; ok {
+		existing.sources = appendUnique(existing.sources, source)
+		return
+	}
+	f[name] = &fragmentDefinition{value: value, sources: []string{source}}
+}
+
+// resolve expands every fragment reference inside value and records where the
+// expanded fields came from.
+func (f fragmentSet) resolve(value any) (any, fieldOrigins, error) {
+	origins := make(fieldOrigins)
+	resolved, err := f.expand(value, "", nil, origins)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolved, origins, nil
+}
+
+func (f fragmentSet) lookup(name string, stack []string) (*fragmentDefinition, error) {
+	for i, seen := range stack {
+		if seen == name {
+			cycle := append(append([]string{}, stack[i:]...), name)
+			return nil, fmt.Errorf("fragment cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	def, ok := f[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fragment %q", name)
+	}
+	if len(def.sources) > 1 {
+		sources := append([]string{}, def.sources...)
+		sort.Strings(sources)
+		return nil, fmt.Errorf("fragment %q is defined more than once (%s)", name, strings.Join(sources, ", "))
+	}
+	return def, nil
+}
+
+func (f fragmentSet) expand(value any, path string, stack []string, origins fieldOrigins) (any, error) {
+	switch node := value.(type) {
+	case map[string]any:
+		if ref, ok := node[fragmentRefKey]; ok {
+			return f.expandRef(node, ref, path, stack, origins)
+		}
+		out := make(map[string]any, len(node))
+		for key, child := range node {
+			expanded, err := f.expand(child, joinFieldPath(path, key), stack, origins)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = expanded
+		}
+		return out, nil
+	case []any:
+		out := make([]any, 0, len(node))
+		for _, item := range node {
+			// A bare reference to a list fragment splices its items in place.
+			if ref, ok := bareRef(item); ok {
+				def, err := f.lookup(ref, stack)
+				if err != nil {
+					return nil, fieldPathError(fmt.Sprintf("%s[%d]", path, len(out)), err)
+				}
+				if items, isList := def.value.([]any); isList {
+					for _, shared := range items {
+						itemPath := fmt.Sprintf("%s[%d]", path, len(out))
+						origins[itemPath] = fieldOrigin{fragment: ref, source: def.sources[0]}
+						expanded, err := f.expand(shared, itemPath, append(stack, ref), origins)
+						if err != nil {
+							return nil, err
+						}
+						out = append(out, expanded)
+					}
+					continue
+				}
+			}
+			expanded, err := f.expand(item, fmt.Sprintf("%s[%d]", path, len(out)), stack, origins)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, expanded)
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+func (f fragmentSet) expandRef(node map[string]any, ref any, path string, stack []string, origins fieldOrigins) (any, error) {
+	name, ok := ref.(string)
+	if !ok || strings.TrimSpace(name) == "" {
+		return nil, fieldPathError(path, fmt.Errorf("%s must be a fragment name", fragmentRefKey))
+	}
+	name = strings.TrimSpace(name)
+	def, err := f.lookup(name, stack)
+	if err != nil {
+		return nil, fieldPathError(path, err)
+	}
+	origins[path] = fieldOrigin{fragment: name, source: def.sources[0]}
+	base, err := f.expand(def.value, path, append(stack, name), origins)
+	if err != nil {
+		return nil, err
+	}
+	if len(node) == 1 {
+		return base, nil
+	}
+
+	merged, ok := base.(map[string]any)
+	if !ok {
+		return nil, fieldPathError(path, fmt.Errorf("fragment %q is not a map and cannot be combined with sibling keys", name))
+	}
+	for key, child := range node {
+		if key == fragmentRefKey {
+			continue
+		}
+		childPath := joinFieldPath(path, key)
+		origins[childPath] = fieldOrigin{}
+		expanded, err := f.expand(child, childPath, stack, origins)
+		if err != nil {
+			return nil, err
+		}
+		merged[key] = mergeFragmentValue(merged[key], expanded)
+	}
+	return merged, nil
+}
+
+// mergeFragmentValue overlays local values on a fragment: maps merge key by
+// key and anything else replaces the fragment's value.
+func mergeFragmentValue(base, overlay any) any {
+	baseMap, baseIsMap := base.(map[string]any)
+	overlayMap, overlayIsMap := overlay.(map[string]any)
+	if !baseIsMap || !overlayIsMap {
+		return overlay
+	}
+	out := make(map[string]any, len(baseMap)+len(overlayMap))
+	for key, value := range baseMap {
+		out[key] = value
+	}
+	for key, value := range overlayMap {
+		out[key] = mergeFragmentValue(out[key], value)
+	}
+	return out
+}
+
+func bareRef(item any) (string, bool) {
+	node, ok := item.(map[string]any)
+	if !ok || len(node) != 1 {
+		return "", false
+	}
+	name, ok := node[fragmentRefKey].(string)
+	return strings.TrimSpace(name), ok
+}
+
+func joinFieldPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func fieldPathError(path string, err error) error {
+	if path == "" {
+		return err
+	}
+	return &fieldError{path: path, err: err}
+}
+
+// rawRuleDocumentFrom splits a parsed rules source into its sections.
+func rawRuleDocumentFrom(raw map[string]any, source string) (rawRuleDocument, error) {
+	doc := rawRuleDocument{source: source}
+	for key, target := range map[string]*map[string]any{
+		"endpoints":        &doc.endpoints,
+		"rules":            &doc.rules,
+		"fragments":        &doc.fragments,
+		"endpointDefaults": &doc.endpointDefaults,
+	} {
+		section, ok := raw[key]
+		if !ok {
+			continue
+		}
+		if section == nil {
+			if key == "endpointDefaults" {
+				*target = map[string]any{}
+			}
+			continue
+		}
+		m, ok := section.(map[string]any)
+		if !ok {
+			return rawRuleDocument{}, fmt.Errorf("config: decode rules from %s: %s must be a map", source, key)
+		}
+		*target = m
+	}
+	return doc, nil
+}
+
+// decodeDefinition decodes one resolved endpoint or rule into its typed
+// configuration using the same koanf tags as the server config.
+func decodeDefinition(raw any, out any) error {
+	k := koanf.New(".")
+	if err := k.Load(confmap.Provider(map[string]any{"definition": raw}, ""), nil); err != nil {
+		return err
+	}
+	return k.Unmarshal("definition", out)
+}
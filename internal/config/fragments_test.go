@@ -0,0 +1,151 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeRuleFiles(t *testing.T, files map[string]string) RulesConfig {
+	t.Helper()
+	dir := t.TempDir()
+	for name, contents := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o600))
+	}
+	return RulesConfig{RulesFolder: dir}
+}
+
+func TestBuildRuleBundleResolvesFragmentsAcrossFiles(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"fragments.yaml": `
+fragments:
+  user-service:
+    url: https://users.internal/check
+    method: GET
+    headers:
+      X-Service: passctrl
+  bearer-auth:
+    - match:
+        - type: bearer
+`,
+		"rules.yaml": `
+rules:
+  user-check:
+    auth:
+      - $ref: bearer-auth
+      - match:
+          - type: header
+            name: X-Api-Key
+    backendApi:
+      $ref: user-service
+      method: POST
+      headers:
+        X-Tenant: acme
+  status-check:
+    backendApi:
+      $ref: user-service
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Empty(t, bundle.Skipped)
+
+	rule := bundle.Rules["user-check"]
+	require.Len(t, rule.Auth, 2)
+	require.Equal(t, "bearer", rule.Auth[0].Match[0].Type)
+	require.Equal(t, "header", rule.Auth[1].Match[0].Type)
+	require.Equal(t, "https://users.internal/check", rule.BackendAPI.URL)
+	require.Equal(t, "POST", rule.BackendAPI.Method, "sibling keys override the fragment")
+	require.Equal(t, "passctrl", *rule.BackendAPI.Headers["X-Service"])
+	require.Equal(t, "acme", *rule.BackendAPI.Headers["X-Tenant"])
+
+	status := bundle.Rules["status-check"]
+	require.Equal(t, "GET", status.BackendAPI.Method)
+	require.Len(t, status.BackendAPI.Headers, 1, "overrides in one rule do not leak into another")
+}
+
+func TestBuildRuleBundleQuarantinesBrokenFragmentReferences(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"a.yaml": `
+fragments:
+  loop-a:
+    headers:
+      $ref: loop-b
+  twice:
+    method: GET
+rules:
+  cyclic:
+    backendApi:
+      $ref: loop-a
+  unknown:
+    backendApi:
+      $ref: missing
+  ambiguous:
+    backendApi:
+      $ref: twice
+  healthy:
+    description: unaffected
+`,
+		"b.yaml": `
+fragments:
+  loop-b:
+    $ref: loop-a
+  twice:
+    method: POST
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "unaffected", bundle.Rules["healthy"].Description)
+
+	reasons := make(map[string]string)
+	for _, skip := range bundle.Skipped {
+		reasons[skip.Name] = skip.Reason
+	}
+	require.Len(t, reasons, 3)
+	require.Contains(t, reasons["cyclic"], "fragment cycle: loop-a -> loop-b -> loop-a")
+	require.Contains(t, reasons["unknown"], `backendApi: unknown fragment "missing"`)
+	require.Contains(t, reasons["ambiguous"], `fragment "twice" is defined more than once`)
+}
+
+func TestBuildRuleBundleReportsFragmentProvenance(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"shared.yaml": `
+fragments:
+  admin-only:
+    pass:
+      - request.method ==
+`,
+		"rules.yaml": `
+rules:
+  admin:
+    conditions:
+      $ref: admin-only
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Len(t, bundle.Skipped, 1)
+	reason := bundle.Skipped[0].Reason
+	require.Contains(t, reason, "conditions.pass[0]:")
+	require.Contains(t, reason, `(from fragment "admin-only" in `+filepath.Join(rulesCfg.RulesFolder, "shared.yaml")+")")
+}
+
+func TestFieldOriginsDescribe(t *testing.T) {
+	origins := fieldOrigins{
+		"backendApi":         {fragment: "svc", source: "shared.yaml"},
+		"backendApi.headers": {},
+		"auth[0]":            {fragment: "auth", source: "auth.yaml"},
+	}
+
+	require.Equal(t, `fragment "svc" in shared.yaml`, origins.describe("backendApi.url"))
+	require.Empty(t, origins.describe("backendApi.headers.X-Tenant"), "local overrides are not attributed to the fragment")
+	require.Equal(t, `fragment "auth" in auth.yaml`, origins.describe("auth[0].match[0].credentialStore"))
+	require.Empty(t, origins.describe("auth[1]"))
+}
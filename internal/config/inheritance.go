@@ -0,0 +1,275 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	endpointExtendsKey    = "extends"
+	endpointDefaultsLabel = "endpointDefaults"
+)
+
+// EndpointInheritance describes how the loader resolved an endpoint declared
+// in a rules document: the parents it extends, the merged definition, and the
+// layer that supplied each field. Inline endpoints have none.
+type EndpointInheritance struct {
+	// Chain lists the endpoint and its ancestors, nearest first.
+	Chain []string
+	// Resolved is the merged definition keyed like the rules document.
+	Resolved map[string]any
+	// Origins maps field paths (for example `responsePolicy.fail.status`) to
+	// the layer that set them: `endpointDefaults (<source>)` or
+	// `endpoints.<name> (<source>)`.
+	Origins map[string]string
+}
+
+type rawEndpoint struct {
+	value  map[string]any
+	source string
+}
+
+type endpointDefaults struct {
+	value  map[string]any
+	source string
+}
+
+type resolvedEndpoint struct {
+	value   map[string]any
+	origins map[string]string
+	chain   []string
+	err     error
+}
+
+// collectEndpointDefaults accepts a single endpointDefaults block across all
+// documents; merging several would make the precedence depend on file order.
+func collectEndpointDefaults(docs []rawRuleDocument, fragments fragmentSet) (*endpointDefaults, error) {
+	var found *endpointDefaults
+	var sources []string
+	for _, doc := range docs {
+		if doc.endpointDefaults == nil {
+			continue
+		}
+		sources = append(sources, doc.source)
+		resolved, _, err := fragments.resolve(doc.endpointDefaults)
+		if err != nil {
+			return nil, fmt.Errorf("config: endpointDefaults in %s: %w", doc.source, err)
+		}
+		value, _ := resolved.(map[string]any)
+		if _, ok := value[endpointExtendsKey]; ok {
+			return nil, fmt.Errorf("config: endpointDefaults in %s cannot declare %s", doc.source, endpointExtendsKey)
+		}
+		found = &endpointDefaults{value: value, source: doc.source}
+	}
+	if len(sources) > 1 {
+		sort.Strings(sources)
+		return nil, fmt.Errorf("config: endpointDefaults declared in more than one source (%s)", strings.Join(sources, ", "))
+	}
+	return found, nil
+}
+
+// resolveEndpointInheritance merges endpointDefaults and every extends chain
+// into the endpoints staged from rules documents, then decodes them. Cycles,
+// missing or unavailable parents, and decode failures quarantine the endpoint.
+func (a *ruleAggregator) resolveEndpointInheritance(defaults *endpointDefaults) {
+	resolved := make(map[string]*resolvedEndpoint, len(a.rawEndpoints))
+	names := make([]string, 0, len(a.rawEndpoints))
+	for name := range a.rawEndpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := a.endpoints[name]; !ok {
+			continue
+		}
+		result := a.resolveEndpoint(name, defaults, resolved, nil)
+		source := a.endpointSources[name]
+		if result.err != nil {
+			a.recordEndpointSkip(name, result.err.Error(), source)
+			delete(a.endpointSources, name)
+			delete(a.endpoints, name)
+			continue
+		}
+		var cfg EndpointConfig
+		if err := decodeDefinition(result.value, &cfg); err != nil {
+			a.recordEndpointSkip(name, fmt.Sprintf("invalid endpoint definition: %v", err), source)
+			delete(a.endpointSources, name)
+			delete(a.endpoints, name)
+			continue
+		}
+		cfg.Extends = extendsTarget(a.rawEndpoints[name].value)
+		cfg.Inheritance = &EndpointInheritance{
+			Chain:    append([]string{}, result.chain...),
+			Resolved: result.value,
+			Origins:  result.origins,
+		}
+		a.endpoints[name] = cfg
+	}
+}
+
+// inheritanceCycleError names the endpoints that extend each other in a loop.
+type inheritanceCycleError struct {
+	cycle []string
+}
+
+func (e *inheritanceCycleError) Error() string {
+	return "inheritance cycle: " + strings.Join(e.cycle, " -> ")
+}
+
+func (e *inheritanceCycleError) includes(name string) bool {
+	for _, member := range e.cycle {
+		if member == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ruleAggregator) resolveEndpoint(name string, defaults *endpointDefaults, memo map[string]*resolvedEndpoint, stack []string) *resolvedEndpoint {
+	if done, ok := memo[name]; ok {
+		return done
+	}
+	raw := a.rawEndpoints[name]
+	result := &resolvedEndpoint{value: map[string]any{}, origins: map[string]string{}}
+	if parent := extendsTarget(raw.value); parent == "" {
+		if defaults != nil {
+			mergeEndpointLayer(result, defaults.value, fmt.Sprintf("%s (%s)", endpointDefaultsLabel, defaults.source))
+		}
+	} else {
+		inherited := a.resolveParent(name, parent, defaults, memo, append(stack, name))
+		if inherited.err != nil {
+			memo[name] = inherited
+			return inherited
+		}
+		result.value = deepCopyMap(inherited.value)
+		result.origins = cloneOrigins(inherited.origins)
+		result.chain = inherited.chain
+	}
+	result.chain = append([]string{name}, result.chain...)
+	mergeEndpointLayer(result, raw.value, fmt.Sprintf("endpoints.%s (%s)", name, raw.source))
+	memo[name] = result
+	return result
+}
+
+func (a *ruleAggregator) resolveParent(name, parent string, defaults *endpointDefaults, memo map[string]*resolvedEndpoint, stack []string) *resolvedEndpoint {
+	for i, seen := range stack {
+		if seen == parent {
+			cycle := append(append([]string{}, stack[i:]...), parent)
+			return &resolvedEndpoint{err: &inheritanceCycleError{cycle: cycle}}
+		}
+	}
+	if _, staged := a.rawEndpoints[parent]; !staged {
+		if _, inline := a.endpoints[parent]; inline {
+			return &resolvedEndpoint{err: fmt.Errorf("parent endpoint %q is defined inline and cannot be extended", parent)}
+		}
+		return &resolvedEndpoint{err: fmt.Errorf("missing parent endpoint %q", parent)}
+	}
+	if _, ok := a.endpoints[parent]; !ok {
+		return &resolvedEndpoint{err: fmt.Errorf("parent endpoint %q is unavailable", parent)}
+	}
+	inherited := a.resolveEndpoint(parent, defaults, memo, stack)
+	if inherited.err != nil {
+		if cycle, ok := inherited.err.(*inheritanceCycleError); ok && cycle.includes(name) {
+			return inherited
+		}
+		return &resolvedEndpoint{err: fmt.Errorf("parent endpoint %q is unavailable", parent)}
+	}
+	return inherited
+}
+
+func extendsTarget(raw map[string]any) string {
+	parent, _ := raw[endpointExtendsKey].(string)
+	return strings.TrimSpace(parent)
+}
+
+// mergeEndpointLayer overlays one layer on the resolved endpoint. Maps merge
+// key by key, other values replace what earlier layers set, and an explicit
+// null is kept so header maps still copy the value from the request. Header
+// names are matched case-insensitively because the runtime lowercases them.
+func mergeEndpointLayer(target *resolvedEndpoint, layer map[string]any, label string) {
+	for key, value := range layer {
+		if key == endpointExtendsKey {
+			continue
+		}
+		mergeEndpointValue(target.value, key, value, key, target.origins, label)
+	}
+}
+
+func mergeEndpointValue(dst map[string]any, key string, value any, path string, origins map[string]string, label string) {
+	if isHeaderMapPath(parentFieldPath(path)) {
+		for existing := range dst {
+			if existing != key && strings.EqualFold(existing, key) {
+				delete(dst, existing)
+				clearOrigins(origins, parentFieldPath(path)+"."+existing)
+			}
+		}
+	}
+	overlay, overlayIsMap := value.(map[string]any)
+	current, currentIsMap := dst[key].(map[string]any)
+	if overlayIsMap && len(overlay) > 0 {
+		if !currentIsMap {
+			clearOrigins(origins, path)
+			current = make(map[string]any, len(overlay))
+			dst[key] = current
+		}
+		for childKey, child := range overlay {
+			mergeEndpointValue(current, childKey, child, path+"."+childKey, origins, label)
+		}
+		return
+	}
+	clearOrigins(origins, path)
+	dst[key] = deepCopyValue(value)
+	origins[path] = label
+}
+
+func isHeaderMapPath(path string) bool {
+	return path == "headers" || strings.HasSuffix(path, ".headers")
+}
+
+func parentFieldPath(path string) string {
+	if idx := strings.LastIndex(path, "."); idx >= 0 {
+		return path[:idx]
+	}
+	return ""
+}
+
+func clearOrigins(origins map[string]string, path string) {
+	for existing := range origins {
+		if existing == path || strings.HasPrefix(existing, path+".") {
+			delete(origins, existing)
+		}
+	}
+}
+
+func cloneOrigins(in map[string]string) map[string]string {
+	out := make(map[string]string, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+func deepCopyMap(in map[string]any) map[string]any {
+	out := make(map[string]any, len(in))
+	for k, v := range in {
+		out[k] = deepCopyValue(v)
+	}
+	return out
+}
+
+func deepCopyValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return deepCopyMap(v)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = deepCopyValue(item)
+		}
+		return out
+	default:
+		return value
+	}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRuleBundleResolvesEndpointInheritance(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"defaults.yaml": `
+endpointDefaults:
+  authentication:
+    allow:
+      authorization: [bearer]
+  forwardProxyPolicy:
+    trustedProxyIPs: [10.0.0.0/8]
+  responsePolicy:
+    fail:
+      status: 403
+      headers:
+        X-Denied-By: passctrl
+        X-Request-Id: null
+`,
+		"endpoints.yaml": `
+endpoints:
+  base:
+    responsePolicy:
+      fail:
+        body: denied
+    rules:
+      - name: allow
+  reports:
+    extends: base
+    responsePolicy:
+      fail:
+        headers:
+          x-denied-by: reports
+    rules:
+      - name: allow
+      - name: audit
+rules:
+  allow:
+    conditions:
+      pass: ["true"]
+  audit:
+    description: audit trail
+`,
+	})
+	base := filepath.Join(rulesCfg.RulesFolder, "endpoints.yaml")
+	defaults := filepath.Join(rulesCfg.RulesFolder, "defaults.yaml")
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Empty(t, bundle.Skipped)
+
+	reports := bundle.Endpoints["reports"]
+	require.Equal(t, "base", reports.Extends)
+	require.Equal(t, []string{"bearer"}, reports.Authentication.Allow.Authorization)
+	require.Equal(t, []string{"10.0.0.0/8"}, reports.ForwardProxyPolicy.TrustedProxyIPs)
+	require.Equal(t, 403, reports.ResponsePolicy.Fail.Status)
+	require.Equal(t, "denied", reports.ResponsePolicy.Fail.Body)
+	require.Len(t, reports.Rules, 2, "lists replace the inherited value")
+
+	headers := reports.ResponsePolicy.Fail.Headers
+	require.Len(t, headers, 2, "header names merge case-insensitively")
+	require.Equal(t, "reports", *headers["x-denied-by"])
+	require.Contains(t, headers, "X-Request-Id")
+	require.Nil(t, headers["X-Request-Id"], "null-copy entries survive the merge")
+
+	require.NotNil(t, reports.Inheritance)
+	require.Equal(t, []string{"reports", "base"}, reports.Inheritance.Chain)
+	origins := reports.Inheritance.Origins
+	require.Equal(t, "endpointDefaults ("+defaults+")", origins["responsePolicy.fail.status"])
+	require.Equal(t, "endpoints.base ("+base+")", origins["responsePolicy.fail.body"])
+	require.Equal(t, "endpoints.reports ("+base+")", origins["responsePolicy.fail.headers.x-denied-by"])
+	require.NotContains(t, origins, "responsePolicy.fail.headers.X-Denied-By")
+
+	require.Len(t, bundle.Endpoints["base"].Rules, 1)
+	require.Len(t, bundle.Endpoints["base"].ResponsePolicy.Fail.Headers, 2, "children do not leak into their parent")
+}
+
+func TestBuildRuleBundleQuarantinesBrokenInheritance(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"endpoints.yaml": `
+endpoints:
+  loop-a:
+    extends: loop-b
+  loop-b:
+    extends: loop-a
+  orphan:
+    extends: nowhere
+  grandchild:
+    extends: loop-a
+  from-inline:
+    extends: inline-parent
+  healthy:
+    description: ok
+`,
+	})
+	inline := map[string]EndpointConfig{"inline-parent": {Description: "inline"}}
+
+	bundle, err := buildRuleBundle(context.Background(), inline, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Endpoints, "healthy")
+	require.Contains(t, bundle.Endpoints, "inline-parent")
+
+	reasons := make(map[string]string)
+	for _, skip := range bundle.Skipped {
+		reasons[skip.Name] = skip.Reason
+	}
+	require.Equal(t, "inheritance cycle: loop-a -> loop-b -> loop-a", reasons["loop-a"])
+	require.Contains(t, reasons["loop-b"], "inheritance cycle:")
+	require.Equal(t, `missing parent endpoint "nowhere"`, reasons["orphan"])
+	require.Equal(t, `parent endpoint "loop-a" is unavailable`, reasons["grandchild"])
+	require.Equal(t, `parent endpoint "inline-parent" is defined inline and cannot be extended`, reasons["from-inline"])
+}
+
+func TestBuildRuleBundleRejectsRepeatedEndpointDefaults(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"a.yaml": "endpointDefaults:\n  description: a\n",
+		"b.yaml": "endpointDefaults:\n  description: b\n",
+	})
+
+	_, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.ErrorContains(t, err, "endpointDefaults declared in more than one source")
+}
@@ -61,12 +61,25 @@ func (l *Loader) Load(ctx context.Context) (Config, error) {
 		canonical := map[string]string{
 			"server.rules.rulesfolder":             "server.rules.rulesFolder",
 			"server.rules.rulesfile":               "server.rules.rulesFile",
+			"server.rules.reload.allowdegraded":    "server.rules.reload.allowDegraded",
+			"server.rules.reload.historysize":      "server.rules.reload.historySize",
+			"server.rules.remote.pollinterval":     "server.rules.remote.pollInterval",
+			"server.rules.remote.cachefile":        "server.rules.remote.cacheFile",
+			"server.rules.remote.publickeyfile":    "server.rules.remote.publicKeyFile",
+			"server.rules.remote.signatureurl":     "server.rules.remote.signatureURL",
 			"server.templates.templatesfolder":     "server.templates.templatesFolder",
 			"server.templates.templatesallowenv":   "server.templates.templatesAllowEnv",
 			"server.templates.templatesallowedenv": "server.templates.templatesAllowedEnv",
 			"server.cache.ttlseconds":              "server.cache.ttlSeconds",
 			"server.cache.keysalt":                 "server.cache.keySalt",
 			"server.cache.redis.tls.cafile":        "server.cache.redis.tls.caFile",
+			"server.admin.combinedlistener":        "server.admin.combinedListener",
+			"server.admin.auth.bearertoken":        "server.admin.auth.bearerToken",
+		}
+		for _, prefix := range []string{"server.listen.tls.", "server.admin.listen.tls."} {
+			for _, field := range []string{"certFile", "keyFile", "minVersion", "cipherSuites", "clientCAFile", "clientAuth"} {
+				canonical[strings.ToLower(prefix+field)] = prefix + field
+			}
 		}
 		transform := func(s string) string {
 			// Double underscores signal a nested path (SERVER__LISTEN__PORT -> server.listen.port).
@@ -90,6 +103,12 @@ func (l *Loader) Load(ctx context.Context) (Config, error) {
 	if err := k.Unmarshal("", &cfg); err != nil {
 		return Config{}, fmt.Errorf("config: unmarshal: %w", err)
 	}
+	// The default admin port yields to a public listener already bound to it,
+	// so deployments serving /auth on 9090 keep starting with the admin
+	// listener off. A port configured for both listeners fails validation.
+	if !k.Exists("server.admin.listen.port") && cfg.Server.Listen.Port != defaultCfg.Server.Admin.Listen.Port {
+		cfg.Server.Admin.Listen.Port = defaultCfg.Server.Admin.Listen.Port
+	}
 
 	// Load environment variables using null-copy semantics
 	loadedEnv, err := loadEnvironmentVariables(cfg.Server.Variables.Environment)
@@ -111,7 +130,11 @@ func (l *Loader) Load(ctx context.Context) (Config, error) {
 	cfg.InlineEndpoints = cloneEndpointMap(cfg.Endpoints)
 	cfg.InlineRules = cloneRuleMap(cfg.Rules)
 
-	bundle, err := buildRuleBundle(ctx, cfg.InlineEndpoints, cfg.InlineRules, cfg.Server.Rules)
+	remote, err := openRemoteRuleSource(ctx, cfg.Server.Rules.Remote)
+	if err != nil {
+		return Config{}, err
+	}
+	bundle, err := buildRuleBundle(ctx, cfg.InlineEndpoints, cfg.InlineRules, cfg.Server, remote)
 	if err != nil {
 		return Config{}, err
 	}
@@ -130,6 +153,11 @@ func structToMap(cfg Config) map[string]any {
 				"address": cfg.Server.Listen.Address,
 				"port":    cfg.Server.Listen.Port,
 			},
+			"admin": map[string]any{
+				"listen": map[string]any{
+					"address": cfg.Server.Admin.Listen.Address,
+				},
+			},
 			"logging": map[string]any{
 				"level":             cfg.Server.Logging.Level,
 				"format":            cfg.Server.Logging.Format,
@@ -138,6 +166,10 @@ func structToMap(cfg Config) map[string]any {
 			"rules": map[string]any{
 				"rulesFolder": cfg.Server.Rules.RulesFolder,
 				"rulesFile":   cfg.Server.Rules.RulesFile,
+				"reload": map[string]any{
+					"allowDegraded": cfg.Server.Rules.Reload.AllowDegraded,
+					"historySize":   cfg.Server.Rules.Reload.HistorySize,
+				},
 			},
 			"templates": map[string]any{
 				"templatesFolder": cfg.Server.Templates.TemplatesFolder,
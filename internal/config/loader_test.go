@@ -27,6 +27,8 @@ func TestLoader(t *testing.T) {
 			},
 			assert: func(t *testing.T, cfg Config) {
 				require.Equal(t, 8080, cfg.Server.Listen.Port)
+				require.Equal(t, "127.0.0.1", cfg.Server.Admin.Listen.Address)
+				require.Equal(t, 9090, cfg.Server.Admin.Listen.Port)
 			},
 		},
 		{
@@ -56,6 +58,48 @@ func TestLoader(t *testing.T) {
 				require.Equal(t, 9091, cfg.Server.Listen.Port)
 			},
 		},
+		{
+			name: "default admin port yields to the public listener",
+			setup: func(t *testing.T) []string {
+				t.Setenv("PASSCTRL_SERVER__RULES__RULESFOLDER", t.TempDir())
+				t.Setenv("PASSCTRL_SERVER__LISTEN__PORT", "9090")
+				return nil
+			},
+			assert: func(t *testing.T, cfg Config) {
+				require.Equal(t, 9090, cfg.Server.Listen.Port)
+				require.Zero(t, cfg.Server.Admin.Listen.Port)
+				require.False(t, cfg.Server.Admin.CombinedListener)
+			},
+		},
+		{
+			name: "rejects an admin port configured on the public port",
+			setup: func(t *testing.T) []string {
+				t.Setenv("PASSCTRL_SERVER__RULES__RULESFOLDER", t.TempDir())
+				t.Setenv("PASSCTRL_SERVER__LISTEN__PORT", "9090")
+				t.Setenv("PASSCTRL_SERVER__ADMIN__LISTEN__PORT", "9090")
+				return nil
+			},
+			wantErr: true,
+		},
+		{
+			name: "reads admin listener overrides",
+			setup: func(t *testing.T) []string {
+				dir := t.TempDir()
+				path := filepath.Join(dir, "server.yaml")
+				contents := "server:\n  admin:\n    combinedListener: true\n    listen:\n      port: 9100\n"
+				require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+				t.Setenv("PASSCTRL_SERVER__RULES__RULESFOLDER", t.TempDir())
+				t.Setenv("PASSCTRL_SERVER__ADMIN__LISTEN__ADDRESS", "0.0.0.0")
+				t.Setenv("PASSCTRL_SERVER__ADMIN__AUTH__BEARERTOKEN", "ops-token")
+				return []string{path}
+			},
+			assert: func(t *testing.T, cfg Config) {
+				require.Equal(t, "0.0.0.0", cfg.Server.Admin.Listen.Address)
+				require.Equal(t, 9100, cfg.Server.Admin.Listen.Port)
+				require.True(t, cfg.Server.Admin.CombinedListener)
+				require.Equal(t, "ops-token", cfg.Server.Admin.Auth.BearerToken)
+			},
+		},
 		{
 			name: "reads template block",
 			setup: func(t *testing.T) []string {
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// validateEndpointMatch checks that every host glob, path pattern, method, and
+// header predicate compiles so the runtime never has to guess at intent.
+func validateEndpointMatch(match EndpointMatchConfig) error {
+	for idx, host := range match.Hosts {
+		field := fmt.Sprintf("match.hosts[%d]", idx)
+		host = strings.TrimSpace(host)
+		if host == "" {
+			return &fieldError{path: field, err: fmt.Errorf("host pattern required")}
+		}
+		if _, err := path.Match(strings.ToLower(host), ""); err != nil {
+			return &fieldError{path: field, err: fmt.Errorf("invalid host pattern %q: %w", host, err)}
+		}
+	}
+	for idx, prefix := range match.PathPrefixes {
+		if !strings.HasPrefix(strings.TrimSpace(prefix), "/") {
+			return &fieldError{path: fmt.Sprintf("match.pathPrefixes[%d]", idx), err: fmt.Errorf("path prefix %q must start with /", prefix)}
+		}
+	}
+	for idx, pattern := range match.PathRegex {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return &fieldError{path: fmt.Sprintf("match.pathRegex[%d]", idx), err: err}
+		}
+	}
+	for idx, method := range match.Methods {
+		method = strings.TrimSpace(method)
+		if method == "" || strings.ContainsAny(method, " \t/") {
+			return &fieldError{path: fmt.Sprintf("match.methods[%d]", idx), err: fmt.Errorf("invalid method %q", method)}
+		}
+	}
+	for idx, header := range match.Headers {
+		field := fmt.Sprintf("match.headers[%d]", idx)
+		name := strings.TrimSpace(header.Name)
+		if name == "" {
+			return &fieldError{path: field + ".name", err: fmt.Errorf("header name required")}
+		}
+		if header.Absent && (len(header.Values) > 0 || header.Regex != "") {
+			return &fieldError{path: field, err: fmt.Errorf("absent cannot be combined with values or regex")}
+		}
+		if header.Regex != "" {
+			if _, err := regexp.Compile(header.Regex); err != nil {
+				return &fieldError{path: field + ".regex", err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// validateEndpointMatches quarantines endpoints whose match block is invalid.
+func (a *ruleAggregator) validateEndpointMatches() {
+	for name, cfg := range a.endpoints {
+		if err := validateEndpointMatch(cfg.Match); err != nil {
+			a.recordEndpointSkip(name, fmt.Sprintf("invalid match: %v", err), a.endpointSources[name])
+			delete(a.endpointSources, name)
+			delete(a.endpoints, name)
+		}
+	}
+}
+
+// validateFallbackEndpoint rejects bundles where several endpoints claim to be
+// the fallback; picking one would depend on which files happened to load.
+func validateFallbackEndpoint(endpoints map[string]EndpointConfig) error {
+	var fallbacks []string
+	for name, cfg := range endpoints {
+		if cfg.Match.Fallback {
+			fallbacks = append(fallbacks, name)
+		}
+	}
+	if len(fallbacks) > 1 {
+		sort.Strings(fallbacks)
+		return fmt.Errorf("config: match.fallback declared by more than one endpoint (%s)", strings.Join(fallbacks, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRuleBundleQuarantinesInvalidMatch(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"endpoints.yaml": `
+endpoints:
+  api:
+    match:
+      hosts: ["*.example.com"]
+      pathPrefixes: [/v1/]
+      methods: [GET]
+      headers:
+        - name: X-Role
+          regex: ^admin$
+  bad-regex:
+    match:
+      pathRegex: ["^/(unclosed"]
+  bad-prefix:
+    match:
+      pathPrefixes: [v1]
+  bad-header:
+    match:
+      headers:
+        - name: X-Debug
+          absent: true
+          values: ["1"]
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Endpoints, "api")
+	require.Equal(t, []string{"*.example.com"}, bundle.Endpoints["api"].Match.Hosts)
+
+	reasons := make(map[string]string)
+	for _, skip := range bundle.Skipped {
+		reasons[skip.Name] = skip.Reason
+	}
+	require.Len(t, reasons, 3)
+	require.Contains(t, reasons["bad-regex"], "invalid match: match.pathRegex[0]:")
+	require.Contains(t, reasons["bad-prefix"], `path prefix "v1" must start with /`)
+	require.Contains(t, reasons["bad-header"], "match.headers[0]: absent cannot be combined with values or regex")
+}
+
+func TestBuildRuleBundleRejectsMultipleFallbackEndpoints(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"a.yaml": `
+endpoints:
+  first:
+    match:
+      fallback: true
+`,
+		"b.yaml": `
+endpoints:
+  second:
+    match:
+      fallback: true
+`,
+	})
+
+	_, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.EqualError(t, err, "config: match.fallback declared by more than one endpoint (first, second)")
+}
@@ -0,0 +1,159 @@
+package config
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule parameter types accepted in RuleParamConfig.Type.
+const (
+	ParamTypeString = "string"
+	ParamTypeInt    = "int"
+	ParamTypeFloat  = "float"
+	ParamTypeBool   = "bool"
+	ParamTypeList   = "list"
+	ParamTypeMap    = "map"
+)
+
+// paramNamePattern keeps parameter names addressable as params.<name> in CEL
+// and templates.
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// validateRuleParams checks each declaration's name, type, and default.
+func validateRuleParams(params map[string]RuleParamConfig) error {
+	for _, name := range sortedParamNames(params) {
+		decl := params[name]
+		path := "params." + name
+		if !paramNamePattern.MatchString(name) {
+			return &fieldError{path: path, err: fmt.Errorf("parameter names must be identifiers")}
+		}
+		kind := normalizeParamType(decl.Type)
+		if !knownParamType(kind) {
+			return &fieldError{path: path + ".type", err: fmt.Errorf("unsupported parameter type %q", decl.Type)}
+		}
+		if decl.Default != nil {
+			if _, err := coerceParam(kind, decl.Default); err != nil {
+				return &fieldError{path: path + ".default", err: err}
+			}
+		}
+	}
+	return nil
+}
+
+// ResolveRuleParams merges the values an endpoint passes with the rule's
+// defaults and converts them to their declared types. Integers resolve to
+// int64 and floats to float64 so CEL sees consistent types.
+func ResolveRuleParams(rule RuleConfig, with map[string]any) (map[string]any, error) {
+	for name := range with {
+		if _, ok := rule.Params[name]; !ok {
+			return nil, fmt.Errorf("unknown parameter %q", name)
+		}
+	}
+	if len(rule.Params) == 0 {
+		return nil, nil
+	}
+	resolved := make(map[string]any, len(rule.Params))
+	for _, name := range sortedParamNames(rule.Params) {
+		decl := rule.Params[name]
+		value, supplied := with[name]
+		if !supplied || value == nil {
+			value = decl.Default
+		}
+		if value == nil {
+			return nil, fmt.Errorf("parameter %q requires a value", name)
+		}
+		coerced, err := coerceParam(normalizeParamType(decl.Type), value)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", name, err)
+		}
+		resolved[name] = coerced
+	}
+	return resolved, nil
+}
+
+func normalizeParamType(kind string) string {
+	return strings.ToLower(strings.TrimSpace(kind))
+}
+
+func knownParamType(kind string) bool {
+	switch kind {
+	case ParamTypeString, ParamTypeInt, ParamTypeFloat, ParamTypeBool, ParamTypeList, ParamTypeMap:
+		return true
+	default:
+		return false
+	}
+}
+
+func coerceParam(kind string, value any) (any, error) {
+	switch kind {
+	case ParamTypeString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case ParamTypeBool:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case ParamTypeInt:
+		if v, ok := paramNumber(value); ok && v == math.Trunc(v) {
+			return int64(v), nil
+		}
+	case ParamTypeFloat:
+		if v, ok := paramNumber(value); ok {
+			return v, nil
+		}
+	case ParamTypeList:
+		switch v := value.(type) {
+		case []any:
+			return append([]any{}, v...), nil
+		case []string:
+			out := make([]any, len(v))
+			for i, item := range v {
+				out[i] = item
+			}
+			return out, nil
+		}
+	case ParamTypeMap:
+		if v, ok := value.(map[string]any); ok {
+			out := make(map[string]any, len(v))
+			for key, item := range v {
+				out[key] = item
+			}
+			return out, nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported parameter type %q", kind)
+	}
+	return nil, fmt.Errorf("expected %s, got %T", kind, value)
+}
+
+func paramNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+func sortedParamNames(params map[string]RuleParamConfig) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveRuleParams(t *testing.T) {
+	rule := RuleConfig{Params: map[string]RuleParamConfig{
+		"group":   {Type: "string"},
+		"limit":   {Type: "int", Default: 10},
+		"ratio":   {Type: "float", Default: 1},
+		"strict":  {Type: "bool", Default: false},
+		"tenants": {Type: "list", Default: []any{"acme"}},
+	}}
+
+	tests := []struct {
+		name string
+		with map[string]any
+		want map[string]any
+		err  string
+	}{
+		{
+			name: "defaults fill omitted values",
+			with: map[string]any{"group": "admins"},
+			want: map[string]any{"group": "admins", "limit": int64(10), "ratio": float64(1), "strict": false, "tenants": []any{"acme"}},
+		},
+		{
+			name: "numbers from json documents",
+			with: map[string]any{"group": "ops", "limit": float64(25), "strict": true},
+			want: map[string]any{"group": "ops", "limit": int64(25), "ratio": float64(1), "strict": true, "tenants": []any{"acme"}},
+		},
+		{name: "missing required value", with: nil, err: `parameter "group" requires a value`},
+		{name: "unknown parameter", with: map[string]any{"group": "a", "team": "b"}, err: `unknown parameter "team"`},
+		{name: "wrong type", with: map[string]any{"group": 7}, err: `parameter "group": expected string, got int`},
+		{name: "fractional int", with: map[string]any{"group": "a", "limit": 2.5}, err: `parameter "limit": expected int, got float64`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ResolveRuleParams(rule, tc.with)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, got)
+		})
+	}
+
+	got, err := ResolveRuleParams(RuleConfig{}, nil)
+	require.NoError(t, err)
+	require.Nil(t, got, "rules without parameters resolve to nothing")
+}
+
+func TestBuildRuleBundleValidatesRuleParams(t *testing.T) {
+	endpoints := map[string]EndpointConfig{
+		"admins": {Rules: []EndpointRuleReference{{Name: "member-of", With: map[string]any{"group": "admins"}}}},
+		"typo":   {Rules: []EndpointRuleReference{{Name: "member-of", With: map[string]any{"grop": "admins"}}}},
+		"broken": {Rules: []EndpointRuleReference{{Name: "bad-default"}}},
+	}
+	rules := map[string]RuleConfig{
+		"member-of": {
+			Params:     map[string]RuleParamConfig{"group": {Type: "string"}},
+			Conditions: RuleConditionConfig{Pass: []string{`params.group in auth.input.groups`}},
+		},
+		"bad-default": {Params: map[string]RuleParamConfig{"limit": {Type: "int", Default: "ten"}}},
+	}
+
+	bundle, err := buildRuleBundle(context.Background(), endpoints, rules, ServerConfig{}, nil)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Endpoints, "admins")
+	require.Contains(t, bundle.Rules, "member-of")
+
+	reasons := make(map[string]string)
+	for _, skip := range bundle.Skipped {
+		reasons[skip.Kind+"/"+skip.Name] = skip.Reason
+	}
+	require.Equal(t, `rules[0] (member-of): unknown parameter "grop"`, reasons["endpoint/typo"])
+	require.Equal(t, "invalid rule parameters: params.limit.default: expected int, got string", reasons["rule/bad-default"])
+	require.Equal(t, "missing rule dependencies: bad-default", reasons["endpoint/broken"])
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/l0p7/passctrl/internal/filewatch"
+)
+
+// WatchConfig reloads the configuration files whenever they change and hands
+// every snapshot that passes full validation to onChange. Load or validation
+// failures go to onError so callers keep serving the last good configuration.
+func (l *Loader) WatchConfig(ctx context.Context, onChange func(Config), onError func(error)) (*filewatch.Watcher, error) {
+	if onChange == nil {
+		return nil, fmt.Errorf("config: watch config requires a change callback")
+	}
+	var files []string
+	for _, path := range l.files {
+		if path != "" {
+			files = append(files, path)
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("config: no configuration file to watch")
+	}
+
+	return filewatch.Watch(ctx, files, func() {
+		cfg, err := l.Load(ctx)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) && onError != nil {
+				onError(err)
+			}
+			return
+		}
+		onChange(cfg)
+	}, onError)
+}
+
+// RestartRequired lists the settings that differ between the running and the
+// reloaded configuration but only take effect when the process restarts:
+// listener sockets, the health scheduler, the log format, credential stores
+// and datasets, whose files are watched separately once loaded, and database
+// connections.
+func RestartRequired(running, next Config) []string {
+	var fields []string
+	compare := func(name string, a, b any) {
+		if !reflect.DeepEqual(a, b) {
+			fields = append(fields, name)
+		}
+	}
+	compare("server.listen", running.Server.Listen, next.Server.Listen)
+	compare("server.admin", running.Server.Admin, next.Server.Admin)
+	compare("server.health", running.Server.Health, next.Server.Health)
+	compare("server.logging.format", running.Server.Logging.Format, next.Server.Logging.Format)
+	compare("server.credentialStores", running.Server.CredentialStores, next.Server.CredentialStores)
+	compare("server.apiKeyStores", running.Server.APIKeyStores, next.Server.APIKeyStores)
+	compare("server.datasets", running.Server.Datasets, next.Server.Datasets)
+	compare("server.databases", running.Server.Databases, next.Server.Databases)
+	return fields
+}
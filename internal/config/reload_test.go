@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchConfigDeliversValidatedSnapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	serverCfg := filepath.Join(dir, "server.yaml")
+	contents := "server:\n  logging:\n    level: %s\n  cache:\n    ttlSeconds: %d\n  rules:\n    rulesFolder: %s\n"
+	write := func(level string, ttl int) {
+		require.NoError(t, os.WriteFile(serverCfg, []byte(fmt.Sprintf(contents, level, ttl, dir)), 0o600))
+	}
+	write("info", 30)
+
+	loader := NewLoader("PASSCTRL_WATCHCONFIG", serverCfg)
+	changeCh := make(chan Config, 4)
+	errCh := make(chan error, 4)
+	watcher, err := loader.WatchConfig(ctx, func(cfg Config) {
+		changeCh <- cfg
+	}, func(err error) {
+		errCh <- err
+	})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	write("debug", 30)
+	select {
+	case cfg := <-changeCh:
+		require.Equal(t, "debug", cfg.Server.Logging.Level)
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for config reload")
+	}
+
+	write("debug", -1)
+	select {
+	case cfg := <-changeCh:
+		require.FailNowf(t, "invalid snapshot delivered", "ttlSeconds=%d", cfg.Server.Cache.TTLSeconds)
+	case err := <-errCh:
+		require.ErrorContains(t, err, "server.cache.ttlSeconds invalid")
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for validation error")
+	}
+}
+
+func TestWatchConfigRequiresFile(t *testing.T) {
+	_, err := NewLoader("PASSCTRL").WatchConfig(context.Background(), func(Config) {}, nil)
+	require.ErrorContains(t, err, "no configuration file")
+}
+
+func TestRestartRequired(t *testing.T) {
+	running := DefaultConfig()
+
+	next := DefaultConfig()
+	next.Server.Logging.Level = "debug"
+	next.Server.Cache.TTLSeconds = 60
+	next.Server.Variables.Environment = map[string]*string{"TZ": nil}
+	require.Empty(t, RestartRequired(running, next), "live settings must not require a restart")
+
+	next.Server.Listen.Port = 9000
+	next.Server.Logging.Format = "text"
+	next.Server.APIKeyStores = map[string]APIKeyStoreConfig{"partners": {File: "keys.txt"}}
+	next.Server.Datasets = map[string]DatasetConfig{"tenants": {File: "tenants.csv", Key: "id"}}
+	next.Server.Databases = map[string]DatabaseConfig{"authz": {Driver: "sqlite", DSN: "authz.db"}}
+	require.Equal(t, []string{"server.listen", "server.logging.format", "server.apiKeyStores", "server.datasets", "server.databases"}, RestartRequired(running, next))
+}
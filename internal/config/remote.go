@@ -0,0 +1,386 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRemotePollInterval = time.Minute
+	defaultRemoteTimeout      = 10 * time.Second
+	maxRemoteRulesBytes       = 16 << 20
+	maxRemoteSignatureBytes   = 64 << 10
+)
+
+// remoteRuleSource fetches the remote rules document, verifies its detached
+// signature, and keeps the last good copy in memory and in the optional cache
+// file. Conditional requests reuse the ETag of the copy it holds.
+type remoteRuleSource struct {
+	cfg       RulesRemoteConfig
+	client    *http.Client
+	publicKey crypto.PublicKey
+	format    string
+	label     string
+
+	mu     sync.Mutex
+	etag   string
+	body   []byte
+	cached bool
+}
+
+// openRemoteRuleSource prepares the remote source and loads its first copy.
+// When the remote cannot be reached or fails verification, the cache file
+// keeps startup possible. It returns nil when no remote is configured.
+func openRemoteRuleSource(ctx context.Context, cfg RulesRemoteConfig) (*remoteRuleSource, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+	src, err := newRemoteRuleSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if _, fetchErr := src.refresh(ctx); fetchErr != nil {
+		if src.hasDocument() {
+			// The fetch succeeded; only the cache file could not be written.
+			return src, nil
+		}
+		if errors.Is(fetchErr, context.Canceled) {
+			return nil, fetchErr
+		}
+		if cacheErr := src.loadCache(); cacheErr != nil {
+			return nil, fmt.Errorf("config: remote rules %s: %w (cache: %v)", src.label, fetchErr, cacheErr)
+		}
+	}
+	return src, nil
+}
+
+func newRemoteRuleSource(cfg RulesRemoteConfig) (*remoteRuleSource, error) {
+	target, err := url.Parse(strings.TrimSpace(cfg.URL))
+	if err != nil {
+		return nil, fmt.Errorf("config: server.rules.remote.url invalid: %w", err)
+	}
+	format := strings.TrimSpace(cfg.Format)
+	if format == "" {
+		format = "yaml"
+		if ext := strings.TrimPrefix(filepath.Ext(target.Path), "."); ext != "" && isSupportedRulesFile(target.Path) {
+			format = ext
+		}
+	}
+
+	timeout := defaultRemoteTimeout
+	if raw := strings.TrimSpace(cfg.Timeout); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			timeout = parsed
+		}
+	}
+
+	src := &remoteRuleSource{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+		format: format,
+		label:  remoteSourceLabel(target),
+	}
+	if keyFile := strings.TrimSpace(cfg.PublicKeyFile); keyFile != "" {
+		key, err := loadVerificationKey(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: server.rules.remote.publicKeyFile: %w", err)
+		}
+		src.publicKey = key
+	}
+	return src, nil
+}
+
+// remoteSourceLabel identifies the remote in RuleSources without leaking
+// credentials carried in the user info or query string.
+func remoteSourceLabel(target *url.URL) string {
+	redacted := url.URL{Scheme: target.Scheme, Host: target.Host, Path: target.Path}
+	return redacted.String()
+}
+
+// pollInterval returns the configured polling period or the default.
+func (s *remoteRuleSource) pollInterval() time.Duration {
+	if raw := strings.TrimSpace(s.cfg.PollInterval); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultRemotePollInterval
+}
+
+func (s *remoteRuleSource) hasDocument() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.body != nil
+}
+
+// refresh fetches the remote document and reports whether the copy in use
+// changed. A document that fails signature verification or does not parse is
+// reported as a BundleLoadError and leaves the current copy in place. When
+// only the cache file write fails, changed is still true.
+func (s *remoteRuleSource) refresh(ctx context.Context) (bool, error) {
+	s.mu.Lock()
+	etag := s.etag
+	s.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, http.NoBody)
+	if err != nil {
+		return false, fmt.Errorf("config: remote rules request: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("config: fetch remote rules %s: %w", s.label, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && etag != "":
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("config: fetch remote rules %s: unexpected status %d", s.label, resp.StatusCode)
+	}
+	body, err := readLimited(resp.Body, maxRemoteRulesBytes)
+	if err != nil {
+		return false, fmt.Errorf("config: read remote rules %s: %w", s.label, err)
+	}
+
+	s.mu.Lock()
+	unchanged := s.body != nil && !s.cached && bytes.Equal(s.body, body)
+	if unchanged {
+		s.etag = resp.Header.Get("ETag")
+	}
+	s.mu.Unlock()
+	if unchanged {
+		return false, nil
+	}
+
+	var signature []byte
+	if s.publicKey != nil {
+		if signature, err = s.verify(ctx, body); err != nil {
+			return false, &BundleLoadError{Err: fmt.Errorf("config: remote rules %s: %w", s.label, err)}
+		}
+	}
+	if _, err := parseRuleDocument(body, s.format, s.label); err != nil {
+		return false, &BundleLoadError{Err: fmt.Errorf("config: remote rules %s: %w", s.label, err)}
+	}
+
+	s.mu.Lock()
+	s.body = body
+	s.etag = resp.Header.Get("ETag")
+	s.cached = false
+	s.mu.Unlock()
+
+	if err := s.writeCache(body, signature); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// verify checks the detached base64 signature published next to the document
+// and returns the decoded signature.
+func (s *remoteRuleSource) verify(ctx context.Context, body []byte) ([]byte, error) {
+	sigURL := strings.TrimSpace(s.cfg.SignatureURL)
+	if sigURL == "" {
+		target, err := url.Parse(s.cfg.URL)
+		if err != nil {
+			return nil, err
+		}
+		target.Path += ".sig"
+		sigURL = target.String()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sigURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("signature request: %w", err)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch signature: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch signature: unexpected status %d", resp.StatusCode)
+	}
+	encoded, err := readLimited(resp.Body, maxRemoteSignatureBytes)
+	if err != nil {
+		return nil, fmt.Errorf("read signature: %w", err)
+	}
+	signature, err := decodeSignature(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyDetachedSignature(s.publicKey, body, signature); err != nil {
+		return nil, err
+	}
+	return signature, nil
+}
+
+func decodeSignature(encoded []byte) ([]byte, error) {
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	return signature, nil
+}
+
+// cacheSignatureFile holds the detached signature of the cached document so
+// offline startups verify the cache like a fresh download.
+func cacheSignatureFile(cacheFile string) string { return cacheFile + ".sig" }
+
+// writeCache stores the document and, when signatures are verified, its
+// detached signature. A crash between the two writes leaves a pair that
+// fails verification rather than an unsigned document that passes.
+func (s *remoteRuleSource) writeCache(body, signature []byte) error {
+	path := strings.TrimSpace(s.cfg.CacheFile)
+	if path == "" {
+		return nil
+	}
+	if err := writeFileAtomic(path, body); err != nil {
+		return fmt.Errorf("config: write remote rules cache: %w", err)
+	}
+	if s.publicKey == nil {
+		return nil
+	}
+	encoded := []byte(base64.StdEncoding.EncodeToString(signature) + "\n")
+	if err := writeFileAtomic(cacheSignatureFile(path), encoded); err != nil {
+		return fmt.Errorf("config: write remote rules cache signature: %w", err)
+	}
+	return nil
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".passctrl-remote-rules-*")
+	if err != nil {
+		return fmt.Errorf("config: write remote rules cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// loadCache falls back to the last verified document written to the cache
+// file. With a publicKeyFile the cached copy must still match the signature
+// stored next to it, so a writable cache file cannot inject unsigned rules.
+func (s *remoteRuleSource) loadCache() error {
+	path := strings.TrimSpace(s.cfg.CacheFile)
+	if path == "" {
+		return errors.New("no cache file configured")
+	}
+	body, err := os.ReadFile(path) // #nosec G304 -- operator-configured cache location
+	if err != nil {
+		return err
+	}
+	if s.publicKey != nil {
+		encoded, err := os.ReadFile(cacheSignatureFile(path)) // #nosec G304 -- derived from the cache location
+		if err != nil {
+			return fmt.Errorf("cache signature: %w", err)
+		}
+		signature, err := decodeSignature(encoded)
+		if err != nil {
+			return fmt.Errorf("cache signature: %w", err)
+		}
+		if err := verifyDetachedSignature(s.publicKey, body, signature); err != nil {
+			return fmt.Errorf("cache signature: %w", err)
+		}
+	}
+	if _, err := parseRuleDocument(body, s.format, path); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+	s.etag = ""
+	s.cached = true
+	return nil
+}
+
+// document parses the copy in use. Its source label notes when it was read
+// from the cache file rather than the remote.
+func (s *remoteRuleSource) document() (rawRuleDocument, error) {
+	s.mu.Lock()
+	body, cached := s.body, s.cached
+	s.mu.Unlock()
+	label := s.label
+	if cached {
+		label += " (cached)"
+	}
+	return parseRuleDocument(body, s.format, label)
+}
+
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("response exceeds %d bytes", limit)
+	}
+	return body, nil
+}
+
+// loadVerificationKey reads a PEM-encoded PKIX public key, the format cosign
+// and openssl emit.
+func loadVerificationKey(path string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-configured key location
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("expected a PEM \"PUBLIC KEY\" block")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (expected ed25519 or ECDSA)", key)
+	}
+}
+
+// verifyDetachedSignature checks an ed25519 signature over the payload, or an
+// ASN.1 ECDSA signature over its SHA-256 digest as produced by cosign
+// sign-blob.
+func verifyDetachedSignature(key crypto.PublicKey, payload, signature []byte) error {
+	switch pub := key.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, payload, signature) {
+			return errors.New("signature verification failed")
+		}
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(payload)
+		if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+			return errors.New("signature verification failed")
+		}
+	default:
+		return fmt.Errorf("unsupported key type %T", key)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package config
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// remoteRulesServer publishes a rules document with an ETag derived from its
+// revision and an optional detached signature at <path>.sig.
+type remoteRulesServer struct {
+	mu           sync.Mutex
+	body         string
+	revision     int
+	signature    string
+	notModified  atomic.Int64
+	fullResponse atomic.Int64
+}
+
+func (s *remoteRulesServer) publish(body, signature string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+	s.signature = signature
+	s.revision++
+}
+
+func (s *remoteRulesServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	body, signature, etag := s.body, s.signature, fmt.Sprintf(`"rev-%d"`, s.revision)
+	s.mu.Unlock()
+
+	if filepath.Ext(r.URL.Path) == ".sig" {
+		_, _ = w.Write([]byte(signature))
+		return
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		s.notModified.Add(1)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.fullResponse.Add(1)
+	w.Header().Set("ETag", etag)
+	_, _ = w.Write([]byte(body))
+}
+
+func remoteRulesDocument(description string) string {
+	return "endpoints:\n  remote-endpoint:\n    authentication:\n      allow:\n        authorization: [bearer]\n    rules:\n      - name: remote-rule\nrules:\n  remote-rule:\n    description: " + description + "\n"
+}
+
+func writeRemoteTestConfig(t *testing.T, dir string, remote string) string {
+	t.Helper()
+	path := filepath.Join(dir, "server.yaml")
+	contents := "server:\n  rules:\n    rulesFolder: \"\"\n    remote:\n" + remote
+	require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+	return path
+}
+
+func TestLoaderMergesRemoteRulesAndCachesThem(t *testing.T) {
+	published := &remoteRulesServer{}
+	published.publish(remoteRulesDocument("v1"), "")
+	srv := httptest.NewServer(published)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cacheFile := filepath.Join(dir, "remote-cache.yaml")
+	remote := fmt.Sprintf("      url: %s/rules.yaml\n      cacheFile: %s\n", srv.URL, cacheFile)
+	cfg, err := NewLoader("PASSCTRL", writeRemoteTestConfig(t, dir, remote)).Load(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, cfg.Endpoints, "remote-endpoint")
+	require.Equal(t, "v1", cfg.Rules["remote-rule"].Description)
+	require.Equal(t, []string{srv.URL + "/rules.yaml"}, cfg.RuleSources)
+
+	cached, err := os.ReadFile(cacheFile)
+	require.NoError(t, err)
+	require.Equal(t, remoteRulesDocument("v1"), string(cached))
+
+	// Offline startup falls back to the last good copy.
+	srv.Close()
+	cfg, err = NewLoader("PASSCTRL", filepath.Join(dir, "server.yaml")).Load(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, cfg.Endpoints, "remote-endpoint")
+	require.Equal(t, []string{srv.URL + "/rules.yaml (cached)"}, cfg.RuleSources)
+}
+
+func TestLoaderFailsWithoutRemoteOrCache(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	dir := t.TempDir()
+	remote := fmt.Sprintf("      url: %s/rules.yaml\n", srv.URL)
+	_, err := NewLoader("PASSCTRL", writeRemoteTestConfig(t, dir, remote)).Load(context.Background())
+	require.ErrorContains(t, err, "unexpected status 404")
+}
+
+func TestLoaderVerifiesRemoteSignatures(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "rules.pub")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+
+	document := remoteRulesDocument("signed")
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(document)))
+	published := &remoteRulesServer{}
+	srv := httptest.NewServer(published)
+	defer srv.Close()
+	remote := fmt.Sprintf("      url: %s/rules.yaml\n      publicKeyFile: %s\n", srv.URL, keyFile)
+	configPath := writeRemoteTestConfig(t, dir, remote)
+
+	published.publish(document, signature)
+	cfg, err := NewLoader("PASSCTRL", configPath).Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "signed", cfg.Rules["remote-rule"].Description)
+
+	published.publish(remoteRulesDocument("tampered"), signature)
+	_, err = NewLoader("PASSCTRL", configPath).Load(context.Background())
+	require.ErrorContains(t, err, "signature verification failed")
+}
+
+func TestLoaderVerifiesCachedRemoteRules(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(public)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "rules.pub")
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0o600))
+	cacheFile := filepath.Join(dir, "remote-cache.yaml")
+
+	document := remoteRulesDocument("signed")
+	published := &remoteRulesServer{}
+	published.publish(document, base64.StdEncoding.EncodeToString(ed25519.Sign(private, []byte(document))))
+	srv := httptest.NewServer(published)
+	remote := fmt.Sprintf("      url: %s/rules.yaml\n      publicKeyFile: %s\n      cacheFile: %s\n", srv.URL, keyFile, cacheFile)
+	configPath := writeRemoteTestConfig(t, dir, remote)
+
+	_, err = NewLoader("PASSCTRL", configPath).Load(context.Background())
+	require.NoError(t, err)
+	require.FileExists(t, cacheFile+".sig")
+
+	// Offline startup accepts the cache only while it matches its signature.
+	srv.Close()
+	cfg, err := NewLoader("PASSCTRL", configPath).Load(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "signed", cfg.Rules["remote-rule"].Description)
+
+	require.NoError(t, os.WriteFile(cacheFile, []byte(remoteRulesDocument("tampered")), 0o600))
+	_, err = NewLoader("PASSCTRL", configPath).Load(context.Background())
+	require.ErrorContains(t, err, "cache signature: signature verification failed")
+
+	require.NoError(t, os.Remove(cacheFile+".sig"))
+	_, err = NewLoader("PASSCTRL", configPath).Load(context.Background())
+	require.ErrorContains(t, err, "cache signature")
+}
+
+func TestWatchRulesPollsRemoteWithETag(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	published := &remoteRulesServer{}
+	published.publish(remoteRulesDocument("v1"), "")
+	srv := httptest.NewServer(published)
+	defer srv.Close()
+
+	dir := t.TempDir()
+	remote := fmt.Sprintf("      url: %s/rules.yaml\n      pollInterval: 20ms\n", srv.URL)
+	loader := NewLoader("PASSCTRL", writeRemoteTestConfig(t, dir, remote))
+	cfg, err := loader.Load(ctx)
+	require.NoError(t, err)
+
+	changes := make(chan RuleBundle, 4)
+	watcher, err := loader.WatchRules(ctx, cfg, func(bundle RuleBundle) {
+		changes <- bundle
+	}, func(err error) {
+		t.Errorf("unexpected watcher error: %v", err)
+	})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	initial := <-changes
+	require.Equal(t, "v1", initial.Rules["remote-rule"].Description)
+	require.Eventually(t, func() bool { return published.notModified.Load() > 0 }, 2*time.Second, 10*time.Millisecond,
+		"unchanged documents are answered with 304")
+
+	published.publish(remoteRulesDocument("v2"), "")
+	select {
+	case bundle := <-changes:
+		require.Equal(t, "v2", bundle.Rules["remote-rule"].Description)
+		require.Equal(t, []string{srv.URL + "/rules.yaml"}, bundle.Sources)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for remote change")
+	}
+}
+
+func TestValidateRulesRemote(t *testing.T) {
+	tests := []struct {
+		name   string
+		remote RulesRemoteConfig
+		want   string
+	}{
+		{name: "disabled", remote: RulesRemoteConfig{}},
+		{name: "https", remote: RulesRemoteConfig{URL: "https://policy.example.com/rules.yaml", PollInterval: "5m"}},
+		{name: "oci reference", remote: RulesRemoteConfig{URL: "oci://registry.example.com/policy:1"}, want: "OCI references are not supported"},
+		{name: "unsupported scheme", remote: RulesRemoteConfig{URL: "ftp://example.com/rules.yaml"}, want: "must use http or https"},
+		{name: "bad interval", remote: RulesRemoteConfig{URL: "https://example.com/rules", PollInterval: "soon"}, want: "pollInterval invalid"},
+		{name: "bad format", remote: RulesRemoteConfig{URL: "https://example.com/rules", Format: "ini"}, want: "format unsupported"},
+		{name: "signature without key", remote: RulesRemoteConfig{URL: "https://example.com/rules", SignatureURL: "https://example.com/rules.sig"}, want: "requires publicKeyFile"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateRulesRemote(tc.remote)
+			if tc.want == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.want)
+		})
+	}
+}
@@ -17,7 +17,10 @@ import (
 	"github.com/l0p7/passctrl/internal/expr"
 )
 
-const inlineSourceName = "inline-config"
+const (
+	inlineSourceName = "inline-config"
+	pushedSourceName = "admin-api"
+)
 
 // RuleBundle captures the merged endpoint/rule definitions after loading every
 // configured source. Runtime agents can use the metadata to explain what was
@@ -42,8 +45,14 @@ type ruleAggregator struct {
 	rules       map[string]RuleConfig
 	ruleSources map[string]string
 	ruleSkips   map[string]*DefinitionSkip
+	ruleOrigins map[string]fieldOrigins
+
+	// rawEndpoints holds endpoints from rules documents until extends and
+	// endpointDefaults are resolved.
+	rawEndpoints map[string]rawEndpoint
 
-	sources map[string]struct{}
+	fragments fragmentSet
+	sources   map[string]struct{}
 }
 
 func newRuleAggregator() *ruleAggregator {
@@ -54,6 +63,9 @@ func newRuleAggregator() *ruleAggregator {
 		rules:           make(map[string]RuleConfig),
 		ruleSources:     make(map[string]string),
 		ruleSkips:       make(map[string]*DefinitionSkip),
+		ruleOrigins:     make(map[string]fieldOrigins),
+		rawEndpoints:    make(map[string]rawEndpoint),
+		fragments:       make(fragmentSet),
 		sources:         make(map[string]struct{}),
 	}
 }
@@ -70,11 +82,84 @@ func (a *ruleAggregator) addDocument(doc ruleDocument, source string) {
 	}
 }
 
+// addRawDocuments merges sources that may share fragments and endpoint
+// parents. Fragments from every document are collected first so references
+// resolve across files; endpoints are decoded once every parent is known.
+func (a *ruleAggregator) addRawDocuments(docs ...rawRuleDocument) error {
+	for _, doc := range docs {
+		for name, value := range doc.fragments {
+			a.fragments.add(name, value, doc.source)
+		}
+	}
+	defaults, err := collectEndpointDefaults(docs, a.fragments)
+	if err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		a.addRawDocument(doc)
+	}
+	a.resolveEndpointInheritance(defaults)
+	return nil
+}
+
+// addRawDocument resolves fragment references in each definition. Rules are
+// decoded right away; endpoints are staged for resolveEndpointInheritance.
+// Definitions that fail are quarantined on their own.
+func (a *ruleAggregator) addRawDocument(doc rawRuleDocument) {
+	if doc.source != "" {
+		a.sources[doc.source] = struct{}{}
+	}
+	for name, raw := range doc.endpoints {
+		resolved, _, err := a.fragments.resolve(raw)
+		value, isMap := resolved.(map[string]any)
+		if err == nil && !isMap && resolved != nil {
+			err = fmt.Errorf("expected a map, got %T", resolved)
+		}
+		if err != nil {
+			a.recordEndpointSkip(name, fmt.Sprintf("invalid endpoint definition: %v", err), doc.source)
+			continue
+		}
+		a.addEndpoint(name, EndpointConfig{}, doc.source)
+		if _, ok := a.endpoints[name]; ok {
+			a.rawEndpoints[name] = rawEndpoint{value: value, source: doc.source}
+		}
+	}
+	for name, raw := range doc.rules {
+		resolved, origins, err := a.fragments.resolve(raw)
+		var cfg RuleConfig
+		if err == nil {
+			err = decodeDefinition(resolved, &cfg)
+		}
+		if err != nil {
+			a.recordRuleSkip(name, fmt.Sprintf("invalid rule definition: %v", err), doc.source)
+			continue
+		}
+		a.addRule(name, cfg, doc.source)
+		if _, ok := a.rules[name]; ok && len(origins) > 0 {
+			a.ruleOrigins[name] = origins
+		}
+	}
+}
+
+// validateRuleParams quarantines rules whose parameter declarations are
+// malformed so endpoints never resolve values against them.
+func (a *ruleAggregator) validateRuleParams() {
+	for name, cfg := range a.rules {
+		if err := validateRuleParams(cfg.Params); err != nil {
+			source := a.ruleSources[name]
+			reason := fmt.Sprintf("invalid rule parameters: %s", withProvenance(err, a.ruleOrigins[name]))
+			a.recordRuleSkip(name, reason, source)
+			delete(a.ruleSources, name)
+			delete(a.rules, name)
+		}
+	}
+}
+
 func (a *ruleAggregator) validateRuleExpressions(env *expr.Environment) {
 	for name, cfg := range a.rules {
 		if err := validateRuleExpressions(cfg, env); err != nil {
 			source := a.ruleSources[name]
-			reason := fmt.Sprintf("invalid rule expressions: %v", err)
+			reason := fmt.Sprintf("invalid rule expressions: %s", withProvenance(err, a.ruleOrigins[name]))
 			a.recordRuleSkip(name, reason, source)
 			delete(a.ruleSources, name)
 			delete(a.rules, name)
@@ -82,6 +167,19 @@ func (a *ruleAggregator) validateRuleExpressions(env *expr.Environment) {
 	}
 }
 
+// validateRuleReferences quarantines rules that point at server-scoped
+// resources (such as credential stores) which are not configured.
+func (a *ruleAggregator) validateRuleReferences(server ServerConfig) {
+	for name, cfg := range a.rules {
+		if err := validateRuleServerReferences(cfg, server); err != nil {
+			source := a.ruleSources[name]
+			a.recordRuleSkip(name, withProvenance(err, a.ruleOrigins[name]), source)
+			delete(a.ruleSources, name)
+			delete(a.rules, name)
+		}
+	}
+}
+
 func (a *ruleAggregator) addEndpoint(name string, cfg EndpointConfig, source string) {
 	if existing, ok := a.endpointSkips[name]; ok {
 		existing.Sources = appendUnique(existing.Sources, source)
@@ -163,16 +261,21 @@ func (a *ruleAggregator) recordRuleSkip(name, reason string, sources ...string)
 func (a *ruleAggregator) pruneInvalidEndpoints() {
 	for name, cfg := range a.endpoints {
 		missingSet := make(map[string]struct{})
-		for _, ref := range cfg.Rules {
+		walkRuleReferences(cfg.Rules, "rules", func(_ string, ref EndpointRuleReference) {
 			if ref.Name == "" {
-				continue
+				return
 			}
 			if _, ok := a.rules[ref.Name]; ok {
-				continue
+				return
 			}
 			missingSet[ref.Name] = struct{}{}
-		}
+		})
 		if len(missingSet) == 0 {
+			if err := a.validateEndpointParams(cfg); err != nil {
+				a.recordEndpointSkip(name, err.Error(), a.endpointSources[name])
+				delete(a.endpointSources, name)
+				delete(a.endpoints, name)
+			}
 			continue
 		}
 		missing := make([]string, 0, len(missingSet))
@@ -188,6 +291,21 @@ func (a *ruleAggregator) pruneInvalidEndpoints() {
 	}
 }
 
+// validateEndpointParams checks the `with` values of each rule reference
+// against the parameters the rule declares.
+func (a *ruleAggregator) validateEndpointParams(cfg EndpointConfig) error {
+	var firstErr error
+	walkRuleReferences(cfg.Rules, "rules", func(path string, ref EndpointRuleReference) {
+		if firstErr != nil || ref.Name == "" {
+			return
+		}
+		if _, err := ResolveRuleParams(a.rules[ref.Name], ref.With); err != nil {
+			firstErr = fmt.Errorf("%s (%s): %w", path, ref.Name, err)
+		}
+	})
+	return firstErr
+}
+
 func (a *ruleAggregator) bundle() RuleBundle {
 	a.pruneInvalidEndpoints()
 	endpoints := make(map[string]EndpointConfig, len(a.endpoints))
@@ -235,7 +353,11 @@ func appendUnique(list []string, value string) []string {
 	return append(list, value)
 }
 
-func buildRuleBundle(ctx context.Context, inlineEndpoints map[string]EndpointConfig, inlineRules map[string]RuleConfig, rulesCfg RulesConfig) (RuleBundle, error) {
+// buildRuleBundle merges inline definitions, the configured rules file or
+// folder, and the copy of the remote document held by remote (nil when no
+// remote is configured).
+func buildRuleBundle(ctx context.Context, inlineEndpoints map[string]EndpointConfig, inlineRules map[string]RuleConfig, server ServerConfig, remote *remoteRuleSource) (RuleBundle, error) {
+	rulesCfg := server.Rules
 	agg := newRuleAggregator()
 	if len(inlineEndpoints) > 0 || len(inlineRules) > 0 {
 		agg.addDocument(ruleDocument{Endpoints: inlineEndpoints, Rules: inlineRules}, inlineSourceName)
@@ -245,6 +367,7 @@ func buildRuleBundle(ctx context.Context, inlineEndpoints map[string]EndpointCon
 	if err != nil {
 		return RuleBundle{}, err
 	}
+	docs := make([]rawRuleDocument, 0, len(files)+1)
 	for _, path := range files {
 		select {
 		case <-ctx.Done():
@@ -255,14 +378,101 @@ func buildRuleBundle(ctx context.Context, inlineEndpoints map[string]EndpointCon
 		if err != nil {
 			return RuleBundle{}, err
 		}
-		agg.addDocument(doc, path)
+		docs = append(docs, doc)
+	}
+	if remote != nil {
+		doc, err := remote.document()
+		if err != nil {
+			return RuleBundle{}, err
+		}
+		docs = append(docs, doc)
+	}
+	if err := agg.addRawDocuments(docs...); err != nil {
+		return RuleBundle{}, err
+	}
+	return finalizeRuleBundle(agg, server)
+}
+
+// LoadRuleBundle rebuilds the bundle from the inline definitions and rules
+// sources of cfg, as the rules watcher does after a change on disk. A
+// configured remote is fetched again.
+func LoadRuleBundle(ctx context.Context, cfg Config) (RuleBundle, error) {
+	remote, err := openRemoteRuleSource(ctx, cfg.Server.Rules.Remote)
+	if err != nil {
+		return RuleBundle{}, err
+	}
+	return buildRuleBundle(ctx, cloneEndpointMap(cfg.InlineEndpoints), cloneRuleMap(cfg.InlineRules), cfg.Server, remote)
+}
+
+// ParseRuleBundle decodes a rules document pushed through the admin API and
+// validates it with the same pipeline as rules files. The document stands in
+// for the configured rules source; inline definitions from cfg still apply.
+// format names the encoding the way a rules file extension would ("yaml",
+// "json", or "toml").
+func ParseRuleBundle(ctx context.Context, cfg Config, data []byte, format string) (RuleBundle, error) {
+	doc, err := parseRuleDocument(data, format, pushedSourceName)
+	if err != nil {
+		return RuleBundle{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+		return RuleBundle{}, ctx.Err()
+	default:
 	}
+	agg := newRuleAggregator()
+	if len(cfg.InlineEndpoints) > 0 || len(cfg.InlineRules) > 0 {
+		agg.addDocument(ruleDocument{Endpoints: cloneEndpointMap(cfg.InlineEndpoints), Rules: cloneRuleMap(cfg.InlineRules)}, inlineSourceName)
+	}
+	if err := agg.addRawDocuments(doc); err != nil {
+		return RuleBundle{}, err
+	}
+	return finalizeRuleBundle(agg, cfg.Server)
+}
+
+// finalizeRuleBundle quarantines definitions that fail validation and returns
+// the merged bundle.
+func finalizeRuleBundle(agg *ruleAggregator, server ServerConfig) (RuleBundle, error) {
 	env, err := expr.NewEnvironment()
 	if err != nil {
 		return RuleBundle{}, err
 	}
+	agg.validateRuleParams()
+	agg.validateTimeouts()
 	agg.validateRuleExpressions(env)
-	return agg.bundle(), nil
+	agg.validateRuleReferences(server)
+	agg.validateEndpointMatches()
+	agg.validateEndpointChains(env)
+	bundle := agg.bundle()
+	if err := validateFallbackEndpoint(bundle.Endpoints); err != nil {
+		return RuleBundle{}, err
+	}
+	return bundle, nil
+}
+
+func validateRuleServerReferences(cfg RuleConfig, server ServerConfig) error {
+	for i, directive := range cfg.Auth {
+		for j, matcher := range directive.Match {
+			if store := strings.TrimSpace(matcher.CredentialStore); store != "" {
+				if _, ok := server.CredentialStores[store]; !ok {
+					return &fieldError{path: fmt.Sprintf("auth[%d].match[%d].credentialStore", i, j), err: fmt.Errorf("unknown credential store %q", store)}
+				}
+			}
+			if store := strings.TrimSpace(matcher.KeyStore); store != "" {
+				if _, ok := server.APIKeyStores[store]; !ok {
+					return &fieldError{path: fmt.Sprintf("auth[%d].match[%d].keyStore", i, j), err: fmt.Errorf("unknown api key store %q", store)}
+				}
+			}
+		}
+	}
+	if strings.EqualFold(strings.TrimSpace(cfg.BackendAPI.Type), "sql") {
+		if database := strings.TrimSpace(cfg.BackendAPI.SQL.Database); database != "" {
+			if _, ok := server.Databases[database]; !ok {
+				return &fieldError{path: "backendApi.sql.database", err: fmt.Errorf("unknown database %q", database)}
+			}
+		}
+	}
+	return nil
 }
 
 func validateRuleExpressions(cfg RuleConfig, env *expr.Environment) error {
@@ -289,7 +499,7 @@ func validateConditionList(env *expr.Environment, name string, expressions []str
 			continue
 		}
 		if _, err := env.Compile(trimmed); err != nil {
-			return fmt.Errorf("conditions.%s[%d]: %w", name, idx, err)
+			return &fieldError{path: fmt.Sprintf("conditions.%s[%d]", name, idx), err: err}
 		}
 	}
 	return nil
@@ -354,26 +564,31 @@ func ensureFileExists(path string) error {
 	return nil
 }
 
-func loadRuleDocument(path string) (ruleDocument, error) {
+func loadRuleDocument(path string) (rawRuleDocument, error) {
 	parser, err := parserFor(path)
 	if err != nil {
-		return ruleDocument{}, err
+		return rawRuleDocument{}, err
 	}
 	k := koanf.New(".")
 	if err := k.Load(file.Provider(path), parser); err != nil {
-		return ruleDocument{}, fmt.Errorf("config: load rules from %s: %w", path, err)
+		return rawRuleDocument{}, fmt.Errorf("config: load rules from %s: %w", path, err)
 	}
-	var doc ruleDocument
-	if err := k.Unmarshal("", &doc); err != nil {
-		return ruleDocument{}, fmt.Errorf("config: decode rules from %s: %w", path, err)
-	}
-	if doc.Endpoints == nil {
-		doc.Endpoints = make(map[string]EndpointConfig)
+	return rawRuleDocumentFrom(k.Raw(), path)
+}
+
+// parseRuleDocument reads a rules document that did not come from a file, such
+// as a pushed or remote bundle. format names the encoding the way a rules file
+// extension would.
+func parseRuleDocument(data []byte, format, source string) (rawRuleDocument, error) {
+	parser, err := parserFor("bundle." + strings.TrimPrefix(strings.TrimSpace(format), "."))
+	if err != nil {
+		return rawRuleDocument{}, err
 	}
-	if doc.Rules == nil {
-		doc.Rules = make(map[string]RuleConfig)
+	raw, err := parser.Unmarshal(data)
+	if err != nil {
+		return rawRuleDocument{}, fmt.Errorf("config: parse rules from %s: %w", source, err)
 	}
-	return doc, nil
+	return rawRuleDocumentFrom(raw, source)
 }
 
 func parserFor(path string) (koanf.Parser, error) {
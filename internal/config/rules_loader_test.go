@@ -133,8 +133,167 @@ func TestBuildRuleBundle(t *testing.T) {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
 			endpoints, rules, cfg := tc.setup(t)
-			bundle, err := buildRuleBundle(ctx, endpoints, rules, cfg)
+			bundle, err := buildRuleBundle(ctx, endpoints, rules, ServerConfig{Rules: cfg}, nil)
 			tc.assert(t, bundle, err)
 		})
 	}
 }
+
+func TestBuildRuleBundleSkipsUnknownCredentialStores(t *testing.T) {
+	endpoints := map[string]EndpointConfig{
+		"protected": {Rules: []EndpointRuleReference{{Name: "store-rule"}}},
+	}
+	rules := map[string]RuleConfig{
+		"store-rule": {
+			Auth: []RuleAuthDirective{{
+				Match: []RuleAuthMatcher{{Type: "basic", CredentialStore: "missing"}},
+			}},
+		},
+		"known-rule": {
+			Auth: []RuleAuthDirective{{
+				Match: []RuleAuthMatcher{{Type: "basic", CredentialStore: "team"}},
+			}},
+		},
+	}
+	server := ServerConfig{
+		CredentialStores: map[string]CredentialStoreConfig{
+			"team": {HtpasswdFile: "users.htpasswd"},
+		},
+	}
+
+	bundle, err := buildRuleBundle(context.Background(), endpoints, rules, server, nil)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Rules, "known-rule")
+	require.NotContains(t, bundle.Rules, "store-rule")
+	require.Empty(t, bundle.Endpoints)
+	require.Len(t, bundle.Skipped, 2)
+	require.Equal(t, "endpoint", bundle.Skipped[0].Kind)
+	require.Equal(t, "rule", bundle.Skipped[1].Kind)
+	require.Equal(t, `auth[0].match[0].credentialStore: unknown credential store "missing"`, bundle.Skipped[1].Reason)
+}
+
+func TestBuildRuleBundleSkipsUnknownAPIKeyStores(t *testing.T) {
+	rules := map[string]RuleConfig{
+		"key-rule": {
+			Auth: []RuleAuthDirective{{
+				Match: []RuleAuthMatcher{{Type: "apiKey", Name: "X-Api-Key", KeyStore: "missing"}},
+			}},
+		},
+	}
+
+	bundle, err := buildRuleBundle(context.Background(), nil, rules, ServerConfig{}, nil)
+	require.NoError(t, err)
+	require.Empty(t, bundle.Rules)
+	require.Len(t, bundle.Skipped, 1)
+	require.Equal(t, `auth[0].match[0].keyStore: unknown api key store "missing"`, bundle.Skipped[0].Reason)
+}
+
+func TestBuildRuleBundleSkipsUnknownDatabases(t *testing.T) {
+	rules := map[string]RuleConfig{
+		"grants": {
+			BackendAPI: RuleBackendConfig{
+				Type: "sql",
+				SQL:  RuleSQLConfig{Database: "missing", Query: "SELECT 1"},
+			},
+		},
+	}
+
+	bundle, err := buildRuleBundle(context.Background(), nil, rules, ServerConfig{}, nil)
+	require.NoError(t, err)
+	require.Empty(t, bundle.Rules)
+	require.Len(t, bundle.Skipped, 1)
+	require.Equal(t, `backendApi.sql.database: unknown database "missing"`, bundle.Skipped[0].Reason)
+}
+
+func TestParseRuleBundle(t *testing.T) {
+	cfg := Config{InlineRules: map[string]RuleConfig{"inline-rule": {Description: "inline"}}}
+
+	tests := []struct {
+		name   string
+		format string
+		body   string
+		assert func(t *testing.T, bundle RuleBundle, err error)
+	}{
+		{
+			name:   "yaml merges with inline definitions",
+			format: "yaml",
+			body:   "endpoints:\n  pushed:\n    rules:\n      - name: pushed-rule\nrules:\n  pushed-rule:\n    conditions:\n      pass: [\"true\"]\n",
+			assert: func(t *testing.T, bundle RuleBundle, err error) {
+				require.NoError(t, err)
+				require.Contains(t, bundle.Endpoints, "pushed")
+				require.Contains(t, bundle.Rules, "pushed-rule")
+				require.Contains(t, bundle.Rules, "inline-rule")
+				require.Equal(t, []string{pushedSourceName, inlineSourceName}, bundle.Sources)
+			},
+		},
+		{
+			name:   "json quarantines invalid expressions",
+			format: "json",
+			body:   `{"endpoints":{"pushed":{"rules":[{"name":"broken"}]}},"rules":{"broken":{"conditions":{"pass":["request.method =="]}}}}`,
+			assert: func(t *testing.T, bundle RuleBundle, err error) {
+				require.NoError(t, err)
+				require.Empty(t, bundle.Endpoints)
+				require.Len(t, bundle.Skipped, 2)
+				require.Equal(t, []string{pushedSourceName}, bundle.Skipped[1].Sources)
+			},
+		},
+		{
+			name:   "toml",
+			format: "toml",
+			body:   "[rules.pushed-rule]\ndescription = \"from toml\"\n",
+			assert: func(t *testing.T, bundle RuleBundle, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "from toml", bundle.Rules["pushed-rule"].Description)
+			},
+		},
+		{
+			name:   "unsupported format",
+			format: "ini",
+			body:   "rules=",
+			assert: func(t *testing.T, _ RuleBundle, err error) {
+				require.ErrorContains(t, err, "unsupported rules file extension")
+			},
+		},
+		{
+			name:   "malformed document",
+			format: "json",
+			body:   `{"rules":`,
+			assert: func(t *testing.T, _ RuleBundle, err error) {
+				require.ErrorContains(t, err, "config: parse rules from admin-api")
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bundle, err := ParseRuleBundle(context.Background(), cfg, []byte(tc.body), tc.format)
+			tc.assert(t, bundle, err)
+		})
+	}
+}
+
+func TestBuildRuleBundleCompilesLibraryHelpers(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"rules.yaml": `
+rules:
+  office-network:
+    conditions:
+      pass:
+        - cidr.contains(["10.0.0.0/8", "192.168.0.0/16"], admission.clientIp)
+        - time.inWindow("Europe/Berlin", "Mon-Fri", "08:00-19:00")
+  typo:
+    conditions:
+      pass:
+        - cidr.contain("10.0.0.0/8", admission.clientIp)
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Rules, "office-network")
+	require.NotContains(t, bundle.Rules, "typo")
+	require.Len(t, bundle.Skipped, 1)
+	require.Equal(t, "typo", bundle.Skipped[0].Name)
+	require.Contains(t, bundle.Skipped[0].Reason, "conditions.pass[0]")
+	require.Contains(t, bundle.Skipped[0].Reason, "cidr.contain")
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// validateTimeout checks a timeout/onTimeout pair declared on an endpoint or a
+// rule. An empty timeout disables the deadline.
+func validateTimeout(timeout, onTimeout string) error {
+	if value := strings.TrimSpace(timeout); value != "" {
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return &fieldError{path: "timeout", err: fmt.Errorf("invalid duration %q", timeout)}
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(onTimeout)) {
+	case "", TimeoutOutcomeError, TimeoutOutcomeFail:
+	default:
+		return &fieldError{path: "onTimeout", err: fmt.Errorf("unsupported outcome %q (expected error or fail)", onTimeout)}
+	}
+	return nil
+}
+
+// validateTimeouts quarantines rules and endpoints whose deadlines do not parse.
+func (a *ruleAggregator) validateTimeouts() {
+	for name, cfg := range a.rules {
+		if err := validateTimeout(cfg.Timeout, cfg.OnTimeout); err != nil {
+			a.recordRuleSkip(name, fmt.Sprintf("invalid timeout: %s", withProvenance(err, a.ruleOrigins[name])), a.ruleSources[name])
+			delete(a.ruleSources, name)
+			delete(a.rules, name)
+		}
+	}
+	for name, cfg := range a.endpoints {
+		if err := validateTimeout(cfg.Timeout, cfg.OnTimeout); err != nil {
+			a.recordEndpointSkip(name, fmt.Sprintf("invalid timeout: %v", err), a.endpointSources[name])
+			delete(a.endpointSources, name)
+			delete(a.endpoints, name)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildRuleBundleValidatesTimeouts(t *testing.T) {
+	rulesCfg := writeRuleFiles(t, map[string]string{
+		"rules.yaml": `
+rules:
+  lookup:
+    timeout: 250ms
+    onTimeout: fail
+  sluggish:
+    timeout: soon
+endpoints:
+  gateway:
+    timeout: 2s
+    rules:
+      - name: lookup
+  bad-outcome:
+    timeout: 1s
+    onTimeout: pass
+    rules:
+      - name: lookup
+`,
+	})
+
+	bundle, err := buildRuleBundle(context.Background(), nil, nil, ServerConfig{Rules: rulesCfg}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "2s", bundle.Endpoints["gateway"].Timeout)
+	require.Equal(t, TimeoutOutcomeFail, bundle.Rules["lookup"].OnTimeout)
+
+	reasons := make(map[string]string)
+	for _, skip := range bundle.Skipped {
+		reasons[skip.Name] = skip.Reason
+	}
+	require.Len(t, reasons, 2)
+	require.Equal(t, `invalid timeout: timeout: invalid duration "soon"`, reasons["sluggish"])
+	require.Equal(t, `invalid timeout: onTimeout: unsupported outcome "pass" (expected error or fail)`, reasons["bad-outcome"])
+}
@@ -3,8 +3,13 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/l0p7/passctrl/internal/credentials"
 )
 
 // Config holds every server-level option plus nested endpoint artifacts once they are loaded.
@@ -39,17 +44,76 @@ type Config struct {
 // ServerConfig collects the bootstrap knobs owned by the Server Configuration & Lifecycle agent.
 type ServerConfig struct {
 	Listen    ListenConfig          `koanf:"listen"`
+	Admin     AdminConfig           `koanf:"admin"`
+	Health    HealthConfig          `koanf:"health"`
 	Logging   LoggingConfig         `koanf:"logging"`
 	Rules     RulesConfig           `koanf:"rules"`
 	Templates TemplatesConfig       `koanf:"templates"`
 	Cache     ServerCacheConfig     `koanf:"cache"`
 	Variables ServerVariablesConfig `koanf:"variables"`
+
+	// CredentialStores declares named username/password-hash stores that basic
+	// auth matchers reference through credentialStore.
+	CredentialStores map[string]CredentialStoreConfig `koanf:"credentialStores"`
+
+	// APIKeyStores declares named API key registries that apiKey matchers
+	// reference through keyStore.
+	APIKeyStores map[string]APIKeyStoreConfig `koanf:"apiKeyStores"`
+
+	// Datasets declares named static lookup tables exposed to CEL and
+	// templates as data.<name>.
+	Datasets map[string]DatasetConfig `koanf:"datasets"`
+
+	// Databases declares named SQL connections that backends of type sql
+	// reference through sql.database.
+	Databases map[string]DatabaseConfig `koanf:"databases"`
 }
 
 // ListenConfig instructs the HTTP listener about bind address and port.
 type ListenConfig struct {
-	Address string `koanf:"address"`
-	Port    int    `koanf:"port"`
+	Address string          `koanf:"address"`
+	Port    int             `koanf:"port"`
+	TLS     ListenTLSConfig `koanf:"tls"`
+}
+
+// ListenTLSConfig enables TLS on the listener when certFile is set. The
+// certificate, key, and client CA bundle are watched and reloaded in place.
+type ListenTLSConfig struct {
+	CertFile     string   `koanf:"certFile"`
+	KeyFile      string   `koanf:"keyFile"`
+	MinVersion   string   `koanf:"minVersion"`   // 1.2 (default) | 1.3
+	CipherSuites []string `koanf:"cipherSuites"` // IANA names; TLS 1.2 only, Go defaults when empty
+	ClientCAFile string   `koanf:"clientCAFile"` // enables client certificate verification
+	ClientAuth   string   `koanf:"clientAuth"`   // require (default with clientCAFile) | optional
+}
+
+// Enabled reports whether the listener should terminate TLS.
+func (c ListenTLSConfig) Enabled() bool { return strings.TrimSpace(c.CertFile) != "" }
+
+// AdminConfig describes the operations listener that serves metrics, health,
+// explain, profiling, and management routes away from the public auth port.
+type AdminConfig struct {
+	Listen ListenConfig    `koanf:"listen"` // port 0 disables the admin listener
+	Auth   AdminAuthConfig `koanf:"auth"`
+	// CombinedListener keeps the legacy layout by also serving the operations
+	// routes on the public listener.
+	CombinedListener bool `koanf:"combinedListener"`
+}
+
+// Enabled reports whether the admin listener should be started.
+func (c AdminConfig) Enabled() bool { return c.Listen.Port > 0 }
+
+// AdminAuthConfig guards the admin listener. Client certificates are verified
+// through listen.tls.clientCAFile; a verified certificate satisfies the check
+// on its own when bearerToken is also set.
+type AdminAuthConfig struct {
+	BearerToken string `koanf:"bearerToken"`
+}
+
+// HealthConfig tunes the background readiness probe scheduler.
+type HealthConfig struct {
+	Interval string `koanf:"interval"` // duration between probe rounds, default 10s
+	Timeout  string `koanf:"timeout"`  // per-probe timeout, default 2s
 }
 
 // LoggingConfig expresses log level, format, and correlation ID wiring.
@@ -61,8 +125,45 @@ type LoggingConfig struct {
 
 // RulesConfig announces how rule documents are sourced.
 type RulesConfig struct {
-	RulesFolder string `koanf:"rulesFolder"`
-	RulesFile   string `koanf:"rulesFile"`
+	RulesFolder string            `koanf:"rulesFolder"`
+	RulesFile   string            `koanf:"rulesFile"`
+	Remote      RulesRemoteConfig `koanf:"remote"`
+	Reload      RulesReloadConfig `koanf:"reload"`
+}
+
+// RulesRemoteConfig pulls a rule document from a central HTTP(S) location.
+// The remote document merges with inline and file definitions like any other
+// rules source.
+type RulesRemoteConfig struct {
+	URL          string `koanf:"url"`
+	Format       string `koanf:"format"`       // yaml | json | toml, default inferred from the URL path (yaml otherwise)
+	PollInterval string `koanf:"pollInterval"` // default 60s
+	Timeout      string `koanf:"timeout"`      // per-request timeout, default 10s
+	// CacheFile keeps the last verified document so the server can start
+	// while the remote is unreachable.
+	CacheFile string `koanf:"cacheFile"`
+	// PublicKeyFile is a PEM (PKIX) ed25519 or ECDSA public key. When set, the
+	// detached base64 signature at SignatureURL must verify before the
+	// document is used.
+	PublicKeyFile string `koanf:"publicKeyFile"`
+	SignatureURL  string `koanf:"signatureURL"` // default: url + ".sig"
+}
+
+// Enabled reports whether a remote rules source is configured.
+func (c RulesRemoteConfig) Enabled() bool {
+	return strings.TrimSpace(c.URL) != ""
+}
+
+// RulesReloadConfig controls how a candidate rule bundle replaces the active
+// snapshot.
+type RulesReloadConfig struct {
+	// AllowDegraded applies bundles even when an endpoint that is serving now
+	// would fail to build. By default such bundles are rejected and the
+	// previous snapshot keeps serving.
+	AllowDegraded bool `koanf:"allowDegraded"`
+	// HistorySize bounds how many reload attempts are kept for the health and
+	// admin APIs.
+	HistorySize int `koanf:"historySize"`
 }
 
 // TemplatesConfig captures the template sandbox root.
@@ -85,6 +186,39 @@ type ServerVariablesConfig struct {
 	Secrets map[string]*string `koanf:"secrets"`
 }
 
+// CredentialStoreConfig defines a static credential store. Users maps usernames
+// to bcrypt, argon2id, or SHA-crypt hashes; HtpasswdFile points at an
+// htpasswd-format file inside the template sandbox that is watched for changes.
+// Inline users override file entries with the same name.
+type CredentialStoreConfig struct {
+	Users        map[string]string `koanf:"users"`
+	HtpasswdFile string            `koanf:"htpasswdFile"`
+}
+
+// APIKeyStoreConfig points at a YAML or JSON key registry inside the template
+// sandbox. Each entry carries a SHA-256 hash of the key plus owner, scopes,
+// expiresAt, and disabled metadata; the file is watched for changes.
+type APIKeyStoreConfig struct {
+	File string `koanf:"file"`
+}
+
+// DatasetConfig points at a CSV, YAML, or JSON table inside the template
+// sandbox. Rows are indexed by the Key column; the file is watched for
+// changes and load errors are reported by the health endpoint.
+type DatasetConfig struct {
+	File string `koanf:"file"`
+	Key  string `koanf:"key"`
+}
+
+// DatabaseConfig opens a pooled SQL connection. The DSN is normally read from
+// a server.variables.secrets entry so credentials stay out of config files.
+type DatabaseConfig struct {
+	Driver       string `koanf:"driver"`       // postgres | sqlite
+	DSN          string `koanf:"dsn"`          // literal DSN, e.g. file:/data/authz.db?mode=ro
+	DSNSecret    string `koanf:"dsnSecret"`    // name of the server.variables.secrets entry holding the DSN
+	MaxOpenConns int    `koanf:"maxOpenConns"` // default 4
+}
+
 type ServerCacheConfig struct {
 	Backend    string                 `koanf:"backend"`
 	TTLSeconds int                    `koanf:"ttlSeconds"`
@@ -129,6 +263,21 @@ type EndpointConfig struct {
 	Rules                []EndpointRuleReference            `koanf:"rules"`
 	ResponsePolicy       EndpointResponsePolicyConfig       `koanf:"responsePolicy"`
 	Cache                EndpointCacheConfig                `koanf:"cache"`
+	// RuleExecution controls whether independent rules run concurrently.
+	RuleExecution EndpointRuleExecutionConfig `koanf:"ruleExecution"`
+	// Timeout bounds the whole rule chain; OnTimeout picks the outcome
+	// reported when it is exceeded.
+	Timeout   string `koanf:"timeout"`
+	OnTimeout string `koanf:"onTimeout"` // error (default) | fail
+	// Match selects this endpoint for requests on the shared /auth route
+	// that do not name an endpoint explicitly.
+	Match EndpointMatchConfig `koanf:"match"`
+	// Extends names the endpoint this one inherits from. Only endpoints in
+	// rules documents can extend, or be extended by, another endpoint.
+	Extends string `koanf:"extends"`
+	// Inheritance is filled in by the loader for endpoints from rules
+	// documents and surfaced by /explain.
+	Inheritance *EndpointInheritance `koanf:"-" json:"-"`
 }
 
 type EndpointAuthenticationConfig struct {
@@ -168,8 +317,48 @@ type EndpointForwardRequestPolicyConfig struct {
 	ForwardProxyHeaders bool `koanf:"forwardProxyHeaders"`
 }
 
+// EndpointRuleReference is one entry of an endpoint's rule chain: either a
+// rule named by Name, or a group of nested entries under AnyOf or AllOf.
 type EndpointRuleReference struct {
 	Name string `koanf:"name"`
+	// With supplies values for the parameters the rule declares. Each
+	// distinct set of values compiles its own instance of the rule.
+	With map[string]any `koanf:"with"`
+	// When is a CEL guard; the entry is skipped when it evaluates to false.
+	When string `koanf:"when"`
+	// OnPass set to "stop" ends the chain with a pass when the entry passes.
+	OnPass string `koanf:"onPass"`
+	// AnyOf passes as soon as one nested entry passes.
+	AnyOf []EndpointRuleReference `koanf:"anyOf"`
+	// AllOf passes when every nested entry passes, like the top-level list.
+	AllOf []EndpointRuleReference `koanf:"allOf"`
+	// Parallel behaves like AllOf but evaluates its entries concurrently.
+	Parallel []EndpointRuleReference `koanf:"parallel"`
+}
+
+// Rule chain onPass actions.
+const (
+	OnPassContinue = "continue"
+	OnPassStop     = "stop"
+)
+
+// IsGroup reports whether the entry nests other entries instead of naming a rule.
+func (r EndpointRuleReference) IsGroup() bool {
+	return len(r.AnyOf) > 0 || len(r.AllOf) > 0 || len(r.Parallel) > 0
+}
+
+// Rule execution parallel modes.
+const (
+	RuleParallelOff  = "off"
+	RuleParallelAuto = "auto"
+)
+
+// EndpointRuleExecutionConfig tunes how the rule chain is evaluated. With
+// Parallel set to auto, consecutive rules that do not read each other's
+// exported variables run concurrently; explicit `parallel` groups always do.
+type EndpointRuleExecutionConfig struct {
+	Parallel   string `koanf:"parallel"`   // off (default) | auto
+	MaxWorkers int    `koanf:"maxWorkers"` // concurrent rules per group, default 4
 }
 
 type EndpointResponsePolicyConfig struct {
@@ -187,6 +376,40 @@ type EndpointResponseConfig struct {
 
 type EndpointCacheConfig struct {
 	ResultTTL string `koanf:"resultTTL"`
+	// Key lists CEL expressions over the request whose values are hashed
+	// into every cache key of the endpoint. Anonymous endpoints are only
+	// cached when a key is declared.
+	Key []string `koanf:"key"`
+}
+
+// EndpointMatchConfig describes the forwarded requests an endpoint accepts
+// when the caller does not name one. Every configured criterion must hold;
+// within a list any entry may match. Higher priorities are evaluated first and
+// ties are broken by endpoint name.
+type EndpointMatchConfig struct {
+	Hosts        []string                    `koanf:"hosts"`        // globs such as *.example.com
+	PathPrefixes []string                    `koanf:"pathPrefixes"` // original path prefixes
+	PathRegex    []string                    `koanf:"pathRegex"`    // regexes against the original path
+	Methods      []string                    `koanf:"methods"`
+	Headers      []EndpointHeaderMatchConfig `koanf:"headers"`
+	Priority     int                         `koanf:"priority"`
+	// Fallback selects this endpoint when no other endpoint matches. At most
+	// one endpoint may set it.
+	Fallback bool `koanf:"fallback"`
+}
+
+// Enabled reports whether the endpoint declares any selection criteria.
+func (c EndpointMatchConfig) Enabled() bool {
+	return len(c.Hosts) > 0 || len(c.PathPrefixes) > 0 || len(c.PathRegex) > 0 || len(c.Methods) > 0 || len(c.Headers) > 0
+}
+
+// EndpointHeaderMatchConfig is a predicate on one forwarded request header.
+// A name alone requires the header to be present.
+type EndpointHeaderMatchConfig struct {
+	Name   string   `koanf:"name"`
+	Values []string `koanf:"values"` // exact values, any may match
+	Regex  string   `koanf:"regex"`
+	Absent bool     `koanf:"absent"`
 }
 
 // RuleConfig captures the declarative controls available to a single rule. The
@@ -199,6 +422,26 @@ type RuleConfig struct {
 	Responses   RuleResponsesConfig `koanf:"responses"`
 	Variables   RuleVariablesConfig `koanf:"variables"`
 	Cache       RuleCacheConfig     `koanf:"cache"`
+	// Params declares typed parameters endpoints pass with `with:`. They are
+	// exposed to CEL and templates as params.<name>.
+	Params map[string]RuleParamConfig `koanf:"params"`
+	// Timeout bounds a single evaluation of the rule, backend calls included.
+	Timeout   string `koanf:"timeout"`
+	OnTimeout string `koanf:"onTimeout"` // error (default) | fail
+}
+
+// Outcomes a timeout may be reported as.
+const (
+	TimeoutOutcomeError = "error"
+	TimeoutOutcomeFail  = "fail"
+)
+
+// RuleParamConfig declares one rule parameter. A parameter without a default
+// must be supplied by every endpoint that references the rule.
+type RuleParamConfig struct {
+	Type        string `koanf:"type"` // string|int|float|bool|list|map
+	Default     any    `koanf:"default"`
+	Description string `koanf:"description"`
 }
 
 type RuleAuthDirective struct {
@@ -207,11 +450,14 @@ type RuleAuthDirective struct {
 }
 
 type RuleAuthMatcher struct {
-	Type     string `koanf:"type"`     // basic|bearer|header|query|none
-	Name     string `koanf:"name"`     // Required for header/query
-	Value    any    `koanf:"value"`    // string or []string - for header/query/bearer (regex or literal)
-	Username any    `koanf:"username"` // string or []string - for basic
-	Password any    `koanf:"password"` // string or []string - for basic
+	Type            string `koanf:"type"`            // basic|bearer|header|query|none
+	Name            string `koanf:"name"`            // Required for header/query
+	Value           any    `koanf:"value"`           // string or []string - for header/query/bearer (regex or literal)
+	Username        any    `koanf:"username"`        // string or []string - for basic
+	Password        any    `koanf:"password"`        // string or []string - for basic
+	CredentialStore string `koanf:"credentialStore"` // basic only - verify username/password against server.credentialStores
+	In              string `koanf:"in"`              // apiKey only - header|query|bearer (default header)
+	KeyStore        string `koanf:"keyStore"`        // apiKey only - registry under server.apiKeyStores
 }
 
 type RuleForwardAsConfig struct {
@@ -224,15 +470,107 @@ type RuleForwardAsConfig struct {
 }
 
 type RuleBackendConfig struct {
-	URL                 string               `koanf:"url"`
-	Method              string               `koanf:"method"`
-	ForwardProxyHeaders bool                 `koanf:"forwardProxyHeaders"`
-	Headers             map[string]*string   `koanf:"headers"`
-	Query               map[string]*string   `koanf:"query"`
-	Body                string               `koanf:"body"`
-	BodyFile            string               `koanf:"bodyFile"`
-	AcceptedStatuses    []int                `koanf:"acceptedStatuses"`
-	Pagination          RulePaginationConfig `koanf:"pagination"`
+	Type                string                  `koanf:"type"` // http (default) | introspection | ldap | sql | grpc
+	URL                 string                  `koanf:"url"`
+	Method              string                  `koanf:"method"`
+	ForwardProxyHeaders bool                    `koanf:"forwardProxyHeaders"`
+	Headers             map[string]*string      `koanf:"headers"`
+	Query               map[string]*string      `koanf:"query"`
+	Body                string                  `koanf:"body"`
+	BodyFile            string                  `koanf:"bodyFile"`
+	AcceptedStatuses    []int                   `koanf:"acceptedStatuses"`
+	Pagination          RulePaginationConfig    `koanf:"pagination"`
+	Introspection       RuleIntrospectionConfig `koanf:"introspection"`
+	LDAP                RuleLDAPConfig          `koanf:"ldap"`
+	SQL                 RuleSQLConfig           `koanf:"sql"`
+	GRPC                RuleGRPCConfig          `koanf:"grpc"`
+	HealthProbe         RuleHealthProbeConfig   `koanf:"healthProbe"`
+	// BodySchema is a JSON Schema for accepted response bodies. Conditions
+	// are type-checked against it at load and violating responses are rule
+	// errors. BodySchemaFile loads it from the template sandbox instead.
+	BodySchema     map[string]any `koanf:"bodySchema"`
+	BodySchemaFile string         `koanf:"bodySchemaFile"` // .json, .yaml or .yml
+}
+
+// RuleHealthProbeConfig declares a URL polled by the readiness scheduler.
+// Critical probes fail /readyz while the backend is unhealthy.
+type RuleHealthProbeConfig struct {
+	URL      string `koanf:"url"`
+	Critical bool   `koanf:"critical"`
+}
+
+// RuleIntrospectionConfig configures an RFC 7662 token introspection backend.
+type RuleIntrospectionConfig struct {
+	Token         string                            `koanf:"token"`         // template; defaults to the matched bearer token
+	TokenTypeHint string                            `koanf:"tokenTypeHint"` // optional token_type_hint parameter
+	ClientAuth    RuleIntrospectionClientAuthConfig `koanf:"clientAuth"`
+	Audience      []string                          `koanf:"audience"` // token must carry at least one of these audiences
+	Issuer        string                            `koanf:"issuer"`   // token iss must match exactly
+	Leeway        string                            `koanf:"leeway"`   // clock skew tolerance for exp/nbf
+}
+
+type RuleIntrospectionClientAuthConfig struct {
+	Method       string `koanf:"method"`       // client_secret_basic (default) | client_secret_post | none
+	ClientID     string `koanf:"clientId"`     // template
+	ClientSecret string `koanf:"clientSecret"` // template, e.g. {{ .variables.secrets.introspection }}
+}
+
+// RuleLDAPConfig configures an LDAP or Active Directory bind backend. The
+// caller is bound directly through UserDN, or located under BaseDN (as BindDN
+// when set) and then bound. {username} in UserDN and UserFilter is replaced
+// with the escaped rendered username.
+type RuleLDAPConfig struct {
+	StartTLS     bool                 `koanf:"startTLS"`     // upgrade ldap:// connections before binding
+	CAFile       string               `koanf:"caFile"`       // PEM bundle for ldaps:// and StartTLS; system roots when empty
+	PoolSize     int                  `koanf:"poolSize"`     // idle connections kept per server (default 4)
+	Username     string               `koanf:"username"`     // template; defaults to the basic auth user
+	Password     string               `koanf:"password"`     // template; defaults to the basic auth password
+	UserDN       string               `koanf:"userDN"`       // direct bind, e.g. uid={username},ou=people,dc=example,dc=com
+	BindDN       string               `koanf:"bindDN"`       // service account for the user search; anonymous when empty
+	BindPassword string               `koanf:"bindPassword"` // template, e.g. {{ .variables.secrets.ldap }}
+	BaseDN       string               `koanf:"baseDN"`       // user search base
+	UserFilter   string               `koanf:"userFilter"`   // default (uid={username})
+	Attributes   []string             `koanf:"attributes"`   // returned as backend.body.attributes
+	Groups       RuleLDAPGroupsConfig `koanf:"groups"`
+}
+
+// RuleLDAPGroupsConfig enables group membership lookups for LDAP backends.
+// {dn} in Filter is replaced with the escaped member DN.
+type RuleLDAPGroupsConfig struct {
+	BaseDN    string `koanf:"baseDN"`
+	Filter    string `koanf:"filter"`    // default (member={dn})
+	Attribute string `koanf:"attribute"` // group name attribute (default cn)
+	Nested    bool   `koanf:"nested"`    // follow groups that are members of other groups
+}
+
+// RuleSQLConfig runs a parameterized query against a server.databases
+// connection. Params are rendered as templates and bound to the query's
+// placeholders ($1 for postgres, ? for sqlite); they are never spliced into
+// the SQL text. Result rows are exposed as backend.body.
+type RuleSQLConfig struct {
+	Database string   `koanf:"database"` // name under server.databases
+	Query    string   `koanf:"query"`
+	Params   []string `koanf:"params"`  // templates bound positionally as text
+	MaxRows  int      `koanf:"maxRows"` // more rows is a rule error (default 100)
+	Timeout  string   `koanf:"timeout"` // per-query timeout (default 5s)
+}
+
+// RuleGRPCConfig calls a unary gRPC method on the grpc:// or grpcs:// target
+// in url. The request message is rendered from body or bodyFile as protobuf
+// JSON and headers are sent as metadata. The method descriptor is read from
+// DescriptorSetFile, or from server reflection when it is empty.
+type RuleGRPCConfig struct {
+	Method            string            `koanf:"method"`            // fully qualified, e.g. policy.v1.Authorizer/Check
+	DescriptorSetFile string            `koanf:"descriptorSetFile"` // protoc --descriptor_set_out --include_imports output in the template sandbox
+	TLS               RuleGRPCTLSConfig `koanf:"tls"`               // grpcs:// only
+}
+
+// RuleGRPCTLSConfig tunes TLS for grpcs:// targets.
+type RuleGRPCTLSConfig struct {
+	CAFile     string `koanf:"caFile"`     // PEM bundle; system roots when empty
+	CertFile   string `koanf:"certFile"`   // client certificate for mutual TLS
+	KeyFile    string `koanf:"keyFile"`    // client key, required with certFile
+	ServerName string `koanf:"serverName"` // overrides the name verified against the certificate
 }
 
 type RulePaginationConfig struct {
@@ -430,7 +768,7 @@ func validateAuthMatcher(matcher RuleAuthMatcher, index int, parentContext strin
 
 	typ := strings.ToLower(strings.TrimSpace(matcher.Type))
 	switch typ {
-	case "basic", "bearer", "header", "query", "none":
+	case "basic", "bearer", "header", "query", "apikey", "none":
 		// Valid types
 	default:
 		return fmt.Errorf("%s.type: unsupported type %q", matcherCtx, matcher.Type)
@@ -442,6 +780,13 @@ func validateAuthMatcher(matcher RuleAuthMatcher, index int, parentContext strin
 	}
 
 	// Validate value constraint applicability
+	if strings.TrimSpace(matcher.CredentialStore) != "" && typ != "basic" {
+		return fmt.Errorf("%s.credentialStore: only valid for type basic", matcherCtx)
+	}
+	if typ != "apikey" && (strings.TrimSpace(matcher.KeyStore) != "" || strings.TrimSpace(matcher.In) != "") {
+		return fmt.Errorf("%s: keyStore and in only valid for type apiKey", matcherCtx)
+	}
+
 	switch typ {
 	case "header", "query", "bearer":
 		if matcher.Username != nil {
@@ -469,11 +814,32 @@ func validateAuthMatcher(matcher RuleAuthMatcher, index int, parentContext strin
 		}
 		// Validate password constraint structure
 		if matcher.Password != nil {
+			if strings.TrimSpace(matcher.CredentialStore) != "" {
+				return fmt.Errorf("%s.password: cannot be combined with credentialStore", matcherCtx)
+			}
 			if _, err := ParseValueConstraint(matcher.Password, matcherCtx+".password"); err != nil {
 				return err
 			}
 		}
 
+	case "apikey":
+		if matcher.Value != nil || matcher.Username != nil || matcher.Password != nil {
+			return fmt.Errorf("%s: value constraints not valid for type apiKey (keys resolve through keyStore)", matcherCtx)
+		}
+		if strings.TrimSpace(matcher.KeyStore) == "" {
+			return fmt.Errorf("%s.keyStore: required for type apiKey", matcherCtx)
+		}
+		in := strings.ToLower(strings.TrimSpace(matcher.In))
+		switch in {
+		case "", "header", "query":
+			if strings.TrimSpace(matcher.Name) == "" {
+				return fmt.Errorf("%s.name: required for type apiKey in %s", matcherCtx, apiKeyLocation(in))
+			}
+		case "bearer":
+		default:
+			return fmt.Errorf("%s.in: unsupported location %q (expected header, query, or bearer)", matcherCtx, matcher.In)
+		}
+
 	case "none":
 		if matcher.Value != nil || matcher.Username != nil || matcher.Password != nil {
 			return fmt.Errorf("%s: value constraints not valid for type none", matcherCtx)
@@ -483,6 +849,212 @@ func validateAuthMatcher(matcher RuleAuthMatcher, index int, parentContext strin
 	return nil
 }
 
+func apiKeyLocation(in string) string {
+	if in == "" {
+		return "header"
+	}
+	return in
+}
+
+// validateBackendType checks the backend type, the body schema source, and,
+// for introspection, LDAP, SQL, and gRPC backends, the fields the runtime
+// generates or requires.
+func validateBackendType(backend RuleBackendConfig, context string) error {
+	typ := strings.ToLower(strings.TrimSpace(backend.Type))
+	if len(backend.BodySchema) > 0 || strings.TrimSpace(backend.BodySchemaFile) != "" {
+		if len(backend.BodySchema) > 0 && strings.TrimSpace(backend.BodySchemaFile) != "" {
+			return fmt.Errorf("%s: bodySchema and bodySchemaFile are mutually exclusive", context)
+		}
+		if strings.TrimSpace(backend.URL) == "" && typ != "sql" {
+			return fmt.Errorf("%s.url: required when a body schema is declared", context)
+		}
+	}
+	switch typ {
+	case "", "http":
+		return nil
+	case "introspection":
+		return validateIntrospectionBackend(backend, context)
+	case "ldap":
+		return validateLDAPBackend(backend, context)
+	case "sql":
+		return validateSQLBackend(backend, context)
+	case "grpc":
+		return validateGRPCBackend(backend, context)
+	default:
+		return fmt.Errorf("%s.type: unsupported type %q (expected http, introspection, ldap, sql, or grpc)", context, backend.Type)
+	}
+}
+
+func validateIntrospectionBackend(backend RuleBackendConfig, context string) error {
+	if strings.TrimSpace(backend.URL) == "" {
+		return fmt.Errorf("%s.url: required for type introspection", context)
+	}
+	if method := strings.TrimSpace(backend.Method); method != "" && !strings.EqualFold(method, http.MethodPost) {
+		return fmt.Errorf("%s.method: introspection always uses POST", context)
+	}
+	if strings.TrimSpace(backend.Body) != "" || strings.TrimSpace(backend.BodyFile) != "" {
+		return fmt.Errorf("%s: body and bodyFile are generated for type introspection", context)
+	}
+	if strings.TrimSpace(backend.Pagination.Type) != "" {
+		return fmt.Errorf("%s.pagination: not supported for type introspection", context)
+	}
+
+	introspection := backend.Introspection
+	if leeway := strings.TrimSpace(introspection.Leeway); leeway != "" {
+		if d, err := time.ParseDuration(leeway); err != nil || d < 0 {
+			return fmt.Errorf("%s.introspection.leeway: invalid duration %q", context, introspection.Leeway)
+		}
+	}
+	method := strings.ToLower(strings.TrimSpace(introspection.ClientAuth.Method))
+	switch method {
+	case "", "client_secret_basic", "client_secret_post":
+		if strings.TrimSpace(introspection.ClientAuth.ClientID) == "" {
+			return fmt.Errorf("%s.introspection.clientAuth.clientId: required unless method is none", context)
+		}
+	case "none":
+	default:
+		return fmt.Errorf("%s.introspection.clientAuth.method: unsupported method %q (expected client_secret_basic, client_secret_post, or none)", context, introspection.ClientAuth.Method)
+	}
+	return nil
+}
+
+// validateLDAPBackend checks the server URL and that exactly one of the
+// direct bind and search-then-bind modes is configured.
+func validateLDAPBackend(backend RuleBackendConfig, context string) error {
+	raw := strings.TrimSpace(backend.URL)
+	if raw == "" {
+		return fmt.Errorf("%s.url: required for type ldap", context)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "ldap" && parsed.Scheme != "ldaps") {
+		return fmt.Errorf("%s.url: expected ldap:// or ldaps:// URL, got %q", context, backend.URL)
+	}
+	if strings.TrimSpace(backend.Method) != "" || strings.TrimSpace(backend.Body) != "" || strings.TrimSpace(backend.BodyFile) != "" {
+		return fmt.Errorf("%s: method, body, and bodyFile are not used by type ldap", context)
+	}
+	if len(backend.Headers) > 0 || len(backend.Query) > 0 || backend.ForwardProxyHeaders {
+		return fmt.Errorf("%s: headers, query, and forwardProxyHeaders are not used by type ldap", context)
+	}
+	if strings.TrimSpace(backend.Pagination.Type) != "" {
+		return fmt.Errorf("%s.pagination: not supported for type ldap", context)
+	}
+
+	ldap := backend.LDAP
+	if ldap.StartTLS && parsed.Scheme == "ldaps" {
+		return fmt.Errorf("%s.ldap.startTLS: not valid with an ldaps:// URL", context)
+	}
+	if ldap.PoolSize < 0 {
+		return fmt.Errorf("%s.ldap.poolSize: must not be negative", context)
+	}
+	userDN := strings.TrimSpace(ldap.UserDN)
+	baseDN := strings.TrimSpace(ldap.BaseDN)
+	switch {
+	case userDN != "" && baseDN != "":
+		return fmt.Errorf("%s.ldap: userDN and baseDN are mutually exclusive", context)
+	case userDN != "":
+		if !strings.Contains(userDN, "{username}") {
+			return fmt.Errorf("%s.ldap.userDN: must contain {username}", context)
+		}
+		if strings.TrimSpace(ldap.BindDN) != "" || strings.TrimSpace(ldap.UserFilter) != "" {
+			return fmt.Errorf("%s.ldap: bindDN and userFilter require baseDN", context)
+		}
+	case baseDN != "":
+		if filter := strings.TrimSpace(ldap.UserFilter); filter != "" && !strings.Contains(filter, "{username}") {
+			return fmt.Errorf("%s.ldap.userFilter: must contain {username}", context)
+		}
+	default:
+		return fmt.Errorf("%s.ldap: userDN or baseDN required", context)
+	}
+	for i, attr := range ldap.Attributes {
+		if strings.TrimSpace(attr) == "" {
+			return fmt.Errorf("%s.ldap.attributes[%d]: empty", context, i)
+		}
+	}
+
+	groups := ldap.Groups
+	if strings.TrimSpace(groups.BaseDN) == "" {
+		if strings.TrimSpace(groups.Filter) != "" || strings.TrimSpace(groups.Attribute) != "" || groups.Nested {
+			return fmt.Errorf("%s.ldap.groups.baseDN: required when groups are configured", context)
+		}
+		return nil
+	}
+	if filter := strings.TrimSpace(groups.Filter); filter != "" && !strings.Contains(filter, "{dn}") {
+		return fmt.Errorf("%s.ldap.groups.filter: must contain {dn}", context)
+	}
+	return nil
+}
+
+// validateSQLBackend checks that an SQL backend names a database and a query
+// and leaves the HTTP request fields unset; the connection comes from
+// server.databases rather than url.
+func validateSQLBackend(backend RuleBackendConfig, context string) error {
+	if strings.TrimSpace(backend.URL) != "" {
+		return fmt.Errorf("%s.url: not used by type sql (name a server.databases entry in sql.database)", context)
+	}
+	if strings.TrimSpace(backend.Method) != "" || strings.TrimSpace(backend.Body) != "" || strings.TrimSpace(backend.BodyFile) != "" {
+		return fmt.Errorf("%s: method, body, and bodyFile are not used by type sql", context)
+	}
+	if len(backend.Headers) > 0 || len(backend.Query) > 0 || backend.ForwardProxyHeaders {
+		return fmt.Errorf("%s: headers, query, and forwardProxyHeaders are not used by type sql", context)
+	}
+	if strings.TrimSpace(backend.Pagination.Type) != "" {
+		return fmt.Errorf("%s.pagination: not supported for type sql", context)
+	}
+
+	sql := backend.SQL
+	if strings.TrimSpace(sql.Database) == "" {
+		return fmt.Errorf("%s.sql.database: required for type sql", context)
+	}
+	if strings.TrimSpace(sql.Query) == "" {
+		return fmt.Errorf("%s.sql.query: required for type sql", context)
+	}
+	if sql.MaxRows < 0 {
+		return fmt.Errorf("%s.sql.maxRows: must not be negative", context)
+	}
+	if timeout := strings.TrimSpace(sql.Timeout); timeout != "" {
+		if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+			return fmt.Errorf("%s.sql.timeout: invalid duration %q", context, sql.Timeout)
+		}
+	}
+	return nil
+}
+
+// validateGRPCBackend checks the target URL, the method name, and that TLS
+// settings are only given for grpcs:// targets.
+func validateGRPCBackend(backend RuleBackendConfig, context string) error {
+	raw := strings.TrimSpace(backend.URL)
+	if raw == "" {
+		return fmt.Errorf("%s.url: required for type grpc", context)
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "grpc" && parsed.Scheme != "grpcs") {
+		return fmt.Errorf("%s.url: expected grpc:// or grpcs:// URL, got %q", context, backend.URL)
+	}
+	if strings.TrimSpace(backend.Method) != "" {
+		return fmt.Errorf("%s.method: not used by type grpc (set grpc.method)", context)
+	}
+	if len(backend.Query) > 0 {
+		return fmt.Errorf("%s.query: not used by type grpc", context)
+	}
+	if strings.TrimSpace(backend.Pagination.Type) != "" {
+		return fmt.Errorf("%s.pagination: not supported for type grpc", context)
+	}
+
+	grpc := backend.GRPC
+	service, method, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(grpc.Method), "/"), "/")
+	if !ok || service == "" || method == "" || strings.Contains(method, "/") {
+		return fmt.Errorf("%s.grpc.method: expected package.Service/Method, got %q", context, grpc.Method)
+	}
+	tls := grpc.TLS
+	if parsed.Scheme == "grpc" && (tls != RuleGRPCTLSConfig{}) {
+		return fmt.Errorf("%s.grpc.tls: requires a grpcs:// URL", context)
+	}
+	if (strings.TrimSpace(tls.CertFile) == "") != (strings.TrimSpace(tls.KeyFile) == "") {
+		return fmt.Errorf("%s.grpc.tls: certFile and keyFile must be set together", context)
+	}
+	return nil
+}
+
 // validateForwardAsArray checks for duplicate targets in forwardAs array.
 func validateForwardAsArray(forwards []RuleForwardAsConfig, context string) error {
 	if len(forwards) == 0 {
@@ -522,6 +1094,74 @@ func buildForwardKey(fwd RuleForwardAsConfig) string {
 	}
 }
 
+// validateListenTLS checks the TLS file pairing and enumerations. Cipher suite
+// names are resolved when the listener is built.
+func validateListenTLS(cfg ListenTLSConfig, context string) error {
+	cert := strings.TrimSpace(cfg.CertFile)
+	key := strings.TrimSpace(cfg.KeyFile)
+	if (cert == "") != (key == "") {
+		return fmt.Errorf("config: %s.certFile and keyFile must be set together", context)
+	}
+	if cert == "" {
+		if strings.TrimSpace(cfg.ClientCAFile) != "" || strings.TrimSpace(cfg.ClientAuth) != "" || strings.TrimSpace(cfg.MinVersion) != "" || len(cfg.CipherSuites) > 0 {
+			return fmt.Errorf("config: %s requires certFile and keyFile", context)
+		}
+		return nil
+	}
+	switch strings.TrimSpace(cfg.MinVersion) {
+	case "", "1.2", "1.3":
+	default:
+		return fmt.Errorf("config: %s.minVersion unsupported: %q (expected 1.2 or 1.3)", context, cfg.MinVersion)
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.ClientAuth)) {
+	case "":
+	case "require", "optional":
+		if strings.TrimSpace(cfg.ClientCAFile) == "" {
+			return fmt.Errorf("config: %s.clientAuth requires clientCAFile", context)
+		}
+	default:
+		return fmt.Errorf("config: %s.clientAuth unsupported: %q (expected require or optional)", context, cfg.ClientAuth)
+	}
+	return nil
+}
+
+// validateAdmin checks the admin listener port. A port shared with the public
+// listener is rejected: the combined layout is only served when
+// server.admin.combinedListener asks for it.
+func validateAdmin(cfg ServerConfig) error {
+	admin := cfg.Admin
+	if admin.Listen.Port < 0 || admin.Listen.Port > 65535 {
+		return fmt.Errorf("config: server.admin.listen.port invalid: %d", admin.Listen.Port)
+	}
+	if !admin.Enabled() {
+		if admin.Listen.TLS.Enabled() || strings.TrimSpace(admin.Auth.BearerToken) != "" {
+			return errors.New("config: server.admin.listen.port required when admin tls or auth is configured")
+		}
+		return nil
+	}
+	if admin.Listen.Port == cfg.Listen.Port {
+		return fmt.Errorf("config: server.admin.listen.port %d conflicts with server.listen.port; choose another port, or set it to 0 with server.admin.combinedListener for the combined layout", admin.Listen.Port)
+	}
+	return validateListenTLS(admin.Listen.TLS, "server.admin.listen.tls")
+}
+
+// validateHealthProbe requires an absolute http(s) URL when critical is set or
+// a URL is present.
+func validateHealthProbe(probe RuleHealthProbeConfig, context string) error {
+	raw := strings.TrimSpace(probe.URL)
+	if raw == "" {
+		if probe.Critical {
+			return fmt.Errorf("%s.url: required when critical is set", context)
+		}
+		return nil
+	}
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%s.url: absolute http(s) URL required, got %q", context, probe.URL)
+	}
+	return nil
+}
+
 // validateBackendHeaders ensures authorization header is not specified in backend config.
 // Authorization must be handled through the auth block for proper credential stripping.
 func validateBackendHeaders(headers map[string]*string, context string) error {
@@ -541,9 +1181,29 @@ func (c *Config) Validate() error {
 	if c.Server.Listen.Port <= 0 || c.Server.Listen.Port > 65535 {
 		return fmt.Errorf("config: listen.port invalid: %d", c.Server.Listen.Port)
 	}
+	if err := validateListenTLS(c.Server.Listen.TLS, "server.listen.tls"); err != nil {
+		return err
+	}
+	if err := validateAdmin(c.Server); err != nil {
+		return err
+	}
+	for field, value := range map[string]string{"interval": c.Server.Health.Interval, "timeout": c.Server.Health.Timeout} {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("config: server.health.%s invalid: %q", field, value)
+		}
+	}
 	if c.Server.Rules.RulesFolder != "" && c.Server.Rules.RulesFile != "" {
 		return errors.New("config: rulesFolder and rulesFile are mutually exclusive")
 	}
+	if err := validateRulesRemote(c.Server.Rules.Remote); err != nil {
+		return err
+	}
+	if c.Server.Rules.Reload.HistorySize < 0 {
+		return fmt.Errorf("config: server.rules.reload.historySize invalid: %d", c.Server.Rules.Reload.HistorySize)
+	}
 	if c.Server.Cache.TTLSeconds < 0 {
 		return fmt.Errorf("config: server.cache.ttlSeconds invalid: %d", c.Server.Cache.TTLSeconds)
 	}
@@ -560,10 +1220,38 @@ func (c *Config) Validate() error {
 	default:
 		return fmt.Errorf("config: server.cache.backend unsupported: %s", c.Server.Cache.Backend)
 	}
+	for name, store := range c.Server.CredentialStores {
+		if err := validateCredentialStore(name, store); err != nil {
+			return err
+		}
+	}
+	for name, store := range c.Server.APIKeyStores {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("config: server.apiKeyStores: empty store name")
+		}
+		if strings.TrimSpace(store.File) == "" {
+			return fmt.Errorf("config: server.apiKeyStores.%s.file required", name)
+		}
+	}
+	for name, dataset := range c.Server.Datasets {
+		if err := validateDataset(name, dataset); err != nil {
+			return err
+		}
+	}
+	for name, database := range c.Server.Databases {
+		if err := validateDatabase(name, database, c.Server.Variables.Secrets); err != nil {
+			return err
+		}
+	}
 	for name, endpoint := range c.Endpoints {
 		if err := validateEndpointAuthentication(name, endpoint.Authentication); err != nil {
 			return err
 		}
+		for i, expression := range endpoint.Cache.Key {
+			if strings.TrimSpace(expression) == "" {
+				return fmt.Errorf("config: endpoint %q cache.key[%d] empty", name, i)
+			}
+		}
 		// Validate endpoint variables (CEL or Template expressions)
 		if err := validateVariableMap(endpoint.Variables, fmt.Sprintf("endpoints[%s].variables", name)); err != nil {
 			return err
@@ -580,6 +1268,12 @@ func (c *Config) Validate() error {
 		if err := validateBackendHeaders(rule.BackendAPI.Headers, fmt.Sprintf("rules[%s].backendApi.headers", name)); err != nil {
 			return err
 		}
+		if err := validateBackendType(rule.BackendAPI, fmt.Sprintf("rules[%s].backendApi", name)); err != nil {
+			return err
+		}
+		if err := validateHealthProbe(rule.BackendAPI.HealthProbe, fmt.Sprintf("rules[%s].backendApi.healthProbe", name)); err != nil {
+			return err
+		}
 		// Validate rule cache TTL durations
 		if err := validateCacheTTLConfig(rule.Cache.TTL, fmt.Sprintf("rules[%s].cache.ttl", name)); err != nil {
 			return err
@@ -602,6 +1296,49 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func validateRulesRemote(remote RulesRemoteConfig) error {
+	if !remote.Enabled() {
+		return nil
+	}
+	for field, raw := range map[string]string{"url": remote.URL, "signatureURL": remote.SignatureURL} {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		parsed, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("config: server.rules.remote.%s invalid: %w", field, err)
+		}
+		switch strings.ToLower(parsed.Scheme) {
+		case "http", "https":
+		case "oci":
+			return fmt.Errorf("config: server.rules.remote.%s: OCI references are not supported; publish the bundle over HTTP(S)", field)
+		default:
+			return fmt.Errorf("config: server.rules.remote.%s must use http or https: %q", field, raw)
+		}
+		if parsed.Host == "" {
+			return fmt.Errorf("config: server.rules.remote.%s missing host: %q", field, raw)
+		}
+	}
+	if format := strings.TrimSpace(remote.Format); format != "" {
+		if _, err := parserFor("bundle." + format); err != nil {
+			return fmt.Errorf("config: server.rules.remote.format unsupported: %s", remote.Format)
+		}
+	}
+	for field, value := range map[string]string{"pollInterval": remote.PollInterval, "timeout": remote.Timeout} {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(value); err != nil || d <= 0 {
+			return fmt.Errorf("config: server.rules.remote.%s invalid: %q", field, value)
+		}
+	}
+	if strings.TrimSpace(remote.SignatureURL) != "" && strings.TrimSpace(remote.PublicKeyFile) == "" {
+		return errors.New("config: server.rules.remote.signatureURL requires publicKeyFile")
+	}
+	return nil
+}
+
 // DefaultConfig returns the baseline values that align with the design defaults.
 func DefaultConfig() Config {
 	return Config{
@@ -610,6 +1347,12 @@ func DefaultConfig() Config {
 				Address: "0.0.0.0",
 				Port:    8080,
 			},
+			Admin: AdminConfig{
+				Listen: ListenConfig{
+					Address: "127.0.0.1",
+					Port:    9090,
+				},
+			},
 			Logging: LoggingConfig{
 				Level:             "info",
 				Format:            "json",
@@ -617,6 +1360,9 @@ func DefaultConfig() Config {
 			},
 			Rules: RulesConfig{
 				RulesFolder: "./rules",
+				Reload: RulesReloadConfig{
+					HistorySize: 20,
+				},
 			},
 			Templates: TemplatesConfig{
 				TemplatesFolder: "./templates",
@@ -633,6 +1379,76 @@ func DefaultConfig() Config {
 	}
 }
 
+func validateCredentialStore(name string, store CredentialStoreConfig) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("config: server.credentialStores: empty store name")
+	}
+	if len(store.Users) == 0 && strings.TrimSpace(store.HtpasswdFile) == "" {
+		return fmt.Errorf("config: server.credentialStores.%s: users or htpasswdFile required", name)
+	}
+	for username, encoded := range store.Users {
+		if strings.TrimSpace(username) == "" {
+			return fmt.Errorf("config: server.credentialStores.%s.users: empty username", name)
+		}
+		if err := credentials.CheckHash(encoded); err != nil {
+			return fmt.Errorf("config: server.credentialStores.%s.users.%s: %w", name, username, err)
+		}
+	}
+	return nil
+}
+
+// validateDataset checks that a dataset names a supported table file and the
+// column its rows are indexed by.
+func validateDataset(name string, dataset DatasetConfig) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("config: server.datasets: empty dataset name")
+	}
+	file := strings.TrimSpace(dataset.File)
+	if file == "" {
+		return fmt.Errorf("config: server.datasets.%s.file required", name)
+	}
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".csv", ".yaml", ".yml", ".json":
+	default:
+		return fmt.Errorf("config: server.datasets.%s.file unsupported extension %q (expected .csv, .yaml, .yml, or .json)", name, filepath.Ext(file))
+	}
+	if strings.TrimSpace(dataset.Key) == "" {
+		return fmt.Errorf("config: server.datasets.%s.key required", name)
+	}
+	return nil
+}
+
+// validateDatabase checks that a database names a supported driver and exactly
+// one DSN source, and that a DSN secret is declared under server.variables.
+func validateDatabase(name string, database DatabaseConfig, secrets map[string]*string) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("config: server.databases: empty database name")
+	}
+	switch strings.ToLower(strings.TrimSpace(database.Driver)) {
+	case "postgres", "sqlite":
+	case "":
+		return fmt.Errorf("config: server.databases.%s.driver required", name)
+	default:
+		return fmt.Errorf("config: server.databases.%s.driver unsupported %q (expected postgres or sqlite)", name, database.Driver)
+	}
+	dsn := strings.TrimSpace(database.DSN)
+	secret := strings.TrimSpace(database.DSNSecret)
+	switch {
+	case dsn != "" && secret != "":
+		return fmt.Errorf("config: server.databases.%s: dsn and dsnSecret are mutually exclusive", name)
+	case dsn == "" && secret == "":
+		return fmt.Errorf("config: server.databases.%s: dsn or dsnSecret required", name)
+	case secret != "":
+		if _, ok := secrets[secret]; !ok {
+			return fmt.Errorf("config: server.databases.%s.dsnSecret: unknown secret %q (declare it under server.variables.secrets)", name, secret)
+		}
+	}
+	if database.MaxOpenConns < 0 {
+		return fmt.Errorf("config: server.databases.%s.maxOpenConns must not be negative", name)
+	}
+	return nil
+}
+
 func validateEndpointAuthentication(name string, auth EndpointAuthenticationConfig) error {
 	authorizationConfigured := false
 	for i, provider := range auth.Allow.Authorization {
@@ -125,6 +125,28 @@ func TestConfigValidate(t *testing.T) {
 		require.NoError(t, validVars.Validate())
 	})
 
+	t.Run("cache key expressions", func(t *testing.T) {
+		keyed := DefaultConfig()
+		keyed.Endpoints = map[string]EndpointConfig{
+			"public": {
+				Authentication: EndpointAuthenticationConfig{
+					Allow: EndpointAuthAllowConfig{None: true},
+				},
+				Cache: EndpointCacheConfig{Key: []string{`lookup(request.headers, "x-tenant")`}},
+			},
+		}
+		require.NoError(t, keyed.Validate())
+
+		blank := keyed
+		blank.Endpoints = map[string]EndpointConfig{
+			"public": {
+				Authentication: keyed.Endpoints["public"].Authentication,
+				Cache:          EndpointCacheConfig{Key: []string{" "}},
+			},
+		}
+		require.ErrorContains(t, blank.Validate(), `endpoint "public" cache.key[0] empty`)
+	})
+
 	// Test authorization header validation
 	t.Run("authorization header in backendApi headers forbidden", func(t *testing.T) {
 		authHeader := strPtr("Bearer token")
@@ -228,6 +250,454 @@ func TestConfigValidate(t *testing.T) {
 		}
 		require.NoError(t, validBackend.Validate())
 	})
+
+	t.Run("credential stores", func(t *testing.T) {
+		withStore := DefaultConfig()
+		withStore.Server.CredentialStores = map[string]CredentialStoreConfig{
+			"team": {Users: map[string]string{"alice": "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"}},
+		}
+		withStore.Rules = map[string]RuleConfig{
+			"basic-rule": {
+				Auth: []RuleAuthDirective{{
+					Match: []RuleAuthMatcher{{Type: "basic", Username: "alice", CredentialStore: "team"}},
+				}},
+			},
+		}
+		require.NoError(t, withStore.Validate())
+
+		plaintext := DefaultConfig()
+		plaintext.Server.CredentialStores = map[string]CredentialStoreConfig{
+			"team": {Users: map[string]string{"alice": "hunter2"}},
+		}
+		err := plaintext.Validate()
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "server.credentialStores.team.users.alice")
+
+		empty := DefaultConfig()
+		empty.Server.CredentialStores = map[string]CredentialStoreConfig{"team": {}}
+		require.ErrorContains(t, empty.Validate(), "users or htpasswdFile required")
+
+		withPassword := withStore
+		withPassword.Rules = map[string]RuleConfig{
+			"basic-rule": {
+				Auth: []RuleAuthDirective{{
+					Match: []RuleAuthMatcher{{Type: "basic", Password: "secret", CredentialStore: "team"}},
+				}},
+			},
+		}
+		require.ErrorContains(t, withPassword.Validate(), "cannot be combined with credentialStore")
+
+		wrongType := withStore
+		wrongType.Rules = map[string]RuleConfig{
+			"bearer-rule": {
+				Auth: []RuleAuthDirective{{
+					Match: []RuleAuthMatcher{{Type: "bearer", CredentialStore: "team"}},
+				}},
+			},
+		}
+		require.ErrorContains(t, wrongType.Validate(), "only valid for type basic")
+	})
+
+	t.Run("introspection backend", func(t *testing.T) {
+		valid := DefaultConfig()
+		valid.Rules = map[string]RuleConfig{
+			"introspect": {
+				BackendAPI: RuleBackendConfig{
+					Type: "introspection",
+					URL:  "https://idp.example.com/introspect",
+					Introspection: RuleIntrospectionConfig{
+						ClientAuth: RuleIntrospectionClientAuthConfig{ClientID: "passctrl", ClientSecret: "secret"},
+						Leeway:     "30s",
+					},
+				},
+			},
+		}
+		require.NoError(t, valid.Validate())
+
+		cases := map[string]struct {
+			mutate  func(*RuleBackendConfig)
+			message string
+		}{
+			"unknown type":      {func(b *RuleBackendConfig) { b.Type = "soap" }, `unsupported type "soap"`},
+			"missing url":       {func(b *RuleBackendConfig) { b.URL = "" }, "url: required for type introspection"},
+			"get method":        {func(b *RuleBackendConfig) { b.Method = "GET" }, "introspection always uses POST"},
+			"custom body":       {func(b *RuleBackendConfig) { b.Body = "token=x" }, "body and bodyFile are generated"},
+			"bad leeway":        {func(b *RuleBackendConfig) { b.Introspection.Leeway = "soon" }, "introspection.leeway: invalid duration"},
+			"missing client id": {func(b *RuleBackendConfig) { b.Introspection.ClientAuth.ClientID = "" }, "clientId: required unless method is none"},
+			"bad client auth":   {func(b *RuleBackendConfig) { b.Introspection.ClientAuth.Method = "private_key_jwt" }, `unsupported method "private_key_jwt"`},
+		}
+		for name, tc := range cases {
+			backend := valid.Rules["introspect"].BackendAPI
+			tc.mutate(&backend)
+			cfg := DefaultConfig()
+			cfg.Rules = map[string]RuleConfig{"introspect": {BackendAPI: backend}}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+
+		public := DefaultConfig()
+		public.Rules = map[string]RuleConfig{
+			"introspect": {
+				BackendAPI: RuleBackendConfig{
+					Type:          "introspection",
+					URL:           "https://idp.example.com/introspect",
+					Introspection: RuleIntrospectionConfig{ClientAuth: RuleIntrospectionClientAuthConfig{Method: "none"}},
+				},
+			},
+		}
+		require.NoError(t, public.Validate())
+	})
+
+	t.Run("ldap backend", func(t *testing.T) {
+		valid := DefaultConfig()
+		valid.Rules = map[string]RuleConfig{
+			"directory": {
+				BackendAPI: RuleBackendConfig{
+					Type: "ldap",
+					URL:  "ldap://ldap.example.com:389",
+					LDAP: RuleLDAPConfig{
+						StartTLS:     true,
+						BindDN:       "cn=passctrl,dc=example,dc=com",
+						BindPassword: "{{ .variables.secrets.ldap }}",
+						BaseDN:       "ou=people,dc=example,dc=com",
+						UserFilter:   "(sAMAccountName={username})",
+						Attributes:   []string{"mail"},
+						Groups:       RuleLDAPGroupsConfig{BaseDN: "ou=groups,dc=example,dc=com", Nested: true},
+					},
+				},
+			},
+		}
+		require.NoError(t, valid.Validate())
+
+		cases := map[string]struct {
+			mutate  func(*RuleBackendConfig)
+			message string
+		}{
+			"missing url":         {func(b *RuleBackendConfig) { b.URL = "" }, "url: required for type ldap"},
+			"http url":            {func(b *RuleBackendConfig) { b.URL = "https://ldap.example.com" }, "expected ldap:// or ldaps:// URL"},
+			"starttls with ldaps": {func(b *RuleBackendConfig) { b.URL = "ldaps://ldap.example.com" }, "startTLS: not valid with an ldaps:// URL"},
+			"custom method":       {func(b *RuleBackendConfig) { b.Method = "POST" }, "not used by type ldap"},
+			"headers":             {func(b *RuleBackendConfig) { b.Headers = map[string]*string{"x-api": nil} }, "not used by type ldap"},
+			"no bind mode":        {func(b *RuleBackendConfig) { b.LDAP.BaseDN = "" }, "userDN or baseDN required"},
+			"both bind modes":     {func(b *RuleBackendConfig) { b.LDAP.UserDN = "uid={username},dc=example,dc=com" }, "userDN and baseDN are mutually exclusive"},
+			"filter placeholder":  {func(b *RuleBackendConfig) { b.LDAP.UserFilter = "(uid=admin)" }, "userFilter: must contain {username}"},
+			"group placeholder":   {func(b *RuleBackendConfig) { b.LDAP.Groups.Filter = "(member=admin)" }, "groups.filter: must contain {dn}"},
+			"groups without base": {func(b *RuleBackendConfig) { b.LDAP.Groups.BaseDN = "" }, "groups.baseDN: required"},
+			"direct bind filter": {func(b *RuleBackendConfig) {
+				b.LDAP.BaseDN = ""
+				b.LDAP.UserDN = "uid={username},dc=example,dc=com"
+			}, "bindDN and userFilter require baseDN"},
+		}
+		for name, tc := range cases {
+			backend := valid.Rules["directory"].BackendAPI
+			tc.mutate(&backend)
+			cfg := DefaultConfig()
+			cfg.Rules = map[string]RuleConfig{"directory": {BackendAPI: backend}}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+
+		direct := DefaultConfig()
+		direct.Rules = map[string]RuleConfig{
+			"directory": {
+				BackendAPI: RuleBackendConfig{
+					Type: "ldap",
+					URL:  "ldaps://ldap.example.com",
+					LDAP: RuleLDAPConfig{UserDN: "uid={username},ou=people,dc=example,dc=com"},
+				},
+			},
+		}
+		require.NoError(t, direct.Validate())
+	})
+
+	t.Run("sql backend", func(t *testing.T) {
+		valid := DefaultConfig()
+		valid.Rules = map[string]RuleConfig{
+			"grants": {
+				BackendAPI: RuleBackendConfig{
+					Type: "sql",
+					SQL: RuleSQLConfig{
+						Database: "authz",
+						Query:    "SELECT role FROM grants WHERE user_id = $1",
+						Params:   []string{"{{ .auth.input.basic.user }}"},
+						MaxRows:  10,
+						Timeout:  "2s",
+					},
+					BodySchema: map[string]any{"type": "array"},
+				},
+			},
+		}
+		require.NoError(t, valid.Validate())
+
+		cases := map[string]struct {
+			mutate  func(*RuleBackendConfig)
+			message string
+		}{
+			"url":              {func(b *RuleBackendConfig) { b.URL = "postgres://db" }, "url: not used by type sql"},
+			"custom method":    {func(b *RuleBackendConfig) { b.Method = "POST" }, "not used by type sql"},
+			"query params":     {func(b *RuleBackendConfig) { b.Query = map[string]*string{"id": nil} }, "not used by type sql"},
+			"pagination":       {func(b *RuleBackendConfig) { b.Pagination.Type = "link-header" }, "pagination: not supported for type sql"},
+			"missing database": {func(b *RuleBackendConfig) { b.SQL.Database = "" }, "sql.database: required"},
+			"missing query":    {func(b *RuleBackendConfig) { b.SQL.Query = " " }, "sql.query: required"},
+			"negative rows":    {func(b *RuleBackendConfig) { b.SQL.MaxRows = -1 }, "sql.maxRows: must not be negative"},
+			"bad timeout":      {func(b *RuleBackendConfig) { b.SQL.Timeout = "soon" }, `sql.timeout: invalid duration "soon"`},
+		}
+		for name, tc := range cases {
+			backend := valid.Rules["grants"].BackendAPI
+			tc.mutate(&backend)
+			cfg := DefaultConfig()
+			cfg.Rules = map[string]RuleConfig{"grants": {BackendAPI: backend}}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+	})
+
+	t.Run("grpc backend", func(t *testing.T) {
+		valid := DefaultConfig()
+		valid.Rules = map[string]RuleConfig{
+			"policy": {
+				BackendAPI: RuleBackendConfig{
+					Type:    "grpc",
+					URL:     "grpcs://policy.internal:8443",
+					Headers: map[string]*string{"x-tenant": nil},
+					Body:    `{"subject": "{{ .auth.input.bearer.token }}"}`,
+					GRPC: RuleGRPCConfig{
+						Method:            "policy.v1.Authorizer/Check",
+						DescriptorSetFile: "protos/policy.pb",
+						TLS:               RuleGRPCTLSConfig{CAFile: "/etc/passctrl/ca.pem", CertFile: "client.pem", KeyFile: "client-key.pem"},
+					},
+				},
+			},
+		}
+		require.NoError(t, valid.Validate())
+
+		cases := map[string]struct {
+			mutate  func(*RuleBackendConfig)
+			message string
+		}{
+			"missing url":      {func(b *RuleBackendConfig) { b.URL = "" }, "url: required for type grpc"},
+			"http url":         {func(b *RuleBackendConfig) { b.URL = "https://policy.internal" }, "expected grpc:// or grpcs:// URL"},
+			"http method":      {func(b *RuleBackendConfig) { b.Method = "POST" }, "method: not used by type grpc"},
+			"query":            {func(b *RuleBackendConfig) { b.Query = map[string]*string{"id": nil} }, "query: not used by type grpc"},
+			"pagination":       {func(b *RuleBackendConfig) { b.Pagination.Type = "link-header" }, "pagination: not supported for type grpc"},
+			"missing method":   {func(b *RuleBackendConfig) { b.GRPC.Method = "" }, "grpc.method: expected package.Service/Method"},
+			"method only":      {func(b *RuleBackendConfig) { b.GRPC.Method = "Check" }, "grpc.method: expected package.Service/Method"},
+			"tls on plaintext": {func(b *RuleBackendConfig) { b.URL = "grpc://policy.internal:8080" }, "grpc.tls: requires a grpcs:// URL"},
+			"cert without key": {func(b *RuleBackendConfig) { b.GRPC.TLS.KeyFile = "" }, "certFile and keyFile must be set together"},
+		}
+		for name, tc := range cases {
+			backend := valid.Rules["policy"].BackendAPI
+			tc.mutate(&backend)
+			cfg := DefaultConfig()
+			cfg.Rules = map[string]RuleConfig{"policy": {BackendAPI: backend}}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+
+		plaintext := DefaultConfig()
+		plaintext.Rules = map[string]RuleConfig{
+			"policy": {BackendAPI: RuleBackendConfig{Type: "grpc", URL: "grpc://127.0.0.1:9090", GRPC: RuleGRPCConfig{Method: "/Authorizer/Check"}}},
+		}
+		require.NoError(t, plaintext.Validate())
+	})
+
+	t.Run("databases", func(t *testing.T) {
+		valid := DefaultConfig()
+		valid.Server.Variables.Secrets = map[string]*string{"authz_dsn": nil}
+		valid.Server.Databases = map[string]DatabaseConfig{
+			"authz": {Driver: "postgres", DSNSecret: "authz_dsn", MaxOpenConns: 8},
+			"local": {Driver: "sqlite", DSN: "file:authz.db?mode=ro"},
+		}
+		require.NoError(t, valid.Validate())
+
+		cases := map[string]struct {
+			database DatabaseConfig
+			message  string
+		}{
+			"missing driver": {DatabaseConfig{DSN: "authz.db"}, "server.databases.authz.driver required"},
+			"unknown driver": {DatabaseConfig{Driver: "mysql", DSN: "authz"}, `driver unsupported "mysql"`},
+			"no dsn":         {DatabaseConfig{Driver: "sqlite"}, "dsn or dsnSecret required"},
+			"both dsn":       {DatabaseConfig{Driver: "sqlite", DSN: "authz.db", DSNSecret: "authz_dsn"}, "dsn and dsnSecret are mutually exclusive"},
+			"unknown secret": {DatabaseConfig{Driver: "postgres", DSNSecret: "other"}, `dsnSecret: unknown secret "other"`},
+			"negative conns": {DatabaseConfig{Driver: "sqlite", DSN: "authz.db", MaxOpenConns: -1}, "maxOpenConns must not be negative"},
+		}
+		for name, tc := range cases {
+			t.Run(name, func(t *testing.T) {
+				cfg := DefaultConfig()
+				cfg.Server.Variables.Secrets = map[string]*string{"authz_dsn": nil}
+				cfg.Server.Databases = map[string]DatabaseConfig{"authz": tc.database}
+				require.ErrorContains(t, cfg.Validate(), tc.message)
+			})
+		}
+	})
+
+	t.Run("backend body schema", func(t *testing.T) {
+		schema := map[string]any{"type": "object"}
+		valid := DefaultConfig()
+		valid.Rules = map[string]RuleConfig{
+			"typed": {BackendAPI: RuleBackendConfig{URL: "https://api.example.com", BodySchema: schema}},
+		}
+		require.NoError(t, valid.Validate())
+
+		both := DefaultConfig()
+		both.Rules = map[string]RuleConfig{
+			"typed": {BackendAPI: RuleBackendConfig{URL: "https://api.example.com", BodySchema: schema, BodySchemaFile: "schemas/account.json"}},
+		}
+		require.ErrorContains(t, both.Validate(), "bodySchema and bodySchemaFile are mutually exclusive")
+
+		noURL := DefaultConfig()
+		noURL.Rules = map[string]RuleConfig{
+			"typed": {BackendAPI: RuleBackendConfig{BodySchemaFile: "schemas/account.json"}},
+		}
+		require.ErrorContains(t, noURL.Validate(), "url: required when a body schema is declared")
+	})
+
+	t.Run("datasets", func(t *testing.T) {
+		valid := DefaultConfig()
+		valid.Server.Datasets = map[string]DatasetConfig{"tenants": {File: "data/tenants.csv", Key: "id"}}
+		require.NoError(t, valid.Validate())
+
+		cases := map[string]struct {
+			dataset DatasetConfig
+			message string
+		}{
+			"missing file":  {DatasetConfig{Key: "id"}, "server.datasets.tenants.file required"},
+			"bad extension": {DatasetConfig{File: "tenants.xlsx", Key: "id"}, `unsupported extension ".xlsx"`},
+			"missing key":   {DatasetConfig{File: "tenants.yaml"}, "server.datasets.tenants.key required"},
+		}
+		for name, tc := range cases {
+			t.Run(name, func(t *testing.T) {
+				cfg := DefaultConfig()
+				cfg.Server.Datasets = map[string]DatasetConfig{"tenants": tc.dataset}
+				require.ErrorContains(t, cfg.Validate(), tc.message)
+			})
+		}
+	})
+
+	t.Run("api key stores", func(t *testing.T) {
+		withStore := DefaultConfig()
+		withStore.Server.APIKeyStores = map[string]APIKeyStoreConfig{
+			"partners": {File: "keys.yaml"},
+		}
+		withStore.Rules = map[string]RuleConfig{
+			"key-rule": {
+				Auth: []RuleAuthDirective{{
+					Match: []RuleAuthMatcher{{Type: "apiKey", Name: "X-Api-Key", KeyStore: "partners"}},
+				}},
+			},
+		}
+		require.NoError(t, withStore.Validate())
+
+		missingFile := DefaultConfig()
+		missingFile.Server.APIKeyStores = map[string]APIKeyStoreConfig{"partners": {}}
+		require.ErrorContains(t, missingFile.Validate(), "server.apiKeyStores.partners.file required")
+
+		cases := map[string]struct {
+			matcher RuleAuthMatcher
+			message string
+		}{
+			"missing store":       {RuleAuthMatcher{Type: "apiKey", Name: "X-Api-Key"}, "keyStore: required for type apiKey"},
+			"missing name":        {RuleAuthMatcher{Type: "apiKey", In: "query", KeyStore: "partners"}, "name: required for type apiKey in query"},
+			"bad location":        {RuleAuthMatcher{Type: "apiKey", In: "cookie", KeyStore: "partners"}, `unsupported location "cookie"`},
+			"value constraint":    {RuleAuthMatcher{Type: "apiKey", Name: "X-Api-Key", KeyStore: "partners", Value: []string{"abc"}}, "value constraints not valid for type apiKey"},
+			"store on non-key":    {RuleAuthMatcher{Type: "bearer", KeyStore: "partners"}, "keyStore and in only valid for type apiKey"},
+			"bearer without name": {RuleAuthMatcher{Type: "apiKey", In: "bearer", KeyStore: "partners"}, ""},
+		}
+		for name, tc := range cases {
+			cfg := withStore
+			cfg.Rules = map[string]RuleConfig{
+				"key-rule": {Auth: []RuleAuthDirective{{Match: []RuleAuthMatcher{tc.matcher}}}},
+			}
+			if tc.message == "" {
+				require.NoError(t, cfg.Validate(), name)
+				continue
+			}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+	})
+
+	t.Run("listener tls", func(t *testing.T) {
+		base := ListenTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key"}
+		cases := map[string]struct {
+			tls     ListenTLSConfig
+			message string
+		}{
+			"disabled":             {ListenTLSConfig{}, ""},
+			"cert and key":         {base, ""},
+			"mtls":                 {ListenTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt", ClientAuth: "optional", MinVersion: "1.3"}, ""},
+			"cert without key":     {ListenTLSConfig{CertFile: "tls.crt"}, "certFile and keyFile must be set together"},
+			"settings without tls": {ListenTLSConfig{ClientCAFile: "ca.crt"}, "requires certFile and keyFile"},
+			"old min version":      {ListenTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", MinVersion: "1.0"}, `minVersion unsupported: "1.0"`},
+			"client auth no ca":    {ListenTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientAuth: "require"}, "clientAuth requires clientCAFile"},
+			"bad client auth":      {ListenTLSConfig{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt", ClientAuth: "request"}, `clientAuth unsupported: "request"`},
+		}
+		for name, tc := range cases {
+			cfg := DefaultConfig()
+			cfg.Server.Listen.TLS = tc.tls
+			if tc.message == "" {
+				require.NoError(t, cfg.Validate(), name)
+				continue
+			}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+	})
+
+	t.Run("rules reload history size", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.Server.Rules.Reload.HistorySize = -1
+		require.ErrorContains(t, cfg.Validate(), "server.rules.reload.historySize invalid: -1")
+	})
+
+	t.Run("health probes", func(t *testing.T) {
+		cases := map[string]struct {
+			mutate  func(*Config)
+			message string
+		}{
+			"intervals":    {func(c *Config) { c.Server.Health = HealthConfig{Interval: "15s", Timeout: "1s"} }, ""},
+			"bad interval": {func(c *Config) { c.Server.Health.Interval = "often" }, `server.health.interval invalid: "often"`},
+			"zero timeout": {func(c *Config) { c.Server.Health.Timeout = "0s" }, `server.health.timeout invalid: "0s"`},
+			"probe url": {func(c *Config) {
+				c.Rules = map[string]RuleConfig{"lookup": {BackendAPI: RuleBackendConfig{HealthProbe: RuleHealthProbeConfig{URL: "https://users.internal/healthz", Critical: true}}}}
+			}, ""},
+			"relative probe url": {func(c *Config) {
+				c.Rules = map[string]RuleConfig{"lookup": {BackendAPI: RuleBackendConfig{HealthProbe: RuleHealthProbeConfig{URL: "/healthz"}}}}
+			}, "rules[lookup].backendApi.healthProbe.url: absolute http(s) URL required"},
+			"critical without url": {func(c *Config) {
+				c.Rules = map[string]RuleConfig{"lookup": {BackendAPI: RuleBackendConfig{HealthProbe: RuleHealthProbeConfig{Critical: true}}}}
+			}, "healthProbe.url: required when critical is set"},
+		}
+		for name, tc := range cases {
+			cfg := DefaultConfig()
+			tc.mutate(&cfg)
+			if tc.message == "" {
+				require.NoError(t, cfg.Validate(), name)
+				continue
+			}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+	})
+
+	t.Run("admin listener", func(t *testing.T) {
+		cases := map[string]struct {
+			mutate  func(*AdminConfig)
+			message string
+		}{
+			"default":               {func(*AdminConfig) {}, ""},
+			"disabled":              {func(a *AdminConfig) { a.Listen.Port = 0 }, ""},
+			"bearer token":          {func(a *AdminConfig) { a.Auth.BearerToken = "ops" }, ""},
+			"negative port":         {func(a *AdminConfig) { a.Listen.Port = -1 }, "server.admin.listen.port invalid"},
+			"shares public port":    {func(a *AdminConfig) { a.Listen.Port = 8080 }, "conflicts with server.listen.port"},
+			"shared port combined":  {func(a *AdminConfig) { a.Listen.Port = 8080; a.CombinedListener = true }, "conflicts with server.listen.port"},
+			"combined without port": {func(a *AdminConfig) { a.Listen.Port = 0; a.CombinedListener = true }, ""},
+			"auth without listener": {func(a *AdminConfig) { a.Listen.Port = 0; a.Auth.BearerToken = "ops" }, "server.admin.listen.port required"},
+			"tls without key":       {func(a *AdminConfig) { a.Listen.TLS.CertFile = "admin.crt" }, "server.admin.listen.tls.certFile and keyFile"},
+		}
+		for name, tc := range cases {
+			cfg := DefaultConfig()
+			tc.mutate(&cfg.Server.Admin)
+			if tc.message == "" {
+				require.NoError(t, cfg.Validate(), name)
+				continue
+			}
+			require.ErrorContains(t, cfg.Validate(), tc.message, name)
+		}
+	})
 }
 
 func strPtr(s string) *string {
@@ -238,8 +708,13 @@ func TestDefaultConfigValues(t *testing.T) {
 	cfg := DefaultConfig()
 	require.Equal(t, "0.0.0.0", cfg.Server.Listen.Address)
 	require.Equal(t, 8080, cfg.Server.Listen.Port)
+	require.Equal(t, "127.0.0.1", cfg.Server.Admin.Listen.Address)
+	require.Equal(t, 9090, cfg.Server.Admin.Listen.Port)
+	require.False(t, cfg.Server.Admin.CombinedListener)
 	require.Equal(t, "info", cfg.Server.Logging.Level)
 	require.Equal(t, "./rules", cfg.Server.Rules.RulesFolder)
+	require.Equal(t, 20, cfg.Server.Rules.Reload.HistorySize)
+	require.False(t, cfg.Server.Rules.Reload.AllowDegraded)
 	require.Equal(t, "./templates", cfg.Server.Templates.TemplatesFolder)
 	require.Empty(t, cfg.Server.Variables.Environment)
 	require.Empty(t, cfg.LoadedEnvironment)
@@ -12,9 +12,20 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
-// RulesWatcher monitors the configured rules source (file or folder) and invokes
-// the supplied callback whenever definitions change. Stop must be called to
-// release filesystem resources.
+// BundleLoadError reports a rule bundle that could not be rebuilt after a
+// change, as opposed to a failure of the watcher itself. The previously loaded
+// bundle stays active.
+type BundleLoadError struct {
+	Err error
+}
+
+func (e *BundleLoadError) Error() string { return e.Err.Error() }
+
+func (e *BundleLoadError) Unwrap() error { return e.Err }
+
+// RulesWatcher monitors the configured rules sources (file or folder, plus a
+// remote URL) and invokes the supplied callback whenever definitions change.
+// Stop must be called to release filesystem resources.
 type RulesWatcher struct {
 	cancel context.CancelFunc
 	done   chan struct{}
@@ -33,13 +44,14 @@ func (w *RulesWatcher) Stop() {
 }
 
 // WatchRules wires fsnotify around the configured rules source and reloads the
-// bundle on any relevant change. The provided config should come from Loader.Load
-// so InlineEndpoints and InlineRules are already captured.
+// bundle on any relevant change. A remote source is polled on its own interval
+// and reloads the bundle when the document changes. The provided config should
+// come from Loader.Load so InlineEndpoints and InlineRules are already captured.
 func (l *Loader) WatchRules(ctx context.Context, cfg Config, onChange func(RuleBundle), onError func(error)) (*RulesWatcher, error) {
 	if onChange == nil {
 		return nil, fmt.Errorf("config: watch rules requires a change callback")
 	}
-	if cfg.Server.Rules.RulesFile == "" && cfg.Server.Rules.RulesFolder == "" {
+	if cfg.Server.Rules.RulesFile == "" && cfg.Server.Rules.RulesFolder == "" && !cfg.Server.Rules.Remote.Enabled() {
 		return nil, fmt.Errorf("config: no rules source configured for watching")
 	}
 
@@ -53,7 +65,14 @@ func (l *Loader) WatchRules(ctx context.Context, cfg Config, onChange func(RuleB
 	inlineEndpoints := cloneEndpointMap(cfg.InlineEndpoints)
 	inlineRules := cloneRuleMap(cfg.InlineRules)
 
-	bundle, err := buildRuleBundle(watchCtx, inlineEndpoints, inlineRules, cfg.Server.Rules)
+	remote, err := openRemoteRuleSource(watchCtx, cfg.Server.Rules.Remote)
+	if err == nil {
+		var bundle RuleBundle
+		bundle, err = buildRuleBundle(watchCtx, inlineEndpoints, inlineRules, cfg.Server, remote)
+		if err == nil {
+			onChange(bundle)
+		}
+	}
 	if err != nil {
 		if closeErr := watcher.Close(); closeErr != nil && onError != nil {
 			onError(fmt.Errorf("config: watch rules close: %w", closeErr))
@@ -61,7 +80,6 @@ func (l *Loader) WatchRules(ctx context.Context, cfg Config, onChange func(RuleB
 		cancel()
 		return nil, err
 	}
-	onChange(bundle)
 
 	done := make(chan struct{})
 	watch := &RulesWatcher{cancel: cancel, done: done}
@@ -83,13 +101,13 @@ func (l *Loader) WatchRules(ctx context.Context, cfg Config, onChange func(RuleB
 		reload := func() {
 			reloadMu.Lock()
 			defer reloadMu.Unlock()
-			bundle, err := buildRuleBundle(watchCtx, inlineEndpoints, inlineRules, cfg.Server.Rules)
+			bundle, err := buildRuleBundle(watchCtx, inlineEndpoints, inlineRules, cfg.Server, remote)
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
 					return
 				}
 				if onError != nil {
-					onError(err)
+					onError(&BundleLoadError{Err: err})
 				}
 				return
 			}
@@ -121,7 +139,7 @@ func (l *Loader) WatchRules(ctx context.Context, cfg Config, onChange func(RuleB
 			}
 			targetFile = filepath.Clean(resolved)
 			addDir(filepath.Dir(targetFile))
-		} else {
+		} else if cfg.Server.Rules.RulesFolder != "" {
 			root, err := filepath.Abs(cfg.Server.Rules.RulesFolder)
 			if err != nil {
 				if onError != nil {
@@ -180,6 +198,13 @@ func (l *Loader) WatchRules(ctx context.Context, cfg Config, onChange func(RuleB
 		}
 		defer flushTimer()
 
+		var pollSignal <-chan time.Time
+		if remote != nil {
+			poll := time.NewTicker(remote.pollInterval())
+			defer poll.Stop()
+			pollSignal = poll.C
+		}
+
 		for {
 			select {
 			case <-watchCtx.Done():
@@ -187,6 +212,14 @@ func (l *Loader) WatchRules(ctx context.Context, cfg Config, onChange func(RuleB
 			case <-reloadSignal:
 				flushTimer()
 				reload()
+			case <-pollSignal:
+				changed, err := remote.refresh(watchCtx)
+				if err != nil && !errors.Is(err, context.Canceled) && onError != nil {
+					onError(err)
+				}
+				if changed {
+					reload()
+				}
 			case event, ok := <-watcher.Events:
 				if !ok {
 					return
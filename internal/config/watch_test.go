@@ -114,3 +114,34 @@ func TestWatchRulesFolderReloads(t *testing.T) {
 		require.FailNow(t, "timeout waiting for folder reload event")
 	}
 }
+
+func TestWatchRulesReportsBundleLoadErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	rulesFile := filepath.Join(dir, "rules.yaml")
+	require.NoError(t, os.WriteFile(rulesFile, []byte("rules:\n  file-rule:\n    description: v1\n"), 0o600))
+	serverCfg := filepath.Join(dir, "server.yaml")
+	require.NoError(t, os.WriteFile(serverCfg, []byte(fmt.Sprintf("server:\n  rules:\n    rulesFolder: \"\"\n    rulesFile: %s\n", rulesFile)), 0o600))
+
+	loader := NewLoader("PASSCTRL", serverCfg)
+	cfg, err := loader.Load(ctx)
+	require.NoError(t, err)
+
+	errCh := make(chan error, 4)
+	watcher, err := loader.WatchRules(ctx, cfg, func(RuleBundle) {}, func(err error) {
+		errCh <- err
+	})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	require.NoError(t, os.WriteFile(rulesFile, []byte("rules: [\n"), 0o600))
+	select {
+	case err := <-errCh:
+		var loadErr *BundleLoadError
+		require.ErrorAs(t, err, &loadErr)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for bundle load error")
+	}
+}
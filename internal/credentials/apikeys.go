@@ -0,0 +1,220 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	kjson "github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/providers/file"
+	"github.com/knadh/koanf/v2"
+)
+
+// APIKey is a single registry entry. Only the SHA-256 digest of the key is
+// stored; the presented value is hashed before lookup.
+type APIKey struct {
+	ID        string
+	Owner     string
+	Scopes    []string
+	ExpiresAt time.Time
+	Disabled  bool
+}
+
+// Metadata renders the key for templates and CEL as `auth.input.key`.
+func (k APIKey) Metadata() map[string]any {
+	scopes := make([]string, len(k.Scopes))
+	copy(scopes, k.Scopes)
+	meta := map[string]any{
+		"id":     k.ID,
+		"owner":  k.Owner,
+		"scopes": scopes,
+	}
+	if !k.ExpiresAt.IsZero() {
+		meta["expiresAt"] = k.ExpiresAt
+	}
+	return meta
+}
+
+func (k APIKey) label() string {
+	switch {
+	case k.ID != "":
+		return k.ID
+	case k.Owner != "":
+		return k.Owner
+	default:
+		return "unnamed"
+	}
+}
+
+type apiKeyDocument struct {
+	Keys []apiKeyEntry `koanf:"keys"`
+}
+
+type apiKeyEntry struct {
+	ID        string   `koanf:"id"`
+	Hash      string   `koanf:"hash"`
+	Owner     string   `koanf:"owner"`
+	Scopes    []string `koanf:"scopes"`
+	ExpiresAt any      `koanf:"expiresAt"`
+	Disabled  bool     `koanf:"disabled"`
+}
+
+// APIKeyStore indexes API keys by SHA-256 digest. Lookups read an immutable
+// snapshot so reloads never block request evaluation.
+type APIKeyStore struct {
+	name string
+	file string
+	now  func() time.Time
+	keys atomic.Pointer[map[[sha256.Size]byte]APIKey]
+}
+
+// NewAPIKeyStore loads the YAML or JSON key file. file must already be
+// resolved against the template sandbox.
+func NewAPIKeyStore(name, file string) (*APIKeyStore, error) {
+	if strings.TrimSpace(file) == "" {
+		return nil, fmt.Errorf("credentials: api key store %q: file required", name)
+	}
+	store := &APIKeyStore{name: name, file: file, now: time.Now}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Name returns the configured store name.
+func (s *APIKeyStore) Name() string { return s.name }
+
+// File returns the resolved key file path.
+func (s *APIKeyStore) File() string { return s.file }
+
+// Reload re-reads the key file and swaps the snapshot. On error the previous
+// snapshot stays active.
+func (s *APIKeyStore) Reload() error {
+	keys, err := loadAPIKeyFile(s.file)
+	if err != nil {
+		return fmt.Errorf("credentials: api key store %q: %s: %w", s.name, s.file, err)
+	}
+	s.keys.Store(&keys)
+	return nil
+}
+
+// Len returns the number of keys in the active snapshot.
+func (s *APIKeyStore) Len() int {
+	if s == nil {
+		return 0
+	}
+	snapshot := s.keys.Load()
+	if snapshot == nil {
+		return 0
+	}
+	return len(*snapshot)
+}
+
+// ResolveKey hashes the presented key and returns its metadata when the key
+// is known, enabled, and unexpired. Otherwise it returns a fail reason that
+// names the key (never the secret) so operators can tell revocations apart.
+func (s *APIKeyStore) ResolveKey(presented string) (map[string]any, string, bool) {
+	if s == nil || presented == "" {
+		return nil, "api key not recognized", false
+	}
+	snapshot := s.keys.Load()
+	if snapshot == nil {
+		return nil, "api key not recognized", false
+	}
+	key, ok := (*snapshot)[sha256.Sum256([]byte(presented))]
+	if !ok {
+		return nil, "api key not recognized", false
+	}
+	if key.Disabled {
+		return nil, fmt.Sprintf("api key %s disabled", key.label()), false
+	}
+	if !key.ExpiresAt.IsZero() && !s.now().Before(key.ExpiresAt) {
+		return nil, fmt.Sprintf("api key %s expired at %s", key.label(), key.ExpiresAt.UTC().Format(time.RFC3339)), false
+	}
+	return key.Metadata(), "", true
+}
+
+func loadAPIKeyFile(path string) (map[[sha256.Size]byte]APIKey, error) {
+	var parser koanf.Parser
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parser = yaml.Parser()
+	case ".json":
+		parser = kjson.Parser()
+	default:
+		return nil, fmt.Errorf("unsupported key file extension %q", filepath.Ext(path))
+	}
+	k := koanf.New(".")
+	if err := k.Load(file.Provider(path), parser); err != nil {
+		return nil, err
+	}
+	var doc apiKeyDocument
+	if err := k.Unmarshal("", &doc); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	keys := make(map[[sha256.Size]byte]APIKey, len(doc.Keys))
+	for i, entry := range doc.Keys {
+		digest, err := parseSHA256Hex(entry.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("keys[%d].hash: %w", i, err)
+		}
+		if _, exists := keys[digest]; exists {
+			return nil, fmt.Errorf("keys[%d].hash: duplicate key", i)
+		}
+		key := APIKey{
+			ID:       strings.TrimSpace(entry.ID),
+			Owner:    strings.TrimSpace(entry.Owner),
+			Scopes:   entry.Scopes,
+			Disabled: entry.Disabled,
+		}
+		key.ExpiresAt, err = parseExpiry(entry.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("keys[%d].expiresAt: %w", i, err)
+		}
+		keys[digest] = key
+	}
+	return keys, nil
+}
+
+// parseExpiry accepts RFC 3339 strings as well as timestamps the YAML parser
+// has already decoded.
+func parseExpiry(value any) (time.Time, error) {
+	switch v := value.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return time.Time{}, nil
+		}
+		return time.Parse(time.RFC3339, strings.TrimSpace(v))
+	default:
+		return time.Time{}, fmt.Errorf("expected RFC 3339 timestamp, got %T", value)
+	}
+}
+
+// parseSHA256Hex accepts a hex digest with an optional `sha256:` prefix.
+func parseSHA256Hex(value string) ([sha256.Size]byte, error) {
+	var digest [sha256.Size]byte
+	trimmed := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "sha256:")
+	if trimmed == "" {
+		return digest, errors.New("required")
+	}
+	decoded, err := hex.DecodeString(trimmed)
+	if err != nil {
+		return digest, fmt.Errorf("invalid hex: %w", err)
+	}
+	if len(decoded) != sha256.Size {
+		return digest, fmt.Errorf("expected %d-byte sha256 digest, got %d bytes", sha256.Size, len(decoded))
+	}
+	copy(digest[:], decoded)
+	return digest, nil
+}
@@ -0,0 +1,98 @@
+package credentials
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestAPIKeyStoreResolveKey(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "keys.yaml")
+	contents := "keys:\n" +
+		"  - id: partner-a\n    hash: " + sha256Hex("key-a") + "\n    owner: Partner A\n    scopes: [read, write]\n    expiresAt: \"2030-01-01T00:00:00Z\"\n" +
+		"  - id: partner-b\n    hash: sha256:" + sha256Hex("key-b") + "\n    owner: Partner B\n    disabled: true\n" +
+		"  - id: partner-c\n    hash: " + sha256Hex("key-c") + "\n    expiresAt: \"2020-01-01T00:00:00Z\"\n"
+	require.NoError(t, os.WriteFile(file, []byte(contents), 0o600))
+
+	store, err := NewAPIKeyStore("partners", file)
+	require.NoError(t, err)
+	store.now = func() time.Time { return time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC) }
+	require.Equal(t, 3, store.Len())
+
+	meta, reason, ok := store.ResolveKey("key-a")
+	require.True(t, ok)
+	require.Empty(t, reason)
+	require.Equal(t, "partner-a", meta["id"])
+	require.Equal(t, "Partner A", meta["owner"])
+	require.Equal(t, []string{"read", "write"}, meta["scopes"])
+	require.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), meta["expiresAt"])
+
+	_, reason, ok = store.ResolveKey("key-b")
+	require.False(t, ok)
+	require.Equal(t, "api key partner-b disabled", reason)
+
+	_, reason, ok = store.ResolveKey("key-c")
+	require.False(t, ok)
+	require.Equal(t, "api key partner-c expired at 2020-01-01T00:00:00Z", reason)
+
+	_, reason, ok = store.ResolveKey("unknown")
+	require.False(t, ok)
+	require.Equal(t, "api key not recognized", reason)
+}
+
+func TestAPIKeyStoreRejectsInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		filename string
+		contents string
+		errText  string
+	}{
+		{name: "bad hash", filename: "keys.yaml", contents: "keys:\n  - hash: nothex\n", errText: "keys[0].hash"},
+		{name: "short hash", filename: "keys.json", contents: `{"keys":[{"hash":"abcd"}]}`, errText: "expected 32-byte"},
+		{name: "duplicate", filename: "dup.yaml", contents: "keys:\n  - hash: " + sha256Hex("a") + "\n  - hash: " + sha256Hex("a") + "\n", errText: "duplicate key"},
+		{name: "bad expiry", filename: "exp.yaml", contents: "keys:\n  - hash: " + sha256Hex("a") + "\n    expiresAt: tomorrow\n", errText: "keys[0].expiresAt"},
+		{name: "unsupported extension", filename: "keys.txt", contents: "", errText: "unsupported key file extension"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(dir, tc.filename)
+			require.NoError(t, os.WriteFile(file, []byte(tc.contents), 0o600))
+			_, err := NewAPIKeyStore("partners", file)
+			require.ErrorContains(t, err, tc.errText)
+		})
+	}
+}
+
+func TestAPIKeyStoreReloadKeepsLastGoodSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "keys.json")
+	require.NoError(t, os.WriteFile(file, []byte(`{"keys":[{"id":"a","hash":"`+sha256Hex("key-a")+`"}]}`), 0o600))
+
+	store, err := NewAPIKeyStore("partners", file)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(file, []byte(`{"keys":[{"hash":"broken"}]}`), 0o600))
+	require.Error(t, store.Reload())
+	_, _, ok := store.ResolveKey("key-a")
+	require.True(t, ok)
+
+	require.NoError(t, os.WriteFile(file, []byte(`{"keys":[{"id":"a","hash":"`+sha256Hex("key-a")+`","disabled":true}]}`), 0o600))
+	require.NoError(t, store.Reload())
+	_, reason, ok := store.ResolveKey("key-a")
+	require.False(t, ok)
+	require.Equal(t, "api key a disabled", reason)
+}
@@ -0,0 +1,155 @@
+package credentials
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrUnsupportedHash reports a stored password that is not encoded with one of
+// the recognised schemes. Plaintext entries fall into this bucket on purpose so
+// operators cannot accidentally store clear passwords.
+var ErrUnsupportedHash = errors.New("unsupported password hash (expected bcrypt, argon2id, or sha-crypt)")
+
+// CheckHash validates that the encoded hash uses a supported scheme and is
+// well-formed. It does not verify any password.
+func CheckHash(encoded string) error {
+	switch {
+	case isBcrypt(encoded):
+		_, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return fmt.Errorf("bcrypt: %w", err)
+		}
+		return nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		_, err := parseArgon2id(encoded)
+		return err
+	case strings.HasPrefix(encoded, "$5$"), strings.HasPrefix(encoded, "$6$"):
+		_, err := parseShaCrypt(encoded)
+		return err
+	default:
+		return ErrUnsupportedHash
+	}
+}
+
+// VerifyPassword reports whether password matches the encoded hash. Digest
+// comparisons run in constant time; malformed or unsupported hashes never match.
+func VerifyPassword(encoded, password string) bool {
+	switch {
+	case isBcrypt(encoded):
+		return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		params, err := parseArgon2id(encoded)
+		if err != nil {
+			return false
+		}
+		derived := argon2.IDKey([]byte(password), params.salt, params.time, params.memory, params.threads, uint32(len(params.key))) // #nosec G115 -- key length bounded by the decoded hash
+		return subtle.ConstantTimeCompare(derived, params.key) == 1
+	case strings.HasPrefix(encoded, "$5$"), strings.HasPrefix(encoded, "$6$"):
+		params, err := parseShaCrypt(encoded)
+		if err != nil {
+			return false
+		}
+		return subtle.ConstantTimeCompare([]byte(params.crypt(password)), []byte(params.digest)) == 1
+	default:
+		return false
+	}
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// burnVerification performs a bcrypt comparison against a throwaway hash so
+// lookups for unknown users cost roughly the same as lookups for known ones.
+func burnVerification(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("passctrl-dummy"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+type argon2idParams struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// parseArgon2id decodes the PHC string format emitted by the reference
+// implementation: $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>.
+func parseArgon2id(encoded string) (argon2idParams, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return argon2idParams{}, errors.New("argon2id: malformed hash")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return argon2idParams{}, fmt.Errorf("argon2id: version: %w", err)
+	}
+	if version != argon2.Version {
+		return argon2idParams{}, fmt.Errorf("argon2id: unsupported version %d", version)
+	}
+	var params argon2idParams
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return argon2idParams{}, fmt.Errorf("argon2id: parameters: %w", err)
+	}
+	if params.memory == 0 || params.time == 0 || params.threads == 0 {
+		return argon2idParams{}, errors.New("argon2id: parameters must be positive")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2idParams{}, fmt.Errorf("argon2id: salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return argon2idParams{}, fmt.Errorf("argon2id: hash: %w", err)
+	}
+	if len(key) == 0 {
+		return argon2idParams{}, errors.New("argon2id: empty hash")
+	}
+	params.salt = salt
+	params.key = key
+	return params, nil
+}
+
+// parseShaCrypt splits a $5$/$6$ hash into its algorithm, rounds, salt, and
+// encoded digest.
+func parseShaCrypt(encoded string) (shaCryptParams, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 && len(parts) != 5 {
+		return shaCryptParams{}, errors.New("sha-crypt: malformed hash")
+	}
+	params := shaCryptParams{id: parts[1], rounds: shaCryptDefaultRounds}
+	saltIndex := 2
+	if len(parts) == 5 {
+		if !strings.HasPrefix(parts[2], "rounds=") {
+			return shaCryptParams{}, errors.New("sha-crypt: malformed rounds")
+		}
+		rounds, err := strconv.Atoi(strings.TrimPrefix(parts[2], "rounds="))
+		if err != nil {
+			return shaCryptParams{}, fmt.Errorf("sha-crypt: rounds: %w", err)
+		}
+		params.rounds = rounds
+		saltIndex = 3
+	}
+	params.salt = parts[saltIndex]
+	params.digest = parts[saltIndex+1]
+	if params.digest == "" {
+		return shaCryptParams{}, errors.New("sha-crypt: empty hash")
+	}
+	return params, nil
+}
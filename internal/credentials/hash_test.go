@@ -0,0 +1,58 @@
+package credentials
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	salt := []byte("0123456789abcdef")
+	key := argon2.IDKey([]byte("s3cret"), salt, 1, 8*1024, 1, 32)
+	argonHash := fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 8*1024, 1, 1,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+
+	tests := []struct {
+		name     string
+		encoded  string
+		password string
+		want     bool
+	}{
+		{name: "bcrypt match", encoded: string(bcryptHash), password: "s3cret", want: true},
+		{name: "bcrypt mismatch", encoded: string(bcryptHash), password: "wrong", want: false},
+		{name: "argon2id match", encoded: argonHash, password: "s3cret", want: true},
+		{name: "argon2id mismatch", encoded: argonHash, password: "wrong", want: false},
+		{name: "sha256-crypt match", encoded: "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5", password: "Hello world!", want: true},
+		{name: "sha256-crypt explicit rounds", encoded: "$5$rounds=10000$saltstringsaltst$3xv.VbSHBb41AL9AvLeujZkZRBAwqFMz2.opqey6IcA", password: "Hello world!", want: true},
+		{name: "sha256-crypt rounds below minimum", encoded: "$5$rounds=10$roundstoolow$yfvwcWrQ8l/K0DAWyuPMDNHpIVlTQebY9l/gL972bIC", password: "the minimum number is still observed", want: true},
+		{name: "sha256-crypt long salt", encoded: "$5$rounds=5000$toolongsaltstring$Un/5jzAHMgOGZ5.mWJpuVolil07guHPvOW8mGRcvxa5", password: "This is just a test", want: true},
+		{name: "sha256-crypt mismatch", encoded: "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5", password: "hello world!", want: false},
+		{name: "sha512-crypt match", encoded: "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1", password: "Hello world!", want: true},
+		{name: "sha512-crypt rounds below minimum", encoded: "$6$rounds=10$roundstoolow$kUMsbe306n21p9R.FRkW3IGn.S9NPN0x50YhH1xhLsPuWGsUSklZt58jaTfF4ZEQpyUNGc0dqbpBYYBaHHrsX.", password: "the minimum number is still observed", want: true},
+		{name: "sha512-crypt mismatch", encoded: "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1", password: "nope", want: false},
+		{name: "plaintext never matches", encoded: "s3cret", password: "s3cret", want: false},
+		{name: "malformed argon2id", encoded: "$argon2id$v=19$broken", password: "s3cret", want: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, VerifyPassword(tc.encoded, tc.password))
+		})
+	}
+}
+
+func TestCheckHash(t *testing.T) {
+	require.NoError(t, CheckHash("$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"))
+	require.NoError(t, CheckHash("$2y$05$CCCCCCCCCCCCCCCCCCCCC.VGOzA784oUp/Z0DY336zx7pLYAy0lwK"))
+	require.ErrorIs(t, CheckHash("plaintext"), ErrUnsupportedHash)
+	require.ErrorIs(t, CheckHash("{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g="), ErrUnsupportedHash)
+	require.Error(t, CheckHash("$argon2id$v=19$m=0,t=1,p=1$c2FsdA$aGFzaA"))
+	require.Error(t, CheckHash("$6$saltonly"))
+}
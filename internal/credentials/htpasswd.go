@@ -0,0 +1,40 @@
+package credentials
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseHtpasswd reads `user:hash` lines in the Apache htpasswd format. Blank
+// lines and `#` comments are ignored. Every hash must use a supported scheme;
+// the first invalid entry aborts the parse so a half-loaded file never
+// replaces a healthy one.
+func ParseHtpasswd(r io.Reader) (map[string]string, error) {
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		username, encoded, ok := strings.Cut(text, ":")
+		if !ok || username == "" || encoded == "" {
+			return nil, fmt.Errorf("line %d: expected user:hash", line)
+		}
+		if err := CheckHash(encoded); err != nil {
+			return nil, fmt.Errorf("line %d (%s): %w", line, username, err)
+		}
+		if _, exists := users[username]; exists {
+			return nil, fmt.Errorf("line %d: duplicate user %q", line, username)
+		}
+		users[username] = encoded
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
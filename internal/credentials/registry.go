@@ -0,0 +1,121 @@
+package credentials
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/filewatch"
+)
+
+// PasswordStoreSpec describes a password store before file paths are loaded.
+type PasswordStoreSpec struct {
+	Users map[string]string
+	File  string
+}
+
+// APIKeyStoreSpec describes an API key store before its file is loaded.
+type APIKeyStoreSpec struct {
+	File string
+}
+
+// RegistrySpec collects every store the registry should build.
+type RegistrySpec struct {
+	Passwords map[string]PasswordStoreSpec
+	APIKeys   map[string]APIKeyStoreSpec
+}
+
+// reloadable is implemented by every file-backed store.
+type reloadable interface {
+	Name() string
+	File() string
+	Reload() error
+}
+
+// Registry owns the named credential stores referenced by rule matchers.
+type Registry struct {
+	passwords map[string]*PasswordStore
+	apiKeys   map[string]*APIKeyStore
+}
+
+// NewRegistry builds every configured store. Any store that fails to load
+// aborts construction so misconfigured credentials surface at startup.
+func NewRegistry(spec RegistrySpec) (*Registry, error) {
+	registry := &Registry{
+		passwords: make(map[string]*PasswordStore, len(spec.Passwords)),
+		apiKeys:   make(map[string]*APIKeyStore, len(spec.APIKeys)),
+	}
+	for name, store := range spec.Passwords {
+		built, err := NewPasswordStore(name, store.Users, store.File)
+		if err != nil {
+			return nil, err
+		}
+		registry.passwords[strings.TrimSpace(name)] = built
+	}
+	for name, store := range spec.APIKeys {
+		built, err := NewAPIKeyStore(name, store.File)
+		if err != nil {
+			return nil, err
+		}
+		registry.apiKeys[strings.TrimSpace(name)] = built
+	}
+	return registry, nil
+}
+
+// PasswordStore returns the named password store.
+func (r *Registry) PasswordStore(name string) (*PasswordStore, bool) {
+	if r == nil {
+		return nil, false
+	}
+	store, ok := r.passwords[strings.TrimSpace(name)]
+	return store, ok
+}
+
+// APIKeyStore returns the named API key store.
+func (r *Registry) APIKeyStore(name string) (*APIKeyStore, bool) {
+	if r == nil {
+		return nil, false
+	}
+	store, ok := r.apiKeys[strings.TrimSpace(name)]
+	return store, ok
+}
+
+// Watch reloads file-backed stores whenever their files change. It returns a
+// nil watcher when no store is backed by a file.
+func (r *Registry) Watch(ctx context.Context, onReload func(name string), onError func(error)) (*filewatch.Watcher, error) {
+	if r == nil {
+		return nil, nil
+	}
+	byFile := make(map[string][]reloadable)
+	for _, store := range r.passwords {
+		if store.File() != "" {
+			byFile[store.File()] = append(byFile[store.File()], store)
+		}
+	}
+	for _, store := range r.apiKeys {
+		byFile[store.File()] = append(byFile[store.File()], store)
+	}
+	if len(byFile) == 0 {
+		return nil, nil
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return filewatch.Watch(ctx, files, func() {
+		for _, file := range files {
+			for _, store := range byFile[file] {
+				if err := store.Reload(); err != nil {
+					if onError != nil {
+						onError(err)
+					}
+					continue
+				}
+				if onReload != nil {
+					onReload(store.Name())
+				}
+			}
+		}
+	}, onError)
+}
@@ -0,0 +1,102 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// PasswordStore maps usernames to password hashes sourced from inline
+// configuration and an optional htpasswd file. Lookups read an immutable
+// snapshot so reloads never block request evaluation.
+type PasswordStore struct {
+	name   string
+	inline map[string]string
+	file   string
+	users  atomic.Pointer[map[string]string]
+}
+
+// NewPasswordStore validates the inline entries, performs the initial file
+// load, and returns a ready store. file must already be resolved against the
+// template sandbox.
+func NewPasswordStore(name string, inline map[string]string, file string) (*PasswordStore, error) {
+	store := &PasswordStore{
+		name:   name,
+		inline: make(map[string]string, len(inline)),
+		file:   file,
+	}
+	for username, encoded := range inline {
+		if err := CheckHash(encoded); err != nil {
+			return nil, fmt.Errorf("credentials: store %q user %q: %w", name, username, err)
+		}
+		store.inline[username] = encoded
+	}
+	if err := store.Reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Name returns the configured store name.
+func (s *PasswordStore) Name() string { return s.name }
+
+// File returns the resolved htpasswd path, or an empty string for inline-only stores.
+func (s *PasswordStore) File() string { return s.file }
+
+// Reload re-reads the htpasswd file and swaps the snapshot. Inline users take
+// precedence over file entries with the same name. On error the previous
+// snapshot stays active.
+func (s *PasswordStore) Reload() error {
+	users := make(map[string]string, len(s.inline))
+	if s.file != "" {
+		f, err := os.Open(s.file) // #nosec G304 -- path resolved inside the template sandbox
+		if err != nil {
+			return fmt.Errorf("credentials: store %q: %w", s.name, err)
+		}
+		parsed, err := ParseHtpasswd(f)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("credentials: store %q: %s: %w", s.name, s.file, err)
+		}
+		for username, encoded := range parsed {
+			users[username] = encoded
+		}
+	}
+	for username, encoded := range s.inline {
+		users[username] = encoded
+	}
+	s.users.Store(&users)
+	return nil
+}
+
+// Verify reports whether the username exists and the password matches its
+// hash. Unknown users still pay for a hash comparison so response timing does
+// not reveal which usernames exist.
+func (s *PasswordStore) Verify(username, password string) bool {
+	if s == nil {
+		return false
+	}
+	snapshot := s.users.Load()
+	if snapshot == nil {
+		burnVerification(password)
+		return false
+	}
+	encoded, ok := (*snapshot)[username]
+	if !ok {
+		burnVerification(password)
+		return false
+	}
+	return VerifyPassword(encoded, password)
+}
+
+// Len returns the number of users in the active snapshot.
+func (s *PasswordStore) Len() int {
+	if s == nil {
+		return 0
+	}
+	snapshot := s.users.Load()
+	if snapshot == nil {
+		return 0
+	}
+	return len(*snapshot)
+}
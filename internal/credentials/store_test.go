@@ -0,0 +1,110 @@
+package credentials
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	helloWorldSHA256 = "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5"
+	helloWorldSHA512 = "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"
+)
+
+func TestParseHtpasswd(t *testing.T) {
+	users, err := ParseHtpasswd(strings.NewReader("# comment\n\nalice:" + helloWorldSHA256 + "\nbob:" + helloWorldSHA512 + "\n"))
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	require.Equal(t, helloWorldSHA256, users["alice"])
+
+	_, err = ParseHtpasswd(strings.NewReader("alice:plaintext\n"))
+	require.ErrorContains(t, err, "line 1 (alice)")
+
+	_, err = ParseHtpasswd(strings.NewReader("missing-separator\n"))
+	require.ErrorContains(t, err, "expected user:hash")
+
+	_, err = ParseHtpasswd(strings.NewReader("alice:" + helloWorldSHA256 + "\nalice:" + helloWorldSHA512 + "\n"))
+	require.ErrorContains(t, err, "duplicate user")
+}
+
+func TestPasswordStoreVerify(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "users.htpasswd")
+	require.NoError(t, os.WriteFile(file, []byte("alice:"+helloWorldSHA256+"\n"), 0o600))
+
+	store, err := NewPasswordStore("team", map[string]string{"bob": helloWorldSHA512}, file)
+	require.NoError(t, err)
+	require.Equal(t, 2, store.Len())
+
+	require.True(t, store.Verify("alice", "Hello world!"))
+	require.True(t, store.Verify("bob", "Hello world!"))
+	require.False(t, store.Verify("alice", "wrong"))
+	require.False(t, store.Verify("mallory", "Hello world!"))
+
+	_, err = NewPasswordStore("bad", map[string]string{"carol": "plaintext"}, "")
+	require.ErrorIs(t, err, ErrUnsupportedHash)
+
+	_, err = NewPasswordStore("missing", nil, filepath.Join(dir, "absent.htpasswd"))
+	require.Error(t, err)
+}
+
+func TestPasswordStoreReloadKeepsLastGoodSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "users.htpasswd")
+	require.NoError(t, os.WriteFile(file, []byte("alice:"+helloWorldSHA256+"\n"), 0o600))
+
+	store, err := NewPasswordStore("team", nil, file)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(file, []byte("alice:plaintext\n"), 0o600))
+	require.Error(t, store.Reload())
+	require.True(t, store.Verify("alice", "Hello world!"))
+
+	require.NoError(t, os.WriteFile(file, []byte("dave:"+helloWorldSHA512+"\n"), 0o600))
+	require.NoError(t, store.Reload())
+	require.False(t, store.Verify("alice", "Hello world!"))
+	require.True(t, store.Verify("dave", "Hello world!"))
+}
+
+func TestRegistryWatchReloadsStores(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "users.htpasswd")
+	require.NoError(t, os.WriteFile(file, []byte("alice:"+helloWorldSHA256+"\n"), 0o600))
+
+	registry, err := NewRegistry(RegistrySpec{Passwords: map[string]PasswordStoreSpec{
+		"team":   {File: file},
+		"inline": {Users: map[string]string{"bob": helloWorldSHA512}},
+	}})
+	require.NoError(t, err)
+
+	store, ok := registry.PasswordStore("team")
+	require.True(t, ok)
+	_, ok = registry.PasswordStore("unknown")
+	require.False(t, ok)
+
+	reloaded := make(chan string, 4)
+	watcher, err := registry.Watch(ctx, func(name string) { reloaded <- name }, func(err error) {
+		require.NoError(t, err)
+	})
+	require.NoError(t, err)
+	require.NotNil(t, watcher)
+	defer watcher.Stop()
+
+	require.NoError(t, os.WriteFile(file, []byte("carol:"+helloWorldSHA512+"\n"), 0o600))
+	select {
+	case name := <-reloaded:
+		require.Equal(t, "team", name)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for credential store reload")
+	}
+	require.True(t, store.Verify("carol", "Hello world!"))
+	require.False(t, store.Verify("alice", "Hello world!"))
+}
@@ -0,0 +1,90 @@
+package databases
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	// Registered database/sql drivers. Both are pure Go so release builds
+	// stay CGO-free.
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+const defaultMaxOpenConns = 4
+
+// Spec describes a named connection after its DSN has been resolved.
+type Spec struct {
+	Driver       string // postgres | sqlite
+	DSN          string
+	MaxOpenConns int
+}
+
+// Registry owns the named connection pools declared under server.databases.
+type Registry struct {
+	dbs map[string]*sql.DB
+}
+
+// Open creates a pool for every spec. Connections are established lazily, so
+// an unreachable server surfaces as a rule error on first use rather than at
+// startup.
+func Open(specs map[string]Spec) (*Registry, error) {
+	registry := &Registry{dbs: make(map[string]*sql.DB, len(specs))}
+	for name, spec := range specs {
+		trimmed := strings.TrimSpace(name)
+		driver, err := driverName(spec.Driver)
+		if err != nil {
+			_ = registry.Close()
+			return nil, fmt.Errorf("database %s: %w", trimmed, err)
+		}
+		db, err := sql.Open(driver, spec.DSN)
+		if err != nil {
+			_ = registry.Close()
+			return nil, fmt.Errorf("database %s: %w", trimmed, err)
+		}
+		conns := spec.MaxOpenConns
+		if conns <= 0 {
+			conns = defaultMaxOpenConns
+		}
+		db.SetMaxOpenConns(conns)
+		db.SetMaxIdleConns(conns)
+		registry.dbs[trimmed] = db
+	}
+	return registry, nil
+}
+
+// DB returns the named connection pool.
+func (r *Registry) DB(name string) (*sql.DB, bool) {
+	if r == nil {
+		return nil, false
+	}
+	db, ok := r.dbs[strings.TrimSpace(name)]
+	return db, ok
+}
+
+// Close closes every pool.
+func (r *Registry) Close() error {
+	if r == nil {
+		return nil
+	}
+	var errs []error
+	for name, db := range r.dbs {
+		if err := db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("database %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// driverName maps the configured driver to its database/sql registration.
+func driverName(driver string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(driver)) {
+	case "postgres":
+		return "pgx", nil
+	case "sqlite":
+		return "sqlite", nil
+	default:
+		return "", fmt.Errorf("unsupported driver %q", driver)
+	}
+}
@@ -0,0 +1,43 @@
+package databases
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryOpen(t *testing.T) {
+	dsn := "file:" + filepath.Join(t.TempDir(), "authz.db")
+	registry, err := Open(map[string]Spec{
+		"authz":     {Driver: "sqlite", DSN: dsn},
+		"warehouse": {Driver: "postgres", DSN: "postgres://passctrl@127.0.0.1:1/authz", MaxOpenConns: 2},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, registry.Close()) })
+
+	db, ok := registry.DB(" authz ")
+	require.True(t, ok)
+	var value int
+	require.NoError(t, db.QueryRowContext(context.Background(), "SELECT ?", 42).Scan(&value))
+	require.Equal(t, 42, value)
+	require.Equal(t, 4, db.Stats().MaxOpenConnections)
+
+	warehouse, ok := registry.DB("warehouse")
+	require.True(t, ok, "postgres pools open lazily")
+	require.Equal(t, 2, warehouse.Stats().MaxOpenConnections)
+
+	_, ok = registry.DB("missing")
+	require.False(t, ok)
+
+	var empty *Registry
+	_, ok = empty.DB("authz")
+	require.False(t, ok)
+	require.NoError(t, empty.Close())
+}
+
+func TestRegistryOpenRejectsUnknownDriver(t *testing.T) {
+	_, err := Open(map[string]Spec{"authz": {Driver: "mysql", DSN: "authz"}})
+	require.EqualError(t, err, `database authz: unsupported driver "mysql"`)
+}
@@ -0,0 +1,197 @@
+package datasets
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	kjson "github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/knadh/koanf/v2"
+)
+
+// Status reports the load state of a dataset for health output.
+type Status struct {
+	Name     string    `json:"name"`
+	File     string    `json:"file"`
+	Entries  int       `json:"entries"`
+	LoadedAt time.Time `json:"loadedAt,omitzero"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Dataset is a static table indexed by its key column. Lookups read an
+// immutable snapshot so reloads never block request evaluation.
+type Dataset struct {
+	name string
+	file string
+	key  string
+	rows atomic.Pointer[map[string]any]
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	lastErr  error
+}
+
+// New loads the CSV, YAML, or JSON table. file must already be resolved
+// against the template sandbox. A load failure leaves the dataset empty and
+// is reported through Status rather than returned, so a broken table does
+// not stop the server.
+func New(name, file, key string) *Dataset {
+	d := &Dataset{name: name, file: file, key: strings.TrimSpace(key)}
+	empty := map[string]any{}
+	d.rows.Store(&empty)
+	_ = d.Reload()
+	return d
+}
+
+// Name returns the configured dataset name.
+func (d *Dataset) Name() string { return d.name }
+
+// File returns the resolved table path.
+func (d *Dataset) File() string { return d.file }
+
+// Reload re-reads the table and swaps the snapshot. On error the previous
+// snapshot stays active and the error is kept for Status.
+func (d *Dataset) Reload() error {
+	rows, err := loadTable(d.file, d.key)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if err != nil {
+		d.lastErr = fmt.Errorf("datasets: %q: %s: %w", d.name, d.file, err)
+		return d.lastErr
+	}
+	d.rows.Store(&rows)
+	d.loadedAt = time.Now().UTC()
+	d.lastErr = nil
+	return nil
+}
+
+// Rows returns the active snapshot keyed by the key column. Callers must not
+// modify it.
+func (d *Dataset) Rows() map[string]any {
+	if d == nil {
+		return nil
+	}
+	return *d.rows.Load()
+}
+
+// Status describes the active snapshot and the most recent load error.
+func (d *Dataset) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := Status{Name: d.name, File: d.file, Entries: len(d.Rows()), LoadedAt: d.loadedAt}
+	if d.lastErr != nil {
+		status.Error = d.lastErr.Error()
+	}
+	return status
+}
+
+// loadTable reads a table and indexes its rows by the key column. CSV files
+// need a header row; YAML and JSON documents list their rows under `rows`.
+func loadTable(path, key string) (map[string]any, error) {
+	if key == "" {
+		return nil, errors.New("key column required")
+	}
+	var (
+		rows []map[string]any
+		err  error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readCSV(path)
+	case ".yaml", ".yml":
+		rows, err = readDocument(path, yaml.Parser())
+	case ".json":
+		rows, err = readDocument(path, kjson.Parser())
+	default:
+		return nil, fmt.Errorf("unsupported table file extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := make(map[string]any, len(rows))
+	for i, row := range rows {
+		value, ok := row[key]
+		if !ok || value == nil {
+			return nil, fmt.Errorf("rows[%d]: missing key column %q", i, key)
+		}
+		id := strings.TrimSpace(fmt.Sprint(value))
+		if id == "" {
+			return nil, fmt.Errorf("rows[%d]: empty key column %q", i, key)
+		}
+		if _, exists := indexed[id]; exists {
+			return nil, fmt.Errorf("rows[%d]: duplicate key %q", i, id)
+		}
+		indexed[id] = row
+	}
+	return indexed, nil
+}
+
+func readCSV(path string) ([]map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("header row required")
+		}
+		return nil, err
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	var rows []map[string]any
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make(map[string]any, len(header))
+		for i, column := range header {
+			row[column] = strings.TrimSpace(record[i])
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readDocument(path string, parser koanf.Parser) ([]map[string]any, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parser.Unmarshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	list, ok := doc["rows"].([]any)
+	if !ok {
+		return nil, errors.New("expected a rows list")
+	}
+	rows := make([]map[string]any, 0, len(list))
+	for i, entry := range list {
+		row, ok := entry.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("rows[%d]: expected an object, got %T", i, entry)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,91 @@
+package datasets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTable(t *testing.T, dir, name, contents string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(file, []byte(contents), 0o600))
+	return file
+}
+
+func TestDatasetLoadsTables(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		filename string
+		contents string
+		key      string
+	}{
+		{name: "csv", filename: "tenants.csv", contents: "id, plan\nacme, gold\nglobex, silver\n", key: "id"},
+		{name: "yaml", filename: "tenants.yaml", contents: "rows:\n  - id: acme\n    plan: gold\n  - id: globex\n    plan: silver\n", key: "id"},
+		{name: "json", filename: "tenants.json", contents: `{"rows":[{"id":"acme","plan":"gold"},{"id":"globex","plan":"silver"}]}`, key: "id"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dataset := New("tenants", writeTable(t, dir, tc.filename, tc.contents), tc.key)
+			status := dataset.Status()
+			require.Empty(t, status.Error)
+			require.Equal(t, 2, status.Entries)
+			require.False(t, status.LoadedAt.IsZero())
+
+			row, ok := dataset.Rows()["acme"].(map[string]any)
+			require.True(t, ok)
+			require.Equal(t, "gold", row["plan"])
+		})
+	}
+
+	t.Run("numeric keys are indexed as strings", func(t *testing.T) {
+		dataset := New("ports", writeTable(t, dir, "ports.json", `{"rows":[{"port":443,"name":"https"}]}`), "port")
+		require.Contains(t, dataset.Rows(), "443")
+	})
+}
+
+func TestDatasetReportsLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		filename string
+		contents string
+		errText  string
+	}{
+		{name: "missing key column", filename: "a.csv", contents: "name\nacme\n", errText: `rows[0]: missing key column "id"`},
+		{name: "duplicate key", filename: "b.yaml", contents: "rows:\n  - id: acme\n  - id: acme\n", errText: `rows[1]: duplicate key "acme"`},
+		{name: "ragged csv", filename: "c.csv", contents: "id,plan\nacme\n", errText: "wrong number of fields"},
+		{name: "no rows list", filename: "d.json", contents: `{"acme":{}}`, errText: "expected a rows list"},
+		{name: "unsupported extension", filename: "e.txt", contents: "", errText: "unsupported table file extension"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dataset := New("tenants", writeTable(t, dir, tc.filename, tc.contents), "id")
+			require.Contains(t, dataset.Status().Error, tc.errText)
+			require.Empty(t, dataset.Rows())
+		})
+	}
+}
+
+func TestDatasetReloadKeepsLastGoodSnapshot(t *testing.T) {
+	file := writeTable(t, t.TempDir(), "tenants.csv", "id,plan\nacme,gold\n")
+	dataset := New("tenants", file, "id")
+	require.Empty(t, dataset.Status().Error)
+
+	require.NoError(t, os.WriteFile(file, []byte("plan\ngold\n"), 0o600))
+	require.Error(t, dataset.Reload())
+	require.Contains(t, dataset.Rows(), "acme")
+	require.Contains(t, dataset.Status().Error, "missing key column")
+
+	require.NoError(t, os.WriteFile(file, []byte("id,plan\nglobex,silver\n"), 0o600))
+	require.NoError(t, dataset.Reload())
+	require.Contains(t, dataset.Rows(), "globex")
+	require.Empty(t, dataset.Status().Error)
+}
@@ -0,0 +1,103 @@
+package datasets
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/filewatch"
+)
+
+// Spec describes a dataset before its file is loaded.
+type Spec struct {
+	File string
+	Key  string
+}
+
+// Tables is the view of every dataset exposed to CEL and templates as `data`.
+// Each entry maps key column values to rows.
+type Tables map[string]map[string]any
+
+// Lookup returns the row stored under key in the named table, or nil. It
+// backs `{{ .data.Lookup "name" "key" }}` in templates.
+func (t Tables) Lookup(name, key string) any {
+	row, ok := t[name][key]
+	if !ok {
+		return nil
+	}
+	return row
+}
+
+// Registry owns the named datasets declared under server.datasets.
+type Registry struct {
+	datasets map[string]*Dataset
+}
+
+// NewRegistry loads every configured dataset. Datasets that fail to load
+// start empty and report the error through Statuses.
+func NewRegistry(specs map[string]Spec) *Registry {
+	registry := &Registry{datasets: make(map[string]*Dataset, len(specs))}
+	for name, spec := range specs {
+		trimmed := strings.TrimSpace(name)
+		registry.datasets[trimmed] = New(trimmed, spec.File, spec.Key)
+	}
+	return registry
+}
+
+// Tables returns the active snapshot of every dataset. It is never nil.
+func (r *Registry) Tables() Tables {
+	if r == nil {
+		return Tables{}
+	}
+	tables := make(Tables, len(r.datasets))
+	for name, dataset := range r.datasets {
+		tables[name] = dataset.Rows()
+	}
+	return tables
+}
+
+// Statuses reports every dataset sorted by name, or nil when none are
+// configured.
+func (r *Registry) Statuses() []Status {
+	if r == nil || len(r.datasets) == 0 {
+		return nil
+	}
+	statuses := make([]Status, 0, len(r.datasets))
+	for _, dataset := range r.datasets {
+		statuses = append(statuses, dataset.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Watch reloads datasets whenever their files change. It returns a nil
+// watcher when no dataset is configured.
+func (r *Registry) Watch(ctx context.Context, onReload func(name string), onError func(error)) (*filewatch.Watcher, error) {
+	if r == nil || len(r.datasets) == 0 {
+		return nil, nil
+	}
+	byFile := make(map[string][]*Dataset)
+	for _, dataset := range r.datasets {
+		byFile[dataset.File()] = append(byFile[dataset.File()], dataset)
+	}
+	files := make([]string, 0, len(byFile))
+	for file := range byFile {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return filewatch.Watch(ctx, files, func() {
+		for _, file := range files {
+			for _, dataset := range byFile[file] {
+				if err := dataset.Reload(); err != nil {
+					if onError != nil {
+						onError(err)
+					}
+					continue
+				}
+				if onReload != nil {
+					onReload(dataset.Name())
+				}
+			}
+		}
+	}, onError)
+}
@@ -0,0 +1,62 @@
+package datasets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryTablesAndStatuses(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewRegistry(map[string]Spec{
+		"tenants": {File: writeTable(t, dir, "tenants.csv", "id,plan\nacme,gold\n"), Key: "id"},
+		"broken":  {File: filepath.Join(dir, "missing.yaml"), Key: "id"},
+	})
+
+	tables := registry.Tables()
+	require.Equal(t, map[string]any{"id": "acme", "plan": "gold"}, tables.Lookup("tenants", "acme"))
+	require.Nil(t, tables.Lookup("tenants", "globex"))
+	require.Nil(t, tables.Lookup("regions", "eu"))
+	require.Empty(t, tables["broken"])
+
+	statuses := registry.Statuses()
+	require.Len(t, statuses, 2)
+	require.Equal(t, "broken", statuses[0].Name)
+	require.Contains(t, statuses[0].Error, "missing.yaml")
+	require.Equal(t, "tenants", statuses[1].Name)
+	require.Empty(t, statuses[1].Error)
+
+	var empty *Registry
+	require.NotNil(t, empty.Tables())
+	require.Nil(t, empty.Statuses())
+}
+
+func TestRegistryWatchReloadsDatasets(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	file := writeTable(t, t.TempDir(), "tenants.csv", "id,plan\nacme,gold\n")
+	registry := NewRegistry(map[string]Spec{"tenants": {File: file, Key: "id"}})
+
+	reloaded := make(chan string, 4)
+	watcher, err := registry.Watch(ctx, func(name string) { reloaded <- name }, func(err error) {
+		require.NoError(t, err)
+	})
+	require.NoError(t, err)
+	require.NotNil(t, watcher)
+	defer watcher.Stop()
+
+	require.NoError(t, os.WriteFile(file, []byte("id,plan\nglobex,silver\n"), 0o600))
+	select {
+	case name := <-reloaded:
+		require.Equal(t, "tenants", name)
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for dataset reload")
+	}
+	require.NotNil(t, registry.Tables().Lookup("tenants", "globex"))
+	require.Nil(t, registry.Tables().Lookup("tenants", "acme"))
+}
@@ -17,22 +17,25 @@ type Environment struct {
 
 // NewEnvironment declares the CEL variables exposed to rule conditions.
 func NewEnvironment() (*Environment, error) {
-	env, err := cel.NewEnv(
+	return newEnvironment(cel.MapType(cel.StringType, cel.DynType))
+}
+
+// newEnvironment declares the rule variables with backend typed as supplied.
+// Options such as a custom type provider are applied before the declarations.
+func newEnvironment(backend *cel.Type, opts ...cel.EnvOption) (*Environment, error) {
+	opts = append(opts,
 		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
 		cel.Variable("admission", cel.MapType(cel.StringType, cel.DynType)),
 		cel.Variable("forward", cel.MapType(cel.StringType, cel.DynType)),
-		cel.Variable("backend", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("auth", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("backend", backend),
 		cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("params", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("data", cel.MapType(cel.StringType, cel.DynType)),
 		cel.Variable("now", cel.DynType),
-		cel.Function("lookup",
-			cel.Overload("lookup_map_string",
-				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType), cel.StringType},
-				cel.DynType,
-				cel.BinaryBinding(lookupMapValue),
-			),
-		),
-		cel.HomogeneousAggregateLiterals(),
+		library(),
 	)
+	env, err := cel.NewEnv(opts...)
 	if err != nil {
 		return nil, fmt.Errorf("expr: build environment: %w", err)
 	}
@@ -133,3 +136,13 @@ func lookupMapValue(mapVal ref.Val, key ref.Val) ref.Val {
 	}
 	return value
 }
+
+// lookupTableRow resolves tables[name][key], yielding null when either the
+// table or the row is missing.
+func lookupTableRow(args ...ref.Val) ref.Val {
+	table := lookupMapValue(args[0], args[1])
+	if table == types.NullValue || types.IsError(table) {
+		return table
+	}
+	return lookupMapValue(table, args[2])
+}
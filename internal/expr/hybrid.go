@@ -133,14 +133,8 @@ func RequestContext(r *http.Request) map[string]any {
 func NewRequestEnvironment() (*Environment, error) {
 	env, err := cel.NewEnv(
 		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
-		cel.Function("lookup",
-			cel.Overload("lookup_map_string",
-				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType), cel.StringType},
-				cel.DynType,
-				cel.BinaryBinding(lookupMapValue),
-			),
-		),
-		cel.HomogeneousAggregateLiterals(),
+		cel.Variable("data", cel.MapType(cel.StringType, cel.DynType)),
+		library(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("expr: build request environment: %w", err)
@@ -149,7 +143,7 @@ func NewRequestEnvironment() (*Environment, error) {
 }
 
 // NewRuleEnvironment creates a CEL environment for rule local variable evaluation.
-// It includes all context available to rules: backend, auth, vars, request, variables.
+// It includes all context available to rules: backend, auth, vars, request, variables, params.
 func NewRuleEnvironment() (*Environment, error) {
 	env, err := cel.NewEnv(
 		cel.Variable("backend", cel.MapType(cel.StringType, cel.DynType)),
@@ -157,14 +151,9 @@ func NewRuleEnvironment() (*Environment, error) {
 		cel.Variable("vars", cel.MapType(cel.StringType, cel.DynType)),
 		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
 		cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
-		cel.Function("lookup",
-			cel.Overload("lookup_map_string",
-				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType), cel.StringType},
-				cel.DynType,
-				cel.BinaryBinding(lookupMapValue),
-			),
-		),
-		cel.HomogeneousAggregateLiterals(),
+		cel.Variable("params", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("data", cel.MapType(cel.StringType, cel.DynType)),
+		library(),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("expr: build rule environment: %w", err)
@@ -0,0 +1,532 @@
+package expr
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+)
+
+// library registers the PassCtrl helper functions shared by every CEL
+// environment. Functions live in namespaces (cidr., ip., time., ...) so they
+// read like member calls without colliding with activation variables.
+func library() cel.EnvOption {
+	stringMap := cel.MapType(cel.StringType, cel.StringType)
+	return cel.Lib(passctrlLib{options: []cel.EnvOption{
+		cel.Function("lookup",
+			cel.Overload("lookup_map_string",
+				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType), cel.StringType},
+				cel.DynType,
+				cel.BinaryBinding(lookupMapValue),
+			),
+			// data.lookup(name, key) reads a row from a dataset table.
+			cel.MemberOverload("map_lookup_string_string",
+				[]*cel.Type{cel.MapType(cel.StringType, cel.DynType), cel.StringType, cel.StringType},
+				cel.DynType,
+				cel.FunctionBinding(lookupTableRow),
+			),
+		),
+		cel.Function("cidr.contains",
+			cel.Overload("cidr_contains_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.BinaryBinding(cidrContains),
+			),
+			cel.Overload("cidr_contains_list_string",
+				[]*cel.Type{cel.ListType(cel.StringType), cel.StringType},
+				cel.BoolType,
+				cel.BinaryBinding(cidrContains),
+			),
+		),
+		cel.Function("ip.isPrivate",
+			cel.Overload("ip_is_private_string",
+				[]*cel.Type{cel.StringType},
+				cel.BoolType,
+				cel.UnaryBinding(ipIsPrivate),
+			),
+		),
+		cel.Function("time.inWindow",
+			cel.Overload("time_in_window_string_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					return timeInWindow(time.Now(), args[0], args[1], args[2])
+				}),
+			),
+			cel.Overload("time_in_window_timestamp_string_string_string",
+				[]*cel.Type{cel.TimestampType, cel.StringType, cel.StringType, cel.StringType},
+				cel.BoolType,
+				cel.FunctionBinding(func(args ...ref.Val) ref.Val {
+					at, ok := args[0].(types.Timestamp)
+					if !ok {
+						return types.MaybeNoSuchOverloadErr(args[0])
+					}
+					return timeInWindow(at.Time, args[1], args[2], args[3])
+				}),
+			),
+		),
+		cel.Function("base64.decode",
+			cel.Overload("passctrl_base64_decode_string",
+				[]*cel.Type{cel.StringType},
+				cel.StringType,
+				cel.UnaryBinding(base64Decode),
+			),
+		),
+		cel.Function("base64.encode",
+			cel.Overload("passctrl_base64_encode_string",
+				[]*cel.Type{cel.StringType},
+				cel.StringType,
+				cel.UnaryBinding(stringFunc(func(s string) (string, error) {
+					return base64.StdEncoding.EncodeToString([]byte(s)), nil
+				})),
+			),
+		),
+		cel.Function("sha256",
+			cel.Overload("sha256_string",
+				[]*cel.Type{cel.StringType},
+				cel.StringType,
+				cel.UnaryBinding(stringFunc(func(s string) (string, error) {
+					sum := sha256.Sum256([]byte(s))
+					return hex.EncodeToString(sum[:]), nil
+				})),
+			),
+		),
+		cel.Function("hmac",
+			cel.Overload("hmac_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				cel.StringType,
+				cel.BinaryBinding(hmacSHA256),
+			),
+		),
+		cel.Function("jwt.decodeUnverified",
+			cel.Overload("jwt_decode_unverified_string",
+				[]*cel.Type{cel.StringType},
+				cel.MapType(cel.StringType, cel.DynType),
+				cel.UnaryBinding(jwtDecodeUnverified),
+			),
+		),
+		cel.Function("regex.capture",
+			cel.Overload("regex_capture_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				stringMap,
+				cel.BinaryBinding(regexCapture),
+			),
+		),
+		cel.Function("url.parse",
+			cel.Overload("url_parse_string",
+				[]*cel.Type{cel.StringType},
+				cel.MapType(cel.StringType, cel.DynType),
+				cel.UnaryBinding(urlParse),
+			),
+		),
+		cel.Function("semver.compare",
+			cel.Overload("semver_compare_string_string",
+				[]*cel.Type{cel.StringType, cel.StringType},
+				cel.IntType,
+				cel.BinaryBinding(semverCompare),
+			),
+		),
+		cel.Function("jsonpath",
+			cel.Overload("jsonpath_dyn_string",
+				[]*cel.Type{cel.DynType, cel.StringType},
+				cel.DynType,
+				cel.BinaryBinding(jsonPathLookup),
+			),
+		),
+		cel.HomogeneousAggregateLiterals(),
+	}})
+}
+
+type passctrlLib struct {
+	options []cel.EnvOption
+}
+
+func (l passctrlLib) CompileOptions() []cel.EnvOption { return l.options }
+
+func (passctrlLib) ProgramOptions() []cel.ProgramOption { return nil }
+
+// stringFunc adapts a string transformation to a CEL unary binding.
+func stringFunc(fn func(string) (string, error)) func(ref.Val) ref.Val {
+	return func(val ref.Val) ref.Val {
+		s, ok := val.(types.String)
+		if !ok {
+			return types.MaybeNoSuchOverloadErr(val)
+		}
+		out, err := fn(string(s))
+		if err != nil {
+			return types.NewErr("%v", err)
+		}
+		return types.String(out)
+	}
+}
+
+func cidrContains(block ref.Val, addr ref.Val) ref.Val {
+	ipText, ok := addr.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(addr)
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(string(ipText)))
+	if err != nil {
+		return types.NewErr("cidr.contains: invalid ip %q", string(ipText))
+	}
+	ip = ip.Unmap()
+	var blocks []string
+	switch v := block.(type) {
+	case types.String:
+		blocks = []string{string(v)}
+	case traits.Lister:
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			s, ok := it.Next().(types.String)
+			if !ok {
+				return types.NewErr("cidr.contains: cidr list must contain strings")
+			}
+			blocks = append(blocks, string(s))
+		}
+	default:
+		return types.MaybeNoSuchOverloadErr(block)
+	}
+	for _, raw := range blocks {
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(raw))
+		if err != nil {
+			return types.NewErr("cidr.contains: invalid cidr %q", raw)
+		}
+		if prefix.Contains(ip) {
+			return types.True
+		}
+	}
+	return types.False
+}
+
+func ipIsPrivate(addr ref.Val) ref.Val {
+	ipText, ok := addr.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(addr)
+	}
+	ip, err := netip.ParseAddr(strings.TrimSpace(string(ipText)))
+	if err != nil {
+		return types.NewErr("ip.isPrivate: invalid ip %q", string(ipText))
+	}
+	ip = ip.Unmap()
+	return types.Bool(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast())
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// timeInWindow reports whether at, converted to tz, falls on one of days and
+// within hours. days is a comma-separated list of weekdays or ranges
+// ("Mon-Fri,Sun", "*"); hours is "HH:MM-HH:MM" and may wrap past midnight.
+func timeInWindow(at time.Time, tzVal, daysVal, hoursVal ref.Val) ref.Val {
+	tz, ok1 := tzVal.(types.String)
+	days, ok2 := daysVal.(types.String)
+	hours, ok3 := hoursVal.(types.String)
+	if !ok1 || !ok2 || !ok3 {
+		return types.NewErr("time.inWindow: arguments must be strings")
+	}
+	loc, err := time.LoadLocation(strings.TrimSpace(string(tz)))
+	if err != nil {
+		return types.NewErr("time.inWindow: unknown time zone %q", string(tz))
+	}
+	local := at.In(loc)
+	allowed, err := parseWeekdays(string(days))
+	if err != nil {
+		return types.NewErr("time.inWindow: %v", err)
+	}
+	start, end, err := parseClockRange(string(hours))
+	if err != nil {
+		return types.NewErr("time.inWindow: %v", err)
+	}
+	minute := local.Hour()*60 + local.Minute()
+	day := local.Weekday()
+	switch {
+	case start <= end:
+		return types.Bool(allowed[day] && minute >= start && minute < end)
+	case minute >= start:
+		return types.Bool(allowed[day])
+	case minute < end:
+		// Early-morning part of a window that opened the previous day.
+		return types.Bool(allowed[(day+6)%7])
+	default:
+		return types.False
+	}
+}
+
+func parseWeekdays(spec string) (map[time.Weekday]bool, error) {
+	allowed := make(map[time.Weekday]bool, 7)
+	spec = strings.TrimSpace(spec)
+	if spec == "*" || spec == "" {
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			allowed[day] = true
+		}
+		return allowed, nil
+	}
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, ok := weekdays[strings.ToLower(strings.TrimSpace(from))]
+		if !ok {
+			return nil, fmt.Errorf("unknown weekday %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[strings.ToLower(strings.TrimSpace(to))]; !ok {
+				return nil, fmt.Errorf("unknown weekday %q", to)
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			allowed[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return allowed, nil
+}
+
+func parseClockRange(spec string) (int, int, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("hours %q must be HH:MM-HH:MM", spec)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
+
+func parseClock(value string) (int, error) {
+	parsed, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		if strings.TrimSpace(value) == "24:00" {
+			return 24 * 60, nil
+		}
+		return 0, fmt.Errorf("invalid time of day %q", value)
+	}
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+func decodeBase64(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawStdEncoding, base64.URLEncoding, base64.RawURLEncoding} {
+		if decoded, err := enc.DecodeString(value); err == nil {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid base64 input")
+}
+
+func base64Decode(val ref.Val) ref.Val {
+	return stringFunc(func(s string) (string, error) {
+		decoded, err := decodeBase64(s)
+		if err != nil {
+			return "", fmt.Errorf("base64.decode: %w", err)
+		}
+		return string(decoded), nil
+	})(val)
+}
+
+func hmacSHA256(key ref.Val, message ref.Val) ref.Val {
+	k, ok1 := key.(types.String)
+	m, ok2 := message.(types.String)
+	if !ok1 || !ok2 {
+		return types.NewErr("hmac: arguments must be strings")
+	}
+	mac := hmac.New(sha256.New, []byte(k))
+	mac.Write([]byte(m))
+	return types.String(hex.EncodeToString(mac.Sum(nil)))
+}
+
+// jwtDecodeUnverified returns the claims of a compact JWT without checking its
+// signature; conditions must not treat the result as authenticated.
+func jwtDecodeUnverified(val ref.Val) ref.Val {
+	token, ok := val.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(val)
+	}
+	parts := strings.Split(strings.TrimSpace(strings.TrimPrefix(string(token), "Bearer ")), ".")
+	if len(parts) != 3 {
+		return types.NewErr("jwt.decodeUnverified: token must have three segments")
+	}
+	payload, err := decodeBase64(parts[1])
+	if err != nil {
+		return types.NewErr("jwt.decodeUnverified: payload: %v", err)
+	}
+	var claims map[string]any
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return types.NewErr("jwt.decodeUnverified: payload is not a JSON object")
+	}
+	return types.DefaultTypeAdapter.NativeToValue(claims)
+}
+
+// regexCapture returns the capture groups of the first match keyed by index
+// ("0" is the whole match) and by name for named groups. The map is empty when
+// the pattern does not match.
+func regexCapture(target ref.Val, pattern ref.Val) ref.Val {
+	s, ok1 := target.(types.String)
+	p, ok2 := pattern.(types.String)
+	if !ok1 || !ok2 {
+		return types.NewErr("regex.capture: arguments must be strings")
+	}
+	re, err := regexp.Compile(string(p))
+	if err != nil {
+		return types.NewErr("regex.capture: invalid pattern %q: %v", string(p), err)
+	}
+	groups := make(map[string]string)
+	match := re.FindStringSubmatch(string(s))
+	for idx, value := range match {
+		groups[strconv.Itoa(idx)] = value
+		if name := re.SubexpNames()[idx]; name != "" {
+			groups[name] = value
+		}
+	}
+	return types.DefaultTypeAdapter.NativeToValue(groups)
+}
+
+func urlParse(val ref.Val) ref.Val {
+	raw, ok := val.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(val)
+	}
+	parsed, err := url.Parse(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return types.NewErr("url.parse: %v", err)
+	}
+	query := make(map[string]any)
+	for name, values := range parsed.Query() {
+		if len(values) > 0 {
+			query[name] = values[0]
+		}
+	}
+	return types.DefaultTypeAdapter.NativeToValue(map[string]any{
+		"scheme":   parsed.Scheme,
+		"host":     parsed.Host,
+		"hostname": parsed.Hostname(),
+		"port":     parsed.Port(),
+		"path":     parsed.Path,
+		"query":    query,
+		"fragment": parsed.Fragment,
+		"user":     parsed.User.Username(),
+	})
+}
+
+func semverCompare(a ref.Val, b ref.Val) ref.Val {
+	left, ok1 := a.(types.String)
+	right, ok2 := b.(types.String)
+	if !ok1 || !ok2 {
+		return types.NewErr("semver.compare: arguments must be strings")
+	}
+	lv, err := semver.NewVersion(string(left))
+	if err != nil {
+		return types.NewErr("semver.compare: invalid version %q", string(left))
+	}
+	rv, err := semver.NewVersion(string(right))
+	if err != nil {
+		return types.NewErr("semver.compare: invalid version %q", string(right))
+	}
+	return types.Int(lv.Compare(rv))
+}
+
+// jsonPathLookup walks value along a path such as `$.items[0].id` or
+// `data['user-id']` and returns null when any segment is missing.
+func jsonPathLookup(value ref.Val, path ref.Val) ref.Val {
+	p, ok := path.(types.String)
+	if !ok {
+		return types.MaybeNoSuchOverloadErr(path)
+	}
+	segments, err := parseJSONPath(string(p))
+	if err != nil {
+		return types.NewErr("jsonpath: %v", err)
+	}
+	current := value
+	for _, segment := range segments {
+		switch container := current.(type) {
+		case traits.Mapper:
+			if segment.index >= 0 {
+				return types.NullValue
+			}
+			next, found := container.Find(types.String(segment.key))
+			if !found {
+				return types.NullValue
+			}
+			current = next
+		case traits.Lister:
+			if segment.index < 0 {
+				return types.NullValue
+			}
+			size, _ := container.Size().(types.Int)
+			if int64(segment.index) >= int64(size) {
+				return types.NullValue
+			}
+			current = container.Get(types.Int(segment.index))
+		default:
+			return types.NullValue
+		}
+	}
+	return current
+}
+
+type jsonPathSegment struct {
+	key   string
+	index int
+}
+
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	rest := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	var segments []jsonPathSegment
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty segment in %q", path)
+			}
+			segments = append(segments, jsonPathSegment{key: rest[:end], index: -1})
+			rest = rest[end:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed bracket in %q", path)
+			}
+			inner := strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if unquoted, err := strconv.Unquote(strings.ReplaceAll(inner, "'", `"`)); err == nil && inner != "" && (inner[0] == '\'' || inner[0] == '"') {
+				segments = append(segments, jsonPathSegment{key: unquoted, index: -1})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid index %q in %q", inner, path)
+			}
+			segments = append(segments, jsonPathSegment{index: idx})
+		default:
+			// A leading bare key, as in `items[0].id`.
+			rest = "." + rest
+		}
+	}
+	return segments, nil
+}
@@ -0,0 +1,113 @@
+package expr
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryFunctions(t *testing.T) {
+	env, err := NewEnvironment()
+	require.NoError(t, err)
+
+	claims := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"alice","roles":["admin"]}`))
+	activation := map[string]any{
+		"admission": map[string]any{"clientIp": "10.1.2.3"},
+		"request": map[string]any{
+			"headers": map[string]any{"authorization": "Bearer e30." + claims + ".sig"},
+		},
+		"backend": map[string]any{
+			"body": map[string]any{
+				"items": []any{map[string]any{"id": "a1", "tags": []any{"x"}}},
+			},
+		},
+		"data": map[string]map[string]any{
+			"tenants": {"acme": map[string]any{"id": "acme", "plan": "gold"}},
+		},
+	}
+
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "cidr contains", expr: `cidr.contains("10.0.0.0/8", admission.clientIp)`},
+		{name: "cidr list", expr: `cidr.contains(["192.168.0.0/16", "10.0.0.0/8"], admission.clientIp)`},
+		{name: "cidr excludes", expr: `!cidr.contains("172.16.0.0/12", admission.clientIp)`},
+		{name: "private ip", expr: `ip.isPrivate(admission.clientIp) && !ip.isPrivate("8.8.8.8")`},
+		{name: "time window", expr: `time.inWindow(timestamp("2024-01-08T10:00:00Z"), "UTC", "Mon-Fri", "09:00-18:00")`},
+		{name: "time window weekend", expr: `!time.inWindow(timestamp("2024-01-06T10:00:00Z"), "UTC", "Mon-Fri", "09:00-18:00")`},
+		{name: "time window overnight", expr: `time.inWindow(timestamp("2024-01-09T02:00:00Z"), "UTC", "Mon", "22:00-06:00")`},
+		{name: "time window zone", expr: `!time.inWindow(timestamp("2024-01-08T10:00:00Z"), "America/New_York", "*", "09:00-18:00")`},
+		{name: "base64 decode", expr: `base64.decode("aGVsbG8=") == "hello" && base64.decode("aGVsbG8") == "hello"`},
+		{name: "base64 encode", expr: `base64.encode("hello") == "aGVsbG8="`},
+		{name: "sha256", expr: `sha256("abc") == "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"`},
+		{name: "hmac", expr: `hmac("key", "The quick brown fox jumps over the lazy dog") == "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8"`},
+		{name: "jwt claims", expr: `jwt.decodeUnverified(request.headers.authorization).sub == "alice"`},
+		{name: "jwt list claim", expr: `"admin" in jwt.decodeUnverified(request.headers.authorization).roles`},
+		{name: "regex capture", expr: `regex.capture("team-ops-42", "^team-(?P<name>[a-z]+)-(\\d+)$").name == "ops"`},
+		{name: "regex capture index", expr: `regex.capture("team-ops-42", "^team-(?P<name>[a-z]+)-(\\d+)$")["2"] == "42"`},
+		{name: "regex no match", expr: `size(regex.capture("nope", "^team-")) == 0`},
+		{name: "url parse", expr: `url.parse("https://user@api.example.com:8443/v1/items?limit=5#top").hostname == "api.example.com"`},
+		{name: "url parse query", expr: `url.parse("https://api.example.com/v1?limit=5").query.limit == "5"`},
+		{name: "semver compare", expr: `semver.compare("1.10.0", "1.9.3") == 1 && semver.compare("v2.0.0", "2.0.0") == 0`},
+		{name: "jsonpath", expr: `jsonpath(backend.body, "$.items[0].id") == "a1"`},
+		{name: "jsonpath nested list", expr: `jsonpath(backend.body, "items[0]['tags'][0]") == "x"`},
+		{name: "jsonpath missing", expr: `jsonpath(backend.body, "$.items[3].id") == null`},
+		{name: "dataset member", expr: `"acme" in data.tenants && data.tenants.acme.plan == "gold"`},
+		{name: "dataset lookup", expr: `data.lookup("tenants", "acme").plan == "gold"`},
+		{name: "dataset lookup missing row", expr: `data.lookup("tenants", "globex") == null && data.lookup("regions", "eu") == null`},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			program, err := env.Compile(tc.expr)
+			require.NoError(t, err)
+			matched, err := program.EvalBool(activation)
+			require.NoError(t, err)
+			require.True(t, matched)
+		})
+	}
+}
+
+func TestLibraryErrors(t *testing.T) {
+	env, err := NewEnvironment()
+	require.NoError(t, err)
+
+	t.Run("invalid arguments surface at evaluation", func(t *testing.T) {
+		for _, source := range []string{
+			`cidr.contains("10.0.0.0/33", "10.0.0.1")`,
+			`ip.isPrivate("not-an-ip")`,
+			`time.inWindow("Mars/Olympus", "*", "00:00-24:00")`,
+			`time.inWindow("UTC", "Funday", "00:00-24:00")`,
+			`jwt.decodeUnverified("abc") == {}`,
+			`semver.compare("one", "1.0.0") == 0`,
+		} {
+			program, err := env.Compile(source)
+			require.NoError(t, err, source)
+			_, err = program.EvalBool(map[string]any{})
+			require.Error(t, err, source)
+		}
+	})
+
+	t.Run("unknown helpers fail to compile", func(t *testing.T) {
+		_, err := env.Compile(`cidr.contain("10.0.0.0/8", "10.0.0.1")`)
+		require.Error(t, err)
+	})
+}
+
+func TestLibraryRegisteredInHybridEnvironments(t *testing.T) {
+	requestEnv, err := NewRequestEnvironment()
+	require.NoError(t, err)
+	ruleEnv, err := NewRuleEnvironment()
+	require.NoError(t, err)
+
+	for _, env := range []*Environment{requestEnv, ruleEnv} {
+		program, err := env.CompileValue(`sha256(base64.decode("YWJj"))`)
+		require.NoError(t, err)
+		value, err := program.Eval(map[string]any{})
+		require.NoError(t, err)
+		require.Equal(t, "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad", value)
+	}
+}
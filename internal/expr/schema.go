@@ -0,0 +1,365 @@
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// maxSchemaViolations bounds how many violations a validation error lists.
+const maxSchemaViolations = 3
+
+// BodySchema is a JSON Schema for a backend response body. It validates
+// responses at runtime and declares the CEL types conditions are checked
+// against at load time.
+type BodySchema struct {
+	root      *schemaNode
+	structs   map[string]map[string]*types.Type
+	validator *gojsonschema.Schema
+}
+
+// schemaNode is the typed shape of one schema location. Kind is the JSON
+// type the CEL declaration assumes; dyn locations are left unchecked.
+type schemaNode struct {
+	kind       string
+	celType    *types.Type
+	properties map[string]*schemaNode
+	elem       *schemaNode
+}
+
+// CompileBodySchema converts a JSON Schema document into CEL declarations for
+// the named rule. Only local $ref pointers (#/$defs/..., #/definitions/...)
+// are followed; the schema may not reach outside the document.
+func CompileBodySchema(name string, document map[string]any) (*BodySchema, error) {
+	if len(document) == 0 {
+		return nil, errors.New("expr: body schema is empty")
+	}
+	if err := checkLocalRefs(document); err != nil {
+		return nil, err
+	}
+	validator, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(document))
+	if err != nil {
+		return nil, fmt.Errorf("expr: body schema: %w", err)
+	}
+	b := &schemaBuilder{
+		document: document,
+		structs:  make(map[string]map[string]*types.Type),
+		visiting: make(map[string]bool),
+	}
+	root, err := b.node("passctrl."+name+".body", document)
+	if err != nil {
+		return nil, err
+	}
+	return &BodySchema{root: root, structs: b.structs, validator: validator}, nil
+}
+
+// Type returns the CEL type declared for backend.body.
+func (s *BodySchema) Type() *cel.Type { return s.root.celType }
+
+// Apply validates body against the schema and returns it with numbers
+// converted to the CEL types the conditions were checked against: integral
+// values of number fields become doubles and integral doubles of integer
+// fields become ints.
+func (s *BodySchema) Apply(body any) (any, error) {
+	result, err := s.validator.Validate(gojsonschema.NewGoLoader(body))
+	if err != nil {
+		return nil, fmt.Errorf("validate body: %w", err)
+	}
+	if !result.Valid() {
+		violations := result.Errors()
+		parts := make([]string, 0, maxSchemaViolations)
+		for i, violation := range violations {
+			if i == maxSchemaViolations {
+				parts = append(parts, fmt.Sprintf("and %d more", len(violations)-i))
+				break
+			}
+			parts = append(parts, violation.String())
+		}
+		return nil, errors.New(strings.Join(parts, "; "))
+	}
+	return s.root.conform(body), nil
+}
+
+func (n *schemaNode) conform(value any) any {
+	switch n.kind {
+	case "number":
+		if v, ok := value.(int64); ok {
+			return float64(v)
+		}
+	case "integer":
+		if v, ok := value.(float64); ok && v == math.Trunc(v) && math.Abs(v) < 1<<63 {
+			return int64(v)
+		}
+	case "object":
+		if m, ok := value.(map[string]any); ok {
+			out := make(map[string]any, len(m))
+			for key, val := range m {
+				if prop := n.properties[key]; prop != nil {
+					val = prop.conform(val)
+				}
+				out[key] = val
+			}
+			return out
+		}
+	case "map":
+		if m, ok := value.(map[string]any); ok {
+			out := make(map[string]any, len(m))
+			for key, val := range m {
+				out[key] = n.elem.conform(val)
+			}
+			return out
+		}
+	case "array":
+		if list, ok := value.([]any); ok {
+			out := make([]any, len(list))
+			for i, val := range list {
+				out[i] = n.elem.conform(val)
+			}
+			return out
+		}
+	}
+	return value
+}
+
+type schemaBuilder struct {
+	document map[string]any
+	structs  map[string]map[string]*types.Type
+	visiting map[string]bool
+}
+
+var dynNode = &schemaNode{kind: "dyn", celType: types.DynType}
+
+func (b *schemaBuilder) node(typeName string, schema map[string]any) (*schemaNode, error) {
+	if location, ok := schema["$ref"].(string); ok {
+		if b.visiting[location] {
+			// Recursive definitions are left unchecked below the cycle.
+			return dynNode, nil
+		}
+		target, err := b.resolve(location)
+		if err != nil {
+			return nil, err
+		}
+		b.visiting[location] = true
+		defer delete(b.visiting, location)
+		return b.node(typeName, target)
+	}
+
+	switch schemaType(schema) {
+	case "string":
+		return &schemaNode{kind: "string", celType: types.StringType}, nil
+	case "integer":
+		return &schemaNode{kind: "integer", celType: types.IntType}, nil
+	case "number":
+		return &schemaNode{kind: "number", celType: types.DoubleType}, nil
+	case "boolean":
+		return &schemaNode{kind: "boolean", celType: types.BoolType}, nil
+	case "null":
+		return &schemaNode{kind: "null", celType: types.NullType}, nil
+	case "array":
+		elem := dynNode
+		if items, ok := schema["items"].(map[string]any); ok {
+			var err error
+			if elem, err = b.node(typeName+".item", items); err != nil {
+				return nil, err
+			}
+		}
+		return &schemaNode{kind: "array", celType: types.NewListType(elem.celType), elem: elem}, nil
+	case "object":
+		props, _ := schema["properties"].(map[string]any)
+		if len(props) == 0 {
+			elem := dynNode
+			if additional, ok := schema["additionalProperties"].(map[string]any); ok {
+				var err error
+				if elem, err = b.node(typeName+".value", additional); err != nil {
+					return nil, err
+				}
+			}
+			return &schemaNode{kind: "map", celType: types.NewMapType(types.StringType, elem.celType), elem: elem}, nil
+		}
+		names := make([]string, 0, len(props))
+		for prop := range props {
+			names = append(names, prop)
+		}
+		sort.Strings(names)
+		node := &schemaNode{
+			kind:       "object",
+			celType:    types.NewObjectType(typeName),
+			properties: make(map[string]*schemaNode, len(names)),
+		}
+		fields := make(map[string]*types.Type, len(names))
+		for _, prop := range names {
+			child := dynNode
+			if propSchema, ok := props[prop].(map[string]any); ok {
+				var err error
+				if child, err = b.node(typeName+"."+prop, propSchema); err != nil {
+					return nil, err
+				}
+			}
+			node.properties[prop] = child
+			fields[prop] = child.celType
+		}
+		b.structs[typeName] = fields
+		return node, nil
+	default:
+		return dynNode, nil
+	}
+}
+
+// schemaType returns the single JSON type a schema declares. A type list is
+// accepted when it only adds "null"; anything else is treated as dyn.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		var single string
+		for _, entry := range t {
+			name, _ := entry.(string)
+			if name == "null" {
+				continue
+			}
+			if single != "" {
+				return ""
+			}
+			single = name
+		}
+		return single
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+func (b *schemaBuilder) resolve(location string) (map[string]any, error) {
+	pointer, ok := strings.CutPrefix(location, "#/")
+	if !ok {
+		return nil, fmt.Errorf("expr: body schema $ref %q: only local references are supported", location)
+	}
+	var current any = b.document
+	for _, segment := range strings.Split(pointer, "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		m, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("expr: body schema $ref %q not found", location)
+		}
+		if current, ok = m[segment]; !ok {
+			return nil, fmt.Errorf("expr: body schema $ref %q not found", location)
+		}
+	}
+	target, ok := current.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("expr: body schema $ref %q is not a schema", location)
+	}
+	return target, nil
+}
+
+// checkLocalRefs rejects $ref values that would make the validator load
+// documents from disk or the network.
+func checkLocalRefs(value any) error {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			if location, ok := child.(string); ok && key == "$ref" && !strings.HasPrefix(location, "#") {
+				return fmt.Errorf("expr: body schema $ref %q: only local references are supported", location)
+			}
+			if err := checkLocalRefs(child); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if err := checkLocalRefs(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// backendStructName is the CEL type of the backend variable when its body is
+// typed by a schema.
+const backendStructName = "passctrl.backend"
+
+// WithBackendSchema returns an environment in which backend.body carries the
+// types declared by schema, so conditions reading undeclared or mistyped
+// fields fail to compile.
+func (e *Environment) WithBackendSchema(schema *BodySchema) (*Environment, error) {
+	if schema == nil {
+		return e, nil
+	}
+	base, err := types.NewRegistry()
+	if err != nil {
+		return nil, fmt.Errorf("expr: build type registry: %w", err)
+	}
+	structs := make(map[string]map[string]*types.Type, len(schema.structs)+1)
+	for name, fields := range schema.structs {
+		structs[name] = fields
+	}
+	structs[backendStructName] = map[string]*types.Type{
+		"requested":     types.BoolType,
+		"status":        types.IntType,
+		"headers":       types.NewMapType(types.StringType, types.DynType),
+		"body":          schema.Type(),
+		"bodyText":      types.StringType,
+		"error":         types.StringType,
+		"accepted":      types.BoolType,
+		"pages":         types.NewListType(types.NewMapType(types.StringType, types.DynType)),
+		"introspection": types.NewMapType(types.StringType, types.DynType),
+	}
+	return newEnvironment(types.NewObjectType(backendStructName), cel.CustomTypeProvider(&schemaProvider{Provider: base, structs: structs}))
+}
+
+// schemaProvider resolves the struct types derived from a body schema and
+// defers everything else to the standard registry. Values stay plain maps at
+// runtime; the struct types only exist for the type checker.
+type schemaProvider struct {
+	types.Provider
+	structs map[string]map[string]*types.Type
+}
+
+func (p *schemaProvider) FindStructType(name string) (*types.Type, bool) {
+	if _, ok := p.structs[name]; ok {
+		return types.NewTypeTypeWithParam(types.NewObjectType(name)), true
+	}
+	return p.Provider.FindStructType(name)
+}
+
+func (p *schemaProvider) FindStructFieldNames(name string) ([]string, bool) {
+	fields, ok := p.structs[name]
+	if !ok {
+		return p.Provider.FindStructFieldNames(name)
+	}
+	names := make([]string, 0, len(fields))
+	for field := range fields {
+		names = append(names, field)
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+func (p *schemaProvider) FindStructFieldType(name, field string) (*types.FieldType, bool) {
+	fields, ok := p.structs[name]
+	if !ok {
+		return p.Provider.FindStructFieldType(name, field)
+	}
+	fieldType, ok := fields[field]
+	if !ok {
+		return nil, false
+	}
+	return &types.FieldType{Type: fieldType}, true
+}
+
+func (p *schemaProvider) NewValue(name string, fields map[string]ref.Val) ref.Val {
+	if _, ok := p.structs[name]; ok {
+		return types.NewErr("expr: %s cannot be constructed in expressions", name)
+	}
+	return p.Provider.NewValue(name, fields)
+}
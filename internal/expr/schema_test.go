@@ -0,0 +1,109 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func accountSchema() map[string]any {
+	return map[string]any{
+		"type":     "object",
+		"required": []any{"active"},
+		"properties": map[string]any{
+			"active": map[string]any{"type": "boolean"},
+			"score":  map[string]any{"type": "number"},
+			"groups": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+			"owner":  map[string]any{"$ref": "#/$defs/user"},
+			"labels": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+		},
+		"$defs": map[string]any{
+			"user": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"id":   map[string]any{"type": "integer"},
+					"name": map[string]any{"type": []any{"string", "null"}},
+				},
+			},
+		},
+	}
+}
+
+func TestWithBackendSchema(t *testing.T) {
+	schema, err := CompileBodySchema("accounts", accountSchema())
+	require.NoError(t, err)
+	base, err := NewEnvironment()
+	require.NoError(t, err)
+	env, err := base.WithBackendSchema(schema)
+	require.NoError(t, err)
+
+	t.Run("compiles declared fields", func(t *testing.T) {
+		for _, source := range []string{
+			`backend.body.active`,
+			`backend.body.score > 2.5 && backend.status == 200`,
+			`"admins" in backend.body.groups`,
+			`backend.body.owner.id == 7 && backend.body.owner.name == "ada"`,
+			`backend.body.labels["team"] == "core"`,
+			`has(backend.body.owner) && lookup(backend.headers, "x") == null`,
+		} {
+			_, err := env.Compile(source)
+			require.NoError(t, err, source)
+		}
+	})
+
+	t.Run("rejects typos and type mismatches", func(t *testing.T) {
+		_, err := env.Compile(`backend.body.acitve`)
+		require.ErrorContains(t, err, "undefined field 'acitve'")
+
+		_, err = env.Compile(`backend.body.owner.id == "7"`)
+		require.Error(t, err)
+
+		_, err = env.Compile(`backend.body.groups`)
+		require.ErrorContains(t, err, "must return bool")
+	})
+
+	t.Run("evaluates against plain maps", func(t *testing.T) {
+		body, err := schema.Apply(map[string]any{
+			"active": true,
+			"score":  int64(3),
+			"groups": []any{"admins"},
+			"owner":  map[string]any{"id": float64(7), "name": "ada"},
+		})
+		require.NoError(t, err)
+
+		program, err := env.Compile(`backend.body.active && backend.body.score + 0.5 == 3.5 && backend.body.owner.id == 7`)
+		require.NoError(t, err)
+		matched, err := program.EvalBool(map[string]any{
+			"backend": map[string]any{"body": body, "status": 200},
+		})
+		require.NoError(t, err)
+		require.True(t, matched)
+	})
+
+	t.Run("untyped environment still accepts any field", func(t *testing.T) {
+		_, err := base.Compile(`backend.body.acitve`)
+		require.NoError(t, err)
+	})
+}
+
+func TestBodySchemaApply(t *testing.T) {
+	schema, err := CompileBodySchema("accounts", accountSchema())
+	require.NoError(t, err)
+
+	_, err = schema.Apply(map[string]any{"active": "yes"})
+	require.ErrorContains(t, err, "active: Invalid type. Expected: boolean, given: string")
+
+	_, err = schema.Apply(map[string]any{"score": 1.5})
+	require.ErrorContains(t, err, "active is required")
+}
+
+func TestCompileBodySchemaRejectsRemoteRefs(t *testing.T) {
+	_, err := CompileBodySchema("remote", map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"user": map[string]any{"$ref": "https://schemas.example/user.json"}},
+	})
+	require.ErrorContains(t, err, "only local references")
+
+	_, err = CompileBodySchema("missing", map[string]any{"$ref": "#/$defs/absent"})
+	require.ErrorContains(t, err, "$defs")
+}
@@ -0,0 +1,161 @@
+package filewatch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultDebounce matches the rules watcher so bursts of editor writes collapse
+// into a single reload.
+const DefaultDebounce = 25 * time.Millisecond
+
+// Watcher observes a fixed set of files and invokes a callback once changes
+// settle. Stop must be called to release filesystem resources.
+type Watcher struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	once   sync.Once
+}
+
+// Stop halts the watcher and waits for the underlying goroutine to exit.
+func (w *Watcher) Stop() {
+	if w == nil {
+		return
+	}
+	w.once.Do(func() {
+		w.cancel()
+		<-w.done
+	})
+}
+
+// Watch registers the parent directories of the supplied files with fsnotify
+// and calls onChange whenever one of the files is written, replaced, or
+// removed. Watching directories instead of the files themselves keeps the
+// watch alive across atomic rename-based writes. Events for other names in a
+// watched directory re-resolve the files' symlinks, so Kubernetes Secret and
+// ConfigMap volumes, which update by swapping a ..data symlink, also count as
+// changes.
+func Watch(ctx context.Context, paths []string, onChange func(), onError func(error)) (*Watcher, error) {
+	if onChange == nil {
+		return nil, errors.New("filewatch: change callback required")
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("filewatch: no files to watch")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("filewatch: %w", err)
+	}
+
+	// targets maps each watched path to the file its symlinks resolve to.
+	targets := make(map[string]string, len(paths))
+	dirs := make(map[string]struct{})
+	for _, path := range paths {
+		resolved, err := filepath.Abs(path)
+		if err != nil {
+			resolved = path
+		}
+		resolved = filepath.Clean(resolved)
+		targets[resolved] = resolveLinks(resolved)
+		dir := filepath.Dir(resolved)
+		if _, ok := dirs[dir]; ok {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("filewatch: watch %s: %w", dir, err)
+		}
+		dirs[dir] = struct{}{}
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	w := &Watcher{cancel: cancel, done: make(chan struct{})}
+
+	go func() {
+		defer close(w.done)
+		defer func() {
+			if err := watcher.Close(); err != nil && onError != nil {
+				onError(fmt.Errorf("filewatch: close: %w", err))
+			}
+		}()
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-watchCtx.Done():
+				return
+			case <-fire:
+				fire = nil
+				onChange()
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Clean(event.Name)
+				if _, tracked := targets[name]; tracked {
+					if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove|fsnotify.Chmod) == 0 {
+						continue
+					}
+					targets[name] = resolveLinks(name)
+				} else if !relinked(targets, filepath.Dir(name)) {
+					continue
+				}
+				if timer == nil {
+					timer = time.NewTimer(DefaultDebounce)
+				} else {
+					timer.Reset(DefaultDebounce)
+				}
+				fire = timer.C
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				if onError != nil {
+					onError(fmt.Errorf("filewatch: %w", err))
+				}
+			}
+		}
+	}()
+
+	return w, nil
+}
+
+// relinked re-resolves the targets inside dir and reports whether any of them
+// now points at a different file.
+func relinked(targets map[string]string, dir string) bool {
+	changed := false
+	for path, previous := range targets {
+		if filepath.Dir(path) != dir {
+			continue
+		}
+		if current := resolveLinks(path); current != previous {
+			targets[path] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
+// resolveLinks returns the file path points at after following symlinks, or
+// an empty string while it does not exist.
+func resolveLinks(path string) string {
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return ""
+	}
+	return resolved
+}
@@ -0,0 +1,92 @@
+package filewatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchInvokesCallbackOnChange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "users.htpasswd")
+	other := filepath.Join(dir, "other.txt")
+	require.NoError(t, os.WriteFile(target, []byte("v1"), 0o600))
+
+	changes := make(chan struct{}, 4)
+	watcher, err := Watch(ctx, []string{target}, func() { changes <- struct{}{} }, func(err error) {
+		require.NoError(t, err)
+	})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	require.NoError(t, os.WriteFile(other, []byte("ignored"), 0o600))
+	select {
+	case <-changes:
+		require.FailNow(t, "unrelated file triggered a change")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	require.NoError(t, os.WriteFile(target, []byte("v2"), 0o600))
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for change notification")
+	}
+}
+
+func TestWatchFollowsSymlinkSwap(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Lay the directory out the way Kubernetes mounts Secrets and ConfigMaps:
+	// tls.crt -> ..data/tls.crt and ..data -> a timestamped directory.
+	dir := t.TempDir()
+	writeVersion := func(version, contents string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version, "tls.crt"), []byte(contents), 0o600))
+	}
+	writeVersion("..2026_01_01", "v1")
+	require.NoError(t, os.Symlink("..2026_01_01", filepath.Join(dir, "..data")))
+	target := filepath.Join(dir, "tls.crt")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "tls.crt"), target))
+
+	changes := make(chan struct{}, 4)
+	watcher, err := Watch(ctx, []string{target}, func() { changes <- struct{}{} }, func(err error) {
+		require.NoError(t, err)
+	})
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	writeVersion("..2026_01_02", "v2")
+	select {
+	case <-changes:
+		require.FailNow(t, "a new version directory alone must not trigger a change")
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	require.NoError(t, os.Symlink("..2026_01_02", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		require.FailNow(t, "timeout waiting for change notification after the ..data swap")
+	}
+	contents, err := os.ReadFile(target)
+	require.NoError(t, err)
+	require.Equal(t, "v2", string(contents))
+}
+
+func TestWatchValidatesArguments(t *testing.T) {
+	_, err := Watch(context.Background(), []string{"file"}, nil, nil)
+	require.Error(t, err)
+
+	_, err = Watch(context.Background(), nil, func() {}, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultInterval spaces probe rounds so orchestrator probes read cached
+	// results instead of fanning out to dependencies on every request.
+	DefaultInterval = 10 * time.Second
+	// DefaultTimeout bounds a single probe.
+	DefaultTimeout = 2 * time.Second
+)
+
+// Check describes a dependency probe. Critical checks gate readiness; the
+// others are reported without affecting it.
+type Check struct {
+	Name     string
+	Critical bool
+	Run      func(context.Context) error
+}
+
+// Result is the cached outcome of the most recent probe run.
+type Result struct {
+	Name      string        `json:"name"`
+	Critical  bool          `json:"critical"`
+	Status    string        `json:"status"` // pass | fail | pending
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checkedAt,omitzero"`
+	Duration  time.Duration `json:"duration,omitempty"`
+}
+
+// Report summarizes readiness for the /readyz handler.
+type Report struct {
+	Ready      bool      `json:"ready"`
+	Status     string    `json:"status"`
+	ObservedAt time.Time `json:"observedAt"`
+	Checks     []Result  `json:"checks,omitempty"`
+}
+
+// Scheduler runs registered checks on a fixed interval and serves the cached
+// results. The listeners start only after the initial rule bundle compiles, so
+// readiness never needs to account for rules still loading.
+type Scheduler struct {
+	logger   *slog.Logger
+	interval time.Duration
+	timeout  time.Duration
+	now      func() time.Time
+
+	mu      sync.RWMutex
+	groups  map[string][]Check
+	results map[string]Result
+	trigger chan struct{}
+}
+
+// NewScheduler builds a scheduler; non-positive durations fall back to the
+// package defaults.
+func NewScheduler(logger *slog.Logger, interval, timeout time.Duration) *Scheduler {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Scheduler{
+		logger:   logger.With(slog.String("agent", "health")),
+		interval: interval,
+		timeout:  timeout,
+		now:      time.Now,
+		groups:   make(map[string][]Check),
+		results:  make(map[string]Result),
+		trigger:  make(chan struct{}, 1),
+	}
+}
+
+// SetChecks replaces the checks registered under group and schedules an
+// immediate probe round. Results for checks that remain registered are kept.
+func (s *Scheduler) SetChecks(group string, checks []Check) {
+	s.mu.Lock()
+	s.groups[group] = append([]Check(nil), checks...)
+	active := make(map[string]struct{})
+	for _, list := range s.groups {
+		for _, check := range list {
+			active[check.Name] = struct{}{}
+		}
+	}
+	for name := range s.results {
+		if _, ok := active[name]; !ok {
+			delete(s.results, name)
+		}
+	}
+	s.mu.Unlock()
+	s.poke()
+}
+
+// Start runs a probe round immediately and then on every interval until ctx
+// is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			s.RunOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.trigger:
+			}
+		}
+	}()
+}
+
+// RunOnce probes every registered check concurrently and records the results.
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	s.mu.RLock()
+	var checks []Check
+	for _, list := range s.groups {
+		checks = append(checks, list...)
+	}
+	s.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := s.probe(ctx, check)
+			s.mu.Lock()
+			previous, seen := s.results[check.Name]
+			s.results[check.Name] = result
+			s.mu.Unlock()
+			if seen && previous.Status != result.Status {
+				s.logger.Info("health check changed", slog.String("check", check.Name), slog.String("status", result.Status), slog.String("error", result.Error))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) probe(ctx context.Context, check Check) Result {
+	probeCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	started := s.now()
+	err := check.Run(probeCtx)
+	result := Result{
+		Name:      check.Name,
+		Critical:  check.Critical,
+		Status:    "pass",
+		CheckedAt: started.UTC(),
+		Duration:  s.now().Sub(started),
+	}
+	if err != nil {
+		if errors.Is(probeCtx.Err(), context.DeadlineExceeded) {
+			err = errors.New("probe timed out")
+		}
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Report assembles readiness from the cached results. Critical
+// checks that have not completed a probe yet count as not ready.
+func (s *Scheduler) Report() Report {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	report := Report{Ready: true, ObservedAt: s.now().UTC()}
+	for _, list := range s.groups {
+		for _, check := range list {
+			result, ok := s.results[check.Name]
+			if !ok {
+				result = Result{Name: check.Name, Critical: check.Critical, Status: "pending"}
+			}
+			if check.Critical && result.Status != "pass" {
+				report.Ready = false
+			}
+			report.Checks = append(report.Checks, result)
+		}
+	}
+	sort.Slice(report.Checks, func(i, j int) bool { return report.Checks[i].Name < report.Checks[j].Name })
+	report.Status = "ready"
+	if !report.Ready {
+		report.Status = "not_ready"
+	}
+	return report
+}
+
+// ReadyHandler serves the cached readiness report, answering 503 while any
+// critical check is failing.
+func (s *Scheduler) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		report := s.Report()
+		status := http.StatusOK
+		if !report.Ready {
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, report)
+	})
+}
+
+// LiveHandler reports process liveness. It never consults dependencies so a
+// failing backend cannot cause the orchestrator to restart PassCtrl.
+func LiveHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"status": "ok", "observedAt": time.Now().UTC()})
+	})
+}
+
+func (s *Scheduler) poke() {
+	select {
+	case s.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
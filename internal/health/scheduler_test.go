@@ -0,0 +1,141 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScheduler() *Scheduler {
+	return NewScheduler(slog.New(slog.NewTextHandler(io.Discard, nil)), time.Hour, 50*time.Millisecond)
+}
+
+func readyz(t *testing.T, s *Scheduler) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ReadyHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestSchedulerReadiness(t *testing.T) {
+	var cacheDown atomic.Bool
+	cacheDown.Store(true)
+
+	s := newTestScheduler()
+	s.SetChecks("cache", []Check{{
+		Name:     "cache",
+		Critical: true,
+		Run: func(context.Context) error {
+			if cacheDown.Load() {
+				return errors.New("connection refused")
+			}
+			return nil
+		},
+	}})
+	s.SetChecks("backends", []Check{{
+		Name: "backend:optional",
+		Run:  func(context.Context) error { return errors.New("down") },
+	}})
+
+	status, report := readyz(t, s)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, "pending", report.Checks[1].Status, "critical checks are pending until the first probe round")
+
+	s.RunOnce(context.Background())
+	status, report = readyz(t, s)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+	require.Equal(t, "not_ready", report.Status)
+	require.Equal(t, "fail", report.Checks[1].Status)
+	require.Equal(t, "connection refused", report.Checks[1].Error)
+
+	cacheDown.Store(false)
+	s.RunOnce(context.Background())
+	status, report = readyz(t, s)
+	require.Equal(t, http.StatusOK, status, "non-critical failures must not gate readiness")
+	require.Equal(t, "ready", report.Status)
+	require.Equal(t, "fail", report.Checks[0].Status)
+	require.Equal(t, "pass", report.Checks[1].Status)
+}
+
+func TestSchedulerCachesResultsBetweenRounds(t *testing.T) {
+	var calls atomic.Int32
+	s := newTestScheduler()
+	s.SetChecks("backends", []Check{{
+		Name:     "backend:api",
+		Critical: true,
+		Run: func(context.Context) error {
+			calls.Add(1)
+			return nil
+		},
+	}})
+	s.RunOnce(context.Background())
+
+	for range 5 {
+		status, _ := readyz(t, s)
+		require.Equal(t, http.StatusOK, status)
+	}
+	require.Equal(t, int32(1), calls.Load(), "readiness requests must not trigger probes")
+}
+
+func TestSchedulerSetChecksDropsStaleResults(t *testing.T) {
+	s := newTestScheduler()
+	s.SetChecks("backends", []Check{{Name: "backend:old", Critical: true, Run: func(context.Context) error { return errors.New("down") }}})
+	s.RunOnce(context.Background())
+
+	s.SetChecks("backends", []Check{{Name: "backend:new", Critical: true, Run: func(context.Context) error { return nil }}})
+	s.RunOnce(context.Background())
+
+	report := s.Report()
+	require.True(t, report.Ready)
+	require.Len(t, report.Checks, 1)
+	require.Equal(t, "backend:new", report.Checks[0].Name)
+}
+
+func TestSchedulerProbeTimeout(t *testing.T) {
+	s := newTestScheduler()
+	s.SetChecks("backends", []Check{{
+		Name:     "backend:slow",
+		Critical: true,
+		Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}})
+	s.RunOnce(context.Background())
+	report := s.Report()
+	require.False(t, report.Ready)
+	require.Equal(t, "probe timed out", report.Checks[0].Error)
+}
+
+func TestHTTPCheck(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer backend.Close()
+
+	check := HTTPCheck("backend:api", backend.URL, true, backend.Client())
+	require.NoError(t, check.Run(context.Background()))
+
+	status.Store(http.StatusServiceUnavailable)
+	require.ErrorContains(t, check.Run(context.Background()), "unexpected status 503")
+}
+
+func TestLiveHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	LiveHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"status":"ok"`)
+}
@@ -11,21 +11,22 @@ import (
 
 // New shapes slog so emitted telemetry matches the runtime policy described in the design docs.
 func New(cfg config.LoggingConfig) (*slog.Logger, error) {
-	var level slog.Level
-	switch strings.ToLower(cfg.Level) {
-	case "debug":
-		level = slog.LevelDebug
-	case "info", "":
-		level = slog.LevelInfo
-	case "warn":
-		level = slog.LevelWarn
-	case "error":
-		level = slog.LevelError
-	default:
-		return nil, fmt.Errorf("logging: unsupported level %q", cfg.Level)
+	logger, _, err := NewWithLevel(cfg)
+	return logger, err
+}
+
+// NewWithLevel builds the logger around a shared level variable so
+// configuration reloads can change verbosity without rebuilding handlers that
+// agents have already captured.
+func NewWithLevel(cfg config.LoggingConfig) (*slog.Logger, *slog.LevelVar, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, nil, err
 	}
+	levelVar := new(slog.LevelVar)
+	levelVar.Set(level)
 
-	opts := &slog.HandlerOptions{Level: level}
+	opts := &slog.HandlerOptions{Level: levelVar}
 	var handler slog.Handler
 	switch strings.ToLower(cfg.Format) {
 	case "json", "":
@@ -33,12 +34,28 @@ func New(cfg config.LoggingConfig) (*slog.Logger, error) {
 	case "text":
 		handler = slog.NewTextHandler(os.Stdout, opts)
 	default:
-		return nil, fmt.Errorf("logging: unsupported format %q", cfg.Format)
+		return nil, nil, fmt.Errorf("logging: unsupported format %q", cfg.Format)
 	}
 
 	logger := slog.New(handler).With(slog.String("component", "passctrl"))
 	if cfg.CorrelationHeader != "" {
 		logger = logger.With(slog.String("correlation_header", cfg.CorrelationHeader))
 	}
-	return logger, nil
+	return logger, levelVar, nil
+}
+
+// ParseLevel maps the configured level name onto slog; an empty value means info.
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info", "":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("logging: unsupported level %q", level)
+	}
 }
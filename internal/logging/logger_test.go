@@ -1,6 +1,8 @@
 package logging
 
 import (
+	"context"
+	"log/slog"
 	"testing"
 
 	"github.com/l0p7/passctrl/internal/config"
@@ -22,3 +24,14 @@ func TestNewRejectsUnknownFormat(t *testing.T) {
 	_, err := New(config.LoggingConfig{Format: "binary"})
 	require.Error(t, err)
 }
+
+func TestNewWithLevelAdjustsVerbosityLive(t *testing.T) {
+	logger, level, err := NewWithLevel(config.LoggingConfig{Level: "warn", Format: "text"})
+	require.NoError(t, err)
+	require.False(t, logger.Enabled(context.Background(), slog.LevelInfo))
+
+	debug, err := ParseLevel("debug")
+	require.NoError(t, err)
+	level.Set(debug)
+	require.True(t, logger.Enabled(context.Background(), slog.LevelDebug))
+}
@@ -43,6 +43,29 @@ const (
 	CacheStoreError CacheStoreOutcome = "error"
 )
 
+// RulesReloadOutcome captures the result of a rule bundle reload.
+type RulesReloadOutcome string
+
+const (
+	// RulesReloadApplied indicates the candidate bundle replaced the active snapshot.
+	RulesReloadApplied RulesReloadOutcome = "applied"
+	// RulesReloadRejected indicates the candidate compiled but was refused
+	// because it would take healthy endpoints out of service.
+	RulesReloadRejected RulesReloadOutcome = "rejected"
+	// RulesReloadFailed indicates the bundle could not be loaded at all.
+	RulesReloadFailed RulesReloadOutcome = "failed"
+)
+
+// TimeoutScope identifies which deadline expired during rule evaluation.
+type TimeoutScope string
+
+const (
+	// TimeoutScopeEndpoint indicates the endpoint's budget for the whole chain expired.
+	TimeoutScopeEndpoint TimeoutScope = "endpoint"
+	// TimeoutScopeRule indicates a single rule exceeded its own timeout.
+	TimeoutScopeRule TimeoutScope = "rule"
+)
+
 // Recorder exposes the metrics surface consumed by runtime agents.
 type Recorder interface {
 	Handler() http.Handler
@@ -50,6 +73,8 @@ type Recorder interface {
 	ObserveAuth(endpoint, outcome string, statusCode int, fromCache bool, duration time.Duration)
 	ObserveCacheLookup(endpoint string, result CacheLookupOutcome, duration time.Duration)
 	ObserveCacheStore(endpoint string, result CacheStoreOutcome, duration time.Duration)
+	ObserveRulesReload(result RulesReloadOutcome)
+	ObserveTimeout(endpoint, rule string, scope TimeoutScope)
 }
 
 type promRecorder struct {
@@ -61,6 +86,10 @@ type promRecorder struct {
 
 	cacheOperations *prometheus.CounterVec
 	cacheLatency    *prometheus.HistogramVec
+
+	rulesReloads     *prometheus.CounterVec
+	rulesLastApplied prometheus.Gauge
+	ruleTimeouts     *prometheus.CounterVec
 }
 
 var _ Recorder = (*promRecorder)(nil)
@@ -108,17 +137,41 @@ func NewRecorder(reg *prometheus.Registry) Recorder {
 		Buckets:   []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5},
 	}, []string{"endpoint", "operation", "result"})
 
-	reg.MustRegister(authRequests, authLatency, cacheOperations, cacheLatency)
+	rulesReloads := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "passctrl",
+		Subsystem: "rules",
+		Name:      "reloads_total",
+		Help:      "Rule bundle reload attempts by result.",
+	}, []string{"result"})
+
+	rulesLastApplied := prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "passctrl",
+		Subsystem: "rules",
+		Name:      "last_applied_timestamp_seconds",
+		Help:      "Unix time of the last rule bundle that replaced the active snapshot.",
+	})
+
+	ruleTimeouts := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "passctrl",
+		Subsystem: "rules",
+		Name:      "timeouts_total",
+		Help:      "Rule evaluations cut short by an endpoint or rule timeout.",
+	}, []string{"endpoint", "rule", "scope"})
+
+	reg.MustRegister(authRequests, authLatency, cacheOperations, cacheLatency, rulesReloads, rulesLastApplied, ruleTimeouts)
 
 	handler := promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
 
 	return &promRecorder{
-		gatherer:        reg,
-		handler:         handler,
-		authRequests:    authRequests,
-		authLatency:     authLatency,
-		cacheOperations: cacheOperations,
-		cacheLatency:    cacheLatency,
+		gatherer:         reg,
+		handler:          handler,
+		authRequests:     authRequests,
+		authLatency:      authLatency,
+		cacheOperations:  cacheOperations,
+		cacheLatency:     cacheLatency,
+		rulesReloads:     rulesReloads,
+		rulesLastApplied: rulesLastApplied,
+		ruleTimeouts:     ruleTimeouts,
 	}
 }
 
@@ -186,6 +239,39 @@ func (r *promRecorder) ObserveCacheStore(endpoint string, result CacheStoreOutco
 	r.observeCache(endpointLabel, CacheOperationStore, resultLabel, duration)
 }
 
+// ObserveRulesReload records a rule bundle reload attempt.
+func (r *promRecorder) ObserveRulesReload(result RulesReloadOutcome) {
+	if r == nil {
+		return
+	}
+	resultLabel := string(result)
+	if resultLabel == "" {
+		resultLabel = string(RulesReloadFailed)
+	}
+	r.rulesReloads.WithLabelValues(resultLabel).Inc()
+	if result == RulesReloadApplied {
+		r.rulesLastApplied.SetToCurrentTime()
+	}
+}
+
+// ObserveTimeout records a rule evaluation that hit a deadline. rule names the
+// rule that was running, or is empty when the endpoint budget expired between
+// rules.
+func (r *promRecorder) ObserveTimeout(endpoint, rule string, scope TimeoutScope) {
+	if r == nil {
+		return
+	}
+	scopeLabel := string(scope)
+	if scopeLabel == "" {
+		scopeLabel = string(TimeoutScopeEndpoint)
+	}
+	ruleLabel := strings.TrimSpace(rule)
+	if ruleLabel == "" {
+		ruleLabel = "none"
+	}
+	r.ruleTimeouts.WithLabelValues(normalizeLabel(endpoint), ruleLabel, scopeLabel).Inc()
+}
+
 func (r *promRecorder) observeCache(endpoint string, operation CacheOperation, result string, duration time.Duration) {
 	opLabel := string(operation)
 	if opLabel == "" {
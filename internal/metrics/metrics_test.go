@@ -127,6 +127,36 @@ func TestRecorderObserveCacheOperations(t *testing.T) {
 	}
 }
 
+func TestRecorderObserveRulesReload(t *testing.T) {
+	rec := NewRecorder(nil)
+	rec.ObserveRulesReload(RulesReloadApplied)
+	rec.ObserveRulesReload(RulesReloadRejected)
+	rec.ObserveRulesReload(RulesReloadRejected)
+
+	families := gather(t, rec, "passctrl_rules_reloads_total", "passctrl_rules_last_applied_timestamp_seconds")
+
+	rejected := findMetric(t, families["passctrl_rules_reloads_total"], map[string]string{"result": string(RulesReloadRejected)})
+	require.InDelta(t, 2, rejected.GetCounter().GetValue(), 1e-9)
+	applied := findMetric(t, families["passctrl_rules_reloads_total"], map[string]string{"result": string(RulesReloadApplied)})
+	require.InDelta(t, 1, applied.GetCounter().GetValue(), 1e-9)
+	lastApplied := findMetric(t, families["passctrl_rules_last_applied_timestamp_seconds"], map[string]string{})
+	require.Positive(t, lastApplied.GetGauge().GetValue())
+}
+
+func TestRecorderObserveTimeout(t *testing.T) {
+	rec := NewRecorder(nil)
+	rec.ObserveTimeout("gateway", "fetch-profile", TimeoutScopeRule)
+	rec.ObserveTimeout("gateway", "fetch-profile", TimeoutScopeRule)
+	rec.ObserveTimeout("gateway", "", TimeoutScopeEndpoint)
+
+	families := gather(t, rec, "passctrl_rules_timeouts_total")
+
+	rule := findMetric(t, families["passctrl_rules_timeouts_total"], map[string]string{"endpoint": "gateway", "rule": "fetch-profile", "scope": "rule"})
+	require.InDelta(t, 2, rule.GetCounter().GetValue(), 1e-9)
+	endpoint := findMetric(t, families["passctrl_rules_timeouts_total"], map[string]string{"endpoint": "gateway", "rule": "none", "scope": "endpoint"})
+	require.InDelta(t, 1, endpoint.GetCounter().GetValue(), 1e-9)
+}
+
 func TestRecorderHandler(t *testing.T) {
 	rec := NewRecorder(nil)
 	rr := httptest.NewRecorder()
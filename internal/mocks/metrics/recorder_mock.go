@@ -299,3 +299,95 @@ func (_c *MockRecorder_ObserveCacheStore_Call) RunAndReturn(run func(endpoint st
 	_c.Run(run)
 	return _c
 }
+
+// ObserveRulesReload provides a mock function for the type MockRecorder
+func (_mock *MockRecorder) ObserveRulesReload(result metrics.RulesReloadOutcome) {
+	_mock.Called(result)
+	return
+}
+
+// MockRecorder_ObserveRulesReload_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ObserveRulesReload'
+type MockRecorder_ObserveRulesReload_Call struct {
+	*mock.Call
+}
+
+// ObserveRulesReload is a helper method to define mock.On call
+//   - result metrics.RulesReloadOutcome
+func (_e *MockRecorder_Expecter) ObserveRulesReload(result interface{}) *MockRecorder_ObserveRulesReload_Call {
+	return &MockRecorder_ObserveRulesReload_Call{Call: _e.mock.On("ObserveRulesReload", result)}
+}
+
+func (_c *MockRecorder_ObserveRulesReload_Call) Run(run func(result metrics.RulesReloadOutcome)) *MockRecorder_ObserveRulesReload_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 metrics.RulesReloadOutcome
+		if args[0] != nil {
+			arg0 = args[0].(metrics.RulesReloadOutcome)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRecorder_ObserveRulesReload_Call) Return() *MockRecorder_ObserveRulesReload_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockRecorder_ObserveRulesReload_Call) RunAndReturn(run func(result metrics.RulesReloadOutcome)) *MockRecorder_ObserveRulesReload_Call {
+	_c.Run(run)
+	return _c
+}
+
+// ObserveTimeout provides a mock function for the type MockRecorder
+func (_mock *MockRecorder) ObserveTimeout(endpoint string, rule string, scope metrics.TimeoutScope) {
+	_mock.Called(endpoint, rule, scope)
+	return
+}
+
+// MockRecorder_ObserveTimeout_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ObserveTimeout'
+type MockRecorder_ObserveTimeout_Call struct {
+	*mock.Call
+}
+
+// ObserveTimeout is a helper method to define mock.On call
+//   - endpoint string
+//   - rule string
+//   - scope metrics.TimeoutScope
+func (_e *MockRecorder_Expecter) ObserveTimeout(endpoint interface{}, rule interface{}, scope interface{}) *MockRecorder_ObserveTimeout_Call {
+	return &MockRecorder_ObserveTimeout_Call{Call: _e.mock.On("ObserveTimeout", endpoint, rule, scope)}
+}
+
+func (_c *MockRecorder_ObserveTimeout_Call) Run(run func(endpoint string, rule string, scope metrics.TimeoutScope)) *MockRecorder_ObserveTimeout_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 string
+		if args[0] != nil {
+			arg0 = args[0].(string)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		var arg2 metrics.TimeoutScope
+		if args[2] != nil {
+			arg2 = args[2].(metrics.TimeoutScope)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockRecorder_ObserveTimeout_Call) Return() *MockRecorder_ObserveTimeout_Call {
+	_c.Call.Return()
+	return _c
+}
+
+func (_c *MockRecorder_ObserveTimeout_Call) RunAndReturn(run func(endpoint string, rule string, scope metrics.TimeoutScope)) *MockRecorder_ObserveTimeout_Call {
+	_c.Run(run)
+	return _c
+}
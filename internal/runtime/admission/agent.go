@@ -101,6 +101,7 @@ func (a *Agent) Execute(_ context.Context, r *http.Request, state *pipeline.Stat
 	state.Admission.Snapshot = nil
 	state.Admission.Allow = admissionAllowSnapshot(a.cfg.Allow)
 	state.Admission.Credentials = nil
+	state.Admission.PeerCertificate = pipeline.VerifiedPeerCertificate(r)
 
 	if state.Admission.ForwardedFor != "" || state.Admission.Forwarded != "" {
 		addr, err := parseRemoteIP(r.RemoteAddr)
@@ -172,7 +173,8 @@ func (a *Agent) finish(state *pipeline.State, status string) pipeline.Result {
 			"query":         append([]string{}, state.Admission.Allow.Query...),
 			"none":          state.Admission.Allow.None,
 		},
-		"credentials": cloneAdmissionCredentials(state.Admission.Credentials),
+		"credentials":     cloneAdmissionCredentials(state.Admission.Credentials),
+		"peerCertificate": state.Admission.PeerCertificate.Map(),
 	}
 	if status == "" {
 		status = decision
@@ -186,6 +188,7 @@ func (a *Agent) finish(state *pipeline.State, status string) pipeline.Result {
 			"trustedProxy":  state.Admission.TrustedProxy,
 			"proxyStripped": state.Admission.ProxyStripped,
 			"decision":      decision,
+			"mtls":          state.Admission.PeerCertificate != nil,
 		},
 	}
 }
@@ -2,10 +2,17 @@ package admission
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
+	"encoding/hex"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/netip"
+	"net/url"
 	"testing"
 
 	"github.com/l0p7/passctrl/internal/runtime/pipeline"
@@ -194,6 +201,48 @@ func TestAgentEmitsChallengeWhenUnauthenticated(t *testing.T) {
 	assert.NotEmpty(t, state.Response.Message)
 }
 
+func TestAgentRecordsVerifiedPeerCertificate(t *testing.T) {
+	cfg := Config{Allow: AllowConfig{None: true}}
+	agent := New(nil, false, cfg)
+
+	leaf := &x509.Certificate{
+		Raw:          []byte("leaf-der"),
+		Subject:      pkix.Name{CommonName: "svc-a", Organization: []string{"Acme"}},
+		Issuer:       pkix.Name{CommonName: "internal-ca"},
+		SerialNumber: big.NewInt(42),
+		DNSNames:     []string{"svc-a.internal"},
+		URIs:         []*url.URL{{Scheme: "spiffe", Host: "acme.test", Path: "/svc-a"}},
+	}
+	req := httptest.NewRequest(http.MethodGet, "https://example.com/auth", nil)
+	req.RemoteAddr = "203.0.113.5:443"
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{leaf}}}
+	state := pipeline.NewState(req, "endpoint", "cache", "corr")
+
+	res := agent.Execute(context.Background(), req, state)
+	require.Equal(t, "pass", res.Status)
+	require.Equal(t, true, res.Meta["mtls"])
+	peer := state.Admission.PeerCertificate
+	require.NotNil(t, peer)
+	require.Equal(t, "svc-a", peer.CommonName)
+	require.Equal(t, "CN=svc-a,O=Acme", peer.Subject)
+	require.Equal(t, "CN=internal-ca", peer.Issuer)
+	require.Equal(t, "42", peer.SerialNumber)
+	require.Equal(t, []string{"spiffe://acme.test/svc-a"}, peer.URIs)
+	sum := sha256.Sum256(leaf.Raw)
+	require.Equal(t, hex.EncodeToString(sum[:]), peer.FingerprintSHA256)
+	require.Equal(t, "svc-a", state.Admission.Snapshot["peerCertificate"].(map[string]any)["commonName"])
+
+	// Presented but unverified certificates never become identity.
+	unverified := httptest.NewRequest(http.MethodGet, "https://example.com/auth", nil)
+	unverified.RemoteAddr = "203.0.113.5:443"
+	unverified.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}}
+	state = pipeline.NewState(unverified, "endpoint", "cache", "corr")
+	res = agent.Execute(context.Background(), unverified, state)
+	require.Equal(t, "pass", res.Status)
+	require.Nil(t, state.Admission.PeerCertificate)
+	require.Equal(t, false, res.Meta["mtls"])
+}
+
 func TestAgentAllowsOptionalAuthenticationWithoutCredentials(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "http://example.com/auth", nil)
 	req.RemoteAddr = "203.0.113.5:443"
@@ -11,6 +11,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/l0p7/passctrl/internal/expr"
 	runtimemocks "github.com/l0p7/passctrl/internal/mocks/runtime"
 	"github.com/l0p7/passctrl/internal/runtime/admission"
 	"github.com/l0p7/passctrl/internal/runtime/cache"
@@ -270,7 +271,7 @@ func TestRuleChainAgentExecute(t *testing.T) {
 
 func TestRuleExecutionAgentExecute(t *testing.T) {
 	newAgent := func(client httpDoer) *ruleExecutionAgent {
-		backendAgent := newBackendInteractionAgent(client, nil)
+		backendAgent := newBackendInteractionAgent(client, nil, nil)
 		return newRuleExecutionAgent(backendAgent, nil, nil, nil, 0, nil, "")
 	}
 
@@ -548,7 +549,7 @@ func TestRuleExecutionAgentExecute(t *testing.T) {
 		state.Rule.ShouldExecute = true
 		state.SetPlan(rulechain.ExecutionPlan{Rules: defs})
 
-		backendAgent := newBackendInteractionAgent(mockClient, nil)
+		backendAgent := newBackendInteractionAgent(mockClient, nil, nil)
 		res := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "").Execute(context.Background(), nil, state)
 		require.Equal(t, "pass", res.Status)
 		require.Equal(t, "pass", state.Rule.Outcome)
@@ -655,6 +656,119 @@ func TestRuleExecutionAgentExecute(t *testing.T) {
 		require.Equal(t, "pass", res.Status)
 		require.Equal(t, "endpoint=test:token", state.Rule.Reason)
 	})
+
+	t.Run("parallel group is decided by the earliest declared failure", func(t *testing.T) {
+		mockClient := runtimemocks.NewMockHTTPDoer(t)
+		mockClient.EXPECT().
+			Do(mock.AnythingOfType("*http.Request")).
+			RunAndReturn(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Path == "/slow" {
+					// Answer only once the later sibling has failed and
+					// cancelled the group.
+					<-req.Context().Done()
+				}
+				return newBackendResponse(http.StatusOK, `{"deny": true}`, map[string]string{"Content-Type": "application/json"}), nil
+			}).Twice()
+
+		deny := func(name, path string) rulechain.DefinitionSpec {
+			return rulechain.DefinitionSpec{
+				Name:        name,
+				Backend:     rulechain.BackendDefinitionSpec{URL: "https://backend.test" + path},
+				Conditions:  rulechain.ConditionSpec{Fail: []string{`backend.body.deny == true`}},
+				FailMessage: name + " denied",
+			}
+		}
+		defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
+			deny("slow", "/slow"),
+			deny("fast", "/fast"),
+			{Name: "queued", Conditions: rulechain.ConditionSpec{Pass: []string{"true"}}},
+		}, nil)
+		require.NoError(t, err)
+
+		state := &pipeline.State{}
+		state.Rule.ShouldExecute = true
+		state.SetPlan(rulechain.ExecutionPlan{Rules: defs, Steps: []rulechain.Step{{
+			Group:   rulechain.GroupParallel,
+			Workers: 2,
+			Steps:   []rulechain.Step{{Rule: &defs[0]}, {Rule: &defs[1]}, {Rule: &defs[2]}},
+		}}})
+
+		res := newAgent(mockClient).Execute(context.Background(), nil, state)
+		require.Equal(t, "fail", res.Status)
+		require.Equal(t, "slow denied", state.Rule.Reason)
+		require.Len(t, state.Rule.History, 1)
+		children := state.Rule.History[0].Children
+		require.Len(t, children, 3)
+		require.Equal(t, "fail", children[0].Outcome)
+		require.Equal(t, "fail", children[1].Outcome)
+		require.Equal(t, outcomeCancelled, children[2].Outcome)
+		require.Equal(t, "not started: cancelled after slow returned fail", children[2].Reason)
+	})
+}
+
+func TestRuleExecutionAgentControlFlow(t *testing.T) {
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
+		{Name: "admin-token", Conditions: rulechain.ConditionSpec{Pass: []string{`lookup(forward.query, "admin") == "yes"`}}},
+		{Name: "session", Conditions: rulechain.ConditionSpec{Pass: []string{`lookup(forward.query, "session") != ""`}}},
+		{Name: "group", Conditions: rulechain.ConditionSpec{Pass: []string{`lookup(forward.query, "group") == "ops"`}}},
+		{Name: "beta-gate", Conditions: rulechain.ConditionSpec{Fail: []string{"true"}}, FailMessage: "beta closed"},
+	}, nil)
+	require.NoError(t, err)
+	env, err := expr.NewEnvironment()
+	require.NoError(t, err)
+	when, err := env.Compile(`lookup(forward.query, "beta") == "1"`)
+	require.NoError(t, err)
+
+	steps := []rulechain.Step{
+		{Group: rulechain.GroupAnyOf, Steps: []rulechain.Step{
+			{Rule: &defs[0], Stop: true},
+			{Group: rulechain.GroupAllOf, Steps: []rulechain.Step{{Rule: &defs[1]}, {Rule: &defs[2]}}},
+		}},
+		{Rule: &defs[3], When: &when},
+	}
+	run := func(query map[string]string) *pipeline.State {
+		state := &pipeline.State{Forward: pipeline.ForwardState{Query: query}}
+		state.Rule.ShouldExecute = true
+		state.SetPlan(rulechain.ExecutionPlan{Rules: defs, Steps: steps})
+		agent.Execute(context.Background(), nil, state)
+		return state
+	}
+
+	t.Run("first alternative stops the chain", func(t *testing.T) {
+		state := run(map[string]string{"admin": "yes", "beta": "1"})
+		require.Equal(t, "pass", state.Rule.Outcome)
+		require.Len(t, state.Rule.History, 1, "rules after onPass: stop are not evaluated")
+		group := state.Rule.History[0]
+		require.Equal(t, rulechain.GroupAnyOf, group.Group)
+		require.True(t, group.Children[0].Stopped)
+		require.Len(t, group.Children, 1)
+	})
+
+	t.Run("second alternative passes and guard skips", func(t *testing.T) {
+		state := run(map[string]string{"session": "abc", "group": "ops"})
+		require.Equal(t, "pass", state.Rule.Outcome)
+		require.Len(t, state.Rule.History, 2)
+		alternatives := state.Rule.History[0].Children
+		require.Equal(t, "fail", alternatives[0].Outcome)
+		require.Equal(t, "pass", alternatives[1].Outcome)
+		require.Equal(t, []string{"session", "group"}, []string{alternatives[1].Children[0].Name, alternatives[1].Children[1].Name})
+		require.Equal(t, "skipped", state.Rule.History[1].Outcome)
+		require.Contains(t, state.Rule.History[1].Reason, "when guard not satisfied")
+	})
+
+	t.Run("guarded rule runs when its guard holds", func(t *testing.T) {
+		state := run(map[string]string{"session": "abc", "group": "ops", "beta": "1"})
+		require.Equal(t, "fail", state.Rule.Outcome)
+		require.Equal(t, "beta closed", state.Rule.Reason)
+	})
+
+	t.Run("no alternative passes", func(t *testing.T) {
+		state := run(map[string]string{"session": "abc", "group": "dev"})
+		require.Equal(t, "fail", state.Rule.Outcome)
+		require.Len(t, state.Rule.History, 1)
+		require.Equal(t, "fail", state.Rule.History[0].Children[1].Children[1].Outcome)
+	})
 }
 
 func TestResponsePolicyAgentExecute(t *testing.T) {
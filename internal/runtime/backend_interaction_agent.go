@@ -16,28 +16,69 @@ import (
 	"github.com/l0p7/passctrl/internal/runtime/rulechain"
 )
 
-// backendInteractionAgent executes HTTP requests to backend APIs with pagination support.
-// It is responsible purely for HTTP execution and response capture, without any template
-// rendering, credential matching, condition evaluation, or caching logic.
+// backendInteractionAgent executes HTTP requests to backend APIs with pagination support,
+// LDAP binds for backends of type ldap, queries for backends of type sql, and unary
+// calls for backends of type grpc. It is responsible purely for execution and
+// response capture, without any template rendering, credential matching, condition
+// evaluation, or caching logic.
 type backendInteractionAgent struct {
 	client httpDoer
 	logger *slog.Logger
+	ldap   *ldapPools
+	grpc   *grpcClients
 }
 
 // newBackendInteractionAgent creates a new backend interaction agent with the given HTTP client and logger.
-func newBackendInteractionAgent(client httpDoer, logger *slog.Logger) *backendInteractionAgent {
+// LDAP and gRPC connections come from pools; a nil pools gives the agent its own.
+func newBackendInteractionAgent(client httpDoer, logger *slog.Logger, pools *backendPools) *backendInteractionAgent {
+	if pools == nil {
+		pools = newBackendPools()
+	}
 	return &backendInteractionAgent{
 		client: client,
 		logger: logger,
+		ldap:   pools.ldap,
+		grpc:   pools.grpc,
 	}
 }
 
+// backendPools holds the LDAP connection pools and gRPC client connections
+// shared by every endpoint of one endpoint set. They live exactly as long as
+// the snapshot built from the set and are closed once it has drained, so a
+// reload never strands the connections of the set it replaced.
+type backendPools struct {
+	ldap *ldapPools
+	grpc *grpcClients
+}
+
+func newBackendPools() *backendPools {
+	return &backendPools{ldap: newLDAPPools(), grpc: newGRPCClients()}
+}
+
+// Close releases every pooled LDAP connection and gRPC client connection.
+func (b *backendPools) Close() error {
+	if b == nil {
+		return nil
+	}
+	b.ldap.close()
+	return b.grpc.close()
+}
+
 // Execute executes a pre-rendered backend request and handles pagination.
 // The rendered parameter contains all template-rendered values (URL, headers, body, etc.).
 // Populates state.Backend.* with responses and errors.
 // Returns error only for fatal issues (nil state, context cancellation).
 // Non-fatal errors (network, timeout, parse) are stored in state.Backend.Error.
 func (a *backendInteractionAgent) Execute(ctx context.Context, rendered renderedBackendRequest, backend rulechain.BackendDefinition, state *pipeline.State) error {
+	if backend.IsLDAP() {
+		return a.executeLDAP(ctx, rendered, backend, state)
+	}
+	if backend.IsSQL() {
+		return a.executeSQL(ctx, rendered, backend, state)
+	}
+	if backend.IsGRPC() {
+		return a.executeGRPC(ctx, rendered, backend, state)
+	}
 	if a.client == nil {
 		return errors.New("backend interaction agent: http client missing")
 	}
@@ -53,6 +94,10 @@ func (a *backendInteractionAgent) Execute(ctx context.Context, rendered rendered
 	pages := make([]pipeline.BackendPageState, 0, maxPages)
 
 	for page := 0; page < maxPages; page++ {
+		if page > 0 && ctx.Err() != nil {
+			// Keep the pages already fetched; the deadline stops pagination.
+			break
+		}
 		trimmed := strings.TrimSpace(nextURL)
 		if trimmed == "" {
 			break
@@ -112,6 +157,11 @@ func (a *backendInteractionAgent) Execute(ctx context.Context, rendered rendered
 
 		resp, err := a.client.Do(req)
 		if err != nil {
+			if cause := context.Cause(ctx); cause != nil {
+				// Report the deadline or cancellation that aborted the call
+				// rather than the transport's generic context error.
+				return fmt.Errorf("backend request aborted: %w", cause)
+			}
 			return fmt.Errorf("backend request: %w", err)
 		}
 
@@ -104,7 +104,7 @@ func TestBackendInteractionAgent_Execute_BasicHTTP(t *testing.T) {
 				errors: []error{nil},
 			}
 
-			agent := newBackendInteractionAgent(mockClient, nil)
+			agent := newBackendInteractionAgent(mockClient, nil, nil)
 			state := &pipeline.State{Backend: pipeline.BackendState{}}
 			rendered := renderedBackendRequest{
 				Method:  "GET",
@@ -187,7 +187,7 @@ func TestBackendInteractionAgent_Execute_JSONParsing(t *testing.T) {
 				errors: []error{nil},
 			}
 
-			agent := newBackendInteractionAgent(mockClient, nil)
+			agent := newBackendInteractionAgent(mockClient, nil, nil)
 			state := &pipeline.State{Backend: pipeline.BackendState{}}
 			rendered := renderedBackendRequest{
 				Method: "GET",
@@ -293,7 +293,7 @@ func TestBackendInteractionAgent_Execute_Pagination_LinkHeader(t *testing.T) {
 				errors:    errs,
 			}
 
-			agent := newBackendInteractionAgent(mockClient, nil)
+			agent := newBackendInteractionAgent(mockClient, nil, nil)
 			state := &pipeline.State{Backend: pipeline.BackendState{}}
 			rendered := renderedBackendRequest{
 				Method: "GET",
@@ -369,7 +369,7 @@ func TestBackendInteractionAgent_Execute_ErrorHandling(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			mockClient := tt.mockSetup()
-			agent := newBackendInteractionAgent(mockClient, nil)
+			agent := newBackendInteractionAgent(mockClient, nil, nil)
 			state := &pipeline.State{Backend: pipeline.BackendState{}}
 
 			rendered := renderedBackendRequest{
@@ -410,7 +410,7 @@ func TestBackendInteractionAgent_Execute_ContextCancellation(t *testing.T) {
 		errors: []error{context.Canceled},
 	}
 
-	agent := newBackendInteractionAgent(mockClient, nil)
+	agent := newBackendInteractionAgent(mockClient, nil, nil)
 	state := &pipeline.State{Backend: pipeline.BackendState{}}
 	rendered := renderedBackendRequest{
 		Method: "GET",
@@ -436,7 +436,7 @@ func TestBackendInteractionAgent_Execute_HeadersAndQuery(t *testing.T) {
 		errors: []error{nil},
 	}
 
-	agent := newBackendInteractionAgent(mockClient, nil)
+	agent := newBackendInteractionAgent(mockClient, nil, nil)
 	state := &pipeline.State{Backend: pipeline.BackendState{}}
 	rendered := renderedBackendRequest{
 		Method:  "POST",
@@ -470,7 +470,7 @@ func TestBackendInteractionAgent_Execute_OversizedBody(t *testing.T) {
 		errors: []error{nil},
 	}
 
-	agent := newBackendInteractionAgent(mockClient, nil)
+	agent := newBackendInteractionAgent(mockClient, nil, nil)
 	state := &pipeline.State{Backend: pipeline.BackendState{}}
 	rendered := renderedBackendRequest{
 		Method: "GET",
@@ -486,7 +486,7 @@ func TestBackendInteractionAgent_Execute_OversizedBody(t *testing.T) {
 }
 
 func TestBackendInteractionAgent_Execute_NilClient(t *testing.T) {
-	agent := newBackendInteractionAgent(nil, nil)
+	agent := newBackendInteractionAgent(nil, nil, nil)
 	state := &pipeline.State{Backend: pipeline.BackendState{}}
 	rendered := renderedBackendRequest{
 		Method: "GET",
@@ -506,7 +506,7 @@ func TestBackendInteractionAgent_Execute_EmptyURL(t *testing.T) {
 		errors:    []error{},
 	}
 
-	agent := newBackendInteractionAgent(mockClient, nil)
+	agent := newBackendInteractionAgent(mockClient, nil, nil)
 	state := &pipeline.State{Backend: pipeline.BackendState{}}
 	rendered := renderedBackendRequest{
 		Method: "GET",
@@ -536,7 +536,7 @@ func TestBackendInteractionAgent_Execute_PaginationQueryPreservation(t *testing.
 		errors: []error{nil},
 	}
 
-	agent := newBackendInteractionAgent(mockClient, nil)
+	agent := newBackendInteractionAgent(mockClient, nil, nil)
 	state := &pipeline.State{Backend: pipeline.BackendState{}}
 	rendered := renderedBackendRequest{
 		Method: "GET",
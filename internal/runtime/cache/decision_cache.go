@@ -39,3 +39,9 @@ type ReloadScope struct {
 type ReloadInvalidator interface {
 	InvalidateOnReload(ctx context.Context, scope ReloadScope) error
 }
+
+// Pinger is implemented by cache backends backed by a remote service so
+// readiness probes can confirm the service is reachable.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
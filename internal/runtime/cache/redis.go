@@ -32,6 +32,29 @@ type redisCache struct {
 }
 
 func NewRedis(cfg RedisConfig) (DecisionCache, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := dialRedis(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &redisCache{client: client}, nil
+}
+
+// PingRedis connects to the configured server, pings it, and disconnects. It
+// lets readiness keep probing a Redis cache the server fell back from.
+func PingRedis(ctx context.Context, cfg RedisConfig) error {
+	client, err := dialRedis(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	client.Close()
+	return nil
+}
+
+// dialRedis builds a client and confirms the server answers a ping within
+// the context deadline.
+func dialRedis(ctx context.Context, cfg RedisConfig) (valkey.Client, error) {
 	if cfg.Address == "" {
 		return nil, errors.New("cache: redis address required")
 	}
@@ -45,6 +68,9 @@ func NewRedis(cfg RedisConfig) (DecisionCache, error) {
 		ForceSingleClient: true,
 		DisableCache:      true,
 	}
+	if deadline, ok := ctx.Deadline(); ok {
+		option.Dialer.Timeout = time.Until(deadline)
+	}
 
 	if cfg.TLS.Enabled {
 		tlsConfig := &tls.Config{
@@ -72,14 +98,12 @@ func NewRedis(cfg RedisConfig) (DecisionCache, error) {
 		return nil, fmt.Errorf("cache: redis client: %w", err)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
 	if err := client.Do(ctx, client.B().Ping().Build()).Error(); err != nil {
 		client.Close()
 		return nil, fmt.Errorf("cache: redis ping: %w", err)
 	}
 
-	return &redisCache{client: client}, nil
+	return client, nil
 }
 
 func (c *redisCache) Lookup(ctx context.Context, key string) (Entry, bool, error) {
@@ -136,6 +160,13 @@ func (c *redisCache) Size(ctx context.Context) (int64, error) {
 	return size, nil
 }
 
+func (c *redisCache) Ping(ctx context.Context) error {
+	if err := c.client.Do(ctx, c.client.B().Ping().Build()).Error(); err != nil {
+		return fmt.Errorf("cache: redis ping: %w", err)
+	}
+	return nil
+}
+
 func (c *redisCache) Close(context.Context) error {
 	c.client.Close()
 	return nil
@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"strings"
 	"time"
 )
 
@@ -46,6 +47,9 @@ type RuleCacheConfig struct {
 	FollowCacheControl bool
 	TTL                RuleCacheTTLConfig
 	Strict             *bool // nil = true (default)
+	// CredentialExpiry is the expiry reported for the evaluated credential
+	// (e.g. introspection `exp`). Zero means the credential carries no expiry.
+	CredentialExpiry time.Time
 }
 
 // GetTTL returns the configured TTL for the given outcome from rule config.
@@ -63,7 +67,9 @@ func (c RuleCacheConfig) GetTTL(outcome string) time.Duration {
 //  4. Endpoint TTL ceiling
 //  5. Server max TTL ceiling
 //
-// The effective TTL is the minimum of all applicable ceilings.
+// The effective TTL is the minimum of all applicable ceilings. When the rule
+// reports a credential expiry, pass outcomes never outlive it: the time
+// remaining acts as an additional ceiling and replaces an unset rule pass TTL.
 //
 // Parameters:
 //   - outcome: The rule outcome ("pass", "fail", or "error")
@@ -86,6 +92,11 @@ func CalculateEffectiveTTL(
 		return 0
 	}
 
+	expiryTTL, hasExpiry := ruleConfig.expiryTTL(outcome)
+	if hasExpiry && expiryTTL <= 0 {
+		return 0 // Credential already expired
+	}
+
 	// Start with maximum possible duration
 	effectiveTTL := time.Duration(0)
 
@@ -99,6 +110,9 @@ func CalculateEffectiveTTL(
 				if effectiveTTL == 0 {
 					return 0
 				}
+				if hasExpiry && expiryTTL < effectiveTTL {
+					effectiveTTL = expiryTTL
+				}
 				// Backend TTL found, now apply ceilings
 				effectiveTTL = applyTTLCeilings(effectiveTTL, serverMaxTTL, endpointTTL, outcome)
 				return effectiveTTL
@@ -106,8 +120,13 @@ func CalculateEffectiveTTL(
 		}
 	}
 
-	// 3. Use rule manual TTL
+	// 3. Use rule manual TTL, falling back to the credential expiry when unset
 	ruleTTL := ruleConfig.GetTTL(outcome)
+	if hasExpiry {
+		if strings.TrimSpace(ruleConfig.TTL.Pass) == "" || expiryTTL < ruleTTL {
+			ruleTTL = expiryTTL
+		}
+	}
 	if ruleTTL == 0 {
 		return 0 // Rule says don't cache
 	}
@@ -120,6 +139,15 @@ func CalculateEffectiveTTL(
 	return effectiveTTL
 }
 
+// expiryTTL returns the time remaining until the credential expires. Only pass
+// outcomes are bounded; fail outcomes keep their configured TTL.
+func (c RuleCacheConfig) expiryTTL(outcome string) (time.Duration, bool) {
+	if outcome != "pass" || c.CredentialExpiry.IsZero() {
+		return 0, false
+	}
+	return time.Until(c.CredentialExpiry), true
+}
+
 // applyTTLCeilings applies the endpoint and server TTL ceilings to the given TTL.
 // Returns the minimum of the given TTL and all applicable ceilings.
 func applyTTLCeilings(ttl time.Duration, serverMaxTTL time.Duration, endpointTTL RuleCacheTTLConfig, outcome string) time.Duration {
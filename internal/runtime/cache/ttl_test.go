@@ -391,3 +391,73 @@ func TestCalculateEffectiveTTL_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateEffectiveTTL_CredentialExpiry(t *testing.T) {
+	expiresIn := func(d time.Duration) time.Time { return time.Now().Add(d) }
+
+	// Unset rule pass TTL derives the TTL from the credential expiry, capped by ceilings
+	ttl := CalculateEffectiveTTL(
+		"pass",
+		10*time.Minute,
+		RuleCacheTTLConfig{Pass: "2m"},
+		RuleCacheConfig{CredentialExpiry: expiresIn(time.Hour)},
+		nil,
+	)
+	require.Equal(t, 2*time.Minute, ttl)
+
+	// Expiry shortens a longer rule TTL
+	ttl = CalculateEffectiveTTL(
+		"pass",
+		0,
+		RuleCacheTTLConfig{},
+		RuleCacheConfig{TTL: RuleCacheTTLConfig{Pass: "1h"}, CredentialExpiry: expiresIn(time.Minute)},
+		nil,
+	)
+	require.InDelta(t, float64(time.Minute), float64(ttl), float64(time.Second))
+
+	// Shorter rule TTL still wins, and an explicit zero disables caching
+	ttl = CalculateEffectiveTTL(
+		"pass",
+		0,
+		RuleCacheTTLConfig{},
+		RuleCacheConfig{TTL: RuleCacheTTLConfig{Pass: "30s"}, CredentialExpiry: expiresIn(time.Hour)},
+		nil,
+	)
+	require.Equal(t, 30*time.Second, ttl)
+	ttl = CalculateEffectiveTTL(
+		"pass",
+		0,
+		RuleCacheTTLConfig{},
+		RuleCacheConfig{TTL: RuleCacheTTLConfig{Pass: "0s"}, CredentialExpiry: expiresIn(time.Hour)},
+		nil,
+	)
+	require.Equal(t, time.Duration(0), ttl)
+
+	// Cache-Control is capped by the expiry
+	ttl = CalculateEffectiveTTL(
+		"pass",
+		0,
+		RuleCacheTTLConfig{},
+		RuleCacheConfig{FollowCacheControl: true, CredentialExpiry: expiresIn(time.Minute)},
+		map[string]string{"cache-control": "max-age=3600"},
+	)
+	require.InDelta(t, float64(time.Minute), float64(ttl), float64(time.Second))
+
+	// Expired credentials are never cached; fail outcomes ignore the expiry
+	ttl = CalculateEffectiveTTL(
+		"pass",
+		0,
+		RuleCacheTTLConfig{},
+		RuleCacheConfig{TTL: RuleCacheTTLConfig{Pass: "5m"}, CredentialExpiry: expiresIn(-time.Second)},
+		nil,
+	)
+	require.Equal(t, time.Duration(0), ttl)
+	ttl = CalculateEffectiveTTL(
+		"fail",
+		0,
+		RuleCacheTTLConfig{},
+		RuleCacheConfig{TTL: RuleCacheTTLConfig{Fail: "30s"}, CredentialExpiry: expiresIn(-time.Second)},
+		nil,
+	)
+	require.Equal(t, 30*time.Second, ttl)
+}
@@ -0,0 +1,143 @@
+package runtime
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/datasets"
+	"github.com/l0p7/passctrl/internal/expr"
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+)
+
+// cacheKeyExpression is a compiled entry of an endpoint's cache.key list.
+type cacheKeyExpression struct {
+	source  string
+	program expr.Program
+}
+
+// compileCacheKey compiles the cache.key expressions of an endpoint against
+// the request-only CEL environment used by endpoint variables.
+func compileCacheKey(sources []string) ([]cacheKeyExpression, error) {
+	if len(sources) == 0 {
+		return nil, nil
+	}
+	env, err := expr.NewRequestEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	compiled := make([]cacheKeyExpression, 0, len(sources))
+	for i, source := range sources {
+		program, err := env.CompileValue(source)
+		if err != nil {
+			return nil, fmt.Errorf("cache.key[%d]: %w", i, err)
+		}
+		compiled = append(compiled, cacheKeyExpression{source: program.Source(), program: program})
+	}
+	return compiled, nil
+}
+
+// evaluateCacheKey returns the quoted value of every cache.key expression for
+// the request. Quoting keeps values containing the key separator from
+// colliding with neighbouring components.
+func evaluateCacheKey(r *http.Request, expressions []cacheKeyExpression, tables datasets.Tables) ([]string, error) {
+	if len(expressions) == 0 {
+		return nil, nil
+	}
+	activation := cacheKeyActivation(r, tables)
+	values := make([]string, 0, len(expressions))
+	for _, expression := range expressions {
+		value, err := expression.program.Eval(activation)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, strconv.Quote(fmt.Sprint(value)))
+	}
+	return values, nil
+}
+
+// cacheKeyActivation exposes the same request and dataset context endpoint
+// variables see.
+func cacheKeyActivation(r *http.Request, tables datasets.Tables) map[string]any {
+	activation := expr.RequestContext(r)
+	activation["data"] = tables
+	return activation
+}
+
+// rawCacheKey assembles the unhashed cache key for a request. It reports
+// false when the request must not be cached: anonymous endpoints without a
+// cache.key, and requests whose key expressions fail to evaluate.
+func rawCacheKey(r *http.Request, ep *endpointRuntime, tables datasets.Tables) (string, bool) {
+	// Anonymous endpoints stay uncached unless cache.key declares which
+	// request inputs the rules depend on; otherwise one caller's decision
+	// could be replayed for another.
+	if ep.authConfig.Allow.None && len(ep.cacheKey) == 0 {
+		return "", false
+	}
+	raw := cacheKeyFromRequest(r, ep.name, &ep.authConfig)
+	values, err := evaluateCacheKey(r, ep.cacheKey, tables)
+	if err != nil {
+		return "", false
+	}
+	for _, value := range values {
+		raw += "|key:" + value
+	}
+	return raw, true
+}
+
+// cacheKeyPreview is the /explain view of how a request's cache key is built.
+type cacheKeyPreview struct {
+	Enabled    bool                `json:"enabled"`
+	Reason     string              `json:"reason,omitempty"`
+	Components []cacheKeyComponent `json:"components,omitempty"`
+}
+
+// cacheKeyComponent is one input hashed into the cache key. Credential
+// values are never echoed; only their source is reported.
+type cacheKeyComponent struct {
+	Source     string `json:"source"`
+	Expression string `json:"expression,omitempty"`
+	Value      string `json:"value,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// previewCacheKey lists the inputs that would contribute to the cache key if
+// r had been sent to the endpoint's auth route.
+func previewCacheKey(r *http.Request, ep *endpointRuntime, tables datasets.Tables) *cacheKeyPreview {
+	preview := &cacheKeyPreview{Enabled: true}
+	if ep.authConfig.Allow.None && len(ep.cacheKey) == 0 {
+		preview.Enabled = false
+		preview.Reason = "anonymous endpoint without cache.key"
+		return preview
+	}
+
+	credential := extractCredential(r, &ep.authConfig)
+	source, _, _ := strings.Cut(credential, ":")
+	if parts := strings.SplitN(credential, ":", 3); len(parts) == 3 && (source == "header" || source == "query") {
+		source += ":" + parts[1]
+	}
+	preview.Components = append(preview.Components,
+		cacheKeyComponent{Source: "credential", Value: source},
+		cacheKeyComponent{Source: "endpoint", Value: ep.name},
+		cacheKeyComponent{Source: "path", Value: r.URL.Path},
+	)
+	if peer := pipeline.VerifiedPeerCertificate(r); peer != nil {
+		preview.Components = append(preview.Components, cacheKeyComponent{Source: "peer", Value: peer.FingerprintSHA256})
+	}
+
+	activation := cacheKeyActivation(r, tables)
+	for i, expression := range ep.cacheKey {
+		component := cacheKeyComponent{Source: fmt.Sprintf("key[%d]", i), Expression: expression.source}
+		value, err := expression.program.Eval(activation)
+		if err != nil {
+			component.Error = err.Error()
+			preview.Enabled = false
+			preview.Reason = "cache.key expression failed"
+		} else {
+			component.Value = fmt.Sprint(value)
+		}
+		preview.Components = append(preview.Components, component)
+	}
+	return preview
+}
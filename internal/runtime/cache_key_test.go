@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/runtime/cache"
+	"github.com/l0p7/passctrl/internal/server"
+	"github.com/stretchr/testify/require"
+)
+
+func cacheKeyTestPipeline(t *testing.T, key []string) *Pipeline {
+	t.Helper()
+	return NewPipeline(nil, PipelineOptions{
+		Cache: cache.NewMemory(time.Minute),
+		Endpoints: map[string]config.EndpointConfig{
+			"public": {
+				Authentication: config.EndpointAuthenticationConfig{
+					Allow: config.EndpointAuthAllowConfig{None: true},
+				},
+				Cache: config.EndpointCacheConfig{Key: key},
+				Rules: []config.EndpointRuleReference{{Name: "tenant-rule"}},
+			},
+		},
+		Rules: map[string]config.RuleConfig{
+			"tenant-rule": {Conditions: config.RuleConditionConfig{Pass: []string{"true"}}},
+		},
+	})
+}
+
+func TestDeriveCacheKeyWithKeyExpressions(t *testing.T) {
+	newReq := func(tenant, remoteAddr string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/public/auth", http.NoBody)
+		req.RemoteAddr = remoteAddr
+		if tenant != "" {
+			req.Header.Set("X-Tenant", tenant)
+		}
+		return req
+	}
+
+	t.Run("anonymous endpoint without key is not cached", func(t *testing.T) {
+		snap := cacheKeyTestPipeline(t, nil).active.Load()
+		ep, ok := snap.lookup("public")
+		require.True(t, ok)
+		require.Empty(t, snap.deriveCacheKey(newReq("acme", "10.0.0.1:1234"), ep, nil))
+	})
+
+	t.Run("key values separate anonymous callers", func(t *testing.T) {
+		snap := cacheKeyTestPipeline(t, []string{`lookup(request.headers, "x-tenant")`}).active.Load()
+		ep, ok := snap.lookup("public")
+		require.True(t, ok)
+
+		acme := snap.deriveCacheKey(newReq("acme", "10.0.0.1:1234"), ep, nil)
+		require.NotEmpty(t, acme)
+		require.Equal(t, acme, snap.deriveCacheKey(newReq("acme", "10.0.0.2:4321"), ep, nil), "same key inputs share an entry across clients")
+		require.NotEqual(t, acme, snap.deriveCacheKey(newReq("globex", "10.0.0.1:1234"), ep, nil))
+		require.NotEqual(t, acme, snap.deriveCacheKey(newReq("", "10.0.0.1:1234"), ep, nil))
+	})
+
+	t.Run("failing key expression disables caching", func(t *testing.T) {
+		snap := cacheKeyTestPipeline(t, []string{`request.headers["x-tenant"]`}).active.Load()
+		ep, ok := snap.lookup("public")
+		require.True(t, ok)
+		require.Empty(t, snap.deriveCacheKey(newReq("", "10.0.0.1:1234"), ep, nil))
+	})
+
+	t.Run("invalid key expression quarantines the endpoint", func(t *testing.T) {
+		pipe := cacheKeyTestPipeline(t, []string{`request.headers[`})
+		require.False(t, pipe.EndpointExists("public"))
+	})
+}
+
+func TestExplainPreviewsCacheKey(t *testing.T) {
+	explain := func(t *testing.T, pipe *Pipeline) *cacheKeyPreview {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/public/explain", http.NoBody)
+		req.Header.Set("X-Tenant", "acme")
+		rec := httptest.NewRecorder()
+		server.NewPipelineHandler(pipe).ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var payload struct {
+			CacheKey *cacheKeyPreview `json:"cacheKey"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+		require.NotNil(t, payload.CacheKey)
+		return payload.CacheKey
+	}
+
+	t.Run("lists key inputs", func(t *testing.T) {
+		preview := explain(t, cacheKeyTestPipeline(t, []string{`lookup(request.headers, "x-tenant")`}))
+		require.True(t, preview.Enabled)
+		require.Equal(t, []cacheKeyComponent{
+			{Source: "credential", Value: "anonymous"},
+			{Source: "endpoint", Value: "public"},
+			{Source: "path", Value: "/public/auth"},
+			{Source: "key[0]", Expression: `lookup(request.headers, "x-tenant")`, Value: "acme"},
+		}, preview.Components)
+	})
+
+	t.Run("reports disabled caching", func(t *testing.T) {
+		preview := explain(t, cacheKeyTestPipeline(t, nil))
+		require.False(t, preview.Enabled)
+		require.Equal(t, "anonymous endpoint without cache.key", preview.Reason)
+		require.Empty(t, preview.Components)
+	})
+}
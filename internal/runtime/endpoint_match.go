@@ -0,0 +1,245 @@
+package runtime
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/config"
+)
+
+// forwardedRequest is the request the proxy is asking about, rebuilt from the
+// X-Forwarded-* (Traefik, Caddy) or X-Original-* (NGINX) headers and falling
+// back to the auth request itself.
+type forwardedRequest struct {
+	method  string
+	host    string
+	path    string
+	headers http.Header
+}
+
+func reconstructForwardedRequest(r *http.Request) forwardedRequest {
+	method := firstHeader(r.Header, "X-Forwarded-Method", "X-Original-Method")
+	if method == "" {
+		method = r.Method
+	}
+	host := firstHeader(r.Header, "X-Forwarded-Host")
+	if host == "" {
+		host = r.Host
+	}
+	if idx := strings.Index(host, ","); idx >= 0 {
+		host = host[:idx]
+	}
+	host = strings.ToLower(strings.TrimSpace(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	uri := firstHeader(r.Header, "X-Forwarded-Uri", "X-Original-Uri", "X-Original-Url")
+	if uri == "" {
+		uri = r.URL.Path
+	}
+	if idx := strings.IndexAny(uri, "?#"); idx >= 0 {
+		uri = uri[:idx]
+	}
+	return forwardedRequest{
+		method:  strings.ToUpper(strings.TrimSpace(method)),
+		host:    host,
+		path:    normalizePath(uri),
+		headers: r.Header,
+	}
+}
+
+// normalizePath decodes and cleans the forwarded path so that percent-encoded
+// or dot-segment variants ("/public/%2e%2e/admin") match the same rules as
+// the path the backend will eventually serve ("/admin").
+func normalizePath(uri string) string {
+	if idx := strings.Index(uri, "://"); idx >= 0 {
+		// X-Original-Url carries the absolute URL; keep only its path.
+		rest := uri[idx+3:]
+		if slash := strings.Index(rest, "/"); slash >= 0 {
+			uri = rest[slash:]
+		} else {
+			uri = "/"
+		}
+	}
+	if decoded, err := url.PathUnescape(uri); err == nil {
+		uri = decoded
+	}
+	if !strings.HasPrefix(uri, "/") {
+		uri = "/" + uri
+	}
+	return path.Clean(uri)
+}
+
+func firstHeader(h http.Header, names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(h.Get(name)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// endpointMatcher is the compiled form of an endpoint's match block.
+type endpointMatcher struct {
+	runtime      *endpointRuntime
+	priority     int
+	hosts        []string
+	pathPrefixes []string
+	pathRegex    []*regexp.Regexp
+	methods      map[string]struct{}
+	headers      []headerPredicate
+}
+
+type headerPredicate struct {
+	name   string
+	values []string
+	regex  *regexp.Regexp
+	absent bool
+}
+
+func compileEndpointMatcher(runtime *endpointRuntime, cfg config.EndpointMatchConfig) (*endpointMatcher, error) {
+	m := &endpointMatcher{runtime: runtime, priority: cfg.Priority}
+	for _, host := range cfg.Hosts {
+		m.hosts = append(m.hosts, strings.ToLower(strings.TrimSpace(host)))
+	}
+	for _, prefix := range cfg.PathPrefixes {
+		m.pathPrefixes = append(m.pathPrefixes, strings.TrimSpace(prefix))
+	}
+	for _, pattern := range cfg.PathRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		m.pathRegex = append(m.pathRegex, re)
+	}
+	if len(cfg.Methods) > 0 {
+		m.methods = make(map[string]struct{}, len(cfg.Methods))
+		for _, method := range cfg.Methods {
+			m.methods[strings.ToUpper(strings.TrimSpace(method))] = struct{}{}
+		}
+	}
+	for _, header := range cfg.Headers {
+		predicate := headerPredicate{
+			name:   http.CanonicalHeaderKey(strings.TrimSpace(header.Name)),
+			values: header.Values,
+			absent: header.Absent,
+		}
+		if header.Regex != "" {
+			re, err := regexp.Compile(header.Regex)
+			if err != nil {
+				return nil, err
+			}
+			predicate.regex = re
+		}
+		m.headers = append(m.headers, predicate)
+	}
+	return m, nil
+}
+
+func (m *endpointMatcher) matches(req forwardedRequest) bool {
+	if len(m.hosts) > 0 && !m.matchesHost(req.host) {
+		return false
+	}
+	if len(m.pathPrefixes) > 0 && !m.matchesPathPrefix(req.path) {
+		return false
+	}
+	if len(m.pathRegex) > 0 && !m.matchesPathRegex(req.path) {
+		return false
+	}
+	if m.methods != nil {
+		if _, ok := m.methods[req.method]; !ok {
+			return false
+		}
+	}
+	for _, predicate := range m.headers {
+		if !predicate.matches(req.headers) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m *endpointMatcher) matchesHost(host string) bool {
+	for _, pattern := range m.hosts {
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *endpointMatcher) matchesPathPrefix(p string) bool {
+	for _, prefix := range m.pathPrefixes {
+		// Prefixes match whole segments: "/api" accepts "/api" and
+		// "/api/users" but not "/apiX".
+		base := strings.TrimSuffix(prefix, "/")
+		if base == "" || p == base || strings.HasPrefix(p, base+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *endpointMatcher) matchesPathRegex(p string) bool {
+	for _, re := range m.pathRegex {
+		if re.MatchString(p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p headerPredicate) matches(h http.Header) bool {
+	values, present := h[p.name]
+	if p.absent {
+		return !present
+	}
+	if !present {
+		return false
+	}
+	if len(p.values) == 0 && p.regex == nil {
+		return true
+	}
+	for _, value := range values {
+		if p.regex != nil && !p.regex.MatchString(value) {
+			continue
+		}
+		if len(p.values) > 0 && !slices.Contains(p.values, value) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// sortEndpointMatchers orders matchers by descending priority, then by
+// endpoint name, so selection never depends on map iteration.
+func sortEndpointMatchers(matchers []*endpointMatcher) {
+	sort.Slice(matchers, func(i, j int) bool {
+		if matchers[i].priority != matchers[j].priority {
+			return matchers[i].priority > matchers[j].priority
+		}
+		return matchers[i].runtime.name < matchers[j].runtime.name
+	})
+}
+
+// matchEndpoint returns the first endpoint whose match block accepts the
+// forwarded request.
+func (s *endpointSet) matchEndpoint(r *http.Request) *endpointRuntime {
+	if len(s.matchers) == 0 {
+		return nil
+	}
+	req := reconstructForwardedRequest(r)
+	for _, m := range s.matchers {
+		if m.matches(req) {
+			return m.runtime
+		}
+	}
+	return nil
+}
@@ -51,6 +51,7 @@ func (a *Agent) Execute(ctx context.Context, r *http.Request, state *pipeline.St
 
 	// Build request context for evaluation
 	requestCtx := expr.RequestContext(r)
+	requestCtx["data"] = state.Data
 
 	// Evaluate each variable
 	evaluated := make(map[string]any, len(a.variables))
@@ -0,0 +1,318 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+)
+
+// grpcHTTPStatus maps gRPC status codes to the HTTP statuses reported as
+// backend.status, following the mapping used by gRPC-HTTP gateways, so
+// acceptedStatuses and conditions treat both backend kinds alike.
+var grpcHTTPStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
+
+// executeGRPC invokes the unary method with the rendered body as the request
+// message and the rendered headers as metadata. Successful responses are
+// published as their protobuf JSON form; failed calls report the mapped
+// status with a {code, message} body. Descriptor, encoding, and aborted calls
+// are returned as errors.
+func (a *backendInteractionAgent) executeGRPC(ctx context.Context, rendered renderedBackendRequest, backend rulechain.BackendDefinition, state *pipeline.State) error {
+	def := backend.GRPC
+	conn, key, err := a.grpc.conn(def)
+	if err != nil {
+		return fmt.Errorf("grpc backend: %w", err)
+	}
+	method, err := a.grpc.method(ctx, conn, key, def)
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			return fmt.Errorf("grpc backend aborted: %w", cause)
+		}
+		return fmt.Errorf("grpc backend: %w", err)
+	}
+
+	request := dynamicpb.NewMessage(method.Input())
+	if body := strings.TrimSpace(rendered.Body); body != "" {
+		if err := protojson.Unmarshal([]byte(body), request); err != nil {
+			return fmt.Errorf("grpc backend: request message: %w", err)
+		}
+	}
+	md := metadata.MD{}
+	for name, value := range rendered.Headers {
+		if strings.TrimSpace(value) != "" {
+			md.Append(strings.ToLower(name), value)
+		}
+	}
+
+	response := dynamicpb.NewMessage(method.Output())
+	var header, trailer metadata.MD
+	err = conn.Invoke(metadata.NewOutgoingContext(ctx, md), rendered.Method, request, response, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			return fmt.Errorf("grpc backend aborted: %w", cause)
+		}
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("grpc backend: %w", err)
+	}
+
+	page := pipeline.BackendPageState{
+		URL:     rendered.URL,
+		Status:  grpcStatus(st.Code()),
+		Headers: grpcResponseHeaders(header, trailer, st),
+	}
+	if st.Code() == codes.OK {
+		encoded, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(response)
+		if err != nil {
+			return fmt.Errorf("grpc backend: response message: %w", err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(encoded))
+		decoder.UseNumber()
+		var payload any
+		if err := decoder.Decode(&payload); err != nil {
+			return fmt.Errorf("grpc backend: response message: %w", err)
+		}
+		page.Body = normalizeJSONNumbers(payload)
+		page.BodyText = string(encoded)
+	} else {
+		body := map[string]any{"code": int64(st.Code()), "message": st.Message()}
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("grpc backend: encode status: %w", err)
+		}
+		page.Body = body
+		page.BodyText = string(encoded)
+	}
+	page.Accepted = backend.Accepts(page.Status)
+
+	state.Backend.Requested = true
+	state.Backend.Pages = []pipeline.BackendPageState{page}
+	state.Backend.Status = page.Status
+	state.Backend.Headers = cloneHeaders(page.Headers)
+	state.Backend.Body = page.Body
+	state.Backend.BodyText = page.BodyText
+	state.Backend.Accepted = page.Accepted
+	return nil
+}
+
+func grpcStatus(code codes.Code) int {
+	if mapped, ok := grpcHTTPStatus[code]; ok {
+		return mapped
+	}
+	return http.StatusInternalServerError
+}
+
+// grpcResponseHeaders flattens response header and trailer metadata to their
+// first values and records the call's grpc-status and grpc-message.
+func grpcResponseHeaders(header, trailer metadata.MD, st *status.Status) map[string]string {
+	headers := make(map[string]string, len(header)+len(trailer)+2)
+	for _, md := range []metadata.MD{header, trailer} {
+		for name, values := range md {
+			if len(values) > 0 {
+				headers[strings.ToLower(name)] = values[0]
+			}
+		}
+	}
+	headers["grpc-status"] = strconv.Itoa(int(st.Code()))
+	if st.Message() != "" {
+		headers["grpc-message"] = st.Message()
+	}
+	return headers
+}
+
+// grpcClients shares one client connection per target and TLS settings, and
+// caches method descriptors resolved through server reflection.
+type grpcClients struct {
+	mu      sync.Mutex
+	conns   map[string]*grpc.ClientConn
+	methods map[string]protoreflect.MethodDescriptor
+}
+
+func newGRPCClients() *grpcClients {
+	return &grpcClients{
+		conns:   make(map[string]*grpc.ClientConn),
+		methods: make(map[string]protoreflect.MethodDescriptor),
+	}
+}
+
+// conn returns the shared connection for def and its cache key. Connections
+// dial lazily, so an unreachable target surfaces as an Unavailable status.
+func (c *grpcClients) conn(def *rulechain.GRPCDefinition) (*grpc.ClientConn, string, error) {
+	key := strings.Join([]string{def.Target, strconv.FormatBool(def.TLS), def.CAFile, def.CertFile, def.KeyFile, def.ServerName}, "|")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if conn, ok := c.conns[key]; ok {
+		return conn, key, nil
+	}
+	creds := insecure.NewCredentials()
+	if def.TLS {
+		tlsConfig, err := grpcTLSConfig(def)
+		if err != nil {
+			return nil, "", err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.NewClient(def.Target, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, "", fmt.Errorf("target %s: %w", def.Target, err)
+	}
+	c.conns[key] = conn
+	return conn, key, nil
+}
+
+// close closes every client connection and forgets the resolved methods.
+func (c *grpcClients) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for key, conn := range c.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("target %s: %w", conn.Target(), err))
+		}
+		delete(c.conns, key)
+	}
+	clear(c.methods)
+	return errors.Join(errs...)
+}
+
+// method returns the descriptor loaded with the rule or resolves it through
+// server reflection. Failed lookups are retried on the next call.
+func (c *grpcClients) method(ctx context.Context, conn *grpc.ClientConn, key string, def *rulechain.GRPCDefinition) (protoreflect.MethodDescriptor, error) {
+	if def.Descriptor != nil {
+		return def.Descriptor, nil
+	}
+	key += "|" + def.FullMethod
+	c.mu.Lock()
+	method, ok := c.methods[key]
+	c.mu.Unlock()
+	if ok {
+		return method, nil
+	}
+	method, err := reflectGRPCMethod(ctx, conn, def)
+	if err != nil {
+		return nil, fmt.Errorf("reflection: %w", err)
+	}
+	c.mu.Lock()
+	c.methods[key] = method
+	c.mu.Unlock()
+	return method, nil
+}
+
+// reflectGRPCMethod asks the server for the file defining the service and
+// any dependencies it did not send, then looks up the method.
+func reflectGRPCMethod(ctx context.Context, conn *grpc.ClientConn, def *rulechain.GRPCDefinition) (protoreflect.MethodDescriptor, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = stream.CloseSend() }()
+
+	files := make(map[string]*descriptorpb.FileDescriptorProto)
+	requested := make(map[string]bool)
+	request := &reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: string(def.Service)},
+	}
+	for request != nil {
+		if err := stream.Send(request); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if failure := resp.GetErrorResponse(); failure != nil {
+			return nil, status.Error(codes.Code(failure.GetErrorCode()), failure.GetErrorMessage())
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return nil, fmt.Errorf("decode descriptor: %w", err)
+			}
+			files[file.GetName()] = file
+		}
+		request = nil
+		for _, file := range files {
+			for _, dep := range file.GetDependency() {
+				if _, ok := files[dep]; ok || request != nil {
+					continue
+				}
+				if requested[dep] {
+					return nil, fmt.Errorf("server did not return dependency %s", dep)
+				}
+				requested[dep] = true
+				request = &reflectionpb.ServerReflectionRequest{
+					MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dep},
+				}
+			}
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	for _, file := range files {
+		set.File = append(set.File, file)
+	}
+	registry, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, err
+	}
+	return rulechain.FindGRPCMethod(registry, def.Service, def.Method)
+}
+
+// grpcTLSConfig builds the client TLS settings for grpcs:// targets.
+func grpcTLSConfig(def *rulechain.GRPCDefinition) (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: def.ServerName, MinVersion: tls.VersionTLS12}
+	var err error
+	if cfg.RootCAs, err = loadCAPool(def.CAFile); err != nil {
+		return nil, err
+	}
+	if def.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(def.CertFile, def.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
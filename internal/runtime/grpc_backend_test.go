@@ -0,0 +1,331 @@
+package runtime
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+	"github.com/l0p7/passctrl/internal/templates"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// grpcTestFile describes policy.v1.Authorizer with a unary Check method and
+// a server-streaming Watch method.
+func grpcTestFile(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	field := func(name string, number int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(number),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	optional := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	file := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("policy/v1/authorizer.proto"),
+		Package:    proto.String("policy.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("CheckRequest"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("subject", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("weight", 2, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+				},
+			},
+			{
+				Name: proto.String("CheckResponse"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("allowed", 1, descriptorpb.FieldDescriptorProto_TYPE_BOOL, optional, ""),
+					field("tenant", 2, descriptorpb.FieldDescriptorProto_TYPE_STRING, optional, ""),
+					field("roles", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ""),
+					field("weight", 4, descriptorpb.FieldDescriptorProto_TYPE_INT32, optional, ""),
+					field("quota", 5, descriptorpb.FieldDescriptorProto_TYPE_INT64, optional, ""),
+					field("checked_at", 6, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, optional, ".google.protobuf.Timestamp"),
+				},
+			},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("Authorizer"),
+			Method: []*descriptorpb.MethodDescriptorProto{
+				{Name: proto.String("Check"), InputType: proto.String(".policy.v1.CheckRequest"), OutputType: proto.String(".policy.v1.CheckResponse")},
+				{Name: proto.String("Watch"), InputType: proto.String(".policy.v1.CheckRequest"), OutputType: proto.String(".policy.v1.CheckResponse"), ServerStreaming: proto.Bool(true)},
+			},
+		}},
+	}
+	fd, err := protodesc.NewFile(file, protoregistry.GlobalFiles)
+	require.NoError(t, err)
+	return fd
+}
+
+// grpcTestDescriptorSet writes the policy file and its imports as a
+// descriptor set inside dir.
+func grpcTestDescriptorSet(t *testing.T, dir string, fd protoreflect.FileDescriptor) {
+	t.Helper()
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{
+		protodesc.ToFileDescriptorProto(timestamppb.File_google_protobuf_timestamp_proto),
+		protodesc.ToFileDescriptorProto(fd),
+	}}
+	raw, err := proto.Marshal(set)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "protos"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "protos", "policy.pb"), raw, 0o600))
+}
+
+// newGRPCStub serves policy.v1.Authorizer with reflection. alice is allowed,
+// mallory is denied with PermissionDenied, and everyone else is not allowed.
+func newGRPCStub(t *testing.T, fd protoreflect.FileDescriptor, serverTLS *tls.Config, opts ...grpc.ServerOption) string {
+	t.Helper()
+	check := fd.Services().ByName("Authorizer").Methods().ByName("Check")
+	output := check.Output().Fields()
+
+	if serverTLS != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(serverTLS)))
+	}
+	server := grpc.NewServer(opts...)
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "policy.v1.Authorizer",
+		HandlerType: (*any)(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Check",
+			Handler: func(_ any, ctx context.Context, dec func(any) error, _ grpc.UnaryServerInterceptor) (any, error) {
+				req := dynamicpb.NewMessage(check.Input())
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				subject := req.Get(check.Input().Fields().ByName("subject")).String()
+				if subject == "mallory" {
+					return nil, status.Error(codes.PermissionDenied, "mallory is blocked")
+				}
+				_ = grpc.SetHeader(ctx, metadata.Pairs("x-policy-version", "7"))
+				md, _ := metadata.FromIncomingContext(ctx)
+
+				resp := dynamicpb.NewMessage(check.Output())
+				if subject == "alice" {
+					resp.Set(output.ByName("allowed"), protoreflect.ValueOfBool(true))
+					roles := resp.Mutable(output.ByName("roles")).List()
+					roles.Append(protoreflect.ValueOfString("admin"))
+				}
+				resp.Set(output.ByName("tenant"), protoreflect.ValueOfString(strings.Join(md.Get("x-tenant"), ",")))
+				resp.Set(output.ByName("weight"), req.Get(check.Input().Fields().ByName("weight")))
+				resp.Set(output.ByName("quota"), protoreflect.ValueOfInt64(42))
+				checkedAt := timestamppb.New(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+				resp.Set(output.ByName("checked_at"), protoreflect.ValueOfMessage(checkedAt.ProtoReflect()))
+				return resp, nil
+			},
+		}},
+	}, struct{}{})
+
+	files := new(protoregistry.Files)
+	require.NoError(t, files.RegisterFile(timestamppb.File_google_protobuf_timestamp_proto))
+	require.NoError(t, files.RegisterFile(fd))
+	reflectionpb.RegisterServerReflectionServer(server, reflection.NewServerV1(reflection.ServerOptions{
+		Services:           server,
+		DescriptorResolver: files,
+	}))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// grpcConnCounter is a server stats handler counting open client connections.
+type grpcConnCounter struct {
+	open atomic.Int32
+}
+
+func (c *grpcConnCounter) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (c *grpcConnCounter) HandleRPC(context.Context, stats.RPCStats) {}
+
+func (c *grpcConnCounter) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (c *grpcConnCounter) HandleConn(_ context.Context, s stats.ConnStats) {
+	switch s.(type) {
+	case *stats.ConnBegin:
+		c.open.Add(1)
+	case *stats.ConnEnd:
+		c.open.Add(-1)
+	}
+}
+
+func TestRuleExecutionAgentGRPCReflection(t *testing.T) {
+	addr := newGRPCStub(t, grpcTestFile(t), nil)
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "policy",
+		Backend: rulechain.BackendDefinitionSpec{
+			Type:    rulechain.BackendTypeGRPC,
+			URL:     "grpc://" + addr,
+			Headers: map[string]*string{"x-tenant": nil},
+			Body:    `{"subject": "{{ index .request.Headers "x-user" }}", "weight": 3}`,
+			GRPC:    rulechain.GRPCSpec{Method: "policy.v1.Authorizer/Check"},
+		},
+		Conditions: rulechain.ConditionSpec{
+			Pass: []string{`backend.accepted && backend.body.allowed && "admin" in backend.body.roles`},
+		},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+	require.Equal(t, "/policy.v1.Authorizer/Check", defs[0].Backend.Method)
+
+	tests := []struct {
+		name    string
+		user    string
+		outcome string
+		status  int
+		body    map[string]any
+	}{
+		{name: "allowed", user: "alice", outcome: "pass", status: http.StatusOK},
+		{name: "not allowed", user: "bob", outcome: "fail", status: http.StatusOK},
+		{name: "permission denied", user: "mallory", outcome: "fail", status: http.StatusForbidden,
+			body: map[string]any{"code": int64(codes.PermissionDenied), "message": "mallory is blocked"}},
+	}
+
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
+			req.Header.Set("X-User", tc.user)
+			req.Header.Set("X-Tenant", "acme")
+			state := pipeline.NewState(req, "endpoint", "cache-key", "")
+
+			outcome, reason, _ := agent.evaluateRule(context.Background(), defs[0], state)
+			require.Equal(t, tc.outcome, outcome, reason)
+			require.Equal(t, tc.status, state.Backend.Status)
+			if tc.body != nil {
+				require.Equal(t, tc.body, state.Backend.Body)
+				require.Equal(t, "7", state.Backend.Headers["grpc-status"])
+				return
+			}
+			body, ok := state.Backend.Body.(map[string]any)
+			require.True(t, ok)
+			require.Equal(t, "acme", body["tenant"], "headers are sent as metadata")
+			require.Equal(t, int64(3), body["weight"])
+			require.Equal(t, "42", body["quota"], "64-bit integers follow the protobuf JSON mapping")
+			require.Equal(t, "2026-01-02T03:04:05Z", body["checkedAt"])
+			require.Equal(t, "7", state.Backend.Headers["x-policy-version"])
+			require.Equal(t, "0", state.Backend.Headers["grpc-status"])
+			if tc.user == "bob" {
+				require.Equal(t, false, body["allowed"], "unpopulated fields are emitted")
+				require.Equal(t, []any{}, body["roles"])
+			}
+		})
+	}
+}
+
+func TestRuleExecutionAgentGRPCDescriptorSetTLS(t *testing.T) {
+	fd := grpcTestFile(t)
+	serverTLS, caFile := backendTestCA(t)
+	addr := newGRPCStub(t, fd, serverTLS)
+
+	dir := t.TempDir()
+	grpcTestDescriptorSet(t, dir, fd)
+	sandbox, err := templates.NewSandbox(dir)
+	require.NoError(t, err)
+	renderer := templates.NewRenderer(sandbox)
+
+	compile := func(method, body string) (rulechain.Definition, error) {
+		defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+			Name: "policy",
+			Backend: rulechain.BackendDefinitionSpec{
+				Type: rulechain.BackendTypeGRPC,
+				URL:  "grpcs://" + addr,
+				Body: body,
+				GRPC: rulechain.GRPCSpec{
+					Method:            method,
+					DescriptorSetFile: "protos/policy.pb",
+					TLS:               rulechain.GRPCTLSSpec{CAFile: caFile},
+				},
+			},
+		}}, renderer)
+		if err != nil {
+			return rulechain.Definition{}, err
+		}
+		return defs[0], nil
+	}
+
+	def, err := compile("policy.v1.Authorizer/Check", `{"subject": "alice"}`)
+	require.NoError(t, err)
+	require.NotNil(t, def.Backend.GRPC.Descriptor)
+
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+	state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+	outcome, reason, _ := agent.evaluateRule(context.Background(), def, state)
+	require.Equal(t, "pass", outcome, reason)
+	require.Equal(t, true, state.Backend.Body.(map[string]any)["allowed"])
+
+	badRequest, err := compile("policy.v1.Authorizer/Check", `{"subjekt": "alice"}`)
+	require.NoError(t, err)
+	state = pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+	outcome, reason, _ = agent.evaluateRule(context.Background(), badRequest, state)
+	require.Equal(t, "error", outcome)
+	require.Contains(t, reason, "grpc backend: request message")
+
+	_, err = compile("policy.v1.Authorizer/Watch", "")
+	require.ErrorContains(t, err, "method policy.v1.Authorizer.Watch is streaming")
+	_, err = compile("policy.v1.Authorizer/Revoke", "")
+	require.ErrorContains(t, err, "method Revoke not found on policy.v1.Authorizer")
+}
+
+func TestRuleExecutionAgentGRPCReflectionErrors(t *testing.T) {
+	addr := newGRPCStub(t, grpcTestFile(t), nil)
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+	evaluate := func(method string) (string, string) {
+		defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+			Name: "policy",
+			Backend: rulechain.BackendDefinitionSpec{
+				Type: rulechain.BackendTypeGRPC,
+				URL:  "grpc://" + addr,
+				GRPC: rulechain.GRPCSpec{Method: method},
+			},
+		}}, templates.NewRenderer(nil))
+		require.NoError(t, err)
+		state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+		outcome, reason, _ := agent.evaluateRule(context.Background(), defs[0], state)
+		return outcome, reason
+	}
+
+	outcome, reason := evaluate("policy.v1.Authorizer/Revoke")
+	require.Equal(t, "error", outcome)
+	require.Contains(t, reason, "reflection: method Revoke not found on policy.v1.Authorizer")
+
+	outcome, reason = evaluate("policy.v1.Unknown/Check")
+	require.Equal(t, "error", outcome)
+	require.Contains(t, reason, "reflection:")
+}
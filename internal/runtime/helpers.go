@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/l0p7/passctrl/internal/runtime/admission"
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
 )
 
 // cacheKeyFromRequest derives a stable cache key by extracting the credential
@@ -16,14 +17,20 @@ import (
 //  1. Authorization header (if allowed)
 //  2. Custom headers (if allowed)
 //  3. Query parameters (if allowed)
-//  4. None/anonymous (handled by caller via empty cache key)
+//  4. None/anonymous (cached only when the endpoint declares cache.key)
 func cacheKeyFromRequest(r *http.Request, endpoint string, authCfg *admission.Config) string {
 	if r == nil || authCfg == nil {
 		return ""
 	}
 
 	credential := extractCredential(r, authCfg)
-	return credential + "|" + endpoint + "|" + r.URL.Path
+	key := credential + "|" + endpoint + "|" + r.URL.Path
+	// Separate decisions per verified mTLS peer so rules keyed on the client
+	// certificate never replay another peer's outcome.
+	if peer := pipeline.VerifiedPeerCertificate(r); peer != nil {
+		key += "|peer:" + peer.FingerprintSHA256
+	}
+	return key
 }
 
 // extractCredential mirrors admission agent's credential extraction logic.
@@ -50,7 +57,11 @@ func extractCredential(r *http.Request, authCfg *admission.Config) string {
 		}
 	}
 
-	// 4. No credential found - use IP address as fallback
-	// Note: This handles non-none endpoints that don't have credentials yet
+	// 4. No credential found. Anonymous endpoints share entries, separated
+	// only by their cache.key expressions; others fall back to the IP address
+	// for requests that do not carry credentials yet.
+	if authCfg.Allow.None {
+		return "anonymous"
+	}
 	return "ip:" + r.RemoteAddr
 }
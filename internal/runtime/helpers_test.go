@@ -1,6 +1,9 @@
 package runtime
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -239,3 +242,23 @@ func TestCacheKeyFromRequest_EndpointIsolation(t *testing.T) {
 	require.Contains(t, key1, "endpoint-A")
 	require.Contains(t, key2, "endpoint-B")
 }
+
+func TestCacheKeyFromRequest_VerifiedPeerCertificate(t *testing.T) {
+	cfg := &admission.Config{
+		Allow: admission.AllowConfig{
+			None: true,
+		},
+	}
+
+	newReq := func(raw string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com/api/data", http.NoBody)
+		req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Raw: []byte(raw), SerialNumber: big.NewInt(1)}}}}
+		return req
+	}
+
+	keyA := cacheKeyFromRequest(newReq("peer-a"), "test-endpoint", cfg)
+	keyB := cacheKeyFromRequest(newReq("peer-b"), "test-endpoint", cfg)
+
+	require.Contains(t, keyA, "|test-endpoint|/api/data|peer:")
+	require.NotEqual(t, keyA, keyB, "different client certificates must not share cache entries")
+}
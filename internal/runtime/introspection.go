@@ -0,0 +1,164 @@
+package runtime
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+)
+
+// renderIntrospectionRequest builds the RFC 7662 form body and applies client
+// authentication to the supplied headers. The Authorization header is reserved
+// for client authentication, so caller credentials never reach the
+// authorization server outside the token parameter.
+func renderIntrospectionRequest(def *rulechain.IntrospectionDefinition, headers map[string]string, state *pipeline.State) (string, error) {
+	ctx := state.TemplateContext()
+	token, err := def.Token.Render(ctx)
+	if err != nil {
+		return "", fmt.Errorf("introspection token render: %w", err)
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("introspection token rendered empty")
+	}
+
+	form := url.Values{}
+	form.Set("token", token)
+	if def.TokenTypeHint != "" {
+		form.Set("token_type_hint", def.TokenTypeHint)
+	}
+
+	delete(headers, "authorization")
+	headers["content-type"] = "application/x-www-form-urlencoded"
+	headers["accept"] = "application/json"
+
+	if def.ClientAuth != rulechain.ClientAuthNone {
+		clientID, err := def.ClientID.Render(ctx)
+		if err != nil {
+			return "", fmt.Errorf("introspection client id render: %w", err)
+		}
+		clientSecret := ""
+		if def.ClientSecret != nil {
+			if clientSecret, err = def.ClientSecret.Render(ctx); err != nil {
+				return "", fmt.Errorf("introspection client secret render: %w", err)
+			}
+		}
+		clientID = strings.TrimSpace(clientID)
+		clientSecret = strings.TrimSpace(clientSecret)
+		switch def.ClientAuth {
+		case rulechain.ClientAuthSecretPost:
+			form.Set("client_id", clientID)
+			if clientSecret != "" {
+				form.Set("client_secret", clientSecret)
+			}
+		default:
+			// RFC 6749 §2.3.1: credentials are form-encoded before base64.
+			pair := url.QueryEscape(clientID) + ":" + url.QueryEscape(clientSecret)
+			headers["authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(pair))
+		}
+	}
+
+	return form.Encode(), nil
+}
+
+// evaluateIntrospection validates the introspection response captured in
+// state.Backend and publishes the typed claims as state.Backend.Introspection.
+// It returns an empty outcome when the token is acceptable so the rule's own
+// conditions can run.
+func evaluateIntrospection(def *rulechain.IntrospectionDefinition, state *pipeline.State, now time.Time) (string, string) {
+	if !state.Backend.Accepted {
+		return "error", fmt.Sprintf("introspection endpoint returned status %d", state.Backend.Status)
+	}
+	body, ok := state.Backend.Body.(map[string]any)
+	if !ok {
+		return "error", "introspection response is not a JSON object"
+	}
+	active, ok := body["active"].(bool)
+	if !ok {
+		return "error", "introspection response missing active flag"
+	}
+
+	claims := introspectionClaims(body)
+	state.Backend.Introspection = claims
+	if !active {
+		return "fail", "token inactive"
+	}
+
+	if exp, ok := claims["exp"].(time.Time); ok && !now.Before(exp.Add(def.Leeway)) {
+		return "fail", fmt.Sprintf("token expired at %s", exp.Format(time.RFC3339))
+	}
+	if nbf, ok := claims["nbf"].(time.Time); ok && now.Add(def.Leeway).Before(nbf) {
+		return "fail", fmt.Sprintf("token not valid before %s", nbf.Format(time.RFC3339))
+	}
+	if def.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != def.Issuer {
+			return "fail", fmt.Sprintf("token issuer %q not accepted", iss)
+		}
+	}
+	if len(def.Audience) > 0 {
+		audiences, _ := claims["aud"].([]string)
+		if !slices.ContainsFunc(audiences, func(aud string) bool { return slices.Contains(def.Audience, aud) }) {
+			return "fail", "token audience not accepted"
+		}
+	}
+	return "", ""
+}
+
+// introspectionClaims converts the raw response into CEL-friendly values:
+// NumericDate fields become timestamps, `aud` is always a list, and the
+// space-delimited `scope` string is also exposed as a `scopes` list.
+func introspectionClaims(body map[string]any) map[string]any {
+	claims := make(map[string]any, len(body)+1)
+	for key, value := range body {
+		claims[key] = value
+	}
+	for _, key := range []string{"exp", "iat", "nbf"} {
+		if ts, ok := numericDate(body[key]); ok {
+			claims[key] = ts
+		}
+	}
+	switch aud := body["aud"].(type) {
+	case string:
+		claims["aud"] = []string{aud}
+	case []any:
+		list := make([]string, 0, len(aud))
+		for _, entry := range aud {
+			if s, ok := entry.(string); ok {
+				list = append(list, s)
+			}
+		}
+		claims["aud"] = list
+	}
+	scopes := []string{}
+	if scope, ok := body["scope"].(string); ok {
+		scopes = strings.Fields(scope)
+	}
+	claims["scopes"] = scopes
+	return claims
+}
+
+func numericDate(value any) (time.Time, bool) {
+	switch v := value.(type) {
+	case int64:
+		return time.Unix(v, 0).UTC(), true
+	case float64:
+		return time.Unix(0, int64(v*float64(time.Second))).UTC(), true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// introspectionExpiry returns the token expiry recorded by the last
+// introspection call, or the zero time when none was reported.
+func introspectionExpiry(state *pipeline.State) time.Time {
+	if exp, ok := state.Backend.Introspection["exp"].(time.Time); ok {
+		return exp
+	}
+	return time.Time{}
+}
@@ -0,0 +1,167 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	runtimemocks "github.com/l0p7/passctrl/internal/mocks/runtime"
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+	"github.com/l0p7/passctrl/internal/templates"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuleExecutionAgentIntrospectionBackend(t *testing.T) {
+	const targetURL = "https://idp.test/oauth2/introspect"
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		outcome string
+		reason  string
+	}{
+		{
+			name:    "active token",
+			status:  http.StatusOK,
+			body:    fmt.Sprintf(`{"active":true,"sub":"user-1","scope":"read write","aud":"orders","iss":"https://idp.test","exp":%d}`, future),
+			outcome: "pass",
+		},
+		{
+			name:    "missing scope",
+			status:  http.StatusOK,
+			body:    fmt.Sprintf(`{"active":true,"scope":"write","aud":["orders"],"iss":"https://idp.test","exp":%d}`, future),
+			outcome: "fail",
+			reason:  "required pass condition not satisfied",
+		},
+		{name: "inactive token", status: http.StatusOK, body: `{"active":false}`, outcome: "fail", reason: "token inactive"},
+		{
+			name:    "expired token",
+			status:  http.StatusOK,
+			body:    fmt.Sprintf(`{"active":true,"scope":"read","aud":"orders","iss":"https://idp.test","exp":%d}`, past),
+			outcome: "fail",
+			reason:  "token expired at",
+		},
+		{
+			name:    "wrong audience",
+			status:  http.StatusOK,
+			body:    fmt.Sprintf(`{"active":true,"scope":"read","aud":["billing"],"iss":"https://idp.test","exp":%d}`, future),
+			outcome: "fail",
+			reason:  "token audience not accepted",
+		},
+		{
+			name:    "wrong issuer",
+			status:  http.StatusOK,
+			body:    fmt.Sprintf(`{"active":true,"scope":"read","aud":"orders","iss":"https://evil.test","exp":%d}`, future),
+			outcome: "fail",
+			reason:  `token issuer "https://evil.test" not accepted`,
+		},
+		{name: "missing active flag", status: http.StatusOK, body: `{"sub":"user-1"}`, outcome: "error", reason: "missing active flag"},
+		{name: "server error", status: http.StatusInternalServerError, body: `{"error":"boom"}`, outcome: "error", reason: "status 500"},
+	}
+
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "introspect",
+		Auth: []rulechain.AuthDirectiveSpec{{
+			Match: []rulechain.AuthMatcherSpec{{Type: "bearer"}},
+		}},
+		Backend: rulechain.BackendDefinitionSpec{
+			Type: rulechain.BackendTypeIntrospection,
+			URL:  targetURL,
+			Introspection: rulechain.IntrospectionSpec{
+				TokenTypeHint: "access_token",
+				ClientAuth: rulechain.IntrospectionClientAuthSpec{
+					ClientID:     "passctrl",
+					ClientSecret: "s3cret",
+				},
+				Audience: []string{"orders"},
+				Issuer:   "https://idp.test",
+			},
+		},
+		Conditions: rulechain.ConditionSpec{
+			Pass: []string{`"read" in backend.introspection.scopes && backend.introspection.exp > now`},
+		},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mockClient := runtimemocks.NewMockHTTPDoer(t)
+			mockClient.EXPECT().
+				Do(mock.AnythingOfType("*http.Request")).
+				RunAndReturn(func(req *http.Request) (*http.Response, error) {
+					require.Equal(t, http.MethodPost, req.Method)
+					require.Equal(t, targetURL, req.URL.String())
+					require.Equal(t, "application/x-www-form-urlencoded", req.Header.Get("Content-Type"))
+					user, pass, ok := req.BasicAuth()
+					require.True(t, ok)
+					require.Equal(t, "passctrl", user)
+					require.Equal(t, "s3cret", pass)
+
+					raw, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+					form, err := url.ParseQuery(string(raw))
+					require.NoError(t, err)
+					require.Equal(t, "caller-token", form.Get("token"))
+					require.Equal(t, "access_token", form.Get("token_type_hint"))
+					return newBackendResponse(tc.status, tc.body, map[string]string{"Content-Type": "application/json"}), nil
+				})
+
+			agent := newRuleExecutionAgent(newBackendInteractionAgent(mockClient, nil, nil), nil, nil, nil, 0, nil, "")
+			state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+			state.Admission.Credentials = []pipeline.AdmissionCredential{{Type: "bearer", Token: "caller-token"}}
+
+			outcome, reason, _ := agent.evaluateRule(context.Background(), defs[0], state)
+			require.Equal(t, tc.outcome, outcome)
+			require.Contains(t, reason, tc.reason)
+			if tc.outcome == "pass" {
+				require.Equal(t, []string{"read", "write"}, state.Backend.Introspection["scopes"])
+				require.Equal(t, []string{"orders"}, state.Backend.Introspection["aud"])
+				require.Equal(t, time.Unix(future, 0).UTC(), introspectionExpiry(state))
+			}
+		})
+	}
+}
+
+func TestRenderIntrospectionRequestClientSecretPost(t *testing.T) {
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "introspect",
+		Backend: rulechain.BackendDefinitionSpec{
+			Type: rulechain.BackendTypeIntrospection,
+			URL:  "https://idp.test/introspect",
+			Introspection: rulechain.IntrospectionSpec{
+				Token: "{{ index .request.Headers \"x-token\" }}",
+				ClientAuth: rulechain.IntrospectionClientAuthSpec{
+					Method:       rulechain.ClientAuthSecretPost,
+					ClientID:     "passctrl",
+					ClientSecret: "s3cret",
+				},
+			},
+		},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
+	req.Header.Set("X-Token", "header-token")
+	state := pipeline.NewState(req, "endpoint", "cache-key", "")
+	headers := map[string]string{"authorization": "Bearer leaked"}
+
+	body, err := renderIntrospectionRequest(defs[0].Backend.Introspection, headers, state)
+	require.NoError(t, err)
+	require.Equal(t, "client_id=passctrl&client_secret=s3cret&token=header-token", body)
+	require.NotContains(t, headers, "authorization")
+
+	empty := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+	_, err = renderIntrospectionRequest(defs[0].Backend.Introspection, map[string]string{}, empty)
+	require.ErrorContains(t, err, "rendered empty")
+}
@@ -0,0 +1,476 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+)
+
+// LDAP outcomes are reported through backend.status so acceptedStatuses and
+// the usual "backend response not accepted" handling apply unchanged.
+const (
+	ldapStatusBound    = http.StatusOK
+	ldapStatusRejected = http.StatusUnauthorized
+)
+
+const (
+	ldapMethod         = "BIND"
+	ldapDefaultTimeout = 10 * time.Second
+	ldapMaxGroupDepth  = 16
+)
+
+// renderedLDAPBind carries the credentials rendered for one LDAP evaluation.
+type renderedLDAPBind struct {
+	Username     string
+	Password     string
+	BindPassword string
+}
+
+// ldapBindResult is the outcome of a bind and the directory data read for
+// the bound user.
+type ldapBindResult struct {
+	status int
+	body   map[string]any
+}
+
+// renderLDAPRequest renders the bind credentials. The descriptor body holds
+// the username and a password digest so per-rule cache entries are keyed by
+// the exact credentials without retaining the password.
+func renderLDAPRequest(backend rulechain.BackendDefinition, state *pipeline.State) (renderedBackendRequest, error) {
+	def := backend.LDAP
+	ctx := state.TemplateContext()
+	username, err := renderBackendValue(def.Username, ctx)
+	if err != nil {
+		return renderedBackendRequest{}, fmt.Errorf("ldap username render: %w", err)
+	}
+	password, err := renderBackendValue(def.Password, ctx)
+	if err != nil {
+		return renderedBackendRequest{}, fmt.Errorf("ldap password render: %w", err)
+	}
+	bindPassword, err := renderBackendValue(def.BindPassword, ctx)
+	if err != nil {
+		return renderedBackendRequest{}, fmt.Errorf("ldap bindPassword render: %w", err)
+	}
+
+	digest := sha256.Sum256([]byte(password))
+	return renderedBackendRequest{
+		Method: ldapMethod,
+		URL:    backend.URL,
+		Body:   "username=" + strings.TrimSpace(username) + "\npassword-sha256=" + hex.EncodeToString(digest[:]),
+		LDAP: &renderedLDAPBind{
+			Username:     strings.TrimSpace(username),
+			Password:     password,
+			BindPassword: bindPassword,
+		},
+	}, nil
+}
+
+// executeLDAP binds the caller against the directory and publishes the
+// bound DN, requested attributes, and groups as the backend body. Rejected
+// credentials are a 401 status; connection and directory failures are
+// returned as errors.
+func (a *backendInteractionAgent) executeLDAP(ctx context.Context, rendered renderedBackendRequest, backend rulechain.BackendDefinition, state *pipeline.State) error {
+	if rendered.LDAP == nil {
+		return errors.New("ldap backend: bind credentials not rendered")
+	}
+	def := backend.LDAP
+
+	// An empty password would be an unauthenticated bind, which directories
+	// accept without checking anything.
+	result := ldapBindResult{status: ldapStatusRejected, body: ldapBody("", nil, nil, nil)}
+	if rendered.LDAP.Username != "" && rendered.LDAP.Password != "" {
+		pool, err := a.ldap.pool(backend.URL, def)
+		if err != nil {
+			return fmt.Errorf("ldap backend: %w", err)
+		}
+		result, err = pool.do(ctx, func(conn *ldap.Conn) (ldapBindResult, error) {
+			return bindLDAPUser(conn, def, *rendered.LDAP)
+		})
+		if err != nil {
+			if cause := context.Cause(ctx); cause != nil {
+				return fmt.Errorf("ldap backend aborted: %w", cause)
+			}
+			return fmt.Errorf("ldap backend: %w", err)
+		}
+	}
+
+	bodyText, err := json.Marshal(result.body)
+	if err != nil {
+		return fmt.Errorf("ldap backend: encode body: %w", err)
+	}
+	page := pipeline.BackendPageState{
+		URL:      rendered.URL,
+		Status:   result.status,
+		Body:     result.body,
+		BodyText: string(bodyText),
+		Accepted: backend.Accepts(result.status),
+	}
+	state.Backend.Requested = true
+	state.Backend.Pages = []pipeline.BackendPageState{page}
+	state.Backend.Status = page.Status
+	state.Backend.Body = page.Body
+	state.Backend.BodyText = page.BodyText
+	state.Backend.Accepted = page.Accepted
+	return nil
+}
+
+// bindLDAPUser authenticates the caller by direct bind or by locating the
+// entry under BaseDN first, then reads attributes and groups.
+func bindLDAPUser(conn *ldap.Conn, def *rulechain.LDAPDefinition, creds renderedLDAPBind) (ldapBindResult, error) {
+	rejected := ldapBindResult{status: ldapStatusRejected, body: ldapBody("", nil, nil, nil)}
+	attributes := def.Attributes
+	if len(attributes) == 0 {
+		// RFC 4511 §4.5.1.8: "1.1" requests no attributes.
+		attributes = []string{"1.1"}
+	}
+
+	var userDN string
+	var entry *ldap.Entry
+	if def.UserDN != "" {
+		userDN = strings.ReplaceAll(def.UserDN, "{username}", ldap.EscapeDN(creds.Username))
+		if ok, err := bindLDAP(conn, userDN, creds.Password); err != nil || !ok {
+			return rejected, err
+		}
+		if len(def.Attributes) > 0 {
+			res, err := conn.Search(ldap.NewSearchRequest(userDN, ldap.ScopeBaseObject, ldap.NeverDerefAliases, 1, 0, false, "(objectClass=*)", attributes, nil))
+			if err != nil {
+				return ldapBindResult{}, fmt.Errorf("read %s: %w", userDN, err)
+			}
+			if len(res.Entries) > 0 {
+				entry = res.Entries[0]
+			}
+		}
+	} else {
+		if err := bindLDAPService(conn, def, creds); err != nil {
+			return ldapBindResult{}, err
+		}
+		filter := strings.ReplaceAll(def.UserFilter, "{username}", ldap.EscapeFilter(creds.Username))
+		res, err := conn.Search(ldap.NewSearchRequest(def.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false, filter, attributes, nil))
+		switch {
+		case ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded):
+			return ldapBindResult{}, fmt.Errorf("user search %s matched more than one entry", filter)
+		case err != nil:
+			return ldapBindResult{}, fmt.Errorf("user search %s: %w", filter, err)
+		}
+		switch len(res.Entries) {
+		case 0:
+			return rejected, nil
+		case 1:
+			entry = res.Entries[0]
+			userDN = entry.DN
+		default:
+			return ldapBindResult{}, fmt.Errorf("user search %s matched %d entries", filter, len(res.Entries))
+		}
+		if ok, err := bindLDAP(conn, userDN, creds.Password); err != nil || !ok {
+			return rejected, err
+		}
+		if def.Groups != nil {
+			// Group lookups run with the service account's view of the tree.
+			if err := bindLDAPService(conn, def, creds); err != nil {
+				return ldapBindResult{}, err
+			}
+		}
+	}
+
+	var groups, groupDNs []any
+	if def.Groups != nil {
+		var err error
+		if groups, groupDNs, err = lookupLDAPGroups(conn, def.Groups, userDN); err != nil {
+			return ldapBindResult{}, err
+		}
+	}
+	return ldapBindResult{status: ldapStatusBound, body: ldapBody(userDN, ldapAttributes(entry, def.Attributes), groups, groupDNs)}, nil
+}
+
+// bindLDAP reports false without an error when the directory rejects the
+// credentials.
+func bindLDAP(conn *ldap.Conn, dn, password string) (bool, error) {
+	err := conn.Bind(dn, password)
+	switch {
+	case err == nil:
+		return true, nil
+	case ldap.IsErrorAnyOf(err, ldap.LDAPResultInvalidCredentials, ldap.LDAPResultInvalidDNSyntax, ldap.LDAPResultNoSuchObject):
+		return false, nil
+	default:
+		return false, fmt.Errorf("bind %s: %w", dn, err)
+	}
+}
+
+// bindLDAPService binds as the configured service account, or anonymously so
+// a pooled connection never searches with a previous caller's identity.
+func bindLDAPService(conn *ldap.Conn, def *rulechain.LDAPDefinition, creds renderedLDAPBind) error {
+	if def.BindDN == "" {
+		if err := conn.UnauthenticatedBind(""); err != nil {
+			return fmt.Errorf("anonymous bind: %w", err)
+		}
+		return nil
+	}
+	if err := conn.Bind(def.BindDN, creds.BindPassword); err != nil {
+		return fmt.Errorf("service bind %s: %w", def.BindDN, err)
+	}
+	return nil
+}
+
+// lookupLDAPGroups returns the names and DNs of the groups listing memberDN.
+// Nested lookups walk breadth-first through groups that are themselves
+// members, skipping groups already seen so cycles terminate.
+func lookupLDAPGroups(conn *ldap.Conn, groups *rulechain.LDAPGroupsDefinition, memberDN string) ([]any, []any, error) {
+	names, dns := []any{}, []any{}
+	seen := map[string]struct{}{}
+	frontier := []string{memberDN}
+	for depth := 0; len(frontier) > 0 && depth < ldapMaxGroupDepth; depth++ {
+		var next []string
+		for _, member := range frontier {
+			filter := strings.ReplaceAll(groups.Filter, "{dn}", ldap.EscapeFilter(member))
+			res, err := conn.Search(ldap.NewSearchRequest(groups.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false, filter, []string{groups.Attribute}, nil))
+			if err != nil {
+				return nil, nil, fmt.Errorf("group search %s: %w", filter, err)
+			}
+			for _, entry := range res.Entries {
+				key := strings.ToLower(entry.DN)
+				if _, ok := seen[key]; ok {
+					continue
+				}
+				seen[key] = struct{}{}
+				dns = append(dns, entry.DN)
+				if name := entry.GetEqualFoldAttributeValue(groups.Attribute); name != "" {
+					names = append(names, name)
+				}
+				next = append(next, entry.DN)
+			}
+		}
+		if !groups.Nested {
+			break
+		}
+		frontier = next
+	}
+	return names, dns, nil
+}
+
+// ldapAttributes returns every requested attribute as a list of values,
+// empty when the entry does not carry it, so conditions can index safely.
+func ldapAttributes(entry *ldap.Entry, names []string) map[string]any {
+	attrs := make(map[string]any, len(names))
+	for _, name := range names {
+		values := []any{}
+		if entry != nil {
+			for _, value := range entry.GetEqualFoldAttributeValues(name) {
+				values = append(values, value)
+			}
+		}
+		attrs[name] = values
+	}
+	return attrs
+}
+
+func ldapBody(dn string, attributes map[string]any, groups, groupDNs []any) map[string]any {
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	if groups == nil {
+		groups = []any{}
+	}
+	if groupDNs == nil {
+		groupDNs = []any{}
+	}
+	return map[string]any{
+		"dn":         dn,
+		"attributes": attributes,
+		"groups":     groups,
+		"groupDNs":   groupDNs,
+	}
+}
+
+// ldapPools shares connection pools between the rules of one endpoint set
+// that target the same server with the same TLS settings.
+type ldapPools struct {
+	mu    sync.Mutex
+	pools map[string]*ldapPool
+}
+
+func newLDAPPools() *ldapPools {
+	return &ldapPools{pools: make(map[string]*ldapPool)}
+}
+
+func (p *ldapPools) pool(rawURL string, def *rulechain.LDAPDefinition) (*ldapPool, error) {
+	key := strings.Join([]string{rawURL, strconv.FormatBool(def.StartTLS), def.CAFile, strconv.Itoa(def.PoolSize)}, "|")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if pool, ok := p.pools[key]; ok {
+		return pool, nil
+	}
+	tlsConfig, err := ldapTLSConfig(rawURL, def.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	pool := &ldapPool{url: rawURL, startTLS: def.StartTLS, tls: tlsConfig, size: def.PoolSize}
+	p.pools[key] = pool
+	return pool, nil
+}
+
+// close shuts every idle connection. Connections still in use are closed
+// when they are returned.
+func (p *ldapPools) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, pool := range p.pools {
+		pool.close()
+		delete(p.pools, key)
+	}
+}
+
+// ldapTLSConfig builds the client TLS settings for ldaps:// and StartTLS,
+// trusting caFile instead of the system roots when it is set.
+func ldapTLSConfig(rawURL, caFile string) (*tls.Config, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("url: %w", err)
+	}
+	cfg := &tls.Config{ServerName: parsed.Hostname(), MinVersion: tls.VersionTLS12}
+	if cfg.RootCAs, err = loadCAPool(caFile); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadCAPool reads a PEM bundle of trusted roots for backend TLS. An empty
+// path returns nil so the system roots apply.
+func loadCAPool(caFile string) (*x509.CertPool, error) {
+	if caFile == "" {
+		return nil, nil
+	}
+	pem, err := os.ReadFile(caFile) // #nosec G304 -- operator-configured CA bundle path
+	if err != nil {
+		return nil, fmt.Errorf("ca file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("ca file %s contains no certificates", caFile)
+	}
+	return roots, nil
+}
+
+// ldapPool keeps up to size idle connections to one directory server. Each
+// evaluation rebinds, so pooled connections carry no caller identity forward.
+type ldapPool struct {
+	url      string
+	startTLS bool
+	tls      *tls.Config
+	size     int
+
+	mu     sync.Mutex
+	idle   []*ldap.Conn
+	closed bool
+}
+
+// do runs fn on a pooled connection. A network failure on an idle
+// connection, which the server may have closed, is retried once on a fresh
+// one.
+func (p *ldapPool) do(ctx context.Context, fn func(*ldap.Conn) (ldapBindResult, error)) (ldapBindResult, error) {
+	conn, pooled, err := p.get(ctx)
+	if err != nil {
+		return ldapBindResult{}, err
+	}
+	result, err := runLDAP(ctx, conn, fn)
+	if err != nil && pooled && ldap.IsErrorWithCode(err, ldap.ErrorNetwork) && ctx.Err() == nil {
+		_ = conn.Close()
+		if conn, err = p.dial(ctx); err != nil {
+			return ldapBindResult{}, err
+		}
+		result, err = runLDAP(ctx, conn, fn)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return ldapBindResult{}, err
+	}
+	p.put(conn)
+	return result, nil
+}
+
+func runLDAP(ctx context.Context, conn *ldap.Conn, fn func(*ldap.Conn) (ldapBindResult, error)) (ldapBindResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ldapBindResult{}, err
+	}
+	conn.SetTimeout(ldapTimeout(ctx))
+	return fn(conn)
+}
+
+func (p *ldapPool) get(ctx context.Context) (*ldap.Conn, bool, error) {
+	p.mu.Lock()
+	for len(p.idle) > 0 {
+		conn := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if !conn.IsClosing() {
+			p.mu.Unlock()
+			return conn, true, nil
+		}
+	}
+	p.mu.Unlock()
+	conn, err := p.dial(ctx)
+	return conn, false, err
+}
+
+func (p *ldapPool) put(conn *ldap.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || conn.IsClosing() || len(p.idle) >= p.size {
+		_ = conn.Close()
+		return
+	}
+	p.idle = append(p.idle, conn)
+}
+
+func (p *ldapPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	for _, conn := range p.idle {
+		_ = conn.Close()
+	}
+	p.idle = nil
+}
+
+func (p *ldapPool) dial(ctx context.Context) (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(p.url, ldap.DialWithDialer(&net.Dialer{Timeout: ldapTimeout(ctx)}), ldap.DialWithTLSConfig(p.tls))
+	if err != nil {
+		return nil, fmt.Errorf("dial %s: %w", p.url, err)
+	}
+	if p.startTLS {
+		conn.SetTimeout(ldapTimeout(ctx))
+		if err := conn.StartTLS(p.tls); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("starttls %s: %w", p.url, err)
+		}
+	}
+	return conn, nil
+}
+
+// ldapTimeout bounds each directory operation by the rule or endpoint
+// deadline, falling back to a fixed limit when none applies.
+func ldapTimeout(ctx context.Context) time.Duration {
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline); remaining > 0 {
+			return remaining
+		}
+		return time.Millisecond
+	}
+	return ldapDefaultTimeout
+}
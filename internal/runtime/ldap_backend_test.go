@@ -0,0 +1,419 @@
+package runtime
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+	"github.com/l0p7/passctrl/internal/templates"
+	"github.com/stretchr/testify/require"
+)
+
+type ldapStubEntry struct {
+	dn       string
+	password string
+	attrs    map[string][]string
+}
+
+// ldapStub is an in-process directory speaking just enough LDAPv3 for the
+// backend: simple bind, StartTLS, and searches with and/or/not, equality,
+// and presence filters.
+type ldapStub struct {
+	url     string
+	tls     *tls.Config
+	entries []ldapStubEntry
+
+	mu    sync.Mutex
+	dials int
+	open  int
+	binds []string
+}
+
+func newLDAPStub(t *testing.T, tlsConfig *tls.Config, entries ...ldapStubEntry) *ldapStub {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	stub := &ldapStub{url: "ldap://" + listener.Addr().String(), tls: tlsConfig, entries: entries}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			stub.mu.Lock()
+			stub.dials++
+			stub.open++
+			stub.mu.Unlock()
+			go stub.serve(conn)
+		}
+	}()
+	return stub
+}
+
+func (s *ldapStub) serve(conn net.Conn) {
+	defer func() {
+		_ = conn.Close()
+		s.mu.Lock()
+		s.open--
+		s.mu.Unlock()
+	}()
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+		msgID, _ := packet.Children[0].Value.(int64)
+		op := packet.Children[1]
+		switch op.Tag {
+		case 0: // bind
+			dn, _ := op.Children[1].Value.(string)
+			password := op.Children[2].Data.String()
+			s.mu.Lock()
+			s.binds = append(s.binds, dn)
+			s.mu.Unlock()
+			_, _ = conn.Write(ldapStubResult(msgID, 1, s.bindResult(dn, password)).Bytes())
+		case 2: // unbind
+			return
+		case 3: // search
+			s.search(conn, msgID, op)
+		case 23: // extended: only StartTLS
+			if s.tls == nil {
+				_, _ = conn.Write(ldapStubResult(msgID, 24, 2).Bytes())
+				continue
+			}
+			_, _ = conn.Write(ldapStubResult(msgID, 24, 0).Bytes())
+			tlsConn := tls.Server(conn, s.tls)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn = tlsConn
+		default:
+			return
+		}
+	}
+}
+
+func (s *ldapStub) bindResult(dn, password string) int64 {
+	if dn == "" && password == "" {
+		return 0
+	}
+	for _, entry := range s.entries {
+		if strings.EqualFold(entry.dn, dn) && entry.password != "" && entry.password == password {
+			return 0
+		}
+	}
+	return 49 // invalidCredentials
+}
+
+func (s *ldapStub) search(conn net.Conn, msgID int64, op *ber.Packet) {
+	base, _ := op.Children[0].Value.(string)
+	scope, _ := op.Children[1].Value.(int64)
+	limit, _ := op.Children[3].Value.(int64)
+	filter := op.Children[6]
+	var attrs []string
+	for _, attr := range op.Children[7].Children {
+		if name, _ := attr.Value.(string); name != "1.1" {
+			attrs = append(attrs, name)
+		}
+	}
+
+	sent := int64(0)
+	for _, entry := range s.entries {
+		inScope := strings.EqualFold(entry.dn, base)
+		if scope != 0 {
+			inScope = inScope || strings.HasSuffix(strings.ToLower(entry.dn), ","+strings.ToLower(base))
+		}
+		if !inScope || !ldapStubMatches(filter, entry) {
+			continue
+		}
+		if limit > 0 && sent == limit {
+			_, _ = conn.Write(ldapStubResult(msgID, 5, 4).Bytes()) // sizeLimitExceeded
+			return
+		}
+		_, _ = conn.Write(ldapStubEntryPacket(msgID, entry, attrs).Bytes())
+		sent++
+	}
+	_, _ = conn.Write(ldapStubResult(msgID, 5, 0).Bytes())
+}
+
+func (s *ldapStub) stats() (int, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dials, append([]string{}, s.binds...)
+}
+
+// openConns counts client connections the stub has not seen close.
+func (s *ldapStub) openConns() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.open
+}
+
+func ldapStubMatches(filter *ber.Packet, entry ldapStubEntry) bool {
+	switch filter.Tag {
+	case 0: // and
+		for _, child := range filter.Children {
+			if !ldapStubMatches(child, entry) {
+				return false
+			}
+		}
+		return true
+	case 1: // or
+		for _, child := range filter.Children {
+			if ldapStubMatches(child, entry) {
+				return true
+			}
+		}
+		return false
+	case 2: // not
+		return !ldapStubMatches(filter.Children[0], entry)
+	case 3: // equalityMatch
+		attr, _ := filter.Children[0].Value.(string)
+		value, _ := filter.Children[1].Value.(string)
+		for _, candidate := range ldapStubValues(entry, attr) {
+			if strings.EqualFold(candidate, value) {
+				return true
+			}
+		}
+		return false
+	case 7: // present
+		attr := filter.Data.String()
+		return strings.EqualFold(attr, "objectClass") || len(ldapStubValues(entry, attr)) > 0
+	default:
+		return false
+	}
+}
+
+func ldapStubValues(entry ldapStubEntry, attr string) []string {
+	for name, values := range entry.attrs {
+		if strings.EqualFold(name, attr) {
+			return values
+		}
+	}
+	return nil
+}
+
+func ldapStubResult(msgID int64, tag ber.Tag, code int64) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, "MessageID"))
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, tag, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	packet.AppendChild(result)
+	return packet
+}
+
+func ldapStubEntryPacket(msgID int64, entry ldapStubEntry, attrs []string) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, "MessageID"))
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, 4, nil, "Search Result Entry")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.dn, "objectName"))
+	list := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for _, name := range attrs {
+		values := ldapStubValues(entry, name)
+		if len(values) == 0 {
+			continue
+		}
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attr.AppendChild(set)
+		list.AppendChild(attr)
+	}
+	result.AppendChild(list)
+	packet.AppendChild(result)
+	return packet
+}
+
+// backendTestCA issues a self-signed certificate for 127.0.0.1 and writes it
+// to a CA file the backend can trust.
+func backendTestCA(t *testing.T) (*tls.Config, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "backend.test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}, caFile
+}
+
+func ldapTestDirectory() []ldapStubEntry {
+	return []ldapStubEntry{
+		{dn: "cn=passctrl,dc=example,dc=com", password: "svc-secret"},
+		{dn: "uid=alice,ou=people,dc=example,dc=com", password: "wonderland", attrs: map[string][]string{
+			"uid": {"alice"}, "mail": {"alice@example.com"},
+		}},
+		{dn: "uid=bob,ou=people,dc=example,dc=com", password: "builder", attrs: map[string][]string{"uid": {"bob"}}},
+		{dn: "uid=dup1,ou=people,dc=example,dc=com", password: "x", attrs: map[string][]string{"uid": {"dup"}}},
+		{dn: "uid=dup2,ou=people,dc=example,dc=com", password: "x", attrs: map[string][]string{"uid": {"dup"}}},
+		{dn: "cn=engineers,ou=groups,dc=example,dc=com", attrs: map[string][]string{
+			"cn": {"engineers"}, "member": {"uid=alice,ou=people,dc=example,dc=com"},
+		}},
+		{dn: "cn=staff,ou=groups,dc=example,dc=com", attrs: map[string][]string{
+			"cn": {"staff"}, "member": {"cn=engineers,ou=groups,dc=example,dc=com", "cn=admins,ou=groups,dc=example,dc=com"},
+		}},
+		{dn: "cn=admins,ou=groups,dc=example,dc=com", attrs: map[string][]string{
+			"cn": {"admins"}, "member": {"cn=staff,ou=groups,dc=example,dc=com"},
+		}},
+	}
+}
+
+func TestRuleExecutionAgentLDAPSearchBind(t *testing.T) {
+	serverTLS, caFile := backendTestCA(t)
+	stub := newLDAPStub(t, serverTLS, ldapTestDirectory()...)
+
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "directory",
+		Auth: []rulechain.AuthDirectiveSpec{{
+			Match: []rulechain.AuthMatcherSpec{{Type: "basic"}},
+		}},
+		Backend: rulechain.BackendDefinitionSpec{
+			Type: rulechain.BackendTypeLDAP,
+			URL:  stub.url,
+			LDAP: rulechain.LDAPSpec{
+				StartTLS:     true,
+				CAFile:       caFile,
+				BindDN:       "cn=passctrl,dc=example,dc=com",
+				BindPassword: "svc-secret",
+				BaseDN:       "ou=people,dc=example,dc=com",
+				Attributes:   []string{"mail"},
+				Groups:       rulechain.LDAPGroupsSpec{BaseDN: "ou=groups,dc=example,dc=com", Nested: true},
+			},
+		},
+		Conditions: rulechain.ConditionSpec{
+			Pass: []string{`backend.accepted && "admins" in backend.body.groups`},
+		},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		outcome  string
+		status   int
+		reason   string
+	}{
+		{name: "nested group member", username: "alice", password: "wonderland", outcome: "pass", status: ldapStatusBound},
+		{name: "wrong password", username: "alice", password: "guess", outcome: "fail", status: ldapStatusRejected},
+		{name: "unknown user", username: "mallory", password: "guess", outcome: "fail", status: ldapStatusRejected},
+		{name: "filter injection", username: "*", password: "x", outcome: "fail", status: ldapStatusRejected},
+		{name: "no groups", username: "bob", password: "builder", outcome: "fail", status: ldapStatusBound},
+		{name: "empty password", username: "alice", password: "", outcome: "fail", status: ldapStatusRejected},
+		{name: "ambiguous user", username: "dup", password: "x", outcome: "error", reason: "matched 2 entries"},
+	}
+
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+			state.Admission.Credentials = []pipeline.AdmissionCredential{{Type: "basic", Username: tc.username, Password: tc.password}}
+
+			outcome, reason, _ := agent.evaluateRule(context.Background(), defs[0], state)
+			require.Equal(t, tc.outcome, outcome, reason)
+			require.Contains(t, reason, tc.reason)
+			if tc.status != 0 {
+				require.Equal(t, tc.status, state.Backend.Status)
+			}
+			if tc.outcome == "pass" {
+				body, ok := state.Backend.Body.(map[string]any)
+				require.True(t, ok)
+				require.Equal(t, "uid=alice,ou=people,dc=example,dc=com", body["dn"])
+				require.Equal(t, map[string]any{"mail": []any{"alice@example.com"}}, body["attributes"])
+				require.Equal(t, []any{"engineers", "staff", "admins"}, body["groups"])
+			}
+		})
+	}
+
+	dials, binds := stub.stats()
+	require.Equal(t, 1, dials, "evaluations reuse the pooled connection")
+	require.NotContains(t, binds, "", "search runs as the service account")
+}
+
+func TestRuleExecutionAgentLDAPDirectBind(t *testing.T) {
+	stub := newLDAPStub(t, nil, ldapTestDirectory()...)
+
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "directory",
+		Backend: rulechain.BackendDefinitionSpec{
+			Type: rulechain.BackendTypeLDAP,
+			URL:  stub.url,
+			LDAP: rulechain.LDAPSpec{
+				Username: `{{ index .request.Headers "x-user" }}`,
+				Password: `{{ index .request.Headers "x-password" }}`,
+				UserDN:   "uid={username},ou=people,dc=example,dc=com",
+				Groups:   rulechain.LDAPGroupsSpec{BaseDN: "ou=groups,dc=example,dc=com"},
+			},
+		},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+	evaluate := func(user, password string) (string, *pipeline.State, renderedBackendRequest) {
+		req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
+		req.Header.Set("X-User", user)
+		req.Header.Set("X-Password", password)
+		state := pipeline.NewState(req, "endpoint", "cache-key", "")
+		rendered, err := agent.renderBackendRequest(defs[0].Backend, nil, state)
+		require.NoError(t, err)
+		outcome, _, _ := agent.evaluateRule(context.Background(), defs[0], state)
+		return outcome, state, rendered
+	}
+
+	outcome, state, first := evaluate("alice", "wonderland")
+	require.Equal(t, "pass", outcome)
+	require.Equal(t, []any{"engineers"}, state.Backend.Body.(map[string]any)["groups"], "nested lookups are opt-in")
+	require.NotContains(t, first.Body, "wonderland")
+
+	outcome, state, second := evaluate("alice", "guess")
+	require.Equal(t, "fail", outcome)
+	require.Equal(t, ldapStatusRejected, state.Backend.Status)
+	require.NotEqual(t, first.Body, second.Body, "cache descriptors differ per password")
+
+	outcome, _, _ = evaluate("alice,ou=admins", "wonderland")
+	require.Equal(t, "fail", outcome, "DN metacharacters in the username are escaped")
+
+	_, binds := stub.stats()
+	require.Contains(t, binds, `uid=alice\,ou=admins,ou=people,dc=example,dc=com`)
+}
@@ -0,0 +1,69 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// PeerCertificate summarizes a verified TLS client certificate.
+type PeerCertificate struct {
+	Subject           string    `json:"subject"`
+	CommonName        string    `json:"commonName"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serialNumber"`
+	DNSNames          []string  `json:"dnsNames,omitempty"`
+	URIs              []string  `json:"uris,omitempty"`
+	EmailAddresses    []string  `json:"emailAddresses,omitempty"`
+	NotBefore         time.Time `json:"notBefore"`
+	NotAfter          time.Time `json:"notAfter"`
+	FingerprintSHA256 string    `json:"fingerprintSha256"`
+}
+
+// Map renders the certificate for CEL activations and admission snapshots.
+// A nil certificate yields an empty map so expressions can test for fields
+// with has().
+func (c *PeerCertificate) Map() map[string]any {
+	if c == nil {
+		return map[string]any{}
+	}
+	return map[string]any{
+		"subject":           c.Subject,
+		"commonName":        c.CommonName,
+		"issuer":            c.Issuer,
+		"serialNumber":      c.SerialNumber,
+		"dnsNames":          append([]string{}, c.DNSNames...),
+		"uris":              append([]string{}, c.URIs...),
+		"emailAddresses":    append([]string{}, c.EmailAddresses...),
+		"notBefore":         c.NotBefore,
+		"notAfter":          c.NotAfter,
+		"fingerprintSha256": c.FingerprintSHA256,
+	}
+}
+
+// VerifiedPeerCertificate returns the leaf of the first verified client
+// certificate chain. Certificates presented without verification (no client
+// CA configured) are ignored so identity is never taken from unverified input.
+func VerifiedPeerCertificate(r *http.Request) *PeerCertificate {
+	if r == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+	sum := sha256.Sum256(leaf.Raw)
+	peer := &PeerCertificate{
+		Subject:           leaf.Subject.String(),
+		CommonName:        leaf.Subject.CommonName,
+		Issuer:            leaf.Issuer.String(),
+		SerialNumber:      leaf.SerialNumber.String(),
+		DNSNames:          append([]string{}, leaf.DNSNames...),
+		EmailAddresses:    append([]string{}, leaf.EmailAddresses...),
+		NotBefore:         leaf.NotBefore.UTC(),
+		NotAfter:          leaf.NotAfter.UTC(),
+		FingerprintSHA256: hex.EncodeToString(sum[:]),
+	}
+	for _, uri := range leaf.URIs {
+		peer.URIs = append(peer.URIs, uri.String())
+	}
+	return peer
+}
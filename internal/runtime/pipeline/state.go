@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/l0p7/passctrl/internal/datasets"
 )
 
 // Agent represents a runtime component that collaborates on processing an
@@ -55,6 +57,9 @@ type AdmissionState struct {
 	Snapshot      map[string]any        `json:"snapshot,omitempty"`
 	Allow         AdmissionAllow        `json:"allow"`
 	Credentials   []AdmissionCredential `json:"credentials,omitempty"`
+	// PeerCertificate is the client certificate verified during the TLS
+	// handshake, or nil when the connection carried none.
+	PeerCertificate *PeerCertificate `json:"peerCertificate,omitempty"`
 }
 
 // ForwardState exposes the curated headers and query parameters the forward
@@ -75,6 +80,8 @@ type RuleState struct {
 	History       []RuleHistoryEntry `json:"history,omitempty"`
 	Auth          RuleAuthState      `json:"auth"`
 	Variables     RuleVariableState  `json:"variables"`
+	// Params holds the parameter values of the rule instance being evaluated.
+	Params map[string]any `json:"params,omitempty"`
 }
 
 // RuleHistoryEntry records the result of a single rule within the chain.
@@ -85,6 +92,12 @@ type RuleHistoryEntry struct {
 	Duration  time.Duration  `json:"duration"`
 	Variables map[string]any `json:"variables,omitempty"`
 	FromCache bool           `json:"fromCache,omitempty"`
+	// Group is anyOf, allOf, or parallel for entries that summarize nested
+	// entries.
+	Group    string             `json:"group,omitempty"`
+	Children []RuleHistoryEntry `json:"children,omitempty"`
+	// Stopped marks the entry whose onPass: stop ended the chain.
+	Stopped bool `json:"stopped,omitempty"`
 }
 
 // RuleAuthState surfaces the matched authentication directive and forwarding
@@ -131,6 +144,8 @@ type BackendState struct {
 	Error     string             `json:"error,omitempty"`
 	Accepted  bool               `json:"accepted"`
 	Pages     []BackendPageState `json:"pages,omitempty"`
+	// Introspection holds the typed claims from an introspection backend.
+	Introspection map[string]any `json:"introspection,omitempty"`
 }
 
 // BackendPageState records metadata for additional backend pages requested
@@ -169,6 +184,9 @@ type State struct {
 	Cache     CacheState     `json:"cache"`
 	Backend   BackendState   `json:"backend"`
 	Variables VariablesState `json:"variables"`
+	// Data holds the dataset tables exposed as data.<name>. It is a shared
+	// read-only snapshot and left out of serialized state.
+	Data datasets.Tables `json:"-"`
 }
 
 // AdmissionAllow mirrors the endpoint authentication configuration so rules can
@@ -261,6 +279,22 @@ func (s *State) Plan() any { return s.plan }
 // ClearPlan removes any stored execution plan from the state.
 func (s *State) ClearPlan() { s.plan = nil }
 
+// Fork returns a copy of the state that a rule can evaluate against while
+// sibling rules run concurrently. The maps rule evaluation writes to are
+// copied; request, admission, and forward data are shared read-only.
+func (s *State) Fork() *State {
+	fork := *s
+	fork.Backend.Headers = cloneStringMap(s.Backend.Headers)
+	fork.Response.Headers = cloneStringMap(s.Response.Headers)
+	fork.Response.Variables = cloneAnyMap(s.Response.Variables)
+	fork.Variables.Rules = make(map[string]map[string]any, len(s.Variables.Rules))
+	for name, vars := range s.Variables.Rules {
+		fork.Variables.Rules[name] = vars
+	}
+	fork.Rule.History = nil
+	return &fork
+}
+
 // TemplateContext exposes a map suitable for template execution, capturing the
 // full pipeline state snapshot.
 func (s *State) TemplateContext() map[string]any {
@@ -294,11 +328,22 @@ func (s *State) TemplateContext() map[string]any {
 	}
 	ctx["auth"] = s.Rule.Auth.templateContext()
 	ctx["variables"] = s.VariablesContext()
+	ctx["params"] = s.Rule.ParamsContext()
 	ctx["chain"] = s.Rule.History
+	ctx["data"] = s.Data
 	ctx["state"] = s
 	return ctx
 }
 
+// ParamsContext returns the active rule's parameters, never nil so templates
+// and CEL can index it when the rule declares none.
+func (r RuleState) ParamsContext() map[string]any {
+	if r.Params == nil {
+		return map[string]any{}
+	}
+	return r.Params
+}
+
 func (a RuleAuthState) templateContext() map[string]any {
 	input := a.Input
 	if input == nil {
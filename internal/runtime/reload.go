@@ -0,0 +1,266 @@
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/metrics"
+)
+
+const defaultReloadHistorySize = 20
+
+// ReloadRecord summarizes one rule bundle reload attempt for the health and
+// admin APIs.
+type ReloadRecord struct {
+	Time       time.Time                  `json:"time"`
+	Status     metrics.RulesReloadOutcome `json:"status"`
+	SourceHash string                     `json:"sourceHash,omitempty"`
+	Sources    []string                   `json:"sources,omitempty"`
+	Diff       ReloadDiff                 `json:"diff"`
+	Errors     []string                   `json:"errors,omitempty"`
+	// DryRun marks a preview; Status then reports what a real reload would
+	// have done.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// ReloadDiff lists the endpoint and rule definitions a bundle adds, removes,
+// or changes relative to the snapshot that was active when it was evaluated.
+type ReloadDiff struct {
+	AddedEndpoints   []string `json:"addedEndpoints,omitempty"`
+	RemovedEndpoints []string `json:"removedEndpoints,omitempty"`
+	ChangedEndpoints []string `json:"changedEndpoints,omitempty"`
+	AddedRules       []string `json:"addedRules,omitempty"`
+	RemovedRules     []string `json:"removedRules,omitempty"`
+	ChangedRules     []string `json:"changedRules,omitempty"`
+}
+
+// Reload compiles the bundle into a candidate endpoint set and swaps it in as
+// one transaction. Unless server.rules.reload.allowDegraded is set, a bundle
+// that would stop an endpoint that is serving now (a broken rule, template, or
+// matcher, or a quarantined duplicate) is rejected and the previous snapshot
+// keeps serving. Applied bundles purge cached decisions.
+func (p *Pipeline) Reload(ctx context.Context, bundle config.RuleBundle) ReloadRecord {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	record, candidate, regressions := p.evaluateBundle(bundle)
+	if record.Status == metrics.RulesReloadRejected {
+		_ = candidate.backends.Close()
+		p.recordReload(record)
+		p.logger.Warn("rule bundle rejected; keeping previous snapshot",
+			slog.String("event", "rules_reload"),
+			slog.Any("errors", record.Errors),
+		)
+		return record
+	}
+
+	p.publish(candidate, bundle.Sources, bundle.Skipped)
+	p.recordReload(record)
+
+	attrs := []any{
+		slog.String("event", "rules_reload"),
+		slog.String("source_hash", record.SourceHash),
+		slog.Uint64("generation", p.generation),
+	}
+	if len(regressions) > 0 {
+		attrs = append(attrs, slog.Any("degraded", regressions))
+	}
+	if prefix, ok := p.purgeDecisionCache(ctx); ok {
+		attrs = append(attrs, slog.String("cache_prefix", prefix))
+	}
+	p.logger.Info("configuration reloaded", attrs...)
+	return record
+}
+
+// PreviewReload evaluates the bundle exactly as Reload would, without
+// publishing it, recording it in the history, or purging cached decisions.
+func (p *Pipeline) PreviewReload(bundle config.RuleBundle) ReloadRecord {
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	record, candidate, _ := p.evaluateBundle(bundle)
+	_ = candidate.backends.Close()
+	record.DryRun = true
+	return record
+}
+
+// evaluateBundle compiles the candidate endpoint set and decides whether the
+// reload policy accepts it. Callers hold reloadMu.
+func (p *Pipeline) evaluateBundle(bundle config.RuleBundle) (ReloadRecord, *endpointSet, []string) {
+	current := p.active.Load()
+	candidate := p.buildEndpointSet(bundle.Endpoints, bundle.Rules)
+	record := ReloadRecord{
+		Time:       time.Now().UTC(),
+		Status:     metrics.RulesReloadApplied,
+		SourceHash: bundleHash(bundle),
+		Sources:    cloneStringSlice(bundle.Sources),
+		Diff:       diffBundles(current.endpointConfigs, current.ruleConfigs, bundle),
+		Errors:     candidateErrors(candidate, bundle.Skipped),
+	}
+
+	regressions := endpointRegressions(current, candidate, bundle.Skipped)
+	if len(regressions) > 0 && !p.rulesReload.AllowDegraded {
+		record.Status = metrics.RulesReloadRejected
+		record.Errors = append(regressions, record.Errors...)
+	}
+	return record, candidate, regressions
+}
+
+// RecordReloadFailure adds a bundle that could not be loaded at all, such as a
+// rules file with a syntax error, to the reload history.
+func (p *Pipeline) RecordReloadFailure(err error) {
+	if err == nil {
+		return
+	}
+	p.recordReload(ReloadRecord{
+		Time:   time.Now().UTC(),
+		Status: metrics.RulesReloadFailed,
+		Errors: []string{err.Error()},
+	})
+}
+
+// ReloadHistory returns the retained reload attempts, newest first.
+func (p *Pipeline) ReloadHistory() []ReloadRecord {
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+	out := make([]ReloadRecord, len(p.history))
+	for i, record := range p.history {
+		out[len(p.history)-1-i] = record
+	}
+	return out
+}
+
+// ServeReloadHistory reports the retained reload attempts for the admin API.
+func (p *Pipeline) ServeReloadHistory(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{"reloads": p.ReloadHistory()}); err != nil {
+		p.logger.Error("reload history encode failed", slog.Any("error", err))
+	}
+}
+
+func (p *Pipeline) lastReload() (ReloadRecord, bool) {
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+	if len(p.history) == 0 {
+		return ReloadRecord{}, false
+	}
+	return p.history[len(p.history)-1], true
+}
+
+func (p *Pipeline) recordReload(record ReloadRecord) {
+	if p.metrics != nil {
+		p.metrics.ObserveRulesReload(record.Status)
+	}
+	limit := p.rulesReload.HistorySize
+	if limit <= 0 {
+		limit = defaultReloadHistorySize
+	}
+	p.historyMu.Lock()
+	defer p.historyMu.Unlock()
+	p.history = append(p.history, record)
+	if overflow := len(p.history) - limit; overflow > 0 {
+		p.history = append([]ReloadRecord(nil), p.history[overflow:]...)
+	}
+}
+
+// endpointRegressions lists endpoints that serve in the active snapshot, are
+// still declared by the bundle, but did not make it into the candidate set.
+// Endpoints the bundle no longer declares were removed on purpose.
+func endpointRegressions(current *snapshot, candidate *endpointSet, skipped []config.DefinitionSkip) []string {
+	if current.usingFallback {
+		return nil
+	}
+	reasons := make(map[string]string, len(candidate.endpointErrors))
+	for name, reason := range candidate.endpointErrors {
+		reasons[strings.ToLower(strings.TrimSpace(name))] = reason
+	}
+	for _, skip := range skipped {
+		if skip.Kind == "endpoint" {
+			reasons[strings.ToLower(strings.TrimSpace(skip.Name))] = skip.Reason
+		}
+	}
+
+	var regressions []string
+	for key, runtime := range current.endpoints {
+		if _, ok := candidate.endpoints[key]; ok {
+			continue
+		}
+		if reason, declared := reasons[key]; declared {
+			regressions = append(regressions, fmt.Sprintf("endpoint %q would stop serving: %s", runtime.name, reason))
+		}
+	}
+	sort.Strings(regressions)
+	return regressions
+}
+
+func candidateErrors(candidate *endpointSet, skipped []config.DefinitionSkip) []string {
+	var errs []string
+	for name, reason := range candidate.ruleErrors {
+		errs = append(errs, fmt.Sprintf("rule %q: %s", name, reason))
+	}
+	for name, reason := range candidate.endpointErrors {
+		errs = append(errs, fmt.Sprintf("endpoint %q: %s", name, reason))
+	}
+	for _, skip := range skipped {
+		errs = append(errs, fmt.Sprintf("%s %q skipped: %s", skip.Kind, skip.Name, skip.Reason))
+	}
+	sort.Strings(errs)
+	return errs
+}
+
+func diffBundles(endpoints map[string]config.EndpointConfig, rules map[string]config.RuleConfig, bundle config.RuleBundle) ReloadDiff {
+	var diff ReloadDiff
+	diff.AddedEndpoints, diff.RemovedEndpoints, diff.ChangedEndpoints = diffDefinitions(endpoints, bundle.Endpoints)
+	diff.AddedRules, diff.RemovedRules, diff.ChangedRules = diffDefinitions(rules, bundle.Rules)
+	return diff
+}
+
+func diffDefinitions[T any](previous, next map[string]T) (added, removed, changed []string) {
+	for name, cfg := range next {
+		prev, ok := previous[name]
+		switch {
+		case !ok:
+			added = append(added, name)
+		case !reflect.DeepEqual(prev, cfg):
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, ok := next[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	sort.Strings(changed)
+	return added, removed, changed
+}
+
+// bundleHash fingerprints the endpoint and rule definitions so operators can
+// tell which bundle revision a reload evaluated. JSON encoding sorts map keys,
+// which keeps the hash stable across loads.
+func bundleHash(bundle config.RuleBundle) string {
+	payload, err := json.Marshal(struct {
+		Endpoints map[string]config.EndpointConfig `json:"endpoints"`
+		Rules     map[string]config.RuleConfig     `json:"rules"`
+	}{bundle.Endpoints, bundle.Rules})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
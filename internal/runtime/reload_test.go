@@ -0,0 +1,258 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/metrics"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func reloadTestBundle(condition string) config.RuleBundle {
+	endpoint := config.EndpointConfig{
+		Authentication: config.EndpointAuthenticationConfig{
+			Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+		},
+		Rules: []config.EndpointRuleReference{{Name: "gate"}},
+	}
+	return config.RuleBundle{
+		Endpoints: map[string]config.EndpointConfig{"alpha": endpoint, "beta": endpoint},
+		Rules: map[string]config.RuleConfig{
+			"gate": {Conditions: config.RuleConditionConfig{Pass: []string{condition}}},
+		},
+		Sources: []string{"rules.yaml"},
+	}
+}
+
+func newReloadTestPipeline(t *testing.T, policy config.RulesReloadConfig) *Pipeline {
+	t.Helper()
+	bundle := reloadTestBundle("true")
+	pipe := NewPipeline(nil, PipelineOptions{
+		Endpoints:   bundle.Endpoints,
+		Rules:       bundle.Rules,
+		RuleSources: bundle.Sources,
+		Metrics:     metrics.NewRecorder(nil),
+		RulesReload: policy,
+	})
+	t.Cleanup(func() { _ = pipe.Close(context.Background()) })
+	return pipe
+}
+
+func reloadTestAuth(pipe *Pipeline, endpoint string) int {
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/auth", http.NoBody)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	pipe.ServeAuth(rec, pipe.RequestWithEndpointHint(req, endpoint))
+	return rec.Code
+}
+
+func TestPipelineReloadRejectsBundleThatBreaksServingEndpoints(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{})
+
+	record := pipe.Reload(context.Background(), reloadTestBundle("request.method =="))
+	require.Equal(t, metrics.RulesReloadRejected, record.Status)
+	require.Equal(t, []string{"gate"}, record.Diff.ChangedRules)
+	require.NotEmpty(t, record.SourceHash)
+	require.Contains(t, record.Errors[0], `endpoint "alpha" would stop serving`)
+
+	require.Equal(t, http.StatusOK, reloadTestAuth(pipe, "alpha"), "previous snapshot must keep serving")
+	require.Equal(t, http.StatusOK, reloadTestAuth(pipe, "beta"))
+}
+
+func TestPipelineReloadAllowDegradedAppliesBrokenBundle(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{AllowDegraded: true})
+
+	record := pipe.Reload(context.Background(), reloadTestBundle("request.method =="))
+	require.Equal(t, metrics.RulesReloadApplied, record.Status)
+	require.False(t, pipe.EndpointExists("alpha"))
+	require.True(t, pipe.EndpointExists("default"), "fallback endpoint replaces the broken set")
+}
+
+func TestPipelineReloadAppliesIntentionalRemoval(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{})
+
+	bundle := reloadTestBundle("true")
+	delete(bundle.Endpoints, "beta")
+	bundle.Rules["extra"] = config.RuleConfig{Conditions: config.RuleConditionConfig{Pass: []string{"true"}}}
+	record := pipe.Reload(context.Background(), bundle)
+
+	require.Equal(t, metrics.RulesReloadApplied, record.Status)
+	require.Equal(t, ReloadDiff{RemovedEndpoints: []string{"beta"}, AddedRules: []string{"extra"}}, record.Diff)
+	require.False(t, pipe.EndpointExists("beta"))
+	require.Equal(t, http.StatusOK, reloadTestAuth(pipe, "alpha"))
+}
+
+func TestPipelineReloadRejectsQuarantinedEndpoint(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{})
+
+	bundle := reloadTestBundle("true")
+	delete(bundle.Endpoints, "beta")
+	bundle.Skipped = []config.DefinitionSkip{{Kind: "endpoint", Name: "beta", Reason: "duplicate definition"}}
+	record := pipe.Reload(context.Background(), bundle)
+
+	require.Equal(t, metrics.RulesReloadRejected, record.Status)
+	require.Contains(t, record.Errors, `endpoint "beta" would stop serving: duplicate definition`)
+	require.True(t, pipe.EndpointExists("beta"))
+}
+
+func TestPipelineReloadHistory(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{HistorySize: 2})
+
+	pipe.RecordReloadFailure(errors.New("yaml: line 3: mapping values are not allowed"))
+	pipe.Reload(context.Background(), reloadTestBundle("request.method =="))
+	pipe.Reload(context.Background(), reloadTestBundle("true"))
+
+	history := pipe.ReloadHistory()
+	require.Len(t, history, 2, "history is bounded by historySize")
+	require.Equal(t, metrics.RulesReloadApplied, history[0].Status, "newest record first")
+	require.Equal(t, metrics.RulesReloadRejected, history[1].Status)
+
+	rec := httptest.NewRecorder()
+	pipe.ServeReloadHistory(rec, httptest.NewRequest(http.MethodGet, "/rules/history", nil))
+	var payload struct {
+		Reloads []ReloadRecord `json:"reloads"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+	require.Len(t, payload.Reloads, 2)
+
+	healthRec := httptest.NewRecorder()
+	pipe.ServeHealth(healthRec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var health struct {
+		LastReload ReloadRecord `json:"lastReload"`
+	}
+	require.NoError(t, json.Unmarshal(healthRec.Body.Bytes(), &health))
+	require.Equal(t, metrics.RulesReloadApplied, health.LastReload.Status)
+}
+
+func TestPipelinePreviewReloadLeavesSnapshotUntouched(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{})
+	generation := pipe.active.Load().generation
+
+	bundle := reloadTestBundle("true")
+	delete(bundle.Endpoints, "beta")
+	record := pipe.PreviewReload(bundle)
+	require.True(t, record.DryRun)
+	require.Equal(t, metrics.RulesReloadApplied, record.Status)
+	require.Equal(t, []string{"beta"}, record.Diff.RemovedEndpoints)
+
+	rejected := pipe.PreviewReload(reloadTestBundle("request.method =="))
+	require.Equal(t, metrics.RulesReloadRejected, rejected.Status)
+
+	require.Equal(t, generation, pipe.active.Load().generation)
+	require.True(t, pipe.EndpointExists("beta"))
+	require.Empty(t, pipe.ReloadHistory(), "previews are not recorded")
+}
+
+func TestPipelineReloadClosesBackendConnections(t *testing.T) {
+	directory := newLDAPStub(t, nil, ldapTestDirectory()...)
+	grpcConns := &grpcConnCounter{}
+	grpcAddr := newGRPCStub(t, grpcTestFile(t), nil, grpc.StatsHandler(grpcConns))
+
+	bundle := func(condition string) config.RuleBundle {
+		endpoint := func(rule string) config.EndpointConfig {
+			return config.EndpointConfig{
+				Authentication: config.EndpointAuthenticationConfig{
+					Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+				},
+				Rules: []config.EndpointRuleReference{{Name: rule}},
+			}
+		}
+		return config.RuleBundle{
+			Endpoints: map[string]config.EndpointConfig{"directory": endpoint("ldap"), "policy": endpoint("grpc")},
+			Rules: map[string]config.RuleConfig{
+				"ldap": {
+					BackendAPI: config.RuleBackendConfig{
+						Type: "ldap",
+						URL:  directory.url,
+						LDAP: config.RuleLDAPConfig{
+							Username: "alice",
+							Password: "wonderland",
+							UserDN:   "uid={username},ou=people,dc=example,dc=com",
+						},
+					},
+					Conditions: config.RuleConditionConfig{Pass: []string{"backend.accepted && " + condition}},
+				},
+				"grpc": {
+					BackendAPI: config.RuleBackendConfig{
+						Type: "grpc",
+						URL:  "grpc://" + grpcAddr,
+						Body: `{"subject": "alice"}`,
+						GRPC: config.RuleGRPCConfig{Method: "policy.v1.Authorizer/Check"},
+					},
+					Conditions: config.RuleConditionConfig{Pass: []string{"backend.body.allowed && " + condition}},
+				},
+			},
+		}
+	}
+	initial := bundle("true")
+	pipe := NewPipeline(nil, PipelineOptions{Endpoints: initial.Endpoints, Rules: initial.Rules})
+
+	for i, condition := range []string{"true", "!false", "1 == 1"} {
+		if i > 0 {
+			record := pipe.Reload(context.Background(), bundle(condition))
+			require.Equal(t, metrics.RulesReloadApplied, record.Status, record.Errors)
+		}
+		require.Equal(t, http.StatusOK, reloadTestAuth(pipe, "directory"))
+		require.Equal(t, http.StatusOK, reloadTestAuth(pipe, "policy"))
+	}
+	require.Eventually(t, func() bool {
+		return directory.openConns() == 1 && grpcConns.open.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond, "only the active snapshot keeps backend connections")
+
+	require.NoError(t, pipe.Close(context.Background()))
+	require.Eventually(t, func() bool {
+		return directory.openConns() == 0 && grpcConns.open.Load() == 0
+	}, 5*time.Second, 10*time.Millisecond, "closing the pipeline releases backend connections")
+}
+
+func TestPipelineCloseReleasesDrainingSnapshots(t *testing.T) {
+	directory := newLDAPStub(t, nil, ldapTestDirectory()...)
+	bundle := func(condition string) config.RuleBundle {
+		return config.RuleBundle{
+			Endpoints: map[string]config.EndpointConfig{"directory": {
+				Authentication: config.EndpointAuthenticationConfig{
+					Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+				},
+				Rules: []config.EndpointRuleReference{{Name: "ldap"}},
+			}},
+			Rules: map[string]config.RuleConfig{"ldap": {
+				BackendAPI: config.RuleBackendConfig{
+					Type: "ldap",
+					URL:  directory.url,
+					LDAP: config.RuleLDAPConfig{
+						Username: "alice",
+						Password: "wonderland",
+						UserDN:   "uid={username},ou=people,dc=example,dc=com",
+					},
+				},
+				Conditions: config.RuleConditionConfig{Pass: []string{"backend.accepted && " + condition}},
+			}},
+		}
+	}
+	initial := bundle("true")
+	pipe := NewPipeline(nil, PipelineOptions{Endpoints: initial.Endpoints, Rules: initial.Rules})
+	require.Equal(t, http.StatusOK, reloadTestAuth(pipe, "directory"))
+
+	// A request still running on the first snapshot keeps it draining.
+	pinned := pipe.acquire()
+	record := pipe.Reload(context.Background(), bundle("!false"))
+	require.Equal(t, metrics.RulesReloadApplied, record.Status, record.Errors)
+	require.Equal(t, http.StatusOK, reloadTestAuth(pipe, "directory"))
+	require.Len(t, pipe.DrainingSnapshots(), 1)
+	require.Eventually(t, func() bool { return directory.openConns() == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	require.NoError(t, pipe.Close(ctx))
+	require.Eventually(t, func() bool {
+		return directory.openConns() == 0
+	}, 5*time.Second, 10*time.Millisecond, "closing the pipeline releases the backends of draining snapshots")
+	pinned.release()
+}
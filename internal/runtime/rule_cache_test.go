@@ -32,7 +32,7 @@ func TestPerRuleCaching_CacheHitSkipsBackend(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -86,7 +86,7 @@ func TestPerRuleCaching_CacheMissExecutesBackend(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -133,7 +133,7 @@ func TestPerRuleCaching_ExportedVariablesCachedAndRestored(t *testing.T) {
 
 	memCache := cache.NewMemory(5 * time.Minute)
 	renderer := templates.NewRenderer(nil)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -202,7 +202,7 @@ func TestPerRuleCaching_StrictMode_UpstreamChangesInvalidate(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	strictTrue := true
@@ -258,7 +258,7 @@ func TestPerRuleCaching_LooseMode_UpstreamChangesDontInvalidate(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	strictFalse := false
@@ -307,7 +307,7 @@ func TestPerRuleCaching_LooseMode_UpstreamChangesDontInvalidate(t *testing.T) {
 
 func TestPerRuleCaching_OnlyRulesWithBackendAreCached(t *testing.T) {
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(nil, nil)
+	backendAgent := newBackendInteractionAgent(nil, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -344,7 +344,7 @@ func TestPerRuleCaching_DifferentBackendRequestsGenerateDifferentHashes(t *testi
 
 	memCache := cache.NewMemory(5 * time.Minute)
 	renderer := templates.NewRenderer(nil)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -394,7 +394,7 @@ func TestPerRuleCaching_TTLRespected(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -454,7 +454,7 @@ func TestPerRuleCaching_FailOutcomeCached(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -506,7 +506,7 @@ func TestPerRuleCaching_ErrorOutcomeNeverCached(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -562,7 +562,7 @@ func TestPerRuleCaching_IncludeProxyHeaders_DefaultTrue(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	// Test demonstrates that by default (IncludeProxyHeaders=nil → true), proxy headers IN THE BACKEND
@@ -656,7 +656,7 @@ func TestPerRuleCaching_IncludeProxyHeaders_ExplicitFalse(t *testing.T) {
 	defer server.Close()
 
 	memCache := cache.NewMemory(5 * time.Minute)
-	backendAgent := newBackendInteractionAgent(&http.Client{}, nil)
+	backendAgent := newBackendInteractionAgent(&http.Client{}, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), memCache, time.Hour, nil, "")
 
 	// Explicitly set IncludeProxyHeaders=false
@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/expr"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+)
+
+// defaultRuleWorkers bounds concurrent rules per parallel group when the
+// endpoint does not set ruleExecution.maxWorkers.
+const defaultRuleWorkers = 4
+
+// buildRuleSteps compiles an endpoint's rule references into the step tree the
+// rule chain agent plans from, instantiating parameterized rules and compiling
+// when guards. With ruleExecution.parallel set to auto, consecutive rules that
+// do not read each other's exports are grouped to run concurrently.
+func buildRuleSteps(refs []config.EndpointRuleReference, exec config.EndpointRuleExecutionConfig, rules map[string]config.RuleConfig, compiled map[string]rulechain.Definition) ([]rulechain.Step, error) {
+	workers := exec.MaxWorkers
+	if workers <= 0 {
+		workers = defaultRuleWorkers
+	}
+	auto := strings.EqualFold(strings.TrimSpace(exec.Parallel), config.RuleParallelAuto)
+
+	var env *expr.Environment
+	var build func(refs []config.EndpointRuleReference, sequential bool) ([]rulechain.Step, error)
+	build = func(refs []config.EndpointRuleReference, sequential bool) ([]rulechain.Step, error) {
+		steps := make([]rulechain.Step, 0, len(refs))
+		for _, ref := range refs {
+			step := rulechain.Step{Stop: strings.EqualFold(strings.TrimSpace(ref.OnPass), config.OnPassStop)}
+			if when := strings.TrimSpace(ref.When); when != "" {
+				if env == nil {
+					var err error
+					if env, err = expr.NewEnvironment(); err != nil {
+						return nil, err
+					}
+				}
+				program, err := env.Compile(when)
+				if err != nil {
+					return nil, fmt.Errorf("when guard %q: %w", when, err)
+				}
+				step.When = &program
+			}
+
+			var nested []config.EndpointRuleReference
+			switch {
+			case len(ref.AnyOf) > 0:
+				step.Group, nested = rulechain.GroupAnyOf, ref.AnyOf
+			case len(ref.AllOf) > 0:
+				step.Group, nested = rulechain.GroupAllOf, ref.AllOf
+			case len(ref.Parallel) > 0:
+				step.Group, nested, step.Workers = rulechain.GroupParallel, ref.Parallel, workers
+			default:
+				ruleName := strings.TrimSpace(ref.Name)
+				if ruleName == "" {
+					continue
+				}
+				def, ok := compiled[ruleName]
+				if !ok {
+					return nil, fmt.Errorf("rule %q not available", ruleName)
+				}
+				params, err := config.ResolveRuleParams(rules[ruleName], ref.With)
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: %w", ruleName, err)
+				}
+				def, err = def.Instantiate(params)
+				if err != nil {
+					return nil, fmt.Errorf("rule %q: %w", ruleName, err)
+				}
+				step.Rule = &def
+			}
+			if step.Rule == nil {
+				children, err := build(nested, step.Group == rulechain.GroupAllOf)
+				if err != nil {
+					return nil, err
+				}
+				step.Steps = children
+			}
+			steps = append(steps, step)
+		}
+		if auto && sequential {
+			steps = batchIndependentSteps(steps, rules, workers)
+		}
+		return steps, nil
+	}
+	return build(refs, true)
+}
+
+// batchIndependentSteps groups runs of consecutive unguarded rules into
+// parallel steps. A rule joins the current batch unless it reads variables
+// exported by a rule already in it; guarded entries, onPass: stop, and groups
+// keep their sequential position.
+func batchIndependentSteps(steps []rulechain.Step, rules map[string]config.RuleConfig, workers int) []rulechain.Step {
+	out := make([]rulechain.Step, 0, len(steps))
+	var batch []rulechain.Step
+	flush := func() {
+		if len(batch) > 1 {
+			out = append(out, rulechain.Step{Group: rulechain.GroupParallel, Steps: batch, Workers: workers})
+		} else {
+			out = append(out, batch...)
+		}
+		batch = nil
+	}
+	for _, step := range steps {
+		if step.Rule == nil || step.When != nil || step.Stop {
+			flush()
+			out = append(out, step)
+			continue
+		}
+		deps := config.AnalyzeRuleDependencies(rules[step.Rule.Name])
+		for _, member := range batch {
+			if deps.DependsOn(member.Rule.Name) {
+				flush()
+				break
+			}
+		}
+		batch = append(batch, step)
+	}
+	flush()
+	return out
+}
+
+// ruleChainNode describes one entry of an endpoint's rule chain for /explain.
+type ruleChainNode struct {
+	Rule   string          `json:"rule,omitempty"`
+	Group  string          `json:"group,omitempty"`
+	When   string          `json:"when,omitempty"`
+	OnPass string          `json:"onPass,omitempty"`
+	With   map[string]any  `json:"with,omitempty"`
+	Steps  []ruleChainNode `json:"steps,omitempty"`
+}
+
+func describeRuleChain(refs []config.EndpointRuleReference) []ruleChainNode {
+	if len(refs) == 0 {
+		return nil
+	}
+	nodes := make([]ruleChainNode, 0, len(refs))
+	for _, ref := range refs {
+		node := ruleChainNode{
+			When:   strings.TrimSpace(ref.When),
+			OnPass: strings.ToLower(strings.TrimSpace(ref.OnPass)),
+			With:   ref.With,
+		}
+		switch {
+		case len(ref.AnyOf) > 0:
+			node.Group, node.Steps = rulechain.GroupAnyOf, describeRuleChain(ref.AnyOf)
+		case len(ref.AllOf) > 0:
+			node.Group, node.Steps = rulechain.GroupAllOf, describeRuleChain(ref.AllOf)
+		case len(ref.Parallel) > 0:
+			node.Group, node.Steps = rulechain.GroupParallel, describeRuleChain(ref.Parallel)
+		default:
+			node.Rule = strings.TrimSpace(ref.Name)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
@@ -3,6 +3,7 @@ package runtime
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -52,6 +53,10 @@ type renderedBackendRequest struct {
 	Headers map[string]string
 	Query   map[string]string
 	Body    string
+	// LDAP carries the rendered bind credentials for backends of type ldap.
+	LDAP *renderedLDAPBind
+	// SQL carries the rendered query arguments for backends of type sql.
+	SQL *renderedSQLQuery
 }
 
 type ruleExecutionAgent struct {
@@ -112,40 +117,24 @@ func (a *ruleExecutionAgent) Execute(ctx context.Context, _ *http.Request, state
 		}
 	}
 
-	history := make([]pipeline.RuleHistoryEntry, 0, len(plan.Rules))
-	var finalOutcome string
-	var finalReason string
-
-	for _, def := range plan.Rules {
-		// Reset cache state before evaluating each rule
-		state.Cache.Hit = false
-		state.Cache.Decision = ""
-		state.Cache.StoredAt = time.Time{}
-		state.Cache.ExpiresAt = time.Time{}
-		state.Cache.Stored = false
-
-		start := time.Now()
-		outcome, reason, _ := a.evaluateRule(ctx, def, state)
-		entry := pipeline.RuleHistoryEntry{
-			Name:      def.Name,
-			Outcome:   outcome,
-			Reason:    reason,
-			Duration:  time.Since(start),
-			Variables: cloneAnyMap(state.Rule.Variables.Rule),
-			FromCache: state.Cache.Hit, // Capture whether this rule result came from cache
-		}
-		history = append(history, entry)
-
-		finalOutcome = outcome
-		finalReason = reason
-		state.Rule.Outcome = outcome
-		state.Rule.Reason = reason
-
-		if outcome == "pass" {
-			continue
-		}
-
-		break
+	steps := plan.Steps
+	if len(steps) == 0 {
+		steps = rulechain.RuleSteps(plan.Rules)
+	}
+	ctx, cancel := withTimeout(ctx, plan.Timeout, metrics.TimeoutScopeEndpoint)
+	defer cancel()
+	result := a.evaluateGroup(ctx, rulechain.GroupAllOf, steps, state)
+	history := result.entry.Children
+	finalOutcome := result.entry.Outcome
+	finalReason := result.entry.Reason
+	if finalOutcome == outcomeSkipped {
+		finalOutcome = "pass"
+		finalReason = "all rules skipped"
+	}
+	if timeout := result.timeout; timeout != nil && timeout.scope == metrics.TimeoutScopeEndpoint {
+		finalOutcome = timeout.outcome
+		finalReason = timeout.Error()
+		a.observeTimeout(state.Endpoint, "", timeout.scope)
 	}
 
 	if finalOutcome == "" && len(history) == 0 {
@@ -155,7 +144,7 @@ func (a *ruleExecutionAgent) Execute(ctx context.Context, _ *http.Request, state
 		}
 	}
 
-	state.Rule.Executed = len(history) > 0
+	state.Rule.Executed = result.evaluated
 	state.Rule.History = history
 	state.Rule.Outcome = finalOutcome
 	state.Rule.Reason = finalReason
@@ -171,11 +160,164 @@ func (a *ruleExecutionAgent) Execute(ctx context.Context, _ *http.Request, state
 		Status:  outcome,
 		Details: finalReason,
 		Meta: map[string]any{
-			"executedRules": len(history),
+			"executedRules": result.executed,
 		},
 	}
 }
 
+// outcomeSkipped marks chain entries whose when guard did not hold, and groups
+// in which every entry was skipped.
+const outcomeSkipped = "skipped"
+
+// stepResult is the outcome of one chain entry together with its history.
+// timeout is set when the endpoint budget ran out while the entry, or one
+// nested in it, was evaluated or waiting.
+type stepResult struct {
+	entry     pipeline.RuleHistoryEntry
+	stop      bool
+	evaluated bool
+	executed  int
+	timeout   *timeoutError
+}
+
+// evaluateStep runs a single rule or group after checking its when guard. A
+// pass on a step marked onPass: stop asks the enclosing groups to end the chain.
+func (a *ruleExecutionAgent) evaluateStep(ctx context.Context, step rulechain.Step, state *pipeline.State) stepResult {
+	start := time.Now()
+	if step.When != nil {
+		ok, err := step.When.EvalBool(buildActivation(state))
+		if err != nil {
+			return stepResult{entry: pipeline.RuleHistoryEntry{
+				Name:     step.Label(),
+				Group:    step.Group,
+				Outcome:  "error",
+				Reason:   fmt.Sprintf("when guard %s evaluation failed: %v", step.When.Source(), err),
+				Duration: time.Since(start),
+			}, evaluated: true}
+		}
+		if !ok {
+			return stepResult{entry: pipeline.RuleHistoryEntry{
+				Name:     step.Label(),
+				Group:    step.Group,
+				Outcome:  outcomeSkipped,
+				Reason:   fmt.Sprintf("when guard not satisfied: %s", step.When.Source()),
+				Duration: time.Since(start),
+			}}
+		}
+	}
+
+	var result stepResult
+	switch {
+	case step.Group == rulechain.GroupParallel:
+		result = a.evaluateParallel(ctx, step, state)
+	case step.Rule == nil:
+		result = a.evaluateGroup(ctx, step.Group, step.Steps, state)
+	default:
+		def := *step.Rule
+		// Reset cache state before evaluating each rule
+		state.Cache.Hit = false
+		state.Cache.Decision = ""
+		state.Cache.StoredAt = time.Time{}
+		state.Cache.ExpiresAt = time.Time{}
+		state.Cache.Stored = false
+
+		ruleCtx, cancel := withTimeout(ctx, def.Timeout, metrics.TimeoutScopeRule)
+		outcome, reason, _ := a.evaluateRule(ruleCtx, def, state)
+		timeout := expiredTimeout(ruleCtx)
+		cancel()
+		if timeout != nil {
+			outcome, reason = timeout.outcome, timeout.Error()
+			state.Rule.Outcome, state.Rule.Reason = outcome, reason
+			if timeout.scope == metrics.TimeoutScopeRule {
+				// The rule's own deadline decides only this entry.
+				a.observeTimeout(state.Endpoint, def.Name, timeout.scope)
+				timeout = nil
+			}
+		}
+		result = stepResult{
+			entry: pipeline.RuleHistoryEntry{
+				Name:      def.Name,
+				Outcome:   outcome,
+				Reason:    reason,
+				Variables: cloneAnyMap(state.Rule.Variables.Rule),
+				FromCache: state.Cache.Hit, // Capture whether this rule result came from cache
+			},
+			evaluated: true,
+			executed:  1,
+			timeout:   timeout,
+		}
+	}
+	result.entry.Duration = time.Since(start)
+	if step.Stop && result.entry.Outcome == "pass" && !result.stop {
+		result.entry.Stopped = true
+		result.stop = true
+	}
+	return result
+}
+
+// evaluateGroup combines nested steps. allOf stops at the first fail or error
+// and passes once every evaluated entry passed; anyOf passes on the first pass
+// and otherwise reports error if any entry errored, else fail. Skipped entries
+// do not count, and a group whose entries were all skipped is skipped itself.
+func (a *ruleExecutionAgent) evaluateGroup(ctx context.Context, kind string, steps []rulechain.Step, state *pipeline.State) stepResult {
+	group := stepResult{entry: pipeline.RuleHistoryEntry{
+		Name:     kind,
+		Group:    kind,
+		Children: make([]pipeline.RuleHistoryEntry, 0, len(steps)),
+	}}
+	var decided bool
+	var sawError bool
+	for _, step := range steps {
+		if timeout := expiredTimeout(ctx); timeout != nil {
+			// The endpoint budget is spent; record the remaining entries
+			// without evaluating them.
+			group.entry.Children = append(group.entry.Children, pipeline.RuleHistoryEntry{
+				Name:    step.Label(),
+				Group:   step.Group,
+				Outcome: outcomeSkipped,
+				Reason:  timeout.Error(),
+			})
+			if group.timeout == nil {
+				group.timeout = timeout
+			}
+			continue
+		}
+		child := a.evaluateStep(ctx, step, state)
+		group.entry.Children = append(group.entry.Children, child.entry)
+		group.evaluated = group.evaluated || child.evaluated
+		group.executed += child.executed
+		if group.timeout == nil {
+			group.timeout = child.timeout
+		}
+		outcome := child.entry.Outcome
+		if outcome == outcomeSkipped {
+			continue
+		}
+		decided = true
+		group.entry.Outcome = outcome
+		group.entry.Reason = child.entry.Reason
+		if outcome == "pass" {
+			if child.stop || kind == rulechain.GroupAnyOf {
+				group.stop = child.stop
+				return group
+			}
+			continue
+		}
+		if kind != rulechain.GroupAnyOf {
+			return group
+		}
+		sawError = sawError || outcome == "error"
+	}
+	switch {
+	case !decided:
+		group.entry.Outcome = outcomeSkipped
+		group.entry.Reason = "all entries skipped"
+	case kind == rulechain.GroupAnyOf && group.entry.Outcome != "pass" && sawError:
+		group.entry.Outcome = "error"
+	}
+	return group
+}
+
 // finishRule evaluates exported variables and returns the outcome
 func (a *ruleExecutionAgent) finishRule(def rulechain.Definition, outcome, reason string, state *pipeline.State) (string, string, *rulechain.ResponseDefinition) {
 	// Set outcome and reason before evaluating variables so they're available in template context
@@ -217,6 +359,7 @@ func (a *ruleExecutionAgent) evaluateRule(ctx context.Context, def rulechain.Def
 	state.Rule.Variables.Rule = make(map[string]any)
 	state.Rule.Variables.Local = make(map[string]any)
 	state.Rule.Variables.Exported = make(map[string]any)
+	state.Rule.Params = def.Params
 
 	selection, authStatus, authReason := a.prepareRuleAuth(def.Auth, state)
 	if authStatus != "" {
@@ -258,6 +401,24 @@ func (a *ruleExecutionAgent) evaluateRule(ctx context.Context, def rulechain.Def
 			reason := a.ruleMessage(def.ErrorTemplate, def.ErrorMessage, fmt.Sprintf("backend request failed: %v", err), state)
 			return a.finishRuleWithCache(ctx, def, renderedBackend, "error", reason, state)
 		}
+
+		if def.Backend.BodySchema != nil && state.Backend.Accepted {
+			body, err := applyBodySchema(def.Backend.BodySchema, state.Backend)
+			if err != nil {
+				reason := a.ruleMessage(def.ErrorTemplate, def.ErrorMessage, fmt.Sprintf("backend response violates body schema: %v", err), state)
+				return a.finishRuleWithCache(ctx, def, renderedBackend, "error", reason, state)
+			}
+			state.Backend.Body = body
+		}
+
+		if def.Backend.IsIntrospection() {
+			switch outcome, reason := evaluateIntrospection(def.Backend.Introspection, state, time.Now()); outcome {
+			case "fail":
+				return a.finishRuleWithCache(ctx, def, renderedBackend, "fail", a.ruleMessage(def.FailTemplate, def.FailMessage, reason, state), state)
+			case "error":
+				return a.finishRuleWithCache(ctx, def, renderedBackend, "error", a.ruleMessage(def.ErrorTemplate, def.ErrorMessage, reason, state), state)
+			}
+		}
 	} else {
 		state.Backend.Accepted = true
 	}
@@ -337,7 +498,7 @@ func (a *ruleExecutionAgent) checkRuleCache(ctx context.Context, def rulechain.D
 	upstreamHash := buildUpstreamVarsHash(strict, state)
 
 	// Build final cache key
-	cacheKey := buildRuleCacheKey(baseKey, def.Name, backendHash, upstreamHash)
+	cacheKey := buildRuleCacheKey(baseKey, def.CacheName(), backendHash, upstreamHash)
 
 	// Lookup cache
 	lookupStart := time.Now()
@@ -401,7 +562,7 @@ func (a *ruleExecutionAgent) storeRuleCache(ctx context.Context, def rulechain.D
 		strict = *def.Cache.Strict
 	}
 	upstreamHash := buildUpstreamVarsHash(strict, state)
-	cacheKey := buildRuleCacheKey(baseKey, def.Name, backendHash, upstreamHash)
+	cacheKey := buildRuleCacheKey(baseKey, def.CacheName(), backendHash, upstreamHash)
 
 	// Calculate effective TTL
 	endpointTTL := cache.RuleCacheTTLConfig{} // TODO: Get from endpoint config
@@ -412,7 +573,8 @@ func (a *ruleExecutionAgent) storeRuleCache(ctx context.Context, def rulechain.D
 			Fail:  def.Cache.TTL.Fail,
 			Error: def.Cache.TTL.Error,
 		},
-		Strict: def.Cache.Strict,
+		Strict:           def.Cache.Strict,
+		CredentialExpiry: introspectionExpiry(state),
 	}
 	ttl := cache.CalculateEffectiveTTL(outcome, a.serverMaxTTL, endpointTTL, ruleConfig, state.Backend.Headers)
 
@@ -453,6 +615,23 @@ func (a *ruleExecutionAgent) storeRuleCache(ctx context.Context, def rulechain.D
 	}
 }
 
+// renderBackendValue renders tmpl, treating the "<no value>" placeholder of an
+// absent key as empty so a missing input never reaches a directory or
+// database as literal text.
+func renderBackendValue(tmpl *templates.Template, ctx map[string]any) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+	out, err := tmpl.Render(ctx)
+	if err != nil {
+		return "", err
+	}
+	if out == "<no value>" {
+		return "", nil
+	}
+	return out, nil
+}
+
 // renderBackendRequest renders all template components of a backend request before execution.
 // This separation allows cache key generation before invoking the backend.
 func (a *ruleExecutionAgent) renderBackendRequest(
@@ -466,6 +645,14 @@ func (a *ruleExecutionAgent) renderBackendRequest(
 		method = http.MethodGet
 	}
 
+	// LDAP backends bind instead of issuing an HTTP request
+	if backend.IsLDAP() {
+		return renderLDAPRequest(backend, state)
+	}
+	if backend.IsSQL() {
+		return renderSQLRequest(backend, state)
+	}
+
 	// URL is used as-is (no template rendering for URL currently)
 	url := backend.URL
 
@@ -517,6 +704,24 @@ func (a *ruleExecutionAgent) renderBackendRequest(
 	// Select query parameters
 	query := backend.SelectQuery(state.Request.Query, state)
 
+	// Introspection backends generate their own body and client authentication
+	if backend.IsIntrospection() {
+		if headers == nil {
+			headers = make(map[string]string)
+		}
+		form, err := renderIntrospectionRequest(backend.Introspection, headers, state)
+		if err != nil {
+			return renderedBackendRequest{}, err
+		}
+		return renderedBackendRequest{
+			Method:  method,
+			URL:     url,
+			Headers: headers,
+			Query:   query,
+			Body:    form,
+		}, nil
+	}
+
 	// Apply auth selection (multiple forwards)
 	if authSel != nil {
 		if headers == nil {
@@ -621,14 +826,19 @@ func (a *ruleExecutionAgent) prepareRuleAuth(directives []rulechain.AuthDirectiv
 	extracted := extractCredentials(state.Admission.Credentials)
 
 	// Try each match group (directive) in order
+	failReason := ""
 	for _, directive := range directives {
 		// Check if ALL matchers in this group succeed
-		if !checkAllMatchers(directive.Matchers, extracted) {
+		matched, key, reason := checkAllMatchers(directive.Matchers, extracted)
+		if !matched {
+			if reason != "" {
+				failReason = reason
+			}
 			continue // Try next group
 		}
 
 		// Build template context with all matched credentials
-		a.buildAuthTemplateContext(extracted, state)
+		a.buildAuthTemplateContext(extracted, key, state)
 
 		// Build forwards (or pass-through if empty)
 		forwards, err := a.buildForwards(directive, extracted, state)
@@ -654,6 +864,9 @@ func (a *ruleExecutionAgent) prepareRuleAuth(directives []rulechain.AuthDirectiv
 	}
 
 	state.Rule.Auth.Input["type"] = "unmatched"
+	if failReason != "" {
+		return nil, "fail", fmt.Sprintf("rule authentication failed: %s", failReason)
+	}
 	return nil, "fail", "rule authentication did not match any credential"
 }
 
@@ -681,58 +894,94 @@ func extractCredentials(creds []pipeline.AdmissionCredential) extractedCredentia
 	return extracted
 }
 
-// checkAllMatchers returns true if ALL matchers in the group match (AND logic)
-func checkAllMatchers(matchers []rulechain.AuthMatcher, extracted extractedCredentials) bool {
+// checkAllMatchers returns true if ALL matchers in the group match (AND logic).
+// apiKey matchers additionally return the resolved key metadata, or a
+// descriptive reason when the key was presented but rejected.
+func checkAllMatchers(matchers []rulechain.AuthMatcher, extracted extractedCredentials) (bool, map[string]any, string) {
+	var key map[string]any
 	for _, matcher := range matchers {
 		switch matcher.Type {
 		case "bearer":
 			if extracted.bearer == nil {
-				return false
+				return false, nil, ""
 			}
 			if !matchesAnyValueMatcher(extracted.bearer.Token, matcher.ValueMatchers) {
-				return false
+				return false, nil, ""
 			}
 
 		case "basic":
 			if extracted.basic == nil {
-				return false
+				return false, nil, ""
 			}
 			if len(matcher.UsernameMatchers) > 0 {
 				if !matchesAnyValueMatcher(extracted.basic.Username, matcher.UsernameMatchers) {
-					return false
+					return false, nil, ""
 				}
 			}
 			if len(matcher.PasswordMatchers) > 0 {
 				if !matchesAnyValueMatcher(extracted.basic.Password, matcher.PasswordMatchers) {
-					return false
+					return false, nil, ""
+				}
+			}
+			if matcher.Credentials != nil {
+				if !matcher.Credentials.Verify(extracted.basic.Username, extracted.basic.Password) {
+					return false, nil, ""
 				}
 			}
 
 		case "header":
 			cred := extracted.headers[matcher.MatchName]
 			if cred == nil {
-				return false
+				return false, nil, ""
 			}
 			if !matchesAnyValueMatcher(cred.Value, matcher.ValueMatchers) {
-				return false
+				return false, nil, ""
 			}
 
 		case "query":
 			cred := extracted.query[matcher.Name]
 			if cred == nil {
-				return false
+				return false, nil, ""
 			}
 			if !matchesAnyValueMatcher(cred.Value, matcher.ValueMatchers) {
-				return false
+				return false, nil, ""
 			}
 
+		case "apikey":
+			presented := apiKeyCredential(matcher, extracted)
+			if presented == nil {
+				return false, nil, ""
+			}
+			value := presented.Value
+			if matcher.In == "bearer" {
+				value = presented.Token
+			}
+			meta, reason, ok := matcher.Keys.ResolveKey(value)
+			if !ok {
+				return false, nil, reason
+			}
+			key = meta
+
 		case "none":
 			// Always matches
 			continue
 		}
 	}
 
-	return true
+	return true, key, ""
+}
+
+// apiKeyCredential returns the admission credential an apiKey matcher reads
+// its key from, or nil when the caller did not present one.
+func apiKeyCredential(matcher rulechain.AuthMatcher, extracted extractedCredentials) *pipeline.AdmissionCredential {
+	switch matcher.In {
+	case "bearer":
+		return extracted.bearer
+	case "query":
+		return extracted.query[matcher.Name]
+	default:
+		return extracted.headers[matcher.MatchName]
+	}
 }
 
 // matchesAnyValueMatcher returns true if input matches any of the value matchers (OR logic)
@@ -752,9 +1001,14 @@ func matchesAnyValueMatcher(input string, matchers []rulechain.ValueMatcher) boo
 }
 
 // buildAuthTemplateContext populates state.Rule.Auth.Input with all matched credentials
-func (a *ruleExecutionAgent) buildAuthTemplateContext(extracted extractedCredentials, state *pipeline.State) {
+func (a *ruleExecutionAgent) buildAuthTemplateContext(extracted extractedCredentials, key map[string]any, state *pipeline.State) {
 	input := make(map[string]any)
 
+	// Add resolved API key metadata if an apiKey matcher succeeded
+	if key != nil {
+		input["key"] = key
+	}
+
 	// Add bearer if present
 	if extracted.bearer != nil {
 		input["bearer"] = map[string]any{
@@ -850,6 +1104,20 @@ func (a *ruleExecutionAgent) buildPassThroughForwards(matchers []rulechain.AuthM
 					Value: cred.Value,
 				})
 			}
+		case "apikey":
+			cred := apiKeyCredential(matcher, extracted)
+			if cred == nil {
+				continue
+			}
+			if matcher.In == "bearer" {
+				forwards = append(forwards, ruleAuthForward{Type: "bearer", Token: cred.Token})
+				continue
+			}
+			forwards = append(forwards, ruleAuthForward{
+				Type:  matcher.In,
+				Name:  cred.Name,
+				Value: cred.Value,
+			})
 		case "none":
 			// No forward for none type
 		}
@@ -963,6 +1231,15 @@ func (f ruleAuthForward) toMap() map[string]any {
 	return out
 }
 
+// applyBodySchema validates an accepted backend body against the rule's
+// schema and returns it converted to the declared CEL types.
+func applyBodySchema(schema *expr.BodySchema, backend pipeline.BackendState) (any, error) {
+	if backend.Body == nil && backend.BodyText != "" {
+		return nil, errors.New("body is not JSON")
+	}
+	return schema.Apply(backend.Body)
+}
+
 func evaluateProgramList(programs []expr.Program, activation map[string]any, requireAll bool) (bool, string, error) {
 	if requireAll {
 		if len(programs) == 0 {
@@ -1006,14 +1283,15 @@ func buildActivation(state *pipeline.State) map[string]any {
 			"query":   toAnyMap(state.Request.Query),
 		},
 		"admission": map[string]any{
-			"authenticated": state.Admission.Authenticated,
-			"reason":        state.Admission.Reason,
-			"clientIp":      state.Admission.ClientIP,
-			"trustedProxy":  state.Admission.TrustedProxy,
-			"proxyStripped": state.Admission.ProxyStripped,
-			"forwardedFor":  state.Admission.ForwardedFor,
-			"forwarded":     state.Admission.Forwarded,
-			"decision":      state.Admission.Decision,
+			"authenticated":   state.Admission.Authenticated,
+			"reason":          state.Admission.Reason,
+			"clientIp":        state.Admission.ClientIP,
+			"trustedProxy":    state.Admission.TrustedProxy,
+			"proxyStripped":   state.Admission.ProxyStripped,
+			"forwardedFor":    state.Admission.ForwardedFor,
+			"forwarded":       state.Admission.Forwarded,
+			"decision":        state.Admission.Decision,
+			"peerCertificate": state.Admission.PeerCertificate.Map(),
 		},
 		"forward": map[string]any{
 			"headers": toAnyMap(state.Forward.Headers),
@@ -1025,16 +1303,19 @@ func buildActivation(state *pipeline.State) map[string]any {
 			"forward":  cloneAnyMap(state.Rule.Auth.Forward),
 		},
 		"backend": map[string]any{
-			"requested": state.Backend.Requested,
-			"status":    state.Backend.Status,
-			"headers":   toAnyMap(state.Backend.Headers),
-			"body":      state.Backend.Body,
-			"bodyText":  state.Backend.BodyText,
-			"error":     state.Backend.Error,
-			"accepted":  state.Backend.Accepted,
-			"pages":     backendPagesActivation(state.Backend.Pages),
+			"requested":     state.Backend.Requested,
+			"status":        state.Backend.Status,
+			"headers":       toAnyMap(state.Backend.Headers),
+			"body":          state.Backend.Body,
+			"bodyText":      state.Backend.BodyText,
+			"error":         state.Backend.Error,
+			"accepted":      state.Backend.Accepted,
+			"pages":         backendPagesActivation(state.Backend.Pages),
+			"introspection": state.Backend.Introspection,
 		},
 		"variables": state.VariablesContext(),
+		"params":    state.Rule.ParamsContext(),
+		"data":      state.Data,
 		"now":       time.Now().UTC(),
 	}
 	return activation
@@ -1187,7 +1468,9 @@ func buildRuleContext(state *pipeline.State) map[string]any {
 		"auth":      auth,
 		"vars":      state.VariablesContext(), // Full variable hierarchy
 		"request":   request,
-		"variables": variables,  // Hybrid: flat local + nested global/rule
+		"variables": variables, // Hybrid: flat local + nested global/rule
+		"params":    state.Rule.ParamsContext(),
+		"data":      state.Data,
 		"rule":      state.Rule, // Rule state for templates to access .rule.Outcome, etc.
 	}
 }
@@ -1252,6 +1535,7 @@ func resetBackendState(state *pipeline.BackendState) {
 	state.Error = ""
 	state.Accepted = false
 	state.Pages = nil
+	state.Introspection = nil
 	if state.Headers == nil {
 		state.Headers = make(map[string]string)
 	} else {
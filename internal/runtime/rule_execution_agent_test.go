@@ -2,12 +2,17 @@ package runtime
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/l0p7/passctrl/internal/credentials"
 	runtimemocks "github.com/l0p7/passctrl/internal/mocks/runtime"
 	"github.com/l0p7/passctrl/internal/runtime/pipeline"
 	"github.com/l0p7/passctrl/internal/runtime/rulechain"
@@ -28,7 +33,7 @@ func TestRuleExecutionAgentBackendDefaultFailWhenNotAccepted(t *testing.T) {
 
 	def := compileBackendOnlyRule(t, targetURL, []int{http.StatusOK})
 
-	backendAgent := newBackendInteractionAgent(mockClient, nil)
+	backendAgent := newBackendInteractionAgent(mockClient, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, nil, nil, 0, nil, "")
 	state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
 
@@ -51,7 +56,7 @@ func TestRuleExecutionAgentBackendDefaultPassWhenAccepted(t *testing.T) {
 
 	def := compileBackendOnlyRule(t, targetURL, []int{http.StatusOK})
 
-	backendAgent := newBackendInteractionAgent(mockClient, nil)
+	backendAgent := newBackendInteractionAgent(mockClient, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, nil, nil, 0, nil, "")
 	state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
 
@@ -62,6 +67,59 @@ func TestRuleExecutionAgentBackendDefaultPassWhenAccepted(t *testing.T) {
 	require.Equal(t, "rule evaluated without explicit outcome", reason)
 }
 
+func TestRuleExecutionAgentBackendBodySchema(t *testing.T) {
+	const targetURL = "https://backend.test/account"
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "account",
+		Backend: rulechain.BackendDefinitionSpec{
+			URL:      targetURL,
+			Accepted: []int{http.StatusOK},
+			BodySchema: map[string]any{
+				"type":     "object",
+				"required": []any{"active", "score"},
+				"properties": map[string]any{
+					"active": map[string]any{"type": "boolean"},
+					"score":  map[string]any{"type": "number"},
+				},
+			},
+		},
+		Conditions: rulechain.ConditionSpec{Pass: []string{`backend.body.active && backend.body.score / 2.0 >= 1.5`}},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		outcome string
+		reason  string
+	}{
+		{name: "conforming body", status: http.StatusOK, body: `{"active":true,"score":3}`, outcome: "pass"},
+		{name: "violating body", status: http.StatusOK, body: `{"active":"yes","score":3}`, outcome: "error", reason: "backend response violates body schema: active: Invalid type. Expected: boolean, given: string"},
+		{name: "non-json body", status: http.StatusOK, body: `ok`, outcome: "error", reason: "body is not JSON"},
+		{name: "rejected status skips validation", status: http.StatusNotFound, body: `{"error":"missing"}`, outcome: "error", reason: "pass condition"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			contentType := "application/json"
+			if tc.body == "ok" {
+				contentType = "text/plain"
+			}
+			mockClient := runtimemocks.NewMockHTTPDoer(t)
+			mockClient.EXPECT().
+				Do(mock.AnythingOfType("*http.Request")).
+				Return(newBackendResponse(tc.status, tc.body, map[string]string{"Content-Type": contentType}), nil)
+
+			agent := newRuleExecutionAgent(newBackendInteractionAgent(mockClient, nil, nil), nil, nil, nil, 0, nil, "")
+			state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+
+			outcome, reason, _ := agent.evaluateRule(context.Background(), defs[0], state)
+			require.Equal(t, tc.outcome, outcome, reason)
+			require.Contains(t, reason, tc.reason)
+		})
+	}
+}
+
 func TestRuleExecutionAgentAuthForwardsBearer(t *testing.T) {
 	const targetURL = "https://backend.test/auth"
 	client := runtimemocks.NewMockHTTPDoer(t)
@@ -76,7 +134,7 @@ func TestRuleExecutionAgentAuthForwardsBearer(t *testing.T) {
 		Match: []rulechain.AuthMatcherSpec{{Type: "bearer"}},
 	}}, targetURL, []int{http.StatusOK})
 
-	backendAgent := newBackendInteractionAgent(client, nil)
+	backendAgent := newBackendInteractionAgent(client, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, nil, nil, 0, nil, "")
 	state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
 	state.Admission.Credentials = []pipeline.AdmissionCredential{{
@@ -117,7 +175,7 @@ func TestRuleExecutionAgentAuthForwardAsHeaderTemplate(t *testing.T) {
 		},
 	}, targetURL, []int{http.StatusOK})
 
-	backendAgent := newBackendInteractionAgent(client, nil)
+	backendAgent := newBackendInteractionAgent(client, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, templates.NewRenderer(nil), nil, 0, nil, "")
 	state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
 	state.Admission.Credentials = []pipeline.AdmissionCredential{{
@@ -143,7 +201,7 @@ func TestRuleExecutionAgentAuthFailsWhenNoMatch(t *testing.T) {
 		Match: []rulechain.AuthMatcherSpec{{Type: "bearer"}},
 	}}, targetURL, []int{http.StatusOK})
 
-	backendAgent := newBackendInteractionAgent(client, nil)
+	backendAgent := newBackendInteractionAgent(client, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, nil, nil, 0, nil, "")
 	state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
 	state.Admission.Credentials = []pipeline.AdmissionCredential{{
@@ -158,6 +216,135 @@ func TestRuleExecutionAgentAuthFailsWhenNoMatch(t *testing.T) {
 	require.Empty(t, state.Rule.Auth.Selected)
 }
 
+func TestRuleExecutionAgentAuthBasicCredentialStore(t *testing.T) {
+	store, err := credentials.NewPasswordStore("team", map[string]string{
+		"alice": "$5$saltstring$5B8vYYiY.CVt1RlTTf8KbXBH3hsxY/GNooZaBBGWEc5",
+	}, "")
+	require.NoError(t, err)
+
+	def := compileRuleWithAuth(t, []rulechain.AuthDirectiveSpec{{
+		Match: []rulechain.AuthMatcherSpec{{
+			Type:            "basic",
+			CredentialStore: "team",
+			Credentials:     store,
+		}},
+	}}, "", nil)
+
+	tests := []struct {
+		name     string
+		username string
+		password string
+		outcome  string
+	}{
+		{name: "valid credentials", username: "alice", password: "Hello world!", outcome: "pass"},
+		{name: "wrong password", username: "alice", password: "nope", outcome: "fail"},
+		{name: "unknown user", username: "mallory", password: "Hello world!", outcome: "fail"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			agent := newRuleExecutionAgent(nil, nil, nil, nil, 0, nil, "")
+			state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+			state.Admission.Credentials = []pipeline.AdmissionCredential{{
+				Type:     "basic",
+				Username: tc.username,
+				Password: tc.password,
+			}}
+
+			outcome, _, _ := agent.evaluateRule(context.Background(), def, state)
+			require.Equal(t, tc.outcome, outcome)
+		})
+	}
+
+	_, err = rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "unresolved",
+		Auth: []rulechain.AuthDirectiveSpec{{
+			Match: []rulechain.AuthMatcherSpec{{Type: "basic", CredentialStore: "missing"}},
+		}},
+	}}, templates.NewRenderer(nil))
+	require.ErrorContains(t, err, `store "missing" not available`)
+}
+
+func TestRuleExecutionAgentAuthAPIKeyStore(t *testing.T) {
+	hash := func(key string) string {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:])
+	}
+	file := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`keys:
+  - id: ci
+    hash: `+hash("ci-secret")+`
+    owner: build-team
+    scopes: [read, write]
+  - id: reader
+    hash: `+hash("read-secret")+`
+    owner: analytics
+    scopes: [read]
+  - id: retired
+    hash: `+hash("old-secret")+`
+    disabled: true
+  - id: lapsed
+    hash: `+hash("lapsed-secret")+`
+    expiresAt: 2020-01-01T00:00:00Z
+`), 0o600))
+	store, err := credentials.NewAPIKeyStore("partners", file)
+	require.NoError(t, err)
+
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "api-key-rule",
+		Auth: []rulechain.AuthDirectiveSpec{{
+			Match: []rulechain.AuthMatcherSpec{{
+				Type:     "apiKey",
+				Name:     "X-Api-Key",
+				KeyStore: "partners",
+				Keys:     store,
+			}},
+		}},
+		Conditions: rulechain.ConditionSpec{
+			Fail: []string{`!("write" in auth.input.key.scopes)`},
+		},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+	require.Len(t, defs, 1)
+
+	tests := []struct {
+		name    string
+		key     string
+		outcome string
+		reason  string
+	}{
+		{name: "scoped key", key: "ci-secret", outcome: "pass"},
+		{name: "missing scope", key: "read-secret", outcome: "fail"},
+		{name: "unknown key", key: "guess", outcome: "fail", reason: "api key not recognized"},
+		{name: "disabled key", key: "old-secret", outcome: "fail", reason: "api key retired disabled"},
+		{name: "expired key", key: "lapsed-secret", outcome: "fail", reason: "api key lapsed expired at 2020-01-01T00:00:00Z"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			agent := newRuleExecutionAgent(nil, nil, nil, nil, 0, nil, "")
+			state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+			state.Admission.Credentials = []pipeline.AdmissionCredential{{
+				Type:  "header",
+				Name:  "X-Api-Key",
+				Value: tc.key,
+			}}
+
+			outcome, reason, _ := agent.evaluateRule(context.Background(), defs[0], state)
+			require.Equal(t, tc.outcome, outcome)
+			if tc.reason != "" {
+				require.Contains(t, reason, tc.reason)
+			}
+			if tc.outcome == "pass" {
+				key, ok := state.Rule.Auth.Input["key"].(map[string]any)
+				require.True(t, ok)
+				require.Equal(t, "ci", key["id"])
+				require.Equal(t, "build-team", key["owner"])
+			}
+		})
+	}
+}
+
 func TestRuleExecutionAgentLocalVariables(t *testing.T) {
 	renderer := templates.NewRenderer(nil)
 	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -188,7 +375,7 @@ func TestRuleExecutionAgentLocalVariables(t *testing.T) {
 			return newBackendResponse(http.StatusOK, `{"userId":"123","displayName":"Alice"}`, map[string]string{"Content-Type": "application/json"}), nil
 		})
 
-	backendAgent := newBackendInteractionAgent(mockClient, nil)
+	backendAgent := newBackendInteractionAgent(mockClient, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "")
 	req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
 	state := pipeline.NewState(req, "endpoint", "cache-key", "")
@@ -229,7 +416,7 @@ func TestRuleExecutionAgentAppliesPassResponse(t *testing.T) {
 	require.Len(t, defs, 1)
 	def := defs[0]
 
-	backendAgent := newBackendInteractionAgent(nil, nil)
+	backendAgent := newBackendInteractionAgent(nil, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "")
 	req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
 	state := pipeline.NewState(req, "endpoint", "cache-key", "")
@@ -265,7 +452,7 @@ func TestRuleExecutionAgentAppliesFailResponse(t *testing.T) {
 	require.Len(t, defs, 1)
 	def := defs[0]
 
-	backendAgent := newBackendInteractionAgent(nil, nil)
+	backendAgent := newBackendInteractionAgent(nil, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "")
 	req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
 	state := pipeline.NewState(req, "endpoint", "cache-key", "")
@@ -302,7 +489,7 @@ func TestRuleExecutionAgentAggregatesPassVariables(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, defs, 2)
 
-	backendAgent := newBackendInteractionAgent(nil, nil)
+	backendAgent := newBackendInteractionAgent(nil, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "")
 	req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
 	state := pipeline.NewState(req, "endpoint", "cache-key", "")
@@ -337,7 +524,7 @@ func TestRuleExecutionAgentAppliesErrorResponse(t *testing.T) {
 	require.Len(t, defs, 1)
 	def := defs[0]
 
-	backendAgent := newBackendInteractionAgent(nil, nil)
+	backendAgent := newBackendInteractionAgent(nil, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "")
 	req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
 	state := pipeline.NewState(req, "endpoint", "cache-key", "")
@@ -408,7 +595,7 @@ func TestRuleExecutionAgentExportedVariables(t *testing.T) {
 			headers := map[string]string{"Content-Type": "application/json"}
 			return newBackendResponse(200, `{"userId":"123","email":"TEST@EXAMPLE.COM","tier":"premium"}`, headers), nil
 		})
-	backendAgent := newBackendInteractionAgent(mockClient, nil)
+	backendAgent := newBackendInteractionAgent(mockClient, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "")
 
 	def, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -477,7 +664,7 @@ func TestRuleExecutionAgentExportedVariablesOnFail(t *testing.T) {
 			headers := map[string]string{"Content-Type": "application/json"}
 			return newBackendResponse(403, `{"error":"forbidden"}`, headers), nil
 		})
-	backendAgent := newBackendInteractionAgent(mockClient, nil)
+	backendAgent := newBackendInteractionAgent(mockClient, nil, nil)
 	agent := newRuleExecutionAgent(backendAgent, nil, renderer, nil, 0, nil, "")
 
 	def, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{
@@ -0,0 +1,186 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+)
+
+// outcomeCancelled marks parallel entries abandoned because a sibling failed.
+const outcomeCancelled = "cancelled"
+
+// evaluateParallel runs the steps of a parallel group concurrently, each
+// against its own fork of the state, with at most step.Workers in flight. The
+// first fail or error cancels the context shared by the remaining siblings.
+// Once every started entry returns, the earliest declared fail or error
+// decides the group, entries whose error surfaced only after the cancellation
+// are recorded as cancelled, and only the entries up to the decisive one are
+// merged back into state, so the result does not depend on which rule
+// finished first.
+func (a *ruleExecutionAgent) evaluateParallel(ctx context.Context, step rulechain.Step, state *pipeline.State) stepResult {
+	workers := step.Workers
+	if workers <= 0 {
+		workers = defaultRuleWorkers
+	}
+	groupCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type branch struct {
+		result      stepResult
+		state       *pipeline.State
+		started     bool
+		interrupted bool
+	}
+	branches := make([]branch, len(step.Steps))
+	base := state.Fork()
+	var tripped sync.Once
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, workers)
+launch:
+	for idx := range step.Steps {
+		select {
+		case slots <- struct{}{}:
+		case <-groupCtx.Done():
+			break launch
+		}
+		if groupCtx.Err() != nil {
+			<-slots
+			break
+		}
+		branches[idx].started = true
+		branches[idx].state = state.Fork()
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			result := a.evaluateStep(groupCtx, step.Steps[idx], branches[idx].state)
+			// An error returned after a sibling cancelled the group is the
+			// cancellation surfacing, not a verdict of its own.
+			branches[idx].interrupted = groupCtx.Err() != nil && result.entry.Outcome == "error" && result.timeout == nil
+			branches[idx].result = result
+			if outcome := result.entry.Outcome; (outcome == "fail" || outcome == "error") && !branches[idx].interrupted {
+				tripped.Do(cancel)
+			}
+		}(idx)
+	}
+	wg.Wait()
+
+	group := stepResult{entry: pipeline.RuleHistoryEntry{
+		Name:     rulechain.GroupParallel,
+		Group:    rulechain.GroupParallel,
+		Children: make([]pipeline.RuleHistoryEntry, 0, len(branches)),
+	}}
+	decider := -1
+	for idx := range branches {
+		b := &branches[idx]
+		if outcome := b.result.entry.Outcome; b.started && !b.interrupted && (outcome == "fail" || outcome == "error") {
+			decider = idx
+			break
+		}
+	}
+	var cause string
+	if decider >= 0 {
+		cause = fmt.Sprintf("%s returned %s", branches[decider].result.entry.Name, branches[decider].result.entry.Outcome)
+	} else if err := context.Cause(groupCtx); err != nil {
+		cause = err.Error()
+	}
+	for idx := range branches {
+		b := &branches[idx]
+		switch {
+		case !b.started && expiredTimeout(groupCtx) != nil:
+			timeout := expiredTimeout(groupCtx)
+			b.result = stepResult{entry: pipeline.RuleHistoryEntry{
+				Name:    step.Steps[idx].Label(),
+				Group:   step.Steps[idx].Group,
+				Outcome: outcomeSkipped,
+				Reason:  timeout.Error(),
+			}, timeout: timeout}
+		case !b.started:
+			b.result = stepResult{entry: pipeline.RuleHistoryEntry{
+				Name:    step.Steps[idx].Label(),
+				Group:   step.Steps[idx].Group,
+				Outcome: outcomeCancelled,
+				Reason:  fmt.Sprintf("not started: cancelled after %s", cause),
+			}}
+		case b.interrupted && decider >= 0:
+			b.result.entry.Outcome = outcomeCancelled
+			b.result.entry.Reason = fmt.Sprintf("cancelled after %s", cause)
+		}
+		group.entry.Children = append(group.entry.Children, b.result.entry)
+		group.evaluated = group.evaluated || b.result.evaluated
+		group.executed += b.result.executed
+		if group.timeout == nil {
+			group.timeout = b.result.timeout
+		}
+	}
+
+	var decisive *pipeline.State
+	var decided bool
+	for idx := range branches {
+		b := branches[idx]
+		outcome := b.result.entry.Outcome
+		if outcome == outcomeSkipped || outcome == outcomeCancelled {
+			continue
+		}
+		decided = true
+		mergeForkedState(base, state, b.state)
+		decisive = b.state
+		group.entry.Outcome = outcome
+		group.entry.Reason = b.result.entry.Reason
+		if outcome != "pass" || b.result.stop {
+			group.stop = b.result.stop
+			break
+		}
+	}
+	if decisive != nil {
+		state.Rule.Outcome = decisive.Rule.Outcome
+		state.Rule.Reason = decisive.Rule.Reason
+		state.Rule.Auth = decisive.Rule.Auth
+		state.Rule.Variables = decisive.Rule.Variables
+		state.Rule.Params = decisive.Rule.Params
+		state.Backend = decisive.Backend
+		state.Cache = decisive.Cache
+	}
+	if !decided {
+		group.entry.Outcome = outcomeSkipped
+		group.entry.Reason = "all entries skipped"
+	}
+	return group
+}
+
+// mergeForkedState copies the exports a forked branch produced into state.
+// Entries the branch left untouched keep the values base held when the group
+// started, so later merges never resurrect stale values.
+func mergeForkedState(base, state, fork *pipeline.State) {
+	for name, vars := range fork.Variables.Rules {
+		if prev, ok := base.Variables.Rules[name]; ok && reflect.DeepEqual(prev, vars) {
+			continue
+		}
+		if state.Variables.Rules == nil {
+			state.Variables.Rules = make(map[string]map[string]any)
+		}
+		state.Variables.Rules[name] = vars
+	}
+	for key, value := range fork.Response.Variables {
+		if prev, ok := base.Response.Variables[key]; ok && reflect.DeepEqual(prev, value) {
+			continue
+		}
+		if state.Response.Variables == nil {
+			state.Response.Variables = make(map[string]any)
+		}
+		state.Response.Variables[key] = value
+	}
+	for key, value := range fork.Response.Headers {
+		if prev, ok := base.Response.Headers[key]; ok && prev == value {
+			continue
+		}
+		if state.Response.Headers == nil {
+			state.Response.Headers = make(map[string]string)
+		}
+		state.Response.Headers[key] = value
+	}
+}
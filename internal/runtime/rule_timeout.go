@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/metrics"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+)
+
+// timeoutError is the cancellation cause attached to endpoint and rule
+// deadlines, so evaluation can tell its own timeouts apart from caller
+// cancellations and report the configured outcome.
+type timeoutError struct {
+	scope   metrics.TimeoutScope
+	limit   time.Duration
+	outcome string
+}
+
+func (e *timeoutError) Error() string {
+	if e.scope == metrics.TimeoutScopeRule {
+		return fmt.Sprintf("rule timed out after %s", e.limit)
+	}
+	return fmt.Sprintf("endpoint deadline of %s exceeded", e.limit)
+}
+
+// buildTimeout converts a timeout/onTimeout pair validated by the loader.
+func buildTimeout(timeout, onTimeout string) rulechain.Timeout {
+	var out rulechain.Timeout
+	if d, err := time.ParseDuration(strings.TrimSpace(timeout)); err == nil && d > 0 {
+		out.Duration = d
+	}
+	out.Outcome = strings.ToLower(strings.TrimSpace(onTimeout))
+	if out.Outcome != config.TimeoutOutcomeFail {
+		out.Outcome = config.TimeoutOutcomeError
+	}
+	return out
+}
+
+// withTimeout derives a context that expires after timeout, recording a
+// timeoutError as the cause. Disabled timeouts return ctx unchanged.
+func withTimeout(ctx context.Context, timeout rulechain.Timeout, scope metrics.TimeoutScope) (context.Context, context.CancelFunc) {
+	if !timeout.Enabled() {
+		return ctx, func() {}
+	}
+	outcome := timeout.Outcome
+	if outcome == "" {
+		outcome = config.TimeoutOutcomeError
+	}
+	return context.WithTimeoutCause(ctx, timeout.Duration, &timeoutError{scope: scope, limit: timeout.Duration, outcome: outcome})
+}
+
+// expiredTimeout returns the deadline that cancelled ctx, or nil when ctx is
+// live or was cancelled for another reason.
+func expiredTimeout(ctx context.Context) *timeoutError {
+	if ctx.Err() == nil {
+		return nil
+	}
+	var timeout *timeoutError
+	if errors.As(context.Cause(ctx), &timeout) {
+		return timeout
+	}
+	return nil
+}
+
+func (a *ruleExecutionAgent) observeTimeout(endpoint, rule string, scope metrics.TimeoutScope) {
+	if a.metrics != nil {
+		a.metrics.ObserveTimeout(endpoint, rule, scope)
+	}
+	if a.logger != nil {
+		a.logger.Warn("rule evaluation timed out",
+			slog.String("endpoint", endpoint),
+			slog.String("rule", rule),
+			slog.String("scope", string(scope)))
+	}
+}
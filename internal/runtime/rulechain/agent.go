@@ -2,6 +2,9 @@ package rulechain
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
@@ -41,6 +44,7 @@ type DefinitionSpec struct {
 	FailMessage  string
 	ErrorMessage string
 	Cache        CacheConfigSpec
+	Timeout      Timeout
 }
 
 // ConditionSpec groups the CEL expressions that govern rule outcomes.
@@ -53,6 +57,7 @@ type ConditionSpec struct {
 // BackendDefinitionSpec captures the declarative backend configuration that a
 // rule may invoke when evaluating conditions.
 type BackendDefinitionSpec struct {
+	Type                string
 	URL                 string
 	Method              string
 	ForwardProxyHeaders bool
@@ -62,6 +67,14 @@ type BackendDefinitionSpec struct {
 	BodyFile            string
 	Accepted            []int
 	Pagination          BackendPaginationSpec
+	Introspection       IntrospectionSpec
+	LDAP                LDAPSpec
+	SQL                 SQLSpec
+	GRPC                GRPCSpec
+	// BodySchema or BodySchemaFile declares a JSON Schema for the response
+	// body; conditions are type-checked against it.
+	BodySchema     map[string]any
+	BodySchemaFile string
 }
 
 // BackendPaginationSpec describes how the backend should paginate responses.
@@ -141,18 +154,64 @@ type Definition struct {
 	FailTemplate  *templates.Template
 	ErrorTemplate *templates.Template
 	Cache         CacheConfigSpec
+	Timeout       Timeout
+	// Params holds the resolved parameter values of this instance.
+	Params map[string]any
+	// Instance distinguishes parameterized instances of the same rule; it is
+	// empty for rules without parameters.
+	Instance string
+}
+
+// Instantiate returns a copy of the definition bound to params. Instances with
+// equal values share an Instance identifier, and therefore cache entries.
+func (d Definition) Instantiate(params map[string]any) (Definition, error) {
+	if len(params) == 0 {
+		return d, nil
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return Definition{}, fmt.Errorf("encode params: %w", err)
+	}
+	sum := sha256.Sum256(encoded)
+	d.Params = params
+	d.Instance = hex.EncodeToString(sum[:8])
+	return d, nil
+}
+
+// CacheName identifies the definition in per-rule cache keys.
+func (d Definition) CacheName() string {
+	if d.Instance == "" {
+		return d.Name
+	}
+	return d.Name + "#" + d.Instance
 }
 
 // ExecutionPlan records the rule definitions that should be evaluated for the
-// current request.
+// current request. Steps carries the chain's control flow; when it is empty
+// the Rules run in order and every one must pass. Timeout is the endpoint's
+// budget for the whole chain.
 type ExecutionPlan struct {
-	Rules []Definition
+	Rules   []Definition
+	Steps   []Step
+	Timeout Timeout
 }
 
 // Agent prepares the execution plan for the rule chain once cache and admission
 // checks have passed.
 type Agent struct {
-	rules []Definition
+	rules   []Definition
+	steps   []Step
+	timeout Timeout
+}
+
+// NewChainAgent constructs an Agent whose plan follows the supplied step tree,
+// including any-of groups, when guards, and early passes, and bounds the chain
+// by the endpoint timeout.
+func NewChainAgent(steps []Step, timeout Timeout) *Agent {
+	agent := NewAgent(flattenSteps(steps))
+	agent.steps = steps
+	agent.timeout = timeout
+	return agent
 }
 
 // NewAgent constructs an Agent instance with the supplied rule definitions.
@@ -196,7 +255,7 @@ func (a *Agent) Execute(_ context.Context, _ *http.Request, state *pipeline.Stat
 	compiled := make([]Definition, len(a.rules))
 	copy(compiled, a.rules)
 
-	state.SetPlan(ExecutionPlan{Rules: compiled})
+	state.SetPlan(ExecutionPlan{Rules: compiled, Steps: a.steps, Timeout: a.timeout})
 	state.Rule.ShouldExecute = true
 	state.Rule.Outcome = ""
 	state.Rule.Reason = ""
@@ -254,7 +313,15 @@ func CompileDefinitions(specs []DefinitionSpec, renderer *templates.Renderer) ([
 func compileDefinition(env *expr.Environment, spec DefinitionSpec, renderer *templates.Renderer) (Definition, error) {
 	ruleName := strings.TrimSpace(spec.Name)
 
-	programs, err := compileConditionPrograms(env, spec.Conditions)
+	bodySchema, err := compileBodySchema(ruleName, spec.Backend, renderer)
+	if err != nil {
+		return Definition{}, fmt.Errorf("backend body schema: %w", err)
+	}
+	ruleEnv, err := env.WithBackendSchema(bodySchema)
+	if err != nil {
+		return Definition{}, fmt.Errorf("backend body schema: %w", err)
+	}
+	programs, err := compileConditionPrograms(ruleEnv, spec.Conditions)
 	if err != nil {
 		return Definition{}, err
 	}
@@ -264,6 +331,37 @@ func compileDefinition(env *expr.Environment, spec DefinitionSpec, renderer *tem
 		return Definition{}, err
 	}
 	backend := buildBackendDefinition(spec.Backend, renderer)
+	if backend.IsConfigured() {
+		backend.BodySchema = bodySchema
+	}
+	backendType, err := normalizeBackendType(spec.Backend.Type)
+	if err != nil {
+		return Definition{}, fmt.Errorf("backend: %w", err)
+	}
+	if backendType == BackendTypeIntrospection && backend.IsConfigured() {
+		backend.Introspection, err = compileIntrospection(ruleName, spec.Backend, renderer)
+		if err != nil {
+			return Definition{}, fmt.Errorf("backend introspection: %w", err)
+		}
+	}
+	if backendType == BackendTypeLDAP && backend.IsConfigured() {
+		backend.LDAP, err = compileLDAP(ruleName, spec.Backend, renderer)
+		if err != nil {
+			return Definition{}, fmt.Errorf("backend ldap: %w", err)
+		}
+	}
+	if backendType == BackendTypeSQL && backend.IsConfigured() {
+		backend.SQL, err = compileSQL(ruleName, spec.Backend, renderer)
+		if err != nil {
+			return Definition{}, fmt.Errorf("backend sql: %w", err)
+		}
+	}
+	if backendType == BackendTypeGRPC && backend.IsConfigured() {
+		backend.GRPC, err = compileGRPC(spec.Backend, renderer)
+		if err != nil {
+			return Definition{}, fmt.Errorf("backend grpc: %w", err)
+		}
+	}
 	responses, err := compileResponseDefinitions(spec.Responses)
 	if err != nil {
 		return Definition{}, fmt.Errorf("responses: %w", err)
@@ -286,6 +384,7 @@ func compileDefinition(env *expr.Environment, spec DefinitionSpec, renderer *tem
 		FailMessage:  strings.TrimSpace(spec.FailMessage),
 		ErrorMessage: strings.TrimSpace(spec.ErrorMessage),
 		Cache:        spec.Cache,
+		Timeout:      spec.Timeout,
 	}
 	if renderer != nil {
 		if tmpl, err := renderer.CompileInline(fmt.Sprintf("%s:pass", compileName), spec.PassMessage); err != nil {
@@ -141,3 +141,24 @@ func TestDefaultDefinitions(t *testing.T) {
 		require.False(t, def.Backend.IsConfigured())
 	}
 }
+
+func TestDefinitionInstantiate(t *testing.T) {
+	base := Definition{Name: "member-of"}
+
+	plain, err := base.Instantiate(nil)
+	require.NoError(t, err)
+	require.Equal(t, "member-of", plain.CacheName())
+
+	admins, err := base.Instantiate(map[string]any{"group": "admins"})
+	require.NoError(t, err)
+	editors, err := base.Instantiate(map[string]any{"group": "editors"})
+	require.NoError(t, err)
+	again, err := base.Instantiate(map[string]any{"group": "admins"})
+	require.NoError(t, err)
+
+	require.Equal(t, "member-of", admins.Name)
+	require.Equal(t, map[string]any{"group": "admins"}, admins.Params)
+	require.NotEqual(t, admins.CacheName(), editors.CacheName())
+	require.Equal(t, admins.CacheName(), again.CacheName())
+	require.Empty(t, base.Params, "instantiation leaves the compiled definition untouched")
+}
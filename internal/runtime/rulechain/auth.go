@@ -21,6 +21,17 @@ type AuthMatcherSpec struct {
 	Value    []string // Parsed value constraints (literal or regex patterns)
 	Username []string // For basic auth
 	Password []string // For basic auth
+
+	// CredentialStore names the server credential store used to verify basic
+	// credentials; Credentials carries the resolved store.
+	CredentialStore string
+	Credentials     PasswordVerifier
+
+	// In selects where apiKey matchers read the presented key (header, query,
+	// or bearer); KeyStore/Keys name and carry the resolved key registry.
+	In       string
+	KeyStore string
+	Keys     APIKeyResolver
 }
 
 // AuthForwardSpec describes how a matched credential should be forwarded.
@@ -47,6 +58,9 @@ type AuthMatcher struct {
 	ValueMatchers    []ValueMatcher
 	UsernameMatchers []ValueMatcher
 	PasswordMatchers []ValueMatcher
+	Credentials      PasswordVerifier
+	In               string // apiKey location: header, query, or bearer
+	Keys             APIKeyResolver
 }
 
 // ValueMatcher is the exported interface for value matching (used by runtime)
@@ -54,6 +68,18 @@ type ValueMatcher interface {
 	Matches(input string) bool
 }
 
+// APIKeyResolver looks up a presented API key. On success it returns the key
+// metadata; otherwise it returns a descriptive fail reason.
+type APIKeyResolver interface {
+	ResolveKey(presented string) (map[string]any, string, bool)
+}
+
+// PasswordVerifier checks a basic-auth username/password pair against a
+// credential store.
+type PasswordVerifier interface {
+	Verify(username, password string) bool
+}
+
 // valueMatcher represents either a literal string or a compiled regex pattern.
 type valueMatcher struct {
 	literal string         // Empty if this is a regex matcher
@@ -136,7 +162,7 @@ func compileAuthMatcher(spec AuthMatcherSpec) (AuthMatcher, error) {
 	}
 
 	switch typ {
-	case "basic", "bearer", "header", "query", "none":
+	case "basic", "bearer", "header", "query", "apikey", "none":
 		// Valid types
 	default:
 		return AuthMatcher{}, fmt.Errorf("unsupported type %q", spec.Type)
@@ -179,6 +205,35 @@ func compileAuthMatcher(spec AuthMatcherSpec) (AuthMatcher, error) {
 				return AuthMatcher{}, fmt.Errorf("password: %w", err)
 			}
 		}
+		if store := strings.TrimSpace(spec.CredentialStore); store != "" {
+			if spec.Credentials == nil {
+				return AuthMatcher{}, fmt.Errorf("credentialStore: store %q not available", store)
+			}
+			matcher.Credentials = spec.Credentials
+		}
+
+	case "apikey":
+		matcher.In = strings.ToLower(strings.TrimSpace(spec.In))
+		if matcher.In == "" {
+			matcher.In = "header"
+		}
+		switch matcher.In {
+		case "header", "query":
+			if name == "" {
+				return AuthMatcher{}, fmt.Errorf("name required for type apikey in %s", matcher.In)
+			}
+		case "bearer":
+		default:
+			return AuthMatcher{}, fmt.Errorf("in: unsupported location %q", spec.In)
+		}
+		store := strings.TrimSpace(spec.KeyStore)
+		if store == "" {
+			return AuthMatcher{}, fmt.Errorf("keyStore required for type apikey")
+		}
+		if spec.Keys == nil {
+			return AuthMatcher{}, fmt.Errorf("keyStore: store %q not available", store)
+		}
+		matcher.Keys = spec.Keys
 
 	case "none":
 		// No value matchers for none type
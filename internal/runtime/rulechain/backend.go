@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/l0p7/passctrl/internal/expr"
 	"github.com/l0p7/passctrl/internal/runtime/pipeline"
 	"github.com/l0p7/passctrl/internal/templates"
 )
@@ -27,6 +28,22 @@ type BackendDefinition struct {
 	Accepted     []int
 	accepted     map[int]struct{}
 	pagination   BackendPagination
+	// Introspection is set for backends of type introspection; the request
+	// body and client authentication are generated from it at render time.
+	Introspection *IntrospectionDefinition
+	// LDAP is set for backends of type ldap; URL names the directory server
+	// and the remaining HTTP fields are unused.
+	LDAP *LDAPDefinition
+	// SQL is set for backends of type sql; URL is the synthetic
+	// sql://<database> address and the HTTP fields are unused.
+	SQL *SQLDefinition
+	// GRPC is set for backends of type grpc; Method holds the
+	// /package.Service/Method path, headers become request metadata, and the
+	// rendered body is the JSON form of the request message.
+	GRPC *GRPCDefinition
+	// BodySchema validates accepted response bodies and converts their
+	// numbers to the types the conditions were checked against.
+	BodySchema *expr.BodySchema
 }
 
 // BackendPagination details how pagination should be performed when querying a
@@ -145,13 +162,23 @@ func (b BackendDefinition) SelectQuery(rawQuery map[string]string, state *pipeli
 
 func buildBackendDefinition(spec BackendDefinitionSpec, renderer *templates.Renderer) BackendDefinition {
 	url := strings.TrimSpace(spec.URL)
+	if strings.EqualFold(strings.TrimSpace(spec.Type), BackendTypeSQL) {
+		// SQL backends name a server database instead of a URL.
+		url = sqlBackendURL(spec.SQL.Database)
+	}
 	if url == "" {
 		return BackendDefinition{}
 	}
 
 	method := strings.ToUpper(strings.TrimSpace(spec.Method))
+	if strings.EqualFold(strings.TrimSpace(spec.Type), BackendTypeGRPC) {
+		method = grpcFullMethod(spec.GRPC.Method)
+	}
 	if method == "" {
 		method = http.MethodGet
+		if strings.EqualFold(strings.TrimSpace(spec.Type), BackendTypeIntrospection) {
+			method = http.MethodPost
+		}
 	}
 
 	accepted := spec.Accepted
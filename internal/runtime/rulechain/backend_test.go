@@ -5,6 +5,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/l0p7/passctrl/internal/runtime/pipeline"
 	"github.com/l0p7/passctrl/internal/templates"
@@ -201,3 +202,46 @@ func TestBackendDefinitionTemplatesHandleEmptyResults(t *testing.T) {
 	// Empty template results should be stripped
 	require.NotContains(t, query, "missing")
 }
+
+func TestCompileIntrospectionBackend(t *testing.T) {
+	renderer := templates.NewRenderer(nil)
+	compile := func(backend BackendDefinitionSpec) (Definition, error) {
+		defs, err := CompileDefinitions([]DefinitionSpec{{Name: "introspect", Backend: backend}}, renderer)
+		if err != nil {
+			return Definition{}, err
+		}
+		return defs[0], nil
+	}
+
+	def, err := compile(BackendDefinitionSpec{
+		Type:          "Introspection",
+		URL:           "https://idp.test/introspect",
+		Introspection: IntrospectionSpec{ClientAuth: IntrospectionClientAuthSpec{ClientID: "passctrl"}, Leeway: "15s"},
+	})
+	require.NoError(t, err)
+	require.True(t, def.Backend.IsIntrospection())
+	require.Equal(t, http.MethodPost, def.Backend.Method)
+	require.Equal(t, ClientAuthSecretBasic, def.Backend.Introspection.ClientAuth)
+	require.Equal(t, 15*time.Second, def.Backend.Introspection.Leeway)
+	require.NotNil(t, def.Backend.Introspection.Token)
+
+	plain, err := compile(BackendDefinitionSpec{URL: "https://api.test"})
+	require.NoError(t, err)
+	require.False(t, plain.Backend.IsIntrospection())
+
+	_, err = compile(BackendDefinitionSpec{Type: "soap", URL: "https://api.test"})
+	require.ErrorContains(t, err, `unsupported type "soap"`)
+
+	_, err = compile(BackendDefinitionSpec{Type: BackendTypeIntrospection, URL: "https://idp.test", Method: http.MethodGet})
+	require.ErrorContains(t, err, "always uses POST")
+
+	_, err = compile(BackendDefinitionSpec{Type: BackendTypeIntrospection, URL: "https://idp.test"})
+	require.ErrorContains(t, err, "clientAuth.clientId: required")
+
+	_, err = compile(BackendDefinitionSpec{
+		Type:          BackendTypeIntrospection,
+		URL:           "https://idp.test",
+		Introspection: IntrospectionSpec{ClientAuth: IntrospectionClientAuthSpec{Method: ClientAuthNone}},
+	})
+	require.NoError(t, err)
+}
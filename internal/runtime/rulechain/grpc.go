@@ -0,0 +1,139 @@
+package rulechain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/l0p7/passctrl/internal/templates"
+)
+
+// GRPCSpec captures the declarative settings for a backend of type grpc.
+type GRPCSpec struct {
+	Method            string
+	DescriptorSetFile string
+	TLS               GRPCTLSSpec
+}
+
+// GRPCTLSSpec tunes TLS for grpcs:// targets.
+type GRPCTLSSpec struct {
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+// GRPCDefinition is the compiled form of GRPCSpec. Target is the host:port
+// taken from the backend URL and FullMethod the /package.Service/Method path.
+type GRPCDefinition struct {
+	Target     string
+	TLS        bool
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+	Service    protoreflect.FullName
+	Method     protoreflect.Name
+	FullMethod string
+	// Descriptor is read from the descriptor set when the rule loads; nil
+	// means server reflection resolves it on first use.
+	Descriptor protoreflect.MethodDescriptor
+}
+
+// IsGRPC reports whether the backend calls a gRPC method rather than issuing
+// an HTTP request.
+func (b BackendDefinition) IsGRPC() bool { return b.GRPC != nil }
+
+// grpcFullMethod normalizes package.Service/Method to /package.Service/Method.
+func grpcFullMethod(method string) string {
+	return "/" + strings.TrimPrefix(strings.TrimSpace(method), "/")
+}
+
+func compileGRPC(spec BackendDefinitionSpec, renderer *templates.Renderer) (*GRPCDefinition, error) {
+	if strings.TrimSpace(spec.Pagination.Type) != "" {
+		return nil, errors.New("pagination not supported for grpc")
+	}
+	parsed, err := url.Parse(strings.TrimSpace(spec.URL))
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "grpc" && parsed.Scheme != "grpcs") {
+		return nil, fmt.Errorf("expected grpc:// or grpcs:// URL, got %q", spec.URL)
+	}
+	cfg := spec.GRPC
+	service, method, ok := strings.Cut(strings.TrimPrefix(strings.TrimSpace(cfg.Method), "/"), "/")
+	if !ok || service == "" || method == "" {
+		return nil, fmt.Errorf("method: expected package.Service/Method, got %q", cfg.Method)
+	}
+	def := &GRPCDefinition{
+		Target:     parsed.Host,
+		TLS:        parsed.Scheme == "grpcs",
+		CAFile:     strings.TrimSpace(cfg.TLS.CAFile),
+		CertFile:   strings.TrimSpace(cfg.TLS.CertFile),
+		KeyFile:    strings.TrimSpace(cfg.TLS.KeyFile),
+		ServerName: strings.TrimSpace(cfg.TLS.ServerName),
+		Service:    protoreflect.FullName(service),
+		Method:     protoreflect.Name(method),
+		FullMethod: grpcFullMethod(cfg.Method),
+	}
+	if !def.Service.IsValid() || !def.Method.IsValid() {
+		return nil, fmt.Errorf("method: invalid name %q", cfg.Method)
+	}
+
+	path := strings.TrimSpace(cfg.DescriptorSetFile)
+	if path == "" {
+		return def, nil
+	}
+	var sandbox *templates.Sandbox
+	if renderer != nil {
+		sandbox = renderer.Sandbox()
+	}
+	if sandbox == nil {
+		return nil, errors.New("descriptorSetFile requires a template sandbox")
+	}
+	resolved, err := sandbox.Resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(resolved) // #nosec G304 -- path resolved inside the template sandbox
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(raw, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", path, err)
+	}
+	if def.Descriptor, err = FindGRPCMethod(files, def.Service, def.Method); err != nil {
+		return nil, fmt.Errorf("descriptorSetFile %s: %w", path, err)
+	}
+	return def, nil
+}
+
+// FindGRPCMethod looks up a unary method in a descriptor registry.
+func FindGRPCMethod(files *protoregistry.Files, service protoreflect.FullName, method protoreflect.Name) (protoreflect.MethodDescriptor, error) {
+	desc, err := files.FindDescriptorByName(service)
+	if err != nil {
+		return nil, fmt.Errorf("service %s not found", service)
+	}
+	svc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a service", service)
+	}
+	md := svc.Methods().ByName(method)
+	if md == nil {
+		return nil, fmt.Errorf("method %s not found on %s", method, service)
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming; only unary methods are supported", md.FullName())
+	}
+	return md, nil
+}
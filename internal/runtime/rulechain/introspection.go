@@ -0,0 +1,142 @@
+package rulechain
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/templates"
+)
+
+// Backend types understood by the rule execution agent.
+const (
+	BackendTypeHTTP          = "http"
+	BackendTypeIntrospection = "introspection"
+	BackendTypeLDAP          = "ldap"
+	BackendTypeSQL           = "sql"
+	BackendTypeGRPC          = "grpc"
+)
+
+// Client authentication methods for introspection requests (RFC 7662 §2.1).
+const (
+	ClientAuthSecretBasic = "client_secret_basic"
+	ClientAuthSecretPost  = "client_secret_post"
+	ClientAuthNone        = "none"
+)
+
+const defaultIntrospectionToken = "{{ .auth.input.bearer.token }}"
+
+// IntrospectionSpec captures the declarative OAuth2 token introspection
+// settings for a backend of type introspection.
+type IntrospectionSpec struct {
+	Token         string
+	TokenTypeHint string
+	ClientAuth    IntrospectionClientAuthSpec
+	Audience      []string
+	Issuer        string
+	Leeway        string
+}
+
+// IntrospectionClientAuthSpec describes how the introspection client
+// authenticates to the authorization server.
+type IntrospectionClientAuthSpec struct {
+	Method       string
+	ClientID     string
+	ClientSecret string
+}
+
+// IntrospectionDefinition is the compiled form of IntrospectionSpec. Token and
+// client credentials are templates so they can draw on auth input and secrets.
+type IntrospectionDefinition struct {
+	Token         *templates.Template
+	TokenTypeHint string
+	ClientAuth    string
+	ClientID      *templates.Template
+	ClientSecret  *templates.Template
+	Audience      []string
+	Issuer        string
+	Leeway        time.Duration
+}
+
+// IsIntrospection reports whether the backend performs RFC 7662 token
+// introspection rather than a free-form HTTP call.
+func (b BackendDefinition) IsIntrospection() bool { return b.Introspection != nil }
+
+// normalizeBackendType lowercases the configured type, treating empty as http.
+func normalizeBackendType(value string) (string, error) {
+	typ := strings.ToLower(strings.TrimSpace(value))
+	switch typ {
+	case "", BackendTypeHTTP:
+		return BackendTypeHTTP, nil
+	case BackendTypeIntrospection, BackendTypeLDAP, BackendTypeSQL, BackendTypeGRPC:
+		return typ, nil
+	default:
+		return "", fmt.Errorf("unsupported type %q", value)
+	}
+}
+
+func compileIntrospection(name string, spec BackendDefinitionSpec, renderer *templates.Renderer) (*IntrospectionDefinition, error) {
+	if renderer == nil {
+		return nil, errors.New("introspection requires a template renderer")
+	}
+	if method := strings.ToUpper(strings.TrimSpace(spec.Method)); method != "" && method != http.MethodPost {
+		return nil, fmt.Errorf("method %s not supported (introspection always uses POST)", method)
+	}
+	if strings.TrimSpace(spec.Body) != "" || strings.TrimSpace(spec.BodyFile) != "" {
+		return nil, errors.New("body and bodyFile are generated for introspection")
+	}
+	if strings.TrimSpace(spec.Pagination.Type) != "" {
+		return nil, errors.New("pagination not supported for introspection")
+	}
+
+	cfg := spec.Introspection
+	token := strings.TrimSpace(cfg.Token)
+	if token == "" {
+		token = defaultIntrospectionToken
+	}
+	tokenTmpl, err := renderer.CompileInline(name+":introspection:token", token)
+	if err != nil {
+		return nil, fmt.Errorf("token template: %w", err)
+	}
+
+	def := &IntrospectionDefinition{
+		Token:         tokenTmpl,
+		TokenTypeHint: strings.TrimSpace(cfg.TokenTypeHint),
+		Issuer:        strings.TrimSpace(cfg.Issuer),
+	}
+	for _, aud := range cfg.Audience {
+		if trimmed := strings.TrimSpace(aud); trimmed != "" {
+			def.Audience = append(def.Audience, trimmed)
+		}
+	}
+	if leeway := strings.TrimSpace(cfg.Leeway); leeway != "" {
+		def.Leeway, err = time.ParseDuration(leeway)
+		if err != nil || def.Leeway < 0 {
+			return nil, fmt.Errorf("leeway: invalid duration %q", cfg.Leeway)
+		}
+	}
+
+	def.ClientAuth = strings.ToLower(strings.TrimSpace(cfg.ClientAuth.Method))
+	switch def.ClientAuth {
+	case "":
+		def.ClientAuth = ClientAuthSecretBasic
+	case ClientAuthSecretBasic, ClientAuthSecretPost, ClientAuthNone:
+	default:
+		return nil, fmt.Errorf("clientAuth.method: unsupported method %q", cfg.ClientAuth.Method)
+	}
+	if def.ClientAuth == ClientAuthNone {
+		return def, nil
+	}
+	if strings.TrimSpace(cfg.ClientAuth.ClientID) == "" {
+		return nil, fmt.Errorf("clientAuth.clientId: required for %s", def.ClientAuth)
+	}
+	if def.ClientID, err = renderer.CompileInline(name+":introspection:clientId", cfg.ClientAuth.ClientID); err != nil {
+		return nil, fmt.Errorf("clientAuth.clientId template: %w", err)
+	}
+	if def.ClientSecret, err = renderer.CompileInline(name+":introspection:clientSecret", cfg.ClientAuth.ClientSecret); err != nil {
+		return nil, fmt.Errorf("clientAuth.clientSecret template: %w", err)
+	}
+	return def, nil
+}
@@ -0,0 +1,155 @@
+package rulechain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/templates"
+)
+
+const (
+	defaultLDAPUsername    = "{{ .auth.input.basic.user }}"
+	defaultLDAPPassword    = "{{ .auth.input.basic.password }}"
+	defaultLDAPUserFilter  = "(uid={username})"
+	defaultLDAPGroupFilter = "(member={dn})"
+	defaultLDAPGroupAttr   = "cn"
+	defaultLDAPPoolSize    = 4
+)
+
+// LDAPSpec captures the declarative settings for a backend of type ldap.
+type LDAPSpec struct {
+	StartTLS     bool
+	CAFile       string
+	PoolSize     int
+	Username     string
+	Password     string
+	UserDN       string
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	UserFilter   string
+	Attributes   []string
+	Groups       LDAPGroupsSpec
+}
+
+// LDAPGroupsSpec describes the group membership lookup performed after a
+// successful bind.
+type LDAPGroupsSpec struct {
+	BaseDN    string
+	Filter    string
+	Attribute string
+	Nested    bool
+}
+
+// LDAPDefinition is the compiled form of LDAPSpec. UserDN is set for direct
+// binds; otherwise the user is located under BaseDN with UserFilter, searching
+// as BindDN when one is configured.
+type LDAPDefinition struct {
+	StartTLS     bool
+	CAFile       string
+	PoolSize     int
+	Username     *templates.Template
+	Password     *templates.Template
+	UserDN       string
+	BindDN       string
+	BindPassword *templates.Template
+	BaseDN       string
+	UserFilter   string
+	Attributes   []string
+	// Groups is nil when group lookups are disabled.
+	Groups *LDAPGroupsDefinition
+}
+
+// LDAPGroupsDefinition is the compiled form of LDAPGroupsSpec.
+type LDAPGroupsDefinition struct {
+	BaseDN    string
+	Filter    string
+	Attribute string
+	Nested    bool
+}
+
+// IsLDAP reports whether the backend binds against an LDAP directory rather
+// than issuing an HTTP request.
+func (b BackendDefinition) IsLDAP() bool { return b.LDAP != nil }
+
+func compileLDAP(name string, spec BackendDefinitionSpec, renderer *templates.Renderer) (*LDAPDefinition, error) {
+	if renderer == nil {
+		return nil, errors.New("ldap requires a template renderer")
+	}
+	cfg := spec.LDAP
+	def := &LDAPDefinition{
+		StartTLS: cfg.StartTLS,
+		CAFile:   strings.TrimSpace(cfg.CAFile),
+		PoolSize: cfg.PoolSize,
+		UserDN:   strings.TrimSpace(cfg.UserDN),
+		BindDN:   strings.TrimSpace(cfg.BindDN),
+		BaseDN:   strings.TrimSpace(cfg.BaseDN),
+	}
+	if def.PoolSize <= 0 {
+		def.PoolSize = defaultLDAPPoolSize
+	}
+	switch {
+	case def.UserDN != "" && def.BaseDN != "":
+		return nil, errors.New("userDN and baseDN are mutually exclusive")
+	case def.UserDN != "":
+		if !strings.Contains(def.UserDN, "{username}") {
+			return nil, errors.New("userDN must contain {username}")
+		}
+	case def.BaseDN != "":
+		def.UserFilter = strings.TrimSpace(cfg.UserFilter)
+		if def.UserFilter == "" {
+			def.UserFilter = defaultLDAPUserFilter
+		}
+		if !strings.Contains(def.UserFilter, "{username}") {
+			return nil, errors.New("userFilter must contain {username}")
+		}
+	default:
+		return nil, errors.New("userDN or baseDN required")
+	}
+	for _, attr := range cfg.Attributes {
+		if trimmed := strings.TrimSpace(attr); trimmed != "" {
+			def.Attributes = append(def.Attributes, trimmed)
+		}
+	}
+
+	var err error
+	if def.Username, err = compileLDAPTemplate(renderer, name+":ldap:username", cfg.Username, defaultLDAPUsername); err != nil {
+		return nil, fmt.Errorf("username template: %w", err)
+	}
+	if def.Password, err = compileLDAPTemplate(renderer, name+":ldap:password", cfg.Password, defaultLDAPPassword); err != nil {
+		return nil, fmt.Errorf("password template: %w", err)
+	}
+	if def.BindDN != "" {
+		if def.BindPassword, err = renderer.CompileInline(name+":ldap:bindPassword", cfg.BindPassword); err != nil {
+			return nil, fmt.Errorf("bindPassword template: %w", err)
+		}
+	}
+
+	if baseDN := strings.TrimSpace(cfg.Groups.BaseDN); baseDN != "" {
+		groups := &LDAPGroupsDefinition{
+			BaseDN:    baseDN,
+			Filter:    strings.TrimSpace(cfg.Groups.Filter),
+			Attribute: strings.TrimSpace(cfg.Groups.Attribute),
+			Nested:    cfg.Groups.Nested,
+		}
+		if groups.Filter == "" {
+			groups.Filter = defaultLDAPGroupFilter
+		}
+		if !strings.Contains(groups.Filter, "{dn}") {
+			return nil, errors.New("groups.filter must contain {dn}")
+		}
+		if groups.Attribute == "" {
+			groups.Attribute = defaultLDAPGroupAttr
+		}
+		def.Groups = groups
+	}
+	return def, nil
+}
+
+func compileLDAPTemplate(renderer *templates.Renderer, name, source, fallback string) (*templates.Template, error) {
+	if strings.TrimSpace(source) == "" {
+		source = fallback
+	}
+	return renderer.CompileInline(name, source)
+}
@@ -0,0 +1,71 @@
+package rulechain
+
+import (
+	"time"
+
+	"github.com/l0p7/passctrl/internal/expr"
+)
+
+// Group kinds for plan steps that nest other steps.
+const (
+	GroupAllOf    = "allOf"
+	GroupAnyOf    = "anyOf"
+	GroupParallel = "parallel"
+)
+
+// Step is one node of an execution plan: a single rule, or a group whose
+// outcome combines its nested steps.
+type Step struct {
+	// Rule is the definition to evaluate; nil for groups.
+	Rule *Definition
+	// Group is GroupAllOf, GroupAnyOf, or GroupParallel when Rule is nil.
+	Group string
+	Steps []Step
+	// Workers bounds how many steps of a parallel group run at once.
+	Workers int
+	// When skips the step unless it evaluates to true.
+	When *expr.Program
+	// Stop ends the chain with a pass once the step passes.
+	Stop bool
+}
+
+// Label names the step in history entries and logs.
+func (s Step) Label() string {
+	if s.Rule != nil {
+		return s.Rule.Name
+	}
+	return s.Group
+}
+
+// RuleSteps wraps a flat list of definitions as sequential steps.
+func RuleSteps(defs []Definition) []Step {
+	steps := make([]Step, len(defs))
+	for i := range defs {
+		def := defs[i]
+		steps[i] = Step{Rule: &def}
+	}
+	return steps
+}
+
+// flattenSteps lists the rule definitions of a step tree in declaration order.
+func flattenSteps(steps []Step) []Definition {
+	var defs []Definition
+	for _, step := range steps {
+		if step.Rule != nil {
+			defs = append(defs, *step.Rule)
+			continue
+		}
+		defs = append(defs, flattenSteps(step.Steps)...)
+	}
+	return defs
+}
+
+// Timeout bounds an evaluation. Outcome is reported when the deadline passes:
+// "error" (the default) or "fail".
+type Timeout struct {
+	Duration time.Duration
+	Outcome  string
+}
+
+// Enabled reports whether the timeout sets a deadline.
+func (t Timeout) Enabled() bool { return t.Duration > 0 }
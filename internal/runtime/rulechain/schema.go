@@ -0,0 +1,52 @@
+package rulechain
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	kjson "github.com/knadh/koanf/parsers/json"
+	"github.com/knadh/koanf/parsers/yaml"
+	"github.com/l0p7/passctrl/internal/expr"
+	"github.com/l0p7/passctrl/internal/templates"
+)
+
+// compileBodySchema loads the backend body schema, inline or from a JSON or
+// YAML file inside the template sandbox. It returns nil when none is set.
+func compileBodySchema(name string, spec BackendDefinitionSpec, renderer *templates.Renderer) (*expr.BodySchema, error) {
+	document := spec.BodySchema
+	if path := strings.TrimSpace(spec.BodySchemaFile); path != "" {
+		var sandbox *templates.Sandbox
+		if renderer != nil {
+			sandbox = renderer.Sandbox()
+		}
+		if sandbox == nil {
+			return nil, errors.New("bodySchemaFile requires a template sandbox")
+		}
+		resolved, err := sandbox.Resolve(path)
+		if err != nil {
+			return nil, err
+		}
+		raw, err := os.ReadFile(resolved) // #nosec G304 -- path resolved inside the template sandbox
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", path, err)
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml":
+			document, err = yaml.Parser().Unmarshal(raw)
+		case ".json":
+			document, err = kjson.Parser().Unmarshal(raw)
+		default:
+			return nil, fmt.Errorf("unsupported schema file extension %q", filepath.Ext(path))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, err)
+		}
+	}
+	if len(document) == 0 {
+		return nil, nil
+	}
+	return expr.CompileBodySchema(name, document)
+}
@@ -0,0 +1,51 @@
+package rulechain
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/l0p7/passctrl/internal/templates"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileDefinitionsTypeChecksBodySchema(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "schemas"), 0o755))
+	schema := "type: object\nproperties:\n  active:\n    type: boolean\n  tier:\n    type: string\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schemas", "account.yaml"), []byte(schema), 0o600))
+	sandbox, err := templates.NewSandbox(dir)
+	require.NoError(t, err)
+	renderer := templates.NewRenderer(sandbox)
+
+	spec := func(condition string) DefinitionSpec {
+		return DefinitionSpec{
+			Name: "account",
+			Backend: BackendDefinitionSpec{
+				URL:            "https://api.example.com/account",
+				BodySchemaFile: "schemas/account.yaml",
+			},
+			Conditions: ConditionSpec{Pass: []string{condition}},
+		}
+	}
+
+	defs, err := CompileDefinitions([]DefinitionSpec{spec(`backend.body.active && backend.body.tier == "gold"`)}, renderer)
+	require.NoError(t, err)
+	require.NotNil(t, defs[0].Backend.BodySchema)
+
+	_, err = CompileDefinitions([]DefinitionSpec{spec(`backend.body.acitve`)}, renderer)
+	require.ErrorContains(t, err, "undefined field 'acitve'")
+
+	_, err = CompileDefinitions([]DefinitionSpec{spec(`backend.body.tier > 3`)}, renderer)
+	require.ErrorContains(t, err, "pass conditions")
+
+	missing := spec(`backend.body.active`)
+	missing.Backend.BodySchemaFile = "../outside.json"
+	_, err = CompileDefinitions([]DefinitionSpec{missing}, renderer)
+	require.ErrorContains(t, err, "escapes sandbox")
+
+	inline := spec(`backend.body.acitve`)
+	inline.Backend.BodySchemaFile = ""
+	_, err = CompileDefinitions([]DefinitionSpec{inline}, renderer)
+	require.NoError(t, err, "rules without a schema keep dyn bodies")
+}
@@ -0,0 +1,96 @@
+package rulechain
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/templates"
+)
+
+const (
+	defaultSQLMaxRows = 100
+	defaultSQLTimeout = 5 * time.Second
+)
+
+// SQLQuerier runs parameterized queries; *sql.DB satisfies it.
+type SQLQuerier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// SQLSpec captures the declarative settings for a backend of type sql.
+// Database names the server connection; DB carries the resolved pool.
+type SQLSpec struct {
+	Database string
+	DB       SQLQuerier
+	Query    string
+	Params   []string
+	MaxRows  int
+	Timeout  string
+}
+
+// SQLDefinition is the compiled form of SQLSpec. Params are templates whose
+// rendered values are bound to the query placeholders in order.
+type SQLDefinition struct {
+	Database string
+	DB       SQLQuerier
+	Query    string
+	Params   []*templates.Template
+	MaxRows  int
+	Timeout  time.Duration
+}
+
+// IsSQL reports whether the backend queries a database rather than issuing
+// an HTTP request.
+func (b BackendDefinition) IsSQL() bool { return b.SQL != nil }
+
+// sqlBackendURL is the synthetic URL of an SQL backend. It identifies the
+// connection in page state and cache descriptors.
+func sqlBackendURL(database string) string {
+	database = strings.TrimSpace(database)
+	if database == "" {
+		return ""
+	}
+	return "sql://" + database
+}
+
+func compileSQL(name string, spec BackendDefinitionSpec, renderer *templates.Renderer) (*SQLDefinition, error) {
+	if renderer == nil {
+		return nil, errors.New("sql requires a template renderer")
+	}
+	cfg := spec.SQL
+	def := &SQLDefinition{
+		Database: strings.TrimSpace(cfg.Database),
+		DB:       cfg.DB,
+		Query:    strings.TrimSpace(cfg.Query),
+		MaxRows:  cfg.MaxRows,
+		Timeout:  defaultSQLTimeout,
+	}
+	if def.DB == nil {
+		return nil, fmt.Errorf("database %q not available", def.Database)
+	}
+	if def.Query == "" {
+		return nil, errors.New("query required")
+	}
+	if def.MaxRows <= 0 {
+		def.MaxRows = defaultSQLMaxRows
+	}
+	if timeout := strings.TrimSpace(cfg.Timeout); timeout != "" {
+		parsed, err := time.ParseDuration(timeout)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("timeout: invalid duration %q", cfg.Timeout)
+		}
+		def.Timeout = parsed
+	}
+	for i, param := range cfg.Params {
+		tmpl, err := renderer.CompileInline(fmt.Sprintf("%s:sql:params[%d]", name, i), param)
+		if err != nil {
+			return nil, fmt.Errorf("params[%d] template: %w", i, err)
+		}
+		def.Params = append(def.Params, tmpl)
+	}
+	return def, nil
+}
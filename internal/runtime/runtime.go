@@ -16,9 +16,13 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/credentials"
+	"github.com/l0p7/passctrl/internal/databases"
+	"github.com/l0p7/passctrl/internal/datasets"
 	"github.com/l0p7/passctrl/internal/metrics"
 	"github.com/l0p7/passctrl/internal/runtime/admission"
 	"github.com/l0p7/passctrl/internal/runtime/cache"
@@ -33,6 +37,9 @@ import (
 const (
 	defaultCacheTTL       = 30 * time.Second
 	defaultCacheNamespace = "passctrl:decision:v1"
+	// drainTimeout bounds how long a settings change waits for requests on
+	// the previous snapshot before closing the cache they use.
+	drainTimeout = 10 * time.Second
 )
 
 type PipelineOptions struct {
@@ -50,34 +57,68 @@ type PipelineOptions struct {
 	Metrics            metrics.Recorder
 	LoadedEnvironment  map[string]string
 	LoadedSecrets      map[string]string
+	CredentialStores   *credentials.Registry
+	Datasets           *datasets.Registry
+	Databases          *databases.Registry
+	RulesReload        config.RulesReloadConfig
+}
+
+// Settings carries the server-level values a configuration reload can swap
+// without restarting the listeners.
+type Settings struct {
+	Cache             cache.DecisionCache
+	CacheTTL          time.Duration
+	CacheEpoch        int
+	CacheKeySalt      string
+	CacheNamespace    string
+	TemplateSandbox   *templates.Sandbox
+	CorrelationHeader string
+	LoadedEnvironment map[string]string
+	LoadedSecrets     map[string]string
+	RulesReload       config.RulesReloadConfig
 }
 
 type Pipeline struct {
-	logger            *slog.Logger
+	logger           *slog.Logger
+	metrics          metrics.Recorder
+	credentialStores *credentials.Registry
+	datasets         *datasets.Registry
+	databases        *databases.Registry
+
+	// active is the snapshot new requests run against. It is replaced
+	// wholesale on reload and never mutated in place.
+	active atomic.Pointer[snapshot]
+
+	// reloadMu serializes rule reloads and settings changes. The builder
+	// state below is only touched while holding it and is copied into each
+	// published snapshot.
+	reloadMu          sync.Mutex
+	generation        uint64
 	cache             cache.DecisionCache
 	cacheTTL          time.Duration
 	cacheEpoch        int
 	cacheSalt         []byte
 	cacheNamespace    string
 	correlationHeader string
-	metrics           metrics.Recorder
 	loadedEnvironment map[string]string
 	loadedSecrets     map[string]string
+	rulesReload       config.RulesReloadConfig
+	templateRenderer  *templates.Renderer
 
-	mu sync.RWMutex
+	drainMu  sync.Mutex
+	draining []*snapshot
 
-	endpoints        map[string]*endpointRuntime
-	defaultEndpoint  *endpointRuntime
-	usingFallback    bool
-	ruleSources      []string
-	skipped          []config.DefinitionSkip
-	templateRenderer *templates.Renderer
+	historyMu sync.Mutex
+	history   []ReloadRecord
 }
 
 type endpointRuntime struct {
 	name       string
 	authConfig admission.Config
 	agents     []pipeline.Agent
+	// cacheKey holds the compiled cache.key expressions whose values are
+	// hashed into every cache key of the endpoint.
+	cacheKey []cacheKeyExpression
 }
 
 type endpointContextKey struct{}
@@ -86,49 +127,122 @@ func NewPipeline(logger *slog.Logger, opts PipelineOptions) *Pipeline {
 	if logger == nil {
 		logger = slog.Default()
 	}
-	ttl := opts.CacheTTL
+
+	p := &Pipeline{
+		logger:           logger.With(slog.String("agent", "pipeline")),
+		metrics:          opts.Metrics,
+		credentialStores: opts.CredentialStores,
+		datasets:         opts.Datasets,
+		databases:        opts.Databases,
+	}
+
+	p.setSettings(Settings{
+		Cache:             opts.Cache,
+		CacheTTL:          opts.CacheTTL,
+		CacheEpoch:        opts.CacheEpoch,
+		CacheKeySalt:      opts.CacheKeySalt,
+		CacheNamespace:    opts.CacheNamespace,
+		TemplateSandbox:   opts.TemplateSandbox,
+		CorrelationHeader: opts.CorrelationHeader,
+		LoadedEnvironment: opts.LoadedEnvironment,
+		LoadedSecrets:     opts.LoadedSecrets,
+		RulesReload:       opts.RulesReload,
+	})
+	p.publish(p.buildEndpointSet(opts.Endpoints, opts.Rules), opts.RuleSources, opts.SkippedDefinitions)
+	return p
+}
+
+// setSettings installs server-level settings, applying the same defaults as
+// NewPipeline, and returns the cache that was active before. Callers hold
+// reloadMu once the pipeline is shared.
+func (p *Pipeline) setSettings(s Settings) cache.DecisionCache {
+	ttl := s.CacheTTL
 	if ttl <= 0 {
 		ttl = defaultCacheTTL
 	}
-	epoch := opts.CacheEpoch
+	epoch := s.CacheEpoch
 	if epoch <= 0 {
 		epoch = 1
 	}
-	namespace := opts.CacheNamespace
+	namespace := s.CacheNamespace
 	if namespace == "" {
 		namespace = defaultCacheNamespace
 	}
-	decisionCache := opts.Cache
+	decisionCache := s.Cache
 	if decisionCache == nil {
 		decisionCache = cache.NewMemory(ttl)
 	}
 
-	p := &Pipeline{
-		logger:            logger.With(slog.String("agent", "pipeline")),
-		cache:             decisionCache,
-		cacheTTL:          ttl,
-		cacheEpoch:        epoch,
-		cacheSalt:         []byte(opts.CacheKeySalt),
-		cacheNamespace:    namespace,
-		correlationHeader: strings.TrimSpace(opts.CorrelationHeader),
-		metrics:           opts.Metrics,
-		loadedEnvironment: opts.LoadedEnvironment,
-		loadedSecrets:     opts.LoadedSecrets,
-		endpoints:         make(map[string]*endpointRuntime),
-	}
-
-	p.templateRenderer = templates.NewRenderer(opts.TemplateSandbox)
-	p.configureEndpoints(opts.Endpoints, opts.Rules)
-	p.ruleSources = cloneStringSlice(opts.RuleSources)
-	p.skipped = cloneDefinitionSkips(opts.SkippedDefinitions)
-	return p
+	previous := p.cache
+	p.cache = decisionCache
+	p.cacheTTL = ttl
+	p.cacheEpoch = epoch
+	p.cacheSalt = []byte(s.CacheKeySalt)
+	p.cacheNamespace = namespace
+	p.correlationHeader = strings.TrimSpace(s.CorrelationHeader)
+	p.loadedEnvironment = s.LoadedEnvironment
+	p.loadedSecrets = s.LoadedSecrets
+	p.rulesReload = s.RulesReload
+	p.templateRenderer = templates.NewRenderer(s.TemplateSandbox)
+	return previous
+}
+
+// ApplySettings swaps server-level settings from a configuration reload.
+// Endpoint agents are rebuilt so they capture the new cache and template
+// renderer, a replaced cache is closed once the requests still running on the
+// previous snapshot have drained, and cached decisions are purged because
+// environment, secret, or template changes can flip outcomes.
+func (p *Pipeline) ApplySettings(ctx context.Context, s Settings) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	p.reloadMu.Lock()
+	defer p.reloadMu.Unlock()
+
+	current := p.active.Load()
+	previousCache := p.setSettings(s)
+	set := p.buildEndpointSet(current.endpointConfigs, current.ruleConfigs)
+	retired := p.publish(set, current.ruleSources, current.skipped)
+
+	if previousCache != nil && previousCache != p.cache {
+		drainCtx, cancel := context.WithTimeout(ctx, drainTimeout)
+		if !retired.awaitDrain(drainCtx) {
+			p.logger.Warn("closing previous decision cache before in-flight requests drained", slog.Uint64("generation", retired.generation))
+		}
+		cancel()
+		if err := previousCache.Close(ctx); err != nil {
+			p.logger.Warn("previous decision cache close failed", slog.Any("error", err))
+		}
+	}
+
+	prefix, ok := p.purgeDecisionCache(ctx)
+	if !ok {
+		p.logger.Info("server settings applied", slog.String("event", "config_reload"))
+		return
+	}
+	p.logger.Info("server settings applied", slog.String("event", "config_reload"), slog.String("cache_prefix", prefix))
 }
 
 func (p *Pipeline) Close(ctx context.Context) error {
-	if p.cache == nil {
-		return nil
+	// Replaced snapshots close their backends once drained; requests still
+	// running on them get until ctx ends before the connections close.
+	p.drainMu.Lock()
+	draining := p.draining
+	p.draining = nil
+	p.drainMu.Unlock()
+	for _, snap := range draining {
+		if !snap.awaitDrain(ctx) {
+			snap.markDrained()
+		}
+	}
+
+	active := p.active.Load()
+	backendsErr := active.backends.Close()
+	if active.cache == nil {
+		return backendsErr
 	}
-	return p.cache.Close(ctx)
+	return errors.Join(backendsErr, active.cache.Close(ctx))
 }
 
 // RequestWithEndpointHint ensures downstream agent selection honors an
@@ -265,6 +379,13 @@ func summarizeRuleHistory(entries []pipeline.RuleHistoryEntry) []map[string]any
 		if len(entry.Variables) > 0 {
 			item["variables"] = cloneInterfaceMap(entry.Variables)
 		}
+		if entry.Group != "" {
+			item["group"] = entry.Group
+			item["children"] = summarizeRuleHistory(entry.Children)
+		}
+		if entry.Stopped {
+			item["stopped"] = true
+		}
 		summary = append(summary, item)
 	}
 	return summary
@@ -273,7 +394,7 @@ func summarizeRuleHistory(entries []pipeline.RuleHistoryEntry) []map[string]any
 // EndpointExists reports whether an endpoint with the provided name is
 // configured in the active pipeline snapshot.
 func (p *Pipeline) EndpointExists(name string) bool {
-	_, ok := p.lookupEndpoint(name)
+	_, ok := p.active.Load().lookup(name)
 	return ok
 }
 
@@ -284,7 +405,7 @@ func (p *Pipeline) WriteError(w http.ResponseWriter, status int, message string)
 		status = http.StatusInternalServerError
 	}
 	payload := map[string]any{"error": message}
-	names := p.endpointNames()
+	names := p.active.Load().endpointNames()
 	if len(names) > 0 {
 		payload["availableEndpoints"] = names
 	}
@@ -295,19 +416,17 @@ func (p *Pipeline) WriteError(w http.ResponseWriter, status int, message string)
 	}
 }
 
-func (p *Pipeline) endpointForRequest(r *http.Request) (*endpointRuntime, string, int, string) {
+func (s *snapshot) endpointForRequest(r *http.Request) (*endpointRuntime, string, int, string) {
 	if hint := endpointHintFromContext(r.Context()); hint != "" {
-		runtime, ok := p.lookupEndpoint(hint)
+		runtime, ok := s.lookup(hint)
 		if !ok {
 			return nil, "", http.StatusNotFound, fmt.Sprintf("endpoint %q not found", hint)
 		}
 		return runtime, runtime.name, http.StatusOK, ""
 	}
 
-	p.mu.RLock()
-	endpointCount := len(p.endpoints)
-	defaultEndpoint := p.defaultEndpoint
-	p.mu.RUnlock()
+	endpointCount := len(s.endpoints)
+	defaultEndpoint := s.defaultEndpoint
 
 	if endpointCount == 0 {
 		if defaultEndpoint != nil {
@@ -316,19 +435,28 @@ func (p *Pipeline) endpointForRequest(r *http.Request) (*endpointRuntime, string
 		return nil, "", http.StatusInternalServerError, "no endpoints configured"
 	}
 
-	name := strings.TrimSpace(r.URL.Query().Get("endpoint"))
-	if name == "" {
-		name = strings.TrimSpace(r.Header.Get("X-PassCtrl-Endpoint"))
+	// Once any endpoint declares a match block, selection follows the
+	// forwarded request only: a client could otherwise name a more permissive
+	// endpoint with ?endpoint= or X-PassCtrl-Endpoint and skip its match.
+	var name string
+	if len(s.matchers) == 0 {
+		name = strings.TrimSpace(r.URL.Query().Get("endpoint"))
+		if name == "" {
+			name = strings.TrimSpace(r.Header.Get("X-PassCtrl-Endpoint"))
+		}
 	}
 
 	if name == "" {
+		if matched := s.matchEndpoint(r); matched != nil {
+			return matched, matched.name, http.StatusOK, ""
+		}
 		if defaultEndpoint != nil {
 			return defaultEndpoint, defaultEndpoint.name, http.StatusOK, ""
 		}
 		return nil, "", http.StatusBadRequest, "endpoint parameter required"
 	}
 
-	runtime, ok := p.lookupEndpoint(name)
+	runtime, ok := s.lookup(name)
 	if !ok {
 		return nil, "", http.StatusNotFound, fmt.Sprintf("endpoint %q not found", name)
 	}
@@ -347,17 +475,23 @@ func endpointHintFromContext(ctx context.Context) string {
 // renders the structured decision payload.
 func (p *Pipeline) ServeAuth(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
-	endpointRuntime, endpointName, errStatus, errMsg := p.endpointForRequest(r)
+	snap := p.acquire()
+	defer snap.release()
+
+	endpointRuntime, endpointName, errStatus, errMsg := snap.endpointForRequest(r)
 	if endpointRuntime == nil {
 		p.WriteError(w, errStatus, errMsg)
 		return
 	}
 
-	correlationID := p.requestCorrelationID(r)
-	cacheKey := p.deriveCacheKey(r, endpointRuntime)
+	correlationHeader := snap.correlationHeader
+	correlationID := requestCorrelationID(r, correlationHeader)
+	tables := p.datasets.Tables()
+	cacheKey := snap.deriveCacheKey(r, endpointRuntime, tables)
 	state := pipeline.NewState(r, endpointName, cacheKey, correlationID)
-	state.Variables.Environment = p.loadedEnvironment
-	state.Variables.Secrets = p.loadedSecrets
+	state.Data = tables
+	state.Variables.Environment = snap.loadedEnvironment
+	state.Variables.Secrets = snap.loadedSecrets
 
 	reqLogger := p.logger.With(
 		slog.String("endpoint", endpointName),
@@ -385,18 +519,18 @@ func (p *Pipeline) ServeAuth(w http.ResponseWriter, r *http.Request) {
 		state.Response.Message = "pipeline did not render a response"
 	}
 
-	if p.correlationHeader != "" {
+	if correlationHeader != "" {
 		if state.Response.Headers == nil {
 			state.Response.Headers = make(map[string]string)
 		}
-		state.Response.Headers[p.correlationHeader] = correlationID
+		state.Response.Headers[correlationHeader] = correlationID
 	}
 
 	for k, v := range state.Response.Headers {
 		w.Header().Set(k, v)
 	}
-	if p.correlationHeader != "" {
-		w.Header().Set(p.correlationHeader, correlationID)
+	if correlationHeader != "" {
+		w.Header().Set(correlationHeader, correlationID)
 	}
 	// Render a near-empty response body. Only intentionally constructed messages
 	// (typically from configured rule/endpoint templates) are echoed. Otherwise
@@ -456,12 +590,19 @@ func (p *Pipeline) ServeAuth(w http.ResponseWriter, r *http.Request) {
 // ServeHealth returns the aggregated runtime health including cache statistics
 // and rule provenance details.
 func (p *Pipeline) ServeHealth(w http.ResponseWriter, r *http.Request) {
-	cacheSize, err := p.cache.Size(r.Context())
+	snap := p.active.Load()
+	cacheSize, err := snap.cache.Size(r.Context())
 	if err != nil {
 		p.logger.Error("cache size query failed", slog.Any("error", err))
 		cacheSize = 0
 	}
-	healthStatus, sources, skipped, fallback := p.healthSnapshot()
+	healthStatus, sources, skipped, fallback := snap.health()
+	datasetStatuses := p.datasets.Statuses()
+	for _, dataset := range datasetStatuses {
+		if dataset.Error != "" {
+			healthStatus = "degraded"
+		}
+	}
 	status := map[string]any{
 		"status":       healthStatus,
 		"cacheEntries": cacheSize,
@@ -476,7 +617,17 @@ func (p *Pipeline) ServeHealth(w http.ResponseWriter, r *http.Request) {
 	if len(skipped) > 0 {
 		status["skippedDefinitions"] = skipped
 	}
-	if names := p.endpointNames(); len(names) > 0 {
+	if len(datasetStatuses) > 0 {
+		status["datasets"] = datasetStatuses
+	}
+	if last, ok := p.lastReload(); ok {
+		status["lastReload"] = last
+	}
+	status["generation"] = snap.generation
+	if draining := p.DrainingSnapshots(); len(draining) > 0 {
+		status["drainingSnapshots"] = draining
+	}
+	if names := snap.endpointNames(); len(names) > 0 {
 		status["availableEndpoints"] = names
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -488,12 +639,13 @@ func (p *Pipeline) ServeHealth(w http.ResponseWriter, r *http.Request) {
 // ServeExplain reports the observable pipeline metadata to callers requesting
 // diagnostics.
 func (p *Pipeline) ServeExplain(w http.ResponseWriter, r *http.Request) {
-	cacheSize, err := p.cache.Size(r.Context())
+	snap := p.active.Load()
+	cacheSize, err := snap.cache.Size(r.Context())
 	if err != nil {
 		p.logger.Error("cache size query failed", slog.Any("error", err))
 		cacheSize = 0
 	}
-	status, sources, skipped, fallback := p.healthSnapshot()
+	status, sources, skipped, fallback := snap.health()
 	payload := struct {
 		Status             string                  `json:"status"`
 		ObservedAt         time.Time               `json:"observedAt"`
@@ -503,6 +655,9 @@ func (p *Pipeline) ServeExplain(w http.ResponseWriter, r *http.Request) {
 		RuleSources        []string                `json:"ruleSources,omitempty"`
 		SkippedDefinitions []config.DefinitionSkip `json:"skippedDefinitions,omitempty"`
 		AvailableEndpoints []string                `json:"availableEndpoints,omitempty"`
+		ResolvedEndpoint   *resolvedEndpoint       `json:"resolvedEndpoint,omitempty"`
+		RuleChain          []ruleChainNode         `json:"ruleChain,omitempty"`
+		CacheKey           *cacheKeyPreview        `json:"cacheKey,omitempty"`
 	}{
 		Status:       status,
 		ObservedAt:   time.Now().UTC(),
@@ -513,6 +668,15 @@ func (p *Pipeline) ServeExplain(w http.ResponseWriter, r *http.Request) {
 	}
 	if hint := endpointHintFromContext(r.Context()); hint != "" {
 		payload.Endpoint = hint
+		payload.ResolvedEndpoint = snap.resolvedEndpoint(hint)
+		payload.RuleChain = snap.ruleChain(hint)
+		if ep, ok := snap.lookup(hint); ok {
+			// Preview the key as if the explain request had been sent to
+			// the endpoint's auth route.
+			example := r.Clone(r.Context())
+			example.URL.Path = "/" + hint + "/auth"
+			payload.CacheKey = previewCacheKey(example, ep, p.datasets.Tables())
+		}
 	}
 	if len(sources) > 0 {
 		payload.RuleSources = sources
@@ -520,7 +684,7 @@ func (p *Pipeline) ServeExplain(w http.ResponseWriter, r *http.Request) {
 	if len(skipped) > 0 {
 		payload.SkippedDefinitions = skipped
 	}
-	if names := p.endpointNames(); len(names) > 0 {
+	if names := snap.endpointNames(); len(names) > 0 {
 		payload.AvailableEndpoints = names
 	}
 	w.Header().Set("Content-Type", "application/json")
@@ -529,35 +693,63 @@ func (p *Pipeline) ServeExplain(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (p *Pipeline) deriveCacheKey(r *http.Request, ep *endpointRuntime) string {
-	// Disable caching for endpoints that allow anonymous authentication
-	// to prevent cache poisoning when rules use request-specific data
-	if ep.authConfig.Allow.None {
-		return ""
+// resolvedEndpoint is the /explain view of an endpoint after extends and
+// endpointDefaults were applied.
+type resolvedEndpoint struct {
+	Name       string            `json:"name"`
+	Extends    []string          `json:"extends,omitempty"`
+	Definition map[string]any    `json:"definition"`
+	Origins    map[string]string `json:"origins"`
+}
+
+// resolvedEndpoint reports how the loader resolved the named endpoint, or nil
+// for inline endpoints and unknown names.
+func (s *snapshot) resolvedEndpoint(name string) *resolvedEndpoint {
+	for configured, cfg := range s.endpointConfigs {
+		if !strings.EqualFold(configured, name) || cfg.Inheritance == nil {
+			continue
+		}
+		return &resolvedEndpoint{
+			Name:       configured,
+			Extends:    cloneStringSlice(cfg.Inheritance.Chain[1:]),
+			Definition: cfg.Inheritance.Resolved,
+			Origins:    cloneStringMap(cfg.Inheritance.Origins),
+		}
 	}
+	return nil
+}
 
-	raw := cacheKeyFromRequest(r, ep.name, &ep.authConfig)
-	sum := sha256.Sum256(append(p.cacheSalt, []byte(raw)...))
-	encoded := base64.RawURLEncoding.EncodeToString(sum[:])
-	return fmt.Sprintf("%s:%d:%s", p.cacheNamespace, p.cacheEpoch, encoded)
+// ruleChain describes the configured rule chain of the named endpoint.
+func (s *snapshot) ruleChain(name string) []ruleChainNode {
+	for configured, cfg := range s.endpointConfigs {
+		if strings.EqualFold(configured, name) {
+			return describeRuleChain(cfg.Rules)
+		}
+	}
+	return nil
 }
 
-func (p *Pipeline) endpointNames() []string {
-	p.mu.RLock()
-	defaultEndpoint := p.defaultEndpoint
-	endpoints := make([]*endpointRuntime, 0, len(p.endpoints))
-	for _, runtime := range p.endpoints {
-		endpoints = append(endpoints, runtime)
+func (s *snapshot) deriveCacheKey(r *http.Request, ep *endpointRuntime, tables datasets.Tables) string {
+	raw, ok := rawCacheKey(r, ep, tables)
+	if !ok {
+		return ""
 	}
-	p.mu.RUnlock()
 
-	names := make([]string, 0, len(endpoints)+1)
+	salt, namespace, epoch := s.cacheSalt, s.cacheNamespace, s.cacheEpoch
+
+	sum := sha256.Sum256(append(salt[:len(salt):len(salt)], []byte(raw)...))
+	encoded := base64.RawURLEncoding.EncodeToString(sum[:])
+	return fmt.Sprintf("%s:%d:%s", namespace, epoch, encoded)
+}
+
+func (s *snapshot) endpointNames() []string {
+	names := make([]string, 0, len(s.endpoints)+1)
 	seen := map[string]struct{}{}
-	if defaultEndpoint != nil {
-		names = append(names, defaultEndpoint.name)
-		seen[defaultEndpoint.name] = struct{}{}
+	if s.defaultEndpoint != nil {
+		names = append(names, s.defaultEndpoint.name)
+		seen[s.defaultEndpoint.name] = struct{}{}
 	}
-	for _, runtime := range endpoints {
+	for _, runtime := range s.endpoints {
 		if _, ok := seen[runtime.name]; ok {
 			continue
 		}
@@ -567,75 +759,99 @@ func (p *Pipeline) endpointNames() []string {
 	return names
 }
 
-func (p *Pipeline) healthSnapshot() (string, []string, []config.DefinitionSkip, bool) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
+func (s *snapshot) health() (string, []string, []config.DefinitionSkip, bool) {
 	status := "ok"
-	if p.usingFallback || len(p.skipped) > 0 {
+	if s.usingFallback || len(s.skipped) > 0 {
 		status = "degraded"
 	}
-	sources := cloneStringSlice(p.ruleSources)
-	skipped := cloneDefinitionSkips(p.skipped)
-	return status, sources, skipped, p.usingFallback
+	sources := cloneStringSlice(s.ruleSources)
+	skipped := cloneDefinitionSkips(s.skipped)
+	return status, sources, skipped, s.usingFallback
 }
 
-func (p *Pipeline) lookupEndpoint(name string) (*endpointRuntime, bool) {
-	trimmed := strings.TrimSpace(name)
-	if trimmed == "" {
-		return nil, false
-	}
-	key := strings.ToLower(trimmed)
-	p.mu.RLock()
-	runtime, ok := p.endpoints[key]
-	p.mu.RUnlock()
-	if !ok {
-		return nil, false
-	}
-	return runtime, true
+// endpointSet is a compiled set of endpoint runtimes together with the
+// definitions it was built from. Reloads build a candidate set off to the side
+// and only publish it once it satisfies the reload policy.
+type endpointSet struct {
+	endpoints       map[string]*endpointRuntime
+	defaultEndpoint *endpointRuntime
+	usingFallback   bool
+	// matchers select an endpoint from the forwarded request when the caller
+	// does not name one, in evaluation order.
+	matchers []*endpointMatcher
+	// backends pools the LDAP and gRPC connections of every endpoint in
+	// the set; the snapshot publishing the set closes them once drained.
+	backends        *backendPools
+	endpointConfigs map[string]config.EndpointConfig
+	ruleConfigs     map[string]config.RuleConfig
+	// endpointErrors and ruleErrors record definitions that were declared but
+	// could not be compiled into the set.
+	endpointErrors map[string]string
+	ruleErrors     map[string]string
 }
 
-func (p *Pipeline) configureEndpoints(endpoints map[string]config.EndpointConfig, rules map[string]config.RuleConfig) {
-	p.endpoints = make(map[string]*endpointRuntime)
-	p.defaultEndpoint = nil
-	p.usingFallback = false
-
-	compiledRules, err := compileConfiguredRules(rules, p.templateRenderer)
-	if err != nil {
-		p.logger.Warn("failed to compile configured rules", slog.Any("error", err))
-		compiledRules = map[string]rulechain.Definition{}
+// buildEndpointSet compiles every rule and endpoint against the current
+// settings without touching the active snapshot. Callers hold reloadMu.
+func (p *Pipeline) buildEndpointSet(endpoints map[string]config.EndpointConfig, rules map[string]config.RuleConfig) *endpointSet {
+	set := &endpointSet{
+		endpoints:       make(map[string]*endpointRuntime),
+		backends:        newBackendPools(),
+		endpointConfigs: endpoints,
+		ruleConfigs:     rules,
+		endpointErrors:  make(map[string]string),
+		ruleErrors:      make(map[string]string),
 	}
 
-	if len(endpoints) == 0 {
-		p.installFallbackEndpoint()
-		return
+	compiledRules, ruleErrs := compileConfiguredRules(rules, p.templateRenderer, p.credentialStores, p.databases)
+	for name, err := range ruleErrs {
+		p.logger.Warn("rule configuration skipped", slog.String("rule", name), slog.Any("error", err))
+		set.ruleErrors[name] = err.Error()
 	}
 
 	for name, cfg := range endpoints {
-		runtime, err := p.buildEndpointRuntime(name, cfg, compiledRules)
+		runtime, err := p.buildEndpointRuntime(name, cfg, rules, compiledRules, set.backends)
 		if err != nil {
 			p.logger.Warn("endpoint configuration skipped", slog.String("endpoint", name), slog.Any("error", err))
+			set.endpointErrors[name] = err.Error()
 			continue
 		}
+		var matcher *endpointMatcher
+		if cfg.Match.Enabled() {
+			matcher, err = compileEndpointMatcher(runtime, cfg.Match)
+			if err != nil {
+				p.logger.Warn("endpoint configuration skipped", slog.String("endpoint", name), slog.Any("error", err))
+				set.endpointErrors[name] = fmt.Sprintf("invalid match: %v", err)
+				continue
+			}
+			set.matchers = append(set.matchers, matcher)
+		}
 		key := strings.ToLower(runtime.name)
-		p.endpoints[key] = runtime
-	}
-
-	if len(p.endpoints) == 1 {
-		for _, runtime := range p.endpoints {
-			p.defaultEndpoint = runtime
+		set.endpoints[key] = runtime
+		if cfg.Match.Fallback {
+			set.defaultEndpoint = runtime
 		}
-		return
 	}
-
-	if len(p.endpoints) == 0 {
-		p.installFallbackEndpoint()
-		return
+	sortEndpointMatchers(set.matchers)
+
+	switch len(set.endpoints) {
+	case 0:
+		fallback := p.fallbackEndpoint(set.backends)
+		set.endpoints[strings.ToLower(fallback.name)] = fallback
+		set.defaultEndpoint = fallback
+		set.usingFallback = true
+	case 1:
+		// A lone endpoint with a match block still only answers the requests
+		// it matches, unless it opted in with match.fallback.
+		if set.defaultEndpoint == nil && len(set.matchers) == 0 {
+			for _, runtime := range set.endpoints {
+				set.defaultEndpoint = runtime
+			}
+		}
 	}
-	p.defaultEndpoint = nil
+	return set
 }
 
-func (p *Pipeline) installFallbackEndpoint() {
+func (p *Pipeline) fallbackEndpoint(backends *backendPools) *endpointRuntime {
 	ruleExecutionLogger := p.logger.With(
 		slog.String("agent", "rule_execution"),
 		slog.String("endpoint", "default"),
@@ -662,7 +878,7 @@ func (p *Pipeline) installFallbackEndpoint() {
 	}
 
 	// Create backend interaction agent with HTTP client
-	backendAgent := newBackendInteractionAgent(&http.Client{Timeout: 10 * time.Second}, backendInteractionLogger)
+	backendAgent := newBackendInteractionAgent(&http.Client{Timeout: 10 * time.Second}, backendInteractionLogger, backends)
 
 	agents := []pipeline.Agent{
 		&serverAgent{},
@@ -672,70 +888,66 @@ func (p *Pipeline) installFallbackEndpoint() {
 		newRuleExecutionAgent(backendAgent, ruleExecutionLogger, p.templateRenderer, p.cache, p.cacheTTL, p.metrics, p.correlationHeader),
 		responsepolicy.NewWithConfig(responsepolicy.Config{Endpoint: "default", Renderer: p.templateRenderer}),
 	}
-	runtime := &endpointRuntime{
+	return &endpointRuntime{
 		name:       "default",
 		authConfig: defaultAuthConfig,
 		agents:     p.instrumentAgents("default", agents),
 	}
-	p.endpoints[strings.ToLower(runtime.name)] = runtime
-	p.defaultEndpoint = runtime
-	p.usingFallback = true
 }
 
-// Reload swaps the active rule bundle and clears cached decisions so subsequent
-// requests evaluate against the latest configuration snapshot.
-func (p *Pipeline) Reload(ctx context.Context, bundle config.RuleBundle) {
+// InvalidateCache purges cached decisions after an out-of-band change, such as
+// a credential store reload, that could flip previously cached outcomes.
+func (p *Pipeline) InvalidateCache(ctx context.Context, reason string) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
+	if prefix, ok := p.purgeDecisionCache(ctx); ok {
+		p.logger.Info("decision cache invalidated", slog.String("reason", reason), slog.String("cache_prefix", prefix))
+	}
+}
 
-	p.mu.Lock()
-	p.configureEndpoints(bundle.Endpoints, bundle.Rules)
-	p.ruleSources = cloneStringSlice(bundle.Sources)
-	p.skipped = cloneDefinitionSkips(bundle.Skipped)
-	p.mu.Unlock()
-
-	if p.cache == nil {
-		p.logger.Info("configuration reloaded", slog.String("event", "rules_reload"))
-		return
+// purgeDecisionCache removes every entry under the active namespace and epoch.
+// It reports false when no cache is configured or the purge failed.
+func (p *Pipeline) purgeDecisionCache(ctx context.Context) (string, bool) {
+	snap := p.active.Load()
+	decisionCache, namespace, epoch := snap.cache, snap.cacheNamespace, snap.cacheEpoch
+	if decisionCache == nil {
+		return "", false
 	}
 
-	prefix := fmt.Sprintf("%s:%d:", p.cacheNamespace, p.cacheEpoch)
-	if err := p.cache.DeletePrefix(ctx, prefix); err != nil {
+	prefix := fmt.Sprintf("%s:%d:", namespace, epoch)
+	if err := decisionCache.DeletePrefix(ctx, prefix); err != nil {
 		p.logger.Warn("cache purge failed", slog.Any("error", err), slog.String("cache_prefix", prefix))
-		return
+		return "", false
 	}
-	if invalidator, ok := p.cache.(cache.ReloadInvalidator); ok {
-		scope := cache.ReloadScope{Namespace: p.cacheNamespace, Epoch: p.cacheEpoch, Prefix: prefix}
+	if invalidator, ok := decisionCache.(cache.ReloadInvalidator); ok {
+		scope := cache.ReloadScope{Namespace: namespace, Epoch: epoch, Prefix: prefix}
 		if err := invalidator.InvalidateOnReload(ctx, scope); err != nil {
 			p.logger.Warn("cache reload invalidation failed", slog.Any("error", err), slog.String("cache_prefix", prefix))
 		}
 	}
-	p.logger.Info("configuration reloaded", slog.String("event", "rules_reload"), slog.String("cache_prefix", prefix))
+	return prefix, true
 }
 
-func (p *Pipeline) buildEndpointRuntime(name string, cfg config.EndpointConfig, compiled map[string]rulechain.Definition) (*endpointRuntime, error) {
+func (p *Pipeline) buildEndpointRuntime(name string, cfg config.EndpointConfig, rules map[string]config.RuleConfig, compiled map[string]rulechain.Definition, backends *backendPools) (*endpointRuntime, error) {
 	trimmed := strings.TrimSpace(name)
 	if trimmed == "" {
 		return nil, errors.New("endpoint name required")
 	}
 
-	ruleDefs := make([]rulechain.Definition, 0, len(cfg.Rules))
-	for _, ref := range cfg.Rules {
-		ruleName := strings.TrimSpace(ref.Name)
-		if ruleName == "" {
-			continue
-		}
-		def, ok := compiled[ruleName]
-		if !ok {
-			return nil, fmt.Errorf("rule %q not available", ruleName)
-		}
-		ruleDefs = append(ruleDefs, def)
+	steps, err := buildRuleSteps(cfg.Rules, cfg.RuleExecution, rules, compiled)
+	if err != nil {
+		return nil, err
 	}
 
 	trusted := append(defaultTrustedNetworks(), admission.ParseCIDRs(cfg.ForwardProxyPolicy.TrustedProxyIPs)...)
 	authConfig := admissionConfigFromEndpoint(cfg.Authentication)
 
+	cacheKey, err := compileCacheKey(cfg.Cache.Key)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build endpoint variables agent (evaluates endpoint.variables before rules)
 	var endpointVarsAgent pipeline.Agent
 	if len(cfg.Variables) > 0 {
@@ -769,10 +981,11 @@ func (p *Pipeline) buildEndpointRuntime(name string, cfg config.EndpointConfig,
 	backendAgent := newBackendInteractionAgent(
 		&http.Client{Timeout: 10 * time.Second},
 		p.logger.With(slog.String("agent", "backend_interaction"), slog.String("endpoint", trimmed)),
+		backends,
 	)
 
 	agents = append(agents,
-		rulechain.NewAgent(ruleDefs),
+		rulechain.NewChainAgent(steps, buildTimeout(cfg.Timeout, cfg.OnTimeout)),
 		newRuleExecutionAgent(backendAgent, p.logger.With(slog.String("agent", "rule_execution"), slog.String("endpoint", trimmed)), p.templateRenderer, p.cache, p.cacheTTL, p.metrics, p.correlationHeader),
 		responsepolicy.NewWithConfig(responsepolicy.Config{
 			Endpoint: trimmed,
@@ -802,16 +1015,14 @@ func (p *Pipeline) buildEndpointRuntime(name string, cfg config.EndpointConfig,
 		name:       trimmed,
 		authConfig: authConfig,
 		agents:     p.instrumentAgents(trimmed, agents),
-	}
-	if p.defaultEndpoint == nil {
-		p.defaultEndpoint = runtime
+		cacheKey:   cacheKey,
 	}
 	return runtime, nil
 }
 
-func (p *Pipeline) requestCorrelationID(r *http.Request) string {
-	if r != nil && p.correlationHeader != "" {
-		if candidate := strings.TrimSpace(r.Header.Get(p.correlationHeader)); candidate != "" {
+func requestCorrelationID(r *http.Request, correlationHeader string) string {
+	if r != nil && correlationHeader != "" {
+		if candidate := strings.TrimSpace(r.Header.Get(correlationHeader)); candidate != "" {
 			return candidate
 		}
 	}
@@ -901,12 +1112,15 @@ func defaultTrustedNetworks() []netip.Prefix {
 	return admission.ParseCIDRs([]string{"127.0.0.0/8", "::1/128"})
 }
 
-func compileConfiguredRules(rules map[string]config.RuleConfig, renderer *templates.Renderer) (map[string]rulechain.Definition, error) {
+// compileConfiguredRules compiles each rule independently so a broken CEL
+// program or template only disables that rule; failures are returned by name.
+func compileConfiguredRules(rules map[string]config.RuleConfig, renderer *templates.Renderer, stores *credentials.Registry, dbs *databases.Registry) (map[string]rulechain.Definition, map[string]error) {
 	if len(rules) == 0 {
 		return map[string]rulechain.Definition{}, nil
 	}
 
 	compiled := make(map[string]rulechain.Definition, len(rules))
+	var failures map[string]error
 	for name, cfg := range rules {
 		trimmedName := strings.TrimSpace(name)
 		if trimmedName == "" {
@@ -916,13 +1130,14 @@ func compileConfiguredRules(rules map[string]config.RuleConfig, renderer *templa
 		specs := []rulechain.DefinitionSpec{{
 			Name:        trimmedName,
 			Description: cfg.Description,
-			Auth:        buildRuleAuthSpec(cfg.Auth),
+			Auth:        buildRuleAuthSpec(cfg.Auth, stores),
 			Conditions: rulechain.ConditionSpec{
 				Pass:  append([]string{}, cfg.Conditions.Pass...),
 				Fail:  append([]string{}, cfg.Conditions.Fail...),
 				Error: append([]string{}, cfg.Conditions.Error...),
 			},
 			Backend: rulechain.BackendDefinitionSpec{
+				Type:                cfg.BackendAPI.Type,
 				URL:                 cfg.BackendAPI.URL,
 				Method:              cfg.BackendAPI.Method,
 				ForwardProxyHeaders: cfg.BackendAPI.ForwardProxyHeaders,
@@ -935,6 +1150,12 @@ func compileConfiguredRules(rules map[string]config.RuleConfig, renderer *templa
 					Type:     cfg.BackendAPI.Pagination.Type,
 					MaxPages: cfg.BackendAPI.Pagination.MaxPages,
 				},
+				Introspection:  buildIntrospectionSpec(cfg.BackendAPI.Introspection),
+				LDAP:           buildLDAPSpec(cfg.BackendAPI.LDAP),
+				SQL:            buildSQLSpec(cfg.BackendAPI.SQL, dbs),
+				GRPC:           buildGRPCSpec(cfg.BackendAPI.GRPC),
+				BodySchema:     cfg.BackendAPI.BodySchema,
+				BodySchemaFile: cfg.BackendAPI.BodySchemaFile,
 			},
 			PassMessage:  "",
 			FailMessage:  "",
@@ -942,21 +1163,26 @@ func compileConfiguredRules(rules map[string]config.RuleConfig, renderer *templa
 			Responses:    buildRuleResponsesSpec(cfg.Responses),
 			Variables:    buildRuleVariablesSpec(cfg.Variables),
 			Cache:        buildRuleCacheSpec(cfg.Cache),
+			Timeout:      buildTimeout(cfg.Timeout, cfg.OnTimeout),
 		}}
 
 		defs, err := rulechain.CompileDefinitions(specs, renderer)
 		if err != nil {
-			return nil, fmt.Errorf("compile rule %s: %w", name, err)
+			if failures == nil {
+				failures = make(map[string]error)
+			}
+			failures[trimmedName] = fmt.Errorf("compile rule %s: %w", name, err)
+			continue
 		}
 		if len(defs) == 0 {
 			continue
 		}
 		compiled[trimmedName] = defs[0]
 	}
-	return compiled, nil
+	return compiled, failures
 }
 
-func buildRuleAuthSpec(directives []config.RuleAuthDirective) []rulechain.AuthDirectiveSpec {
+func buildRuleAuthSpec(directives []config.RuleAuthDirective, stores *credentials.Registry) []rulechain.AuthDirectiveSpec {
 	if len(directives) == 0 {
 		return nil
 	}
@@ -993,6 +1219,19 @@ func buildRuleAuthSpec(directives []config.RuleAuthDirective) []rulechain.AuthDi
 				}
 				matcher.Password = values
 			}
+			if name := strings.TrimSpace(m.CredentialStore); name != "" {
+				matcher.CredentialStore = name
+				if store, ok := stores.PasswordStore(name); ok {
+					matcher.Credentials = store
+				}
+			}
+			if name := strings.TrimSpace(m.KeyStore); name != "" {
+				matcher.In = strings.TrimSpace(m.In)
+				matcher.KeyStore = name
+				if store, ok := stores.APIKeyStore(name); ok {
+					matcher.Keys = store
+				}
+			}
 
 			matchers = append(matchers, matcher)
 		}
@@ -1018,6 +1257,70 @@ func buildRuleAuthSpec(directives []config.RuleAuthDirective) []rulechain.AuthDi
 	return specs
 }
 
+func buildIntrospectionSpec(cfg config.RuleIntrospectionConfig) rulechain.IntrospectionSpec {
+	return rulechain.IntrospectionSpec{
+		Token:         cfg.Token,
+		TokenTypeHint: cfg.TokenTypeHint,
+		ClientAuth: rulechain.IntrospectionClientAuthSpec{
+			Method:       cfg.ClientAuth.Method,
+			ClientID:     cfg.ClientAuth.ClientID,
+			ClientSecret: cfg.ClientAuth.ClientSecret,
+		},
+		Audience: append([]string{}, cfg.Audience...),
+		Issuer:   cfg.Issuer,
+		Leeway:   cfg.Leeway,
+	}
+}
+
+func buildLDAPSpec(cfg config.RuleLDAPConfig) rulechain.LDAPSpec {
+	return rulechain.LDAPSpec{
+		StartTLS:     cfg.StartTLS,
+		CAFile:       cfg.CAFile,
+		PoolSize:     cfg.PoolSize,
+		Username:     cfg.Username,
+		Password:     cfg.Password,
+		UserDN:       cfg.UserDN,
+		BindDN:       cfg.BindDN,
+		BindPassword: cfg.BindPassword,
+		BaseDN:       cfg.BaseDN,
+		UserFilter:   cfg.UserFilter,
+		Attributes:   append([]string{}, cfg.Attributes...),
+		Groups: rulechain.LDAPGroupsSpec{
+			BaseDN:    cfg.Groups.BaseDN,
+			Filter:    cfg.Groups.Filter,
+			Attribute: cfg.Groups.Attribute,
+			Nested:    cfg.Groups.Nested,
+		},
+	}
+}
+
+func buildSQLSpec(cfg config.RuleSQLConfig, dbs *databases.Registry) rulechain.SQLSpec {
+	spec := rulechain.SQLSpec{
+		Database: strings.TrimSpace(cfg.Database),
+		Query:    cfg.Query,
+		Params:   append([]string{}, cfg.Params...),
+		MaxRows:  cfg.MaxRows,
+		Timeout:  cfg.Timeout,
+	}
+	if db, ok := dbs.DB(spec.Database); ok {
+		spec.DB = db
+	}
+	return spec
+}
+
+func buildGRPCSpec(cfg config.RuleGRPCConfig) rulechain.GRPCSpec {
+	return rulechain.GRPCSpec{
+		Method:            cfg.Method,
+		DescriptorSetFile: cfg.DescriptorSetFile,
+		TLS: rulechain.GRPCTLSSpec{
+			CAFile:     cfg.TLS.CAFile,
+			CertFile:   cfg.TLS.CertFile,
+			KeyFile:    cfg.TLS.KeyFile,
+			ServerName: cfg.TLS.ServerName,
+		},
+	}
+}
+
 func buildRuleResponsesSpec(cfg config.RuleResponsesConfig) rulechain.ResponsesSpec {
 	return rulechain.ResponsesSpec{
 		Pass:  buildRuleResponseSpec(cfg.Pass),
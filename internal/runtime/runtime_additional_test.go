@@ -29,9 +29,10 @@ func TestPipelineCloseInvokesCache(t *testing.T) {
 
 func TestPipelineFallbackEndpoint(t *testing.T) {
 	pipe := NewPipeline(nil, PipelineOptions{})
-	require.True(t, pipe.usingFallback, "expected fallback endpoint to be installed when no endpoints configured")
-	require.NotNil(t, pipe.defaultEndpoint)
-	require.Equal(t, "default", pipe.defaultEndpoint.name)
+	snap := pipe.active.Load()
+	require.True(t, snap.usingFallback, "expected fallback endpoint to be installed when no endpoints configured")
+	require.NotNil(t, snap.defaultEndpoint)
+	require.Equal(t, "default", snap.defaultEndpoint.name)
 	require.True(t, pipe.EndpointExists("default"), "expected fallback endpoint to be discoverable")
 
 	req := httptest.NewRequest(http.MethodGet, "http://example.com/auth?error=false", http.NoBody)
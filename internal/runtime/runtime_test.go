@@ -7,11 +7,16 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/datasets"
+	"github.com/l0p7/passctrl/internal/metrics"
 	metricsmocks "github.com/l0p7/passctrl/internal/mocks/metrics"
 	"github.com/l0p7/passctrl/internal/runtime/cache"
 	"github.com/l0p7/passctrl/internal/server"
@@ -548,3 +553,627 @@ func TestEndpointResponsePolicyBodiesAndHeaders(t *testing.T) {
 	require.Equal(t, "Denied", strings.TrimSpace(rec.Body.String()))
 	require.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
 }
+
+type closeRecordingCache struct {
+	cache.DecisionCache
+	closed bool
+}
+
+func (c *closeRecordingCache) Close(ctx context.Context) error {
+	c.closed = true
+	return c.DecisionCache.Close(ctx)
+}
+
+func TestPipelineApplySettingsSwapsLiveSettings(t *testing.T) {
+	ctx := context.Background()
+	initialCache := &closeRecordingCache{DecisionCache: cache.NewMemory(5 * time.Minute)}
+	pipe := NewPipeline(nil, PipelineOptions{
+		Cache:             initialCache,
+		CorrelationHeader: "X-Request-ID",
+		LoadedEnvironment: map[string]string{"MODE": "closed"},
+		Endpoints: map[string]config.EndpointConfig{
+			"solo": {
+				Authentication: config.EndpointAuthenticationConfig{
+					Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+				},
+				Rules: []config.EndpointRuleReference{{Name: "solo-rule"}},
+			},
+		},
+		Rules: map[string]config.RuleConfig{
+			"solo-rule": {
+				Conditions: config.RuleConditionConfig{
+					Pass: []string{`variables.environment.MODE == "open"`},
+					Fail: []string{`variables.environment.MODE != "open"`},
+				},
+			},
+		},
+	})
+	handler := server.NewPipelineHandler(pipe)
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/solo/auth", http.NoBody)
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve()
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.NotEmpty(t, rec.Header().Get("X-Request-ID"))
+
+	nextCache := cache.NewMemory(5 * time.Minute)
+	pipe.ApplySettings(ctx, Settings{
+		Cache:             nextCache,
+		CorrelationHeader: "X-Trace-ID",
+		LoadedEnvironment: map[string]string{"MODE": "open"},
+	})
+	require.True(t, initialCache.closed, "replaced cache must be closed")
+
+	rec = serve()
+	require.Equal(t, http.StatusOK, rec.Code, "endpoints must be rebuilt against the new environment")
+	require.Empty(t, rec.Header().Get("X-Request-ID"))
+	require.NotEmpty(t, rec.Header().Get("X-Trace-ID"))
+}
+
+func TestPipelineInstantiatesParameterizedRules(t *testing.T) {
+	var roles []string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		roles = append(roles, r.URL.Query().Get("role"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	role := "{{ .params.role }}"
+	memberOf := func(group string) config.EndpointRuleReference {
+		return config.EndpointRuleReference{Name: "member-of", With: map[string]any{"group": group}}
+	}
+	allowBearer := config.EndpointAuthenticationConfig{
+		Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+	}
+	pipe := NewPipeline(nil, PipelineOptions{
+		Endpoints: map[string]config.EndpointConfig{
+			"admins":  {Authentication: allowBearer, Rules: []config.EndpointRuleReference{memberOf("admins")}},
+			"editors": {Authentication: allowBearer, Rules: []config.EndpointRuleReference{memberOf("editors")}},
+		},
+		Rules: map[string]config.RuleConfig{
+			"member-of": {
+				Params: map[string]config.RuleParamConfig{
+					"group": {Type: "string"},
+					"role":  {Type: "string", Default: "reader"},
+				},
+				BackendAPI: config.RuleBackendConfig{
+					URL:   backend.URL,
+					Query: map[string]*string{"role": &role},
+				},
+				Conditions: config.RuleConditionConfig{
+					Pass: []string{`request.query["group"] == params.group`},
+				},
+			},
+		},
+	})
+	handler := server.NewPipelineHandler(pipe)
+
+	serve := func(path string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com"+path, http.NoBody)
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusOK, serve("/admins/auth?group=admins"))
+	require.Equal(t, http.StatusForbidden, serve("/admins/auth?group=editors"))
+	require.Equal(t, http.StatusOK, serve("/editors/auth?group=editors"))
+	require.Equal(t, []string{"reader", "reader", "reader"}, roles, "defaults render into backend templates")
+}
+
+func TestPipelineExplainShowsResolvedEndpoint(t *testing.T) {
+	pipe := NewPipeline(nil, PipelineOptions{
+		Endpoints: map[string]config.EndpointConfig{
+			"reports": {
+				Extends: "base",
+				Inheritance: &config.EndpointInheritance{
+					Chain:    []string{"reports", "base"},
+					Resolved: map[string]any{"responsePolicy": map[string]any{"fail": map[string]any{"status": 403}}},
+					Origins:  map[string]string{"responsePolicy.fail.status": "endpointDefaults (rules/defaults.yaml)"},
+				},
+			},
+			"inline": {},
+		},
+	})
+	handler := server.NewPipelineHandler(pipe)
+
+	explain := func(path string) map[string]any {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com"+path, http.NoBody))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var payload map[string]any
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+		return payload
+	}
+
+	resolved, ok := explain("/reports/explain")["resolvedEndpoint"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "reports", resolved["name"])
+	require.Equal(t, []any{"base"}, resolved["extends"])
+	require.Equal(t, map[string]any{"responsePolicy.fail.status": "endpointDefaults (rules/defaults.yaml)"}, resolved["origins"])
+
+	require.NotContains(t, explain("/inline/explain"), "resolvedEndpoint")
+}
+
+func TestPipelineSelectsEndpointByForwardedRequest(t *testing.T) {
+	endpoint := func(body string, match config.EndpointMatchConfig) config.EndpointConfig {
+		return config.EndpointConfig{
+			Authentication: config.EndpointAuthenticationConfig{
+				Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+			},
+			ResponsePolicy: config.EndpointResponsePolicyConfig{
+				Fail: config.EndpointResponseConfig{Body: body},
+			},
+			Rules: []config.EndpointRuleReference{{Name: "deny"}},
+			Match: match,
+		}
+	}
+	opts := PipelineOptions{
+		Cache: cache.NewMemory(1 * time.Minute),
+		Endpoints: map[string]config.EndpointConfig{
+			"api": endpoint("api", config.EndpointMatchConfig{
+				Hosts:        []string{"*.example.com"},
+				PathPrefixes: []string{"/v1/"},
+			}),
+			"admin": endpoint("admin", config.EndpointMatchConfig{
+				PathRegex: []string{`^/(admin|v1/admin)(/|$)`},
+				Methods:   []string{"get", "post"},
+				Headers:   []config.EndpointHeaderMatchConfig{{Name: "X-Role", Values: []string{"admin"}}},
+				Priority:  10,
+			}),
+			"public": endpoint("public", config.EndpointMatchConfig{Fallback: true}),
+		},
+		Rules: map[string]config.RuleConfig{
+			"deny": {Conditions: config.RuleConditionConfig{Fail: []string{"true"}}},
+		},
+	}
+	handler := server.NewPipelineHandler(NewPipeline(nil, opts))
+
+	cases := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		want    string
+	}{
+		{
+			name:    "host glob and path prefix",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Host": "API.example.com:443", "X-Forwarded-Uri": "/v1/users?page=2"},
+			want:    "api",
+		},
+		{
+			name:    "higher priority wins when both match",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Host": "api.example.com", "X-Forwarded-Uri": "/v1/admin/users", "X-Role": "admin"},
+			want:    "admin",
+		},
+		{
+			name:    "nginx original headers",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Original-Uri": "/admin", "X-Original-Method": "POST", "X-Role": "admin"},
+			want:    "admin",
+		},
+		{
+			name:    "failed header predicate falls back",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Uri": "/admin", "X-Role": "viewer"},
+			want:    "public",
+		},
+		{
+			name:    "method outside the list falls back",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Method": "DELETE", "X-Forwarded-Uri": "/admin", "X-Role": "admin"},
+			want:    "public",
+		},
+		{
+			name:    "dot segments are cleaned before matching",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Host": "api.example.com", "X-Forwarded-Uri": "/v1/../admin"},
+			want:    "public",
+		},
+		{
+			name:    "encoded dot segments are cleaned before matching",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Host": "api.example.com", "X-Forwarded-Uri": "/v1/%2e%2e/admin"},
+			want:    "public",
+		},
+		{
+			name:    "path prefix requires a segment boundary",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Host": "api.example.com", "X-Forwarded-Uri": "/v1x/users"},
+			want:    "public",
+		},
+		{
+			name:    "path prefix matches the bare segment",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Host": "api.example.com", "X-Forwarded-Uri": "/v1"},
+			want:    "api",
+		},
+		{
+			name:    "nginx original url keeps only the path",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Host": "api.example.com", "X-Original-Url": "https://api.example.com/v1/users"},
+			want:    "api",
+		},
+		{
+			name:    "query selector cannot override match",
+			target:  "http://passctrl/auth?endpoint=public",
+			headers: map[string]string{"X-Forwarded-Host": "api.example.com", "X-Forwarded-Uri": "/v1/users"},
+			want:    "api",
+		},
+		{
+			name:    "header selector cannot override match",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Uri": "/admin", "X-Role": "admin", "X-PassCtrl-Endpoint": "public"},
+			want:    "admin",
+		},
+		{
+			name:    "header selector cannot bypass fallback",
+			target:  "http://passctrl/auth",
+			headers: map[string]string{"X-Forwarded-Uri": "/admin", "X-PassCtrl-Endpoint": "admin"},
+			want:    "public",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.target, http.NoBody)
+			req.Header.Set("Authorization", "Bearer token")
+			for name, value := range tc.headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusForbidden, rec.Code)
+			require.Equal(t, tc.want, strings.TrimSpace(rec.Body.String()))
+		})
+	}
+}
+
+func TestPipelineSingleMatchedEndpointOnlyAnswersMatches(t *testing.T) {
+	serve := func(match config.EndpointMatchConfig, uri string) (int, string) {
+		pipe := NewPipeline(nil, PipelineOptions{
+			Cache: cache.NewMemory(1 * time.Minute),
+			Endpoints: map[string]config.EndpointConfig{
+				"api": {
+					Authentication: config.EndpointAuthenticationConfig{
+						Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+					},
+					ResponsePolicy: config.EndpointResponsePolicyConfig{
+						Fail: config.EndpointResponseConfig{Body: "api"},
+					},
+					Rules: []config.EndpointRuleReference{{Name: "deny"}},
+					Match: match,
+				},
+			},
+			Rules: map[string]config.RuleConfig{
+				"deny": {Conditions: config.RuleConditionConfig{Fail: []string{"true"}}},
+			},
+		})
+		req := httptest.NewRequest(http.MethodGet, "http://passctrl/auth", http.NoBody)
+		req.Header.Set("Authorization", "Bearer token")
+		req.Header.Set("X-Forwarded-Uri", uri)
+		rec := httptest.NewRecorder()
+		server.NewPipelineHandler(pipe).ServeHTTP(rec, req)
+		return rec.Code, strings.TrimSpace(rec.Body.String())
+	}
+
+	scoped := config.EndpointMatchConfig{PathPrefixes: []string{"/v1/"}}
+	code, body := serve(scoped, "/v1/users")
+	require.Equal(t, http.StatusForbidden, code)
+	require.Equal(t, "api", body)
+
+	code, _ = serve(scoped, "/v2/users")
+	require.Equal(t, http.StatusBadRequest, code, "a lone matched endpoint must not answer requests outside its match")
+
+	scoped.Fallback = true
+	code, body = serve(scoped, "/v2/users")
+	require.Equal(t, http.StatusForbidden, code)
+	require.Equal(t, "api", body)
+
+	code, body = serve(config.EndpointMatchConfig{}, "/v2/users")
+	require.Equal(t, http.StatusForbidden, code)
+	require.Equal(t, "api", body)
+}
+
+func TestPipelineRuleChainGroups(t *testing.T) {
+	opts := PipelineOptions{
+		Cache: cache.NewMemory(1 * time.Minute),
+		Endpoints: map[string]config.EndpointConfig{
+			"gateway": {
+				Authentication: config.EndpointAuthenticationConfig{
+					Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+				},
+				ResponsePolicy: config.EndpointResponsePolicyConfig{
+					Fail: config.EndpointResponseConfig{Body: "denied"},
+				},
+				Rules: []config.EndpointRuleReference{
+					{AnyOf: []config.EndpointRuleReference{
+						{Name: "admin", OnPass: config.OnPassStop},
+						{AllOf: []config.EndpointRuleReference{{Name: "session"}, {Name: "group"}}},
+					}},
+					{Name: "deny", When: `lookup(request.query, "locked") == "true"`},
+				},
+			},
+		},
+		Rules: map[string]config.RuleConfig{
+			"admin":   {Conditions: config.RuleConditionConfig{Pass: []string{`lookup(request.query, "admin") == "yes"`}}},
+			"session": {Conditions: config.RuleConditionConfig{Pass: []string{`lookup(request.query, "session") != ""`}}},
+			"group":   {Conditions: config.RuleConditionConfig{Pass: []string{`lookup(request.query, "group") == "ops"`}}},
+			"deny":    {Conditions: config.RuleConditionConfig{Fail: []string{"true"}}},
+		},
+	}
+	handler := server.NewPipelineHandler(NewPipeline(nil, opts))
+
+	auth := func(query string) int {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/gateway/auth?"+query, http.NoBody)
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	require.Equal(t, http.StatusOK, auth("admin=yes&locked=true"), "onPass: stop skips the locked rule")
+	require.Equal(t, http.StatusOK, auth("session=abc&group=ops"))
+	require.Equal(t, http.StatusForbidden, auth("session=abc&group=ops&locked=true"))
+	require.Equal(t, http.StatusForbidden, auth("session=abc&group=dev"))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/gateway/explain", http.NoBody)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var payload struct {
+		RuleChain []map[string]any `json:"ruleChain"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &payload))
+	require.Len(t, payload.RuleChain, 2)
+	require.Equal(t, "anyOf", payload.RuleChain[0]["group"])
+	steps := payload.RuleChain[0]["steps"].([]any)
+	require.Equal(t, map[string]any{"rule": "admin", "onPass": "stop"}, steps[0])
+	require.Equal(t, `lookup(request.query, "locked") == "true"`, payload.RuleChain[1]["when"])
+}
+
+func TestPipelineRunsIndependentRulesConcurrently(t *testing.T) {
+	var inflight, peak atomic.Int32
+	cancelled := make(chan struct{}, 1)
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inflight.Add(1)
+		defer inflight.Add(-1)
+		for {
+			seen := peak.Load()
+			if current <= seen || peak.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		delay := 50 * time.Millisecond
+		if r.URL.Path == "/slow" {
+			delay = 5 * time.Second
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			cancelled <- struct{}{}
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"user":"` + strings.TrimPrefix(r.URL.Path, "/") + `"}`))
+	}))
+	defer backend.Close()
+
+	lookupRule := func(name string) config.RuleConfig {
+		return config.RuleConfig{
+			BackendAPI: config.RuleBackendConfig{URL: backend.URL + "/" + name, AcceptedStatuses: []int{http.StatusOK}},
+			Conditions: config.RuleConditionConfig{Pass: []string{`backend.body.user == "` + name + `"`}},
+			Responses: config.RuleResponsesConfig{
+				Pass: config.RuleResponseConfig{Variables: map[string]string{name: "backend.body.user"}},
+			},
+		}
+	}
+	combined := "{{ .response.combined }}"
+	bearer := config.EndpointAuthenticationConfig{
+		Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+	}
+	handler := server.NewPipelineHandler(NewPipeline(nil, PipelineOptions{
+		Endpoints: map[string]config.EndpointConfig{
+			"auto": {
+				Authentication: bearer,
+				RuleExecution:  config.EndpointRuleExecutionConfig{Parallel: config.RuleParallelAuto, MaxWorkers: 2},
+				ResponsePolicy: config.EndpointResponsePolicyConfig{
+					Pass: config.EndpointResponseConfig{Headers: map[string]*string{"X-Users": &combined}},
+				},
+				Rules: []config.EndpointRuleReference{{Name: "alpha"}, {Name: "beta"}, {Name: "gamma"}, {Name: "summary"}},
+			},
+			"explicit": {
+				Authentication: bearer,
+				Rules: []config.EndpointRuleReference{
+					{Parallel: []config.EndpointRuleReference{{Name: "slow"}, {Name: "deny"}}},
+				},
+			},
+		},
+		Rules: map[string]config.RuleConfig{
+			"alpha": lookupRule("alpha"),
+			"beta":  lookupRule("beta"),
+			"gamma": lookupRule("gamma"),
+			"slow":  lookupRule("slow"),
+			"summary": {
+				Conditions: config.RuleConditionConfig{Pass: []string{"true"}},
+				Responses: config.RuleResponsesConfig{
+					Pass: config.RuleResponseConfig{Variables: map[string]string{
+						"combined": `vars.rule.alpha.alpha + "/" + vars.rule.beta.beta + "/" + vars.rule.gamma.gamma`,
+					}},
+				},
+			},
+			"deny": {
+				BackendAPI: config.RuleBackendConfig{URL: backend.URL + "/deny", AcceptedStatuses: []int{http.StatusOK}},
+				Conditions: config.RuleConditionConfig{Fail: []string{`backend.body.user == "deny"`}},
+			},
+		},
+	}))
+
+	serve := func(endpoint string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/"+endpoint+"/auth", http.NoBody)
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("auto")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "alpha/beta/gamma", rec.Header().Get("X-Users"), "exports merge in declaration order before dependent rules run")
+	require.Equal(t, int32(2), peak.Load(), "independent rules run concurrently up to maxWorkers")
+
+	start := time.Now()
+	rec = serve("explicit")
+	require.Equal(t, http.StatusForbidden, rec.Code)
+	require.Less(t, time.Since(start), 2*time.Second, "a failing sibling cancels the slow rule")
+	select {
+	case <-cancelled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("slow backend request was not cancelled")
+	}
+}
+
+func TestPipelineEnforcesEvaluationDeadlines(t *testing.T) {
+	var calls atomic.Int32
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		delay := 10 * time.Millisecond
+		if r.URL.Path == "/slow" {
+			delay = 5 * time.Second
+		}
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	call := func(path, timeout, onTimeout string) config.RuleConfig {
+		return config.RuleConfig{
+			BackendAPI: config.RuleBackendConfig{URL: backend.URL + path, AcceptedStatuses: []int{http.StatusOK}},
+			Conditions: config.RuleConditionConfig{Pass: []string{"backend.status == 200"}},
+			Timeout:    timeout,
+			OnTimeout:  onTimeout,
+		}
+	}
+	bearer := config.EndpointAuthenticationConfig{
+		Allow: config.EndpointAuthAllowConfig{Authorization: []string{"bearer"}},
+	}
+	metricsMock := metricsmocks.NewMockRecorder(t)
+	metricsMock.EXPECT().ObserveAuth(mock.Anything, mock.Anything, mock.Anything, false, mock.AnythingOfType("time.Duration")).Maybe()
+	metricsMock.EXPECT().ObserveCacheLookup(mock.Anything, mock.Anything, mock.Anything).Maybe()
+	metricsMock.EXPECT().ObserveCacheStore(mock.Anything, mock.Anything, mock.Anything).Maybe()
+	metricsMock.EXPECT().ObserveTimeout("budget", "", metrics.TimeoutScopeEndpoint).Once()
+	metricsMock.EXPECT().ObserveTimeout("budget-fail", "", metrics.TimeoutScopeEndpoint).Once()
+	metricsMock.EXPECT().ObserveTimeout("fallback", "slow-capped", metrics.TimeoutScopeRule).Once()
+
+	handler := server.NewPipelineHandler(NewPipeline(nil, PipelineOptions{
+		Endpoints: map[string]config.EndpointConfig{
+			"budget": {
+				Authentication: bearer,
+				Timeout:        "100ms",
+				Rules:          []config.EndpointRuleReference{{Name: "fast"}, {Name: "slow"}, {Name: "fast-after"}},
+			},
+			"budget-fail": {
+				Authentication: bearer,
+				Timeout:        "100ms",
+				OnTimeout:      config.TimeoutOutcomeFail,
+				Rules:          []config.EndpointRuleReference{{Name: "slow"}},
+			},
+			"fallback": {
+				Authentication: bearer,
+				Rules: []config.EndpointRuleReference{
+					{AnyOf: []config.EndpointRuleReference{{Name: "slow-capped"}, {Name: "fast"}}},
+				},
+			},
+		},
+		Rules: map[string]config.RuleConfig{
+			"fast":        call("/fast", "", ""),
+			"fast-after":  call("/fast-after", "", ""),
+			"slow":        call("/slow", "", ""),
+			"slow-capped": call("/slow", "50ms", config.TimeoutOutcomeFail),
+		},
+		Metrics: metricsMock,
+	}))
+
+	serve := func(endpoint string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com/"+endpoint+"/auth", http.NoBody)
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	start := time.Now()
+	require.Equal(t, http.StatusBadGateway, serve("budget").Code, "an exceeded endpoint budget reports error by default")
+	require.Less(t, time.Since(start), 2*time.Second)
+	require.Equal(t, int32(2), calls.Load(), "rules after the deadline are skipped")
+
+	require.Equal(t, http.StatusForbidden, serve("budget-fail").Code, "onTimeout: fail reports a deny")
+
+	require.Equal(t, http.StatusOK, serve("fallback").Code, "a rule timeout fails only that rule")
+}
+
+func TestPipelineExposesDatasets(t *testing.T) {
+	dir := t.TempDir()
+	tenants := filepath.Join(dir, "tenants.csv")
+	require.NoError(t, os.WriteFile(tenants, []byte("id,plan\nacme,gold\n"), 0o600))
+	registry := datasets.NewRegistry(map[string]datasets.Spec{
+		"tenants": {File: tenants, Key: "id"},
+		"regions": {File: filepath.Join(dir, "regions.yaml"), Key: "code"},
+	})
+
+	planHeader := `{{ (.data.Lookup "tenants" "acme").plan }}`
+	pipe := NewPipeline(nil, PipelineOptions{
+		Cache:    cache.NewMemory(time.Minute),
+		Datasets: registry,
+		Endpoints: map[string]config.EndpointConfig{
+			"tenant": {
+				Authentication: config.EndpointAuthenticationConfig{
+					Allow: config.EndpointAuthAllowConfig{None: true},
+				},
+				ResponsePolicy: config.EndpointResponsePolicyConfig{
+					Pass: config.EndpointResponseConfig{Headers: map[string]*string{"X-Plan": &planHeader}},
+				},
+				Rules: []config.EndpointRuleReference{{Name: "known-tenant"}},
+			},
+		},
+		Rules: map[string]config.RuleConfig{
+			"known-tenant": {Conditions: config.RuleConditionConfig{
+				Pass: []string{`data.lookup("tenants", request.query["tenant"]) != null`},
+			}},
+		},
+	})
+	handler := server.NewPipelineHandler(pipe)
+
+	serve := func(tenant string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://example.com/tenant/auth?tenant="+tenant, http.NoBody))
+		return rec
+	}
+	rec := serve("acme")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "gold", rec.Header().Get("X-Plan"))
+	require.Equal(t, http.StatusForbidden, serve("globex").Code)
+
+	healthRec := httptest.NewRecorder()
+	pipe.ServeHealth(healthRec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	var health struct {
+		Status   string            `json:"status"`
+		Datasets []datasets.Status `json:"datasets"`
+	}
+	require.NoError(t, json.Unmarshal(healthRec.Body.Bytes(), &health))
+	require.Equal(t, "degraded", health.Status, "a dataset that failed to load degrades health")
+	require.Len(t, health.Datasets, 2)
+	require.Equal(t, "regions", health.Datasets[0].Name)
+	require.NotEmpty(t, health.Datasets[0].Error)
+	require.Equal(t, 1, health.Datasets[1].Entries)
+}
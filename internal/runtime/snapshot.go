@@ -0,0 +1,171 @@
+package runtime
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/runtime/cache"
+)
+
+// snapshot is an immutable view of everything a request needs: the compiled
+// endpoint set, rule provenance, and the request-scoped settings. Reloads build
+// a new snapshot off the request path and publish it with a single atomic
+// store; requests load the active snapshot once and finish on it.
+type snapshot struct {
+	*endpointSet
+
+	generation  uint64
+	ruleSources []string
+	skipped     []config.DefinitionSkip
+
+	cache             cache.DecisionCache
+	cacheSalt         []byte
+	cacheNamespace    string
+	cacheEpoch        int
+	correlationHeader string
+	loadedEnvironment map[string]string
+	loadedSecrets     map[string]string
+
+	inflight  atomic.Int64
+	retired   atomic.Bool
+	retiredAt time.Time
+	drained   chan struct{}
+	drainOnce sync.Once
+}
+
+// DrainingSnapshot describes a replaced snapshot that still has requests in
+// flight.
+type DrainingSnapshot struct {
+	Generation uint64    `json:"generation"`
+	RetiredAt  time.Time `json:"retiredAt"`
+	InFlight   int64     `json:"inFlight"`
+}
+
+func (s *snapshot) lookup(name string) (*endpointRuntime, bool) {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return nil, false
+	}
+	runtime, ok := s.endpoints[strings.ToLower(trimmed)]
+	return runtime, ok
+}
+
+// release ends a request that acquired the snapshot and signals drain
+// completion once a retired snapshot has no requests left.
+func (s *snapshot) release() {
+	if s.inflight.Add(-1) == 0 && s.retired.Load() {
+		s.markDrained()
+	}
+}
+
+// markDrained signals drain completion and closes the backend connections of
+// the snapshot's endpoint set, which no request can reach any more.
+func (s *snapshot) markDrained() {
+	s.drainOnce.Do(func() {
+		close(s.drained)
+		_ = s.backends.Close()
+	})
+}
+
+// acquire pins the active snapshot for the duration of a request. The retry
+// covers a reload that swaps snapshots between the load and the increment, so
+// a retired snapshot never gains requests after its drain was signalled.
+func (p *Pipeline) acquire() *snapshot {
+	for {
+		snap := p.active.Load()
+		snap.inflight.Add(1)
+		if p.active.Load() == snap {
+			return snap
+		}
+		snap.release()
+	}
+}
+
+// publish builds a snapshot from set and the current settings, makes it
+// active, and returns the snapshot it replaced. Callers hold reloadMu.
+func (p *Pipeline) publish(set *endpointSet, sources []string, skipped []config.DefinitionSkip) *snapshot {
+	p.generation++
+	next := &snapshot{
+		endpointSet:       set,
+		generation:        p.generation,
+		ruleSources:       cloneStringSlice(sources),
+		skipped:           cloneDefinitionSkips(skipped),
+		cache:             p.cache,
+		cacheSalt:         p.cacheSalt,
+		cacheNamespace:    p.cacheNamespace,
+		cacheEpoch:        p.cacheEpoch,
+		correlationHeader: p.correlationHeader,
+		loadedEnvironment: p.loadedEnvironment,
+		loadedSecrets:     p.loadedSecrets,
+		drained:           make(chan struct{}),
+	}
+	previous := p.active.Swap(next)
+	if previous != nil {
+		p.retire(previous)
+	}
+	return previous
+}
+
+func (p *Pipeline) retire(snap *snapshot) {
+	snap.retiredAt = time.Now().UTC()
+	snap.retired.Store(true)
+	if snap.inflight.Load() == 0 {
+		snap.markDrained()
+	}
+
+	p.drainMu.Lock()
+	defer p.drainMu.Unlock()
+	kept := p.draining[:0]
+	for _, old := range p.draining {
+		if !old.isDrained() {
+			kept = append(kept, old)
+		}
+	}
+	if !snap.isDrained() {
+		kept = append(kept, snap)
+	}
+	p.draining = kept
+}
+
+func (s *snapshot) isDrained() bool {
+	select {
+	case <-s.drained:
+		return true
+	default:
+		return false
+	}
+}
+
+// awaitDrain blocks until every request that started on snap has finished or
+// the context ends. It reports whether the snapshot drained.
+func (s *snapshot) awaitDrain(ctx context.Context) bool {
+	select {
+	case <-s.drained:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// DrainingSnapshots lists replaced snapshots that still serve in-flight
+// requests.
+func (p *Pipeline) DrainingSnapshots() []DrainingSnapshot {
+	p.drainMu.Lock()
+	defer p.drainMu.Unlock()
+	var out []DrainingSnapshot
+	for _, snap := range p.draining {
+		if snap.isDrained() {
+			continue
+		}
+		out = append(out, DrainingSnapshot{
+			Generation: snap.generation,
+			RetiredAt:  snap.retiredAt,
+			InFlight:   snap.inflight.Load(),
+		})
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineSnapshotPinsInFlightRequests(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{})
+	pinned := pipe.acquire()
+	require.Equal(t, uint64(1), pinned.generation)
+
+	bundle := reloadTestBundle("true")
+	delete(bundle.Endpoints, "beta")
+	pipe.Reload(context.Background(), bundle)
+
+	_, ok := pinned.lookup("beta")
+	require.True(t, ok, "in-flight request keeps the endpoint set it started with")
+	require.False(t, pipe.EndpointExists("beta"))
+
+	draining := pipe.DrainingSnapshots()
+	require.Len(t, draining, 1)
+	require.Equal(t, uint64(1), draining[0].Generation)
+	require.Equal(t, int64(1), draining[0].InFlight)
+
+	next := pipe.acquire()
+	require.Equal(t, uint64(2), next.generation)
+	next.release()
+
+	pinned.release()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.True(t, pinned.awaitDrain(ctx))
+	require.Empty(t, pipe.DrainingSnapshots())
+}
+
+func TestPipelineSnapshotRetiresIdleSnapshotImmediately(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{})
+	first := pipe.active.Load()
+
+	pipe.Reload(context.Background(), reloadTestBundle("true"))
+	require.True(t, first.isDrained())
+	require.Empty(t, pipe.DrainingSnapshots())
+}
+
+func TestPipelineServeAuthDuringReloads(t *testing.T) {
+	pipe := newReloadTestPipeline(t, config.RulesReloadConfig{})
+
+	var wg sync.WaitGroup
+	codes := make(chan int, 200)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				codes <- reloadTestAuth(pipe, "alpha")
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		pipe.Reload(context.Background(), reloadTestBundle("true"))
+	}
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		require.Equal(t, http.StatusOK, code)
+	}
+	require.Empty(t, pipe.DrainingSnapshots())
+}
@@ -0,0 +1,159 @@
+package runtime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+)
+
+// SQL outcomes are reported through backend.status: a query that returns
+// rows is 200 and an empty result is 404, so the default acceptedStatuses
+// fail a rule whose lookup found nothing.
+const (
+	sqlStatusRows   = http.StatusOK
+	sqlStatusNoRows = http.StatusNotFound
+)
+
+const sqlMethod = "QUERY"
+
+// renderedSQLQuery carries the arguments bound to the query placeholders.
+type renderedSQLQuery struct {
+	Args []any
+}
+
+// renderSQLRequest renders the query parameters. The descriptor body holds
+// the query and its JSON-encoded arguments so per-rule cache entries are
+// keyed by both.
+func renderSQLRequest(backend rulechain.BackendDefinition, state *pipeline.State) (renderedBackendRequest, error) {
+	def := backend.SQL
+	ctx := state.TemplateContext()
+	params := make([]string, len(def.Params))
+	args := make([]any, len(def.Params))
+	for i, tmpl := range def.Params {
+		value, err := renderBackendValue(tmpl, ctx)
+		if err != nil {
+			return renderedBackendRequest{}, fmt.Errorf("sql params[%d] render: %w", i, err)
+		}
+		params[i] = value
+		args[i] = value
+	}
+	encoded, err := json.Marshal(params)
+	if err != nil {
+		return renderedBackendRequest{}, fmt.Errorf("sql params encode: %w", err)
+	}
+	return renderedBackendRequest{
+		Method: sqlMethod,
+		URL:    backend.URL,
+		Body:   def.Query + "\n" + string(encoded),
+		SQL:    &renderedSQLQuery{Args: args},
+	}, nil
+}
+
+// executeSQL runs the query and publishes the result rows as the backend
+// body, a list of column-name maps. Connection, query, and row limit failures
+// are returned as errors.
+func (a *backendInteractionAgent) executeSQL(ctx context.Context, rendered renderedBackendRequest, backend rulechain.BackendDefinition, state *pipeline.State) error {
+	if rendered.SQL == nil {
+		return errors.New("sql backend: query arguments not rendered")
+	}
+	def := backend.SQL
+
+	queryCtx, cancel := context.WithTimeout(ctx, def.Timeout)
+	defer cancel()
+	rows, err := querySQLRows(queryCtx, def, rendered.SQL.Args)
+	if err != nil {
+		if cause := context.Cause(ctx); cause != nil {
+			return fmt.Errorf("sql backend aborted: %w", cause)
+		}
+		if errors.Is(queryCtx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("sql backend: query timed out after %s", def.Timeout)
+		}
+		return fmt.Errorf("sql backend: %w", err)
+	}
+
+	status := sqlStatusRows
+	if len(rows) == 0 {
+		status = sqlStatusNoRows
+	}
+	bodyText, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("sql backend: encode body: %w", err)
+	}
+	page := pipeline.BackendPageState{
+		URL:      rendered.URL,
+		Status:   status,
+		Body:     rows,
+		BodyText: string(bodyText),
+		Accepted: backend.Accepts(status),
+	}
+	state.Backend.Requested = true
+	state.Backend.Pages = []pipeline.BackendPageState{page}
+	state.Backend.Status = page.Status
+	state.Backend.Body = page.Body
+	state.Backend.BodyText = page.BodyText
+	state.Backend.Accepted = page.Accepted
+	return nil
+}
+
+// querySQLRows runs the query and converts every row to a map keyed by
+// column name. More than MaxRows rows is an error rather than a silently
+// truncated result.
+func querySQLRows(ctx context.Context, def *rulechain.SQLDefinition, args []any) ([]any, error) {
+	rows, err := def.DB.QueryContext(ctx, def.Query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query %s: %w", def.Database, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("query %s columns: %w", def.Database, err)
+	}
+	result := make([]any, 0)
+	for rows.Next() {
+		if len(result) == def.MaxRows {
+			return nil, fmt.Errorf("query %s returned more than %d rows", def.Database, def.MaxRows)
+		}
+		values := make([]any, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("query %s scan: %w", def.Database, err)
+		}
+		row := make(map[string]any, len(columns))
+		for i, column := range columns {
+			row[column] = sqlValue(values[i])
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query %s: %w", def.Database, err)
+	}
+	return result, nil
+}
+
+// sqlValue converts driver values to the types CEL and templates expect:
+// byte slices become strings and every integer width becomes int64.
+func sqlValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return string(v)
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case int16:
+		return int64(v)
+	case float32:
+		return float64(v)
+	default:
+		return v
+	}
+}
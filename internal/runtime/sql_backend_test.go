@@ -0,0 +1,162 @@
+package runtime
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/l0p7/passctrl/internal/databases"
+	"github.com/l0p7/passctrl/internal/runtime/pipeline"
+	"github.com/l0p7/passctrl/internal/runtime/rulechain"
+	"github.com/l0p7/passctrl/internal/templates"
+	"github.com/stretchr/testify/require"
+)
+
+// sqlTestDatabase opens a SQLite database seeded with a grants table.
+func sqlTestDatabase(t *testing.T) rulechain.SQLQuerier {
+	t.Helper()
+	registry, err := databases.Open(map[string]databases.Spec{
+		"authz": {Driver: "sqlite", DSN: "file:" + filepath.Join(t.TempDir(), "authz.db")},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, registry.Close()) })
+	db, ok := registry.DB("authz")
+	require.True(t, ok)
+
+	ctx := context.Background()
+	_, err = db.ExecContext(ctx, `CREATE TABLE grants (user_id TEXT, role TEXT, weight INTEGER, scope BLOB)`)
+	require.NoError(t, err)
+	for _, grant := range [][]any{
+		{"alice", "admin", 10, []byte("all")},
+		{"alice", "viewer", 1, nil},
+		{"bob", "viewer", 1, nil},
+		{"crowd", "a", 1, nil},
+		{"crowd", "b", 1, nil},
+		{"crowd", "c", 1, nil},
+		{"crowd", "d", 1, nil},
+	} {
+		_, err = db.ExecContext(ctx, `INSERT INTO grants VALUES (?, ?, ?, ?)`, grant...)
+		require.NoError(t, err)
+	}
+	return db
+}
+
+func TestRuleExecutionAgentSQL(t *testing.T) {
+	db := sqlTestDatabase(t)
+	defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name: "grants",
+		Backend: rulechain.BackendDefinitionSpec{
+			Type: rulechain.BackendTypeSQL,
+			SQL: rulechain.SQLSpec{
+				Database: "authz",
+				DB:       db,
+				Query:    "SELECT role, weight, scope FROM grants WHERE user_id = ? ORDER BY role",
+				Params:   []string{`{{ index .request.Headers "x-user" }}`},
+				MaxRows:  3,
+			},
+		},
+		Conditions: rulechain.ConditionSpec{
+			Pass: []string{`backend.body.exists(g, g.role == "admin" && g.weight >= 10)`},
+		},
+	}}, templates.NewRenderer(nil))
+	require.NoError(t, err)
+	require.Equal(t, "sql://authz", defs[0].Backend.URL)
+
+	tests := []struct {
+		name    string
+		user    string
+		outcome string
+		status  int
+		reason  string
+	}{
+		{name: "admin grant", user: "alice", outcome: "pass", status: sqlStatusRows},
+		{name: "viewer only", user: "bob", outcome: "fail", status: sqlStatusRows},
+		{name: "no rows", user: "mallory", outcome: "fail", status: sqlStatusNoRows},
+		{name: "injection attempt", user: "x' OR '1'='1", outcome: "fail", status: sqlStatusNoRows},
+		{name: "missing parameter", user: "", outcome: "fail", status: sqlStatusNoRows},
+		{name: "row limit", user: "crowd", outcome: "error", reason: "returned more than 3 rows"},
+	}
+
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
+			if tc.user != "" {
+				req.Header.Set("X-User", tc.user)
+			}
+			state := pipeline.NewState(req, "endpoint", "cache-key", "")
+
+			outcome, reason, _ := agent.evaluateRule(context.Background(), defs[0], state)
+			require.Equal(t, tc.outcome, outcome, reason)
+			require.Contains(t, reason, tc.reason)
+			if tc.status != 0 {
+				require.Equal(t, tc.status, state.Backend.Status)
+			}
+			if tc.outcome == "pass" {
+				require.Equal(t, []any{
+					map[string]any{"role": "admin", "weight": int64(10), "scope": "all"},
+					map[string]any{"role": "viewer", "weight": int64(1), "scope": nil},
+				}, state.Backend.Body)
+				require.JSONEq(t, `[{"role":"admin","weight":10,"scope":"all"},{"role":"viewer","weight":1,"scope":null}]`, state.Backend.BodyText)
+			}
+		})
+	}
+}
+
+func TestRuleExecutionAgentSQLDescriptorAndTimeout(t *testing.T) {
+	db := sqlTestDatabase(t)
+	compile := func(spec rulechain.SQLSpec) rulechain.Definition {
+		defs, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+			Name:    "grants",
+			Backend: rulechain.BackendDefinitionSpec{Type: rulechain.BackendTypeSQL, SQL: spec},
+		}}, templates.NewRenderer(nil))
+		require.NoError(t, err)
+		return defs[0]
+	}
+	agent := newRuleExecutionAgent(newBackendInteractionAgent(nil, nil, nil), nil, nil, nil, 0, nil, "")
+
+	def := compile(rulechain.SQLSpec{
+		Database: "authz",
+		DB:       db,
+		Query:    "SELECT role FROM grants WHERE user_id = ?",
+		Params:   []string{`{{ index .request.Headers "x-user" }}`},
+	})
+	render := func(user string) renderedBackendRequest {
+		req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
+		req.Header.Set("X-User", user)
+		rendered, err := agent.renderBackendRequest(def.Backend, nil, pipeline.NewState(req, "endpoint", "cache-key", ""))
+		require.NoError(t, err)
+		return rendered
+	}
+	alice := render("alice")
+	require.Equal(t, "QUERY", alice.Method)
+	require.Equal(t, "sql://authz", alice.URL)
+	require.Equal(t, "SELECT role FROM grants WHERE user_id = ?\n[\"alice\"]", alice.Body)
+	require.Equal(t, []any{"alice"}, alice.SQL.Args)
+	require.NotEqual(t, alice.Body, render("bob").Body, "cache descriptors differ per parameter")
+
+	req := httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil)
+	req.Header.Set("X-User", "mallory")
+	outcome, reason, _ := agent.evaluateRule(context.Background(), def, pipeline.NewState(req, "endpoint", "cache-key", ""))
+	require.Equal(t, "fail", outcome)
+	require.Equal(t, "backend response not accepted: status 404", reason, "an empty result is not accepted by default")
+
+	slow := compile(rulechain.SQLSpec{
+		Database: "authz",
+		DB:       db,
+		Query:    "WITH RECURSIVE n(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM n) SELECT count(*) FROM n",
+		Timeout:  "50ms",
+	})
+	state := pipeline.NewState(httptest.NewRequest(http.MethodGet, "http://unit.test/request", nil), "endpoint", "cache-key", "")
+	outcome, reason, _ = agent.evaluateRule(context.Background(), slow, state)
+	require.Equal(t, "error", outcome)
+	require.Contains(t, reason, "query timed out after 50ms")
+
+	_, err := rulechain.CompileDefinitions([]rulechain.DefinitionSpec{{
+		Name:    "grants",
+		Backend: rulechain.BackendDefinitionSpec{Type: rulechain.BackendTypeSQL, SQL: rulechain.SQLSpec{Database: "authz", Query: "SELECT 1"}},
+	}}, templates.NewRenderer(nil))
+	require.ErrorContains(t, err, `backend sql: database "authz" not available`)
+}
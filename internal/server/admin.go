@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/l0p7/passctrl/internal/config"
+)
+
+// AdminMux collects the operations routes served by the admin listener.
+// Management APIs register additional handlers through Handle before the
+// listener starts.
+type AdminMux struct {
+	mux  *http.ServeMux
+	auth config.AdminAuthConfig
+}
+
+// NewAdminMux mounts metrics, health, explain, and pprof routes. Health and
+// explain keep the `/<endpoint>/...` scoping used by the combined layout.
+func NewAdminMux(p PipelineHTTP, metrics http.Handler, auth config.AdminAuthConfig) *AdminMux {
+	mux := http.NewServeMux()
+	if metrics != nil {
+		mux.Handle("/metrics", metrics)
+	}
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/", newRouteHandler(p, routesOps))
+	return &AdminMux{mux: mux, auth: auth}
+}
+
+// Handle registers an additional management route on the admin listener.
+func (m *AdminMux) Handle(pattern string, handler http.Handler) {
+	m.mux.Handle(pattern, handler)
+}
+
+// HandleMutating registers a management route that changes server state,
+// such as replacing the rule bundle. Unlike read-only routes it is never open:
+// without a configured bearer token or a verified client certificate the
+// route answers 403.
+func (m *AdminMux) HandleMutating(pattern string, handler http.Handler) {
+	m.mux.Handle(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !adminAuthenticated(m.auth, r) {
+			http.Error(w, "forbidden: admin authentication is not configured", http.StatusForbidden)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+// ServeHTTP authenticates the caller before dispatching to the admin routes.
+func (m *AdminMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(m.auth, r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="passctrl-admin"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	m.mux.ServeHTTP(w, r)
+}
+
+// adminAuthorized accepts a verified client certificate or the configured
+// bearer token. Without a bearer token the listener relies on its TLS client
+// verification (or network placement) alone.
+func adminAuthorized(auth config.AdminAuthConfig, r *http.Request) bool {
+	token := strings.TrimSpace(auth.BearerToken)
+	if token == "" {
+		return true
+	}
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+	scheme, presented, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "bearer") {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(presented)), []byte(token)) == 1
+}
+
+// adminAuthenticated reports whether the caller proved an identity rather than
+// reaching an unauthenticated listener. ServeHTTP has already matched the
+// bearer token when one is configured.
+func adminAuthenticated(auth config.AdminAuthConfig, r *http.Request) bool {
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		return true
+	}
+	return strings.TrimSpace(auth.BearerToken) != ""
+}
@@ -0,0 +1,135 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/l0p7/passctrl/internal/config"
+	servermocks "github.com/l0p7/passctrl/internal/mocks/server"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminMuxRoutes(t *testing.T) {
+	mockPipeline := servermocks.NewMockPipelineHTTP(t)
+	mockPipeline.EXPECT().
+		ServeHealth(mock.Anything, mock.Anything).
+		Run(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}).
+		Once()
+	mockPipeline.EXPECT().
+		ServeExplain(mock.Anything, mock.Anything).
+		Run(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}).
+		Once()
+
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux := NewAdminMux(mockPipeline, metrics, config.AdminAuthConfig{})
+	mux.Handle("/cache/invalidate", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	expect := newPipelineExpect(t, mux)
+
+	expect.GET("/metrics").Expect().Status(http.StatusOK)
+	expect.GET("/healthz").Expect().Status(http.StatusOK)
+	expect.GET("/explain").Expect().Status(http.StatusOK)
+	expect.GET("/debug/pprof/").Expect().Status(http.StatusOK)
+	expect.POST("/cache/invalidate").Expect().Status(http.StatusAccepted)
+	expect.GET("/auth").Expect().Status(http.StatusNotFound)
+}
+
+func TestAdminMuxBearerAuthentication(t *testing.T) {
+	metrics := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux := NewAdminMux(servermocks.NewMockPipelineHTTP(t), metrics, config.AdminAuthConfig{BearerToken: "ops-token"})
+
+	tests := []struct {
+		name   string
+		header string
+		tls    *tls.ConnectionState
+		want   int
+	}{
+		{name: "missing token", want: http.StatusUnauthorized},
+		{name: "wrong token", header: "Bearer nope", want: http.StatusUnauthorized},
+		{name: "wrong scheme", header: "Basic ops-token", want: http.StatusUnauthorized},
+		{name: "valid token", header: "Bearer ops-token", want: http.StatusOK},
+		{name: "case-insensitive scheme", header: "bearer ops-token", want: http.StatusOK},
+		{
+			name: "verified client certificate",
+			tls:  &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}},
+			want: http.StatusOK,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			req.TLS = tc.tls
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, tc.want, rec.Code)
+			if tc.want == http.StatusUnauthorized {
+				require.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+			}
+		})
+	}
+}
+
+func TestAdminMuxMutatingRoutesRequireAuthentication(t *testing.T) {
+	pushed := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	verified := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+
+	tests := []struct {
+		name   string
+		auth   config.AdminAuthConfig
+		header string
+		tls    *tls.ConnectionState
+		want   int
+	}{
+		{name: "no admin authentication configured", want: http.StatusForbidden},
+		{name: "unverified tls connection", tls: &tls.ConnectionState{}, want: http.StatusForbidden},
+		{name: "verified client certificate", tls: verified, want: http.StatusOK},
+		{name: "bearer token", auth: config.AdminAuthConfig{BearerToken: "ops-token"}, header: "Bearer ops-token", want: http.StatusOK},
+		{name: "missing bearer token", auth: config.AdminAuthConfig{BearerToken: "ops-token"}, want: http.StatusUnauthorized},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mux := NewAdminMux(servermocks.NewMockPipelineHTTP(t), nil, tc.auth)
+			mux.HandleMutating("POST /rules", pushed)
+
+			req := httptest.NewRequest(http.MethodPost, "/rules", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			req.TLS = tc.tls
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			require.Equal(t, tc.want, rec.Code)
+		})
+	}
+}
+
+func TestNewAdminUsesAdminListen(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.Admin.Listen.Port = 9191
+
+	srv, err := NewAdmin(cfg, newTestLogger(), http.NewServeMux())
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1:9191", srv.httpServer.Addr)
+
+	cfg.Server.Admin.Listen.Port = 0
+	_, err = NewAdmin(cfg, newTestLogger(), http.NewServeMux())
+	require.ErrorContains(t, err, "admin listener disabled")
+}
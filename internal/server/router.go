@@ -17,10 +17,32 @@ type PipelineHTTP interface {
 	WriteError(http.ResponseWriter, int, string)
 }
 
+// routeSet selects which pipeline routes a listener serves.
+type routeSet uint8
+
+const (
+	routeAuth routeSet = 1 << iota
+	routeHealth
+	routeExplain
+
+	routesOps = routeHealth | routeExplain
+	routesAll = routeAuth | routesOps
+)
+
 // NewPipelineHandler wires the HTTP routing facade to the runtime pipeline so
 // the lifecycle server owns URL dispatch without embedding routing logic into
-// the pipeline itself.
+// the pipeline itself. It serves the combined auth, health, and explain layout.
 func NewPipelineHandler(p PipelineHTTP) http.Handler {
+	return newRouteHandler(p, routesAll)
+}
+
+// NewAuthHandler serves only the `/auth` routes for the public listener so
+// health and explain metadata are not reachable from the auth port.
+func NewAuthHandler(p PipelineHTTP) http.Handler {
+	return newRouteHandler(p, routeAuth)
+}
+
+func newRouteHandler(p PipelineHTTP, routes routeSet) http.Handler {
 	if p == nil {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "pipeline unavailable", http.StatusServiceUnavailable)
@@ -33,13 +55,13 @@ func NewPipelineHandler(p PipelineHTTP) http.Handler {
 			return
 		}
 
-		switch route {
-		case "auth":
+		switch {
+		case route == "auth" && routes&routeAuth != 0:
 			if endpoint != "" {
 				r = p.RequestWithEndpointHint(r, endpoint)
 			}
 			p.ServeAuth(w, r)
-		case "healthz":
+		case route == "healthz" && routes&routeHealth != 0:
 			if endpoint != "" {
 				if !p.EndpointExists(endpoint) {
 					p.WriteError(w, http.StatusNotFound, fmt.Sprintf("endpoint %q not found", endpoint))
@@ -48,7 +70,7 @@ func NewPipelineHandler(p PipelineHTTP) http.Handler {
 				r = p.RequestWithEndpointHint(r, endpoint)
 			}
 			p.ServeHealth(w, r)
-		case "explain":
+		case route == "explain" && routes&routeExplain != 0:
 			if endpoint != "" {
 				if !p.EndpointExists(endpoint) {
 					p.WriteError(w, http.StatusNotFound, fmt.Sprintf("endpoint %q not found", endpoint))
@@ -197,6 +197,22 @@ func TestPipelineHandlerNotFound(t *testing.T) {
 	// no pipeline methods should be invoked for unsupported routes; any unexpected call would fail via mock expectations.
 }
 
+func TestAuthHandlerServesOnlyAuthRoutes(t *testing.T) {
+	mockPipeline := servermocks.NewMockPipelineHTTP(t)
+	mockPipeline.EXPECT().
+		ServeAuth(mock.Anything, mock.Anything).
+		Run(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}).
+		Once()
+
+	expect := newPipelineExpect(t, NewAuthHandler(mockPipeline))
+	expect.GET("/auth").Expect().Status(http.StatusOK)
+	for _, path := range []string{"/healthz", "/explain", "/tenant/health", "/tenant/explain"} {
+		expect.GET(path).Expect().Status(http.StatusNotFound)
+	}
+}
+
 func requestWithHint(t *testing.T, expected string) interface{} {
 	t.Helper()
 	return mock.MatchedBy(func(r *http.Request) bool {
@@ -12,23 +12,37 @@ import (
 	"time"
 
 	"github.com/l0p7/passctrl/internal/config"
+	"github.com/l0p7/passctrl/internal/filewatch"
 )
 
 // Server owns the HTTP lifecycle and orchestrates graceful shutdown.
 type Server struct {
-	cfg        config.Config
 	logger     *slog.Logger
 	httpServer *http.Server
+	tls        *tlsReloader
 	once       sync.Once
 }
 
 // New equips the lifecycle agent with the first handler hook so later reloads inherit consistent listener settings.
 func New(cfg config.Config, logger *slog.Logger, handler http.Handler) (*Server, error) {
+	return newServer("public", cfg.Server.Listen, logger, handler)
+}
+
+// NewAdmin builds the operations listener from server.admin.listen so metrics,
+// health, and management routes stay off the public auth socket.
+func NewAdmin(cfg config.Config, logger *slog.Logger, handler http.Handler) (*Server, error) {
+	if !cfg.Server.Admin.Enabled() {
+		return nil, errors.New("server: admin listener disabled")
+	}
+	return newServer("admin", cfg.Server.Admin.Listen, logger, handler)
+}
+
+func newServer(name string, listen config.ListenConfig, logger *slog.Logger, handler http.Handler) (*Server, error) {
 	if handler == nil {
 		return nil, errors.New("server: handler required")
 	}
 
-	addr := net.JoinHostPort(cfg.Server.Listen.Address, strconv.Itoa(cfg.Server.Listen.Port))
+	addr := net.JoinHostPort(listen.Address, strconv.Itoa(listen.Port))
 	httpSrv := &http.Server{
 		Addr:              addr,
 		Handler:           handler,
@@ -36,20 +50,45 @@ func New(cfg config.Config, logger *slog.Logger, handler http.Handler) (*Server,
 		IdleTimeout:       120 * time.Second,
 	}
 
-	return &Server{
-		cfg:        cfg,
-		logger:     logger.With(slog.String("agent", "lifecycle")),
+	srv := &Server{
+		logger:     logger.With(slog.String("agent", "lifecycle"), slog.String("listener", name)),
 		httpServer: httpSrv,
-	}, nil
+	}
+	if tlsCfg := listen.TLS; tlsCfg.Enabled() {
+		reloader, err := newTLSReloader(tlsCfg)
+		if err != nil {
+			return nil, err
+		}
+		httpSrv.TLSConfig, err = buildTLSConfig(tlsCfg, reloader)
+		if err != nil {
+			return nil, err
+		}
+		srv.tls = reloader
+	}
+	return srv, nil
 }
 
 // Run keeps the lifecycle agent active until shutdown signals arrive, ensuring graceful exits over abrupt restarts.
 func (s *Server) Run(ctx context.Context) error {
 	errCh := make(chan error, 1)
 
+	if s.tls != nil {
+		watcher, err := s.watchTLS(ctx)
+		if err != nil {
+			s.logger.Error("tls watcher setup failed", slog.Any("error", err))
+		}
+		defer watcher.Stop()
+	}
+
 	go func() {
-		s.logger.Info("http listener starting", slog.String("address", s.httpServer.Addr))
-		if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Info("http listener starting", slog.String("address", s.httpServer.Addr), slog.Bool("tls", s.tls != nil))
+		var err error
+		if s.tls != nil {
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			errCh <- fmt.Errorf("server: listen: %w", err)
 		}
 		close(errCh)
@@ -71,6 +110,22 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 }
 
+// watchTLS reloads the listener certificate and client CA bundle when their
+// files change. New handshakes pick up the swapped snapshot immediately.
+func (s *Server) watchTLS(ctx context.Context) (*filewatch.Watcher, error) {
+	return filewatch.Watch(ctx, s.tls.files(), func() {
+		if err := s.tls.reload(); err != nil {
+			s.logger.Error("tls reload failed; keeping previous certificate", slog.Any("error", err))
+			return
+		}
+		s.logger.Info("tls certificate reloaded", slog.String("cert_file", s.tls.certFile))
+	}, func(err error) {
+		if err != nil {
+			s.logger.Error("tls watcher error", slog.Any("error", err))
+		}
+	})
+}
+
 // shutdown collapses the listener once to stop duplicate shutdown work during cascading cancellations.
 func (s *Server) shutdown(ctx context.Context) error {
 	var shutdownErr error
@@ -0,0 +1,141 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/l0p7/passctrl/internal/config"
+)
+
+// tlsReloader serves the listener certificate and client CA pool from
+// snapshots that are swapped whenever the files change on disk. A failed
+// reload keeps the previous snapshot so a half-written file never takes the
+// listener down.
+type tlsReloader struct {
+	certFile     string
+	keyFile      string
+	clientCAFile string
+	cert         atomic.Pointer[tls.Certificate]
+	clientCAs    atomic.Pointer[x509.CertPool]
+}
+
+func newTLSReloader(cfg config.ListenTLSConfig) (*tlsReloader, error) {
+	r := &tlsReloader{
+		certFile:     strings.TrimSpace(cfg.CertFile),
+		keyFile:      strings.TrimSpace(cfg.KeyFile),
+		clientCAFile: strings.TrimSpace(cfg.ClientCAFile),
+	}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload re-reads the key pair and client CA bundle and swaps both snapshots
+// only when every file parses.
+func (r *tlsReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("server: tls: load key pair: %w", err)
+	}
+	var pool *x509.CertPool
+	if r.clientCAFile != "" {
+		pem, err := os.ReadFile(r.clientCAFile) // #nosec G304 -- operator-configured CA bundle path
+		if err != nil {
+			return fmt.Errorf("server: tls: read client ca: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("server: tls: client ca %s contains no certificates", r.clientCAFile)
+		}
+	}
+	r.cert.Store(&cert)
+	if pool != nil {
+		r.clientCAs.Store(pool)
+	}
+	return nil
+}
+
+// files lists the paths that trigger a reload when they change.
+func (r *tlsReloader) files() []string {
+	files := []string{r.certFile, r.keyFile}
+	if r.clientCAFile != "" {
+		files = append(files, r.clientCAFile)
+	}
+	return files
+}
+
+func (r *tlsReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := r.cert.Load()
+	if cert == nil {
+		return nil, errors.New("server: tls: certificate not loaded")
+	}
+	return cert, nil
+}
+
+// buildTLSConfig assembles the listener TLS configuration. Certificates and the
+// client CA pool are resolved per handshake so reloads apply to new
+// connections without restarting the listener.
+func buildTLSConfig(cfg config.ListenTLSConfig, reloader *tlsReloader) (*tls.Config, error) {
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+	switch strings.TrimSpace(cfg.MinVersion) {
+	case "", "1.2":
+	case "1.3":
+		base.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("server: tls: unsupported minVersion %q", cfg.MinVersion)
+	}
+
+	if len(cfg.CipherSuites) > 0 {
+		suites, err := cipherSuiteIDs(cfg.CipherSuites)
+		if err != nil {
+			return nil, err
+		}
+		base.CipherSuites = suites
+	}
+
+	if reloader.clientCAFile == "" {
+		return base, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.ClientAuth)) {
+	case "", "require":
+		base.ClientAuth = tls.RequireAndVerifyClientCert
+	case "optional":
+		base.ClientAuth = tls.VerifyClientCertIfGiven
+	default:
+		return nil, fmt.Errorf("server: tls: unsupported clientAuth %q", cfg.ClientAuth)
+	}
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		perConn := base.Clone()
+		perConn.GetConfigForClient = nil
+		perConn.ClientCAs = reloader.clientCAs.Load()
+		return perConn, nil
+	}
+	return base, nil
+}
+
+// cipherSuiteIDs maps IANA cipher suite names to IDs. Only suites Go considers
+// secure are accepted.
+func cipherSuiteIDs(names []string) ([]uint16, error) {
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("server: tls: unsupported cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
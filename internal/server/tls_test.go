@@ -0,0 +1,272 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/l0p7/passctrl/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pair tls.Certificate
+}
+
+func issueTestCert(t *testing.T, cn string, parent *testCert, isCA bool, usage x509.ExtKeyUsage) *testCert {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if isCA {
+		tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+	} else {
+		tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+	}
+	signer, signerKey := tmpl, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCert{
+		cert: cert,
+		key:  key,
+		pair: tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: cert},
+	}
+}
+
+func writeTestCert(t *testing.T, dir, name string, c *testCert) (string, string) {
+	t.Helper()
+	certPath := filepath.Join(dir, name+".crt")
+	keyPath := filepath.Join(dir, name+".key")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}), 0o600))
+	keyDER, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certPath, keyPath
+}
+
+// serveTLS starts the configured server on an ephemeral port using the
+// listener TLS configuration built by New.
+func serveTLS(t *testing.T, srv *Server) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.httpServer.Serve(tls.NewListener(ln, srv.httpServer.TLSConfig)) }()
+	t.Cleanup(func() { _ = srv.httpServer.Close() })
+	return "https://" + ln.Addr().String()
+}
+
+func tlsClient(roots *x509.CertPool, certs ...tls.Certificate) *http.Client {
+	return &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+			MinVersion:   tls.VersionTLS12,
+		}},
+	}
+}
+
+func TestServerTLSRequiresVerifiedClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCert(t, "test-ca", nil, true, 0)
+	serverCert := issueTestCert(t, "localhost", ca, false, x509.ExtKeyUsageServerAuth)
+	clientCert := issueTestCert(t, "client-a", ca, false, x509.ExtKeyUsageClientAuth)
+	rogueCA := issueTestCert(t, "rogue-ca", nil, true, 0)
+	rogueClient := issueTestCert(t, "client-b", rogueCA, false, x509.ExtKeyUsageClientAuth)
+
+	certFile, keyFile := writeTestCert(t, dir, "server", serverCert)
+	caFile, _ := writeTestCert(t, dir, "ca", ca)
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Listen.TLS = config.ListenTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		MinVersion:   "1.3",
+	}
+	var peer string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			peer = r.TLS.VerifiedChains[0][0].Subject.CommonName
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv, err := New(cfg, newTestLogger(), handler)
+	require.NoError(t, err)
+	url := serveTLS(t, srv)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	resp, err := tlsClient(roots, clientCert.pair).Get(url)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	require.Equal(t, "client-a", peer)
+
+	_, err = tlsClient(roots).Get(url)
+	require.Error(t, err, "handshake without client certificate must fail")
+
+	_, err = tlsClient(roots, rogueClient.pair).Get(url)
+	require.Error(t, err, "client certificate from an untrusted CA must fail")
+}
+
+func TestServerTLSOptionalClientAuth(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCert(t, "test-ca", nil, true, 0)
+	serverCert := issueTestCert(t, "localhost", ca, false, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := writeTestCert(t, dir, "server", serverCert)
+	caFile, _ := writeTestCert(t, dir, "ca", ca)
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Listen.TLS = config.ListenTLSConfig{
+		CertFile:     certFile,
+		KeyFile:      keyFile,
+		ClientCAFile: caFile,
+		ClientAuth:   "optional",
+	}
+	srv, err := New(cfg, newTestLogger(), http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	require.NoError(t, err)
+	url := serveTLS(t, srv)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	resp, err := tlsClient(roots).Get(url)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestTLSReloaderKeepsLastGoodCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCert(t, "test-ca", nil, true, 0)
+	first := issueTestCert(t, "first", ca, false, x509.ExtKeyUsageServerAuth)
+	second := issueTestCert(t, "second", ca, false, x509.ExtKeyUsageServerAuth)
+
+	certFile, keyFile := writeTestCert(t, dir, "server", first)
+	reloader, err := newTLSReloader(config.ListenTLSConfig{CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+	current, err := reloader.getCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, first.cert.Raw, current.Certificate[0])
+
+	require.NoError(t, os.WriteFile(certFile, []byte("partial write"), 0o600))
+	require.Error(t, reloader.reload())
+	current, err = reloader.getCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, first.cert.Raw, current.Certificate[0], "failed reload must keep the previous certificate")
+
+	writeTestCert(t, dir, "server", second)
+	require.NoError(t, reloader.reload())
+	current, err = reloader.getCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, second.cert.Raw, current.Certificate[0])
+}
+
+func TestServerTLSReloadsSymlinkSwappedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCert(t, "test-ca", nil, true, 0)
+	first := issueTestCert(t, "first", ca, false, x509.ExtKeyUsageServerAuth)
+	second := issueTestCert(t, "second", ca, false, x509.ExtKeyUsageServerAuth)
+
+	// Mount the pair the way a Kubernetes Secret volume does and rotate it by
+	// swapping the ..data symlink.
+	writeVersion := func(version string, c *testCert) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o700))
+		writeTestCert(t, filepath.Join(dir, version), "tls", c)
+	}
+	writeVersion("..v1", first)
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	for _, name := range []string{"tls.crt", "tls.key"} {
+		require.NoError(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Server.Listen.TLS = config.ListenTLSConfig{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")}
+	srv, err := New(cfg, newTestLogger(), http.NotFoundHandler())
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	watcher, err := srv.watchTLS(ctx)
+	require.NoError(t, err)
+	defer watcher.Stop()
+
+	writeVersion("..v2", second)
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	require.Eventually(t, func() bool {
+		current, err := srv.tls.getCertificate(nil)
+		return err == nil && string(current.Certificate[0]) == string(second.cert.Raw)
+	}, 2*time.Second, 10*time.Millisecond, "rotated certificate must be served after the ..data swap")
+}
+
+func TestNewRejectsInvalidTLSSettings(t *testing.T) {
+	dir := t.TempDir()
+	ca := issueTestCert(t, "test-ca", nil, true, 0)
+	serverCert := issueTestCert(t, "localhost", ca, false, x509.ExtKeyUsageServerAuth)
+	certFile, keyFile := writeTestCert(t, dir, "server", serverCert)
+
+	tests := []struct {
+		name    string
+		tls     config.ListenTLSConfig
+		wantErr string
+	}{
+		{
+			name:    "missing key file",
+			tls:     config.ListenTLSConfig{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.key")},
+			wantErr: "load key pair",
+		},
+		{
+			name:    "insecure cipher suite",
+			tls:     config.ListenTLSConfig{CertFile: certFile, KeyFile: keyFile, CipherSuites: []string{"TLS_RSA_WITH_RC4_128_SHA"}},
+			wantErr: "unsupported cipher suite",
+		},
+		{
+			name:    "client ca without certificates",
+			tls:     config.ListenTLSConfig{CertFile: certFile, KeyFile: keyFile, ClientCAFile: keyFile},
+			wantErr: "contains no certificates",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Server.Listen.TLS = tc.tls
+			_, err := New(cfg, newTestLogger(), http.NewServeMux())
+			require.ErrorContains(t, err, tc.wantErr)
+		})
+	}
+}